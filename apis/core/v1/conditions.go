@@ -0,0 +1,63 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a Condition
+type ConditionType string
+
+const (
+	// ConditionTypeDriftDetected is True if the resource in Azure has been changed outside of Kubernetes
+	ConditionTypeDriftDetected = ConditionType("DriftDetected")
+)
+
+// Reasons used for conditions set by the generic controller
+const (
+	ReasonDrifted = "Drifted"
+	ReasonInSync  = "InSync"
+)
+
+// Condition describes one aspect of the current state of a resource
+type Condition struct {
+	// Type of the condition
+	// +kubebuilder:validation:Required
+	Type ConditionType `json:"type"`
+
+	// Status of the condition, one of True, False or Unknown
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	Status metav1.ConditionStatus `json:"status"`
+
+	// LastTransitionTime is the last time the condition changed from one status to another
+	// +kubebuilder:validation:Required
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// Reason is a CamelCase identifier for the reason for the condition's last transition
+	// +kubebuilder:validation:Required
+	Reason string `json:"reason"`
+
+	// Message is a human readable message with details about the transition
+	Message string `json:"message,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into out
+func (c *Condition) DeepCopyInto(out *Condition) {
+	*out = *c
+	c.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy creates a copy of the receiver
+func (c *Condition) DeepCopy() *Condition {
+	if c == nil {
+		return nil
+	}
+	out := new(Condition)
+	c.DeepCopyInto(out)
+	return out
+}
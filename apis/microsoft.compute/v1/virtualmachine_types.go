@@ -230,6 +230,8 @@ type (
 		// +k8s:conversion-gen=false
 		DeploymentID      string `json:"deploymentId,omitempty"`
 		ProvisioningState string `json:"provisioningState,omitempty"`
+		// +k8s:conversion-gen=false
+		Conditions []azcorev1.Condition `json:"conditions,omitempty"`
 	}
 
 	// +kubebuilder:object:root=true
@@ -7,6 +7,8 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	azcorev1 "github.com/Azure/k8s-infra/apis/core/v1"
 )
 
 // ResourceGroupSpec defines the desired state of ResourceGroup
@@ -24,6 +26,8 @@ type ResourceGroupStatus struct {
 	// +k8s:conversion-gen=false
 	DeploymentID      string `json:"deploymentId,omitempty"`
 	ProvisioningState string `json:"provisioningState,omitempty"`
+	// +k8s:conversion-gen=false
+	Conditions []azcorev1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -10,6 +10,7 @@ Licensed under the MIT license.
 package v1
 
 import (
+	corev1 "github.com/Azure/k8s-infra/apis/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -19,7 +20,7 @@ func (in *ResourceGroup) DeepCopyInto(out *ResourceGroup) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceGroup.
@@ -97,6 +98,13 @@ func (in *ResourceGroupSpec) DeepCopy() *ResourceGroupSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceGroupStatus) DeepCopyInto(out *ResourceGroupStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]corev1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceGroupStatus.
@@ -0,0 +1,71 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DriftRemediation determines what the controller does when it finds that a resource in Azure no longer matches
+// its spec, for example because somebody changed it through the portal
+type DriftRemediation string
+
+const (
+	// DriftRemediationReport records the drift on the object but leaves Azure alone
+	DriftRemediationReport = DriftRemediation("Report")
+	// DriftRemediationReapply applies the spec to Azure again, undoing the drift
+	DriftRemediationReapply = DriftRemediation("Reapply")
+)
+
+type (
+	// DriftDetectionPolicy configures periodic checks that resources in Azure still match their specs
+	DriftDetectionPolicy struct {
+		// Interval is how often each resource is checked. Zero disables drift detection.
+		Interval time.Duration
+		// Remediation is what to do when drift is found. Defaults to DriftRemediationReport.
+		Remediation DriftRemediation
+	}
+
+	// DriftDetectionOptions configures drift detection for all kinds of resource
+	DriftDetectionOptions struct {
+		// Default is the policy for kinds of resource without an override
+		Default DriftDetectionPolicy
+		// Overrides are the policies for specific kinds of resource
+		Overrides map[schema.GroupKind]DriftDetectionPolicy
+	}
+)
+
+// Enabled returns true if resources should be checked for drift
+func (p DriftDetectionPolicy) Enabled() bool {
+	return p.Interval > 0
+}
+
+// Validate returns an error if the policy is not valid
+func (p DriftDetectionPolicy) Validate() error {
+	switch p.Remediation {
+	case "", DriftRemediationReport, DriftRemediationReapply:
+		return nil
+	default:
+		return fmt.Errorf("unknown drift remediation %q, expected %q or %q", p.Remediation, DriftRemediationReport, DriftRemediationReapply)
+	}
+}
+
+// PolicyFor returns the drift detection policy for the given kind of resource
+func (o DriftDetectionOptions) PolicyFor(gk schema.GroupKind) DriftDetectionPolicy {
+	policy, ok := o.Overrides[gk]
+	if !ok {
+		policy = o.Default
+	}
+
+	if policy.Remediation == "" {
+		policy.Remediation = DriftRemediationReport
+	}
+
+	return policy
+}
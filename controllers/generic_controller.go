@@ -8,11 +8,13 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -38,6 +40,9 @@ import (
 const (
 	// ResourceSigAnnotationKey is an annotation key which holds the value of the hash of the spec
 	ResourceSigAnnotationKey = "resource-sig.infra.azure.com"
+	// DriftDetectedAnnotationKey is an annotation key which holds the fields of the resource which have been changed
+	// in Azure outside of Kubernetes, if drift detection is enabled and has found any
+	DriftDetectedAnnotationKey = "drift-detected.infra.azure.com"
 )
 
 var (
@@ -90,16 +95,18 @@ type (
 		GVK        schema.GroupVersionKind
 		Controller controller.Controller
 		Converter  *xform.ARMConverter
+		// DriftDetection configures periodic checks that the resource in Azure still matches its spec
+		DriftDetection DriftDetectionPolicy
 	}
 )
 
-func RegisterAll(mgr ctrl.Manager, applier zips.Applier, objs []runtime.Object, log logr.Logger, options controller.Options) []error {
+func RegisterAll(mgr ctrl.Manager, applier zips.Applier, objs []runtime.Object, log logr.Logger, options controller.Options, driftDetection DriftDetectionOptions) []error {
 	var errs []error
 	for _, obj := range objs {
 		mgr := mgr
 		applier := applier
 		obj := obj
-		if err := register(mgr, applier, obj, log, options); err != nil {
+		if err := register(mgr, applier, obj, log, options, driftDetection); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -113,7 +120,7 @@ func RegisterAll(mgr ctrl.Manager, applier zips.Applier, objs []runtime.Object,
 // to the concrete type defined as part of a closure, while allowing for
 // independent controllers per GVK (== better parallelism, vs 1 controller
 // managing many, many List/Watches)
-func register(mgr ctrl.Manager, applier zips.Applier, obj runtime.Object, log logr.Logger, options controller.Options) error {
+func register(mgr ctrl.Manager, applier zips.Applier, obj runtime.Object, log logr.Logger, options controller.Options, driftDetection DriftDetectionOptions) error {
 	v, err := conversion.EnforcePtr(obj)
 	if err != nil {
 		return err
@@ -153,6 +160,8 @@ func register(mgr ctrl.Manager, applier zips.Applier, obj runtime.Object, log lo
 		Recorder:  mgr.GetEventRecorderFor(controllerName),
 		GVK:       gvk,
 		Converter: xform.NewARMConverter(mgr.GetClient(), mgr.GetScheme()),
+		// drift detection can be configured per kind of resource
+		DriftDetection: driftDetection.PolicyFor(gvk.GroupKind()),
 	}
 
 	ctrlBuilder := ctrl.NewControllerManagedBy(mgr).
@@ -315,6 +324,11 @@ func (gr *GenericReconciler) reconcileApply(ctx context.Context, metaObj azcorev
 
 	// if the resource hash (spec) has not changed, don't apply again
 	if !hasChanged && zips.IsTerminalProvisioningState(resource.ProvisioningState) {
+		if gr.DriftDetection.Enabled() && resource.ProvisioningState == zips.SucceededProvisioningState && resource.ID != "" {
+			// check that nobody has changed the resource in Azure since we applied it
+			return gr.detectDrift(ctx, metaObj, resource)
+		}
+
 		msg := fmt.Sprintf("resource in state %q and spec has not changed", resource.ProvisioningState)
 		gr.Recorder.Event(metaObj, v1.EventTypeNormal, "ResourceHasNotChanged", msg)
 		return ctrl.Result{}, nil
@@ -336,6 +350,73 @@ func (gr *GenericReconciler) reconcileApply(ctx context.Context, metaObj azcorev
 	}
 }
 
+// detectDrift compares the resource in Azure with the spec to find changes made outside of Kubernetes. Any drift is
+// recorded on the object and, depending on the policy, undone by applying the spec again. The resource will be
+// requeued to be checked again after the drift detection interval.
+func (gr *GenericReconciler) detectDrift(ctx context.Context, metaObj azcorev1.MetaObject, resource *zips.Resource) (ctrl.Result, error) {
+	var drift []string
+	live, err := gr.Applier.GetResource(ctx, &zips.Resource{ID: resource.ID, APIVersion: resource.APIVersion})
+	switch {
+	case zips.IsNotFound(err):
+		// the resource has been deleted out from under us
+		drift = []string{"id"}
+	case err != nil:
+		return ctrl.Result{}, fmt.Errorf("failed to get resource %q with: %w", resource.ID, err)
+	default:
+		drift, err = zips.Drift(resource, live)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to compare resource %q with: %w", resource.ID, err)
+		}
+	}
+
+	result := ctrl.Result{
+		RequeueAfter: gr.DriftDetection.Interval,
+	}
+
+	if len(drift) == 0 {
+		condition, err := statusutil.GetCondition(metaObj, azcorev1.ConditionTypeDriftDetected)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		_, hasDrift := metaObj.GetAnnotations()[DriftDetectedAnnotationKey]
+		if !hasDrift && condition != nil && condition.Status == metav1.ConditionFalse {
+			return result, nil
+		}
+
+		err = patcher(ctx, gr.Client, metaObj, func(mutObj azcorev1.MetaObject) error {
+			setDriftAnnotation(mutObj, nil)
+			return statusutil.SetCondition(mutObj, azcorev1.Condition{
+				Type:   azcorev1.ConditionTypeDriftDetected,
+				Status: metav1.ConditionFalse,
+				Reason: azcorev1.ReasonInSync,
+			})
+		})
+		return result, err
+	}
+
+	msg := fmt.Sprintf("resource in Azure no longer matches spec, changed fields: %s", strings.Join(drift, ", "))
+	gr.Recorder.Event(metaObj, v1.EventTypeWarning, "DriftDetected", msg)
+
+	if err := patcher(ctx, gr.Client, metaObj, func(mutObj azcorev1.MetaObject) error {
+		setDriftAnnotation(mutObj, drift)
+		return statusutil.SetCondition(mutObj, azcorev1.Condition{
+			Type:    azcorev1.ConditionTypeDriftDetected,
+			Status:  metav1.ConditionTrue,
+			Reason:  azcorev1.ReasonDrifted,
+			Message: msg,
+		})
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to patch with: %w", err)
+	}
+
+	if gr.DriftDetection.Remediation == DriftRemediationReapply {
+		return gr.applySpecChange(ctx, metaObj)
+	}
+
+	return result, nil
+}
+
 func (gr *GenericReconciler) isResourceGroupReady(ctx context.Context, grouped azcorev1.Grouped) (bool, error) {
 	// has a resource group, so check if the resource group is already provisioned
 	groupRef := grouped.GetResourceGroupObjectRef()
@@ -551,6 +632,20 @@ func addResourceHashAnnotation(metaObj azcorev1.MetaObject) error {
 	return nil
 }
 
+func setDriftAnnotation(metaObj azcorev1.MetaObject, drift []string) {
+	annotations := metaObj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if len(drift) == 0 {
+		delete(annotations, DriftDetectedAnnotationKey)
+	} else {
+		annotations[DriftDetectedAnnotationKey] = strings.Join(drift, ",")
+	}
+	metaObj.SetAnnotations(annotations)
+}
+
 func patcher(ctx context.Context, c client.Client, metaObj azcorev1.MetaObject, mutator func(azcorev1.MetaObject) error) error {
 	patchHelper, err := patch.NewHelper(metaObj, c)
 	if err != nil {
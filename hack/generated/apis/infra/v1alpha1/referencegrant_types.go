@@ -0,0 +1,96 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ReferenceGrant allows resources in other namespaces to refer to resources in the namespace of the grant, either as
+// their owner or through a resource reference. Without a grant, resources can only refer to resources in their own
+// namespace.
+// +kubebuilder:object:root=true
+type ReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ReferenceGrantSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ReferenceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReferenceGrant `json:"items"`
+}
+
+type ReferenceGrantSpec struct {
+	// +kubebuilder:validation:MinItems=1
+	// From lists the namespaces and kinds of the resources allowed to refer to resources in this namespace
+	From []ReferenceGrantFrom `json:"from"`
+
+	// +kubebuilder:validation:MinItems=1
+	// To lists the resources in this namespace which may be referred to
+	To []ReferenceGrantTo `json:"to"`
+}
+
+// ReferenceGrantFrom identifies the resources allowed to make references
+type ReferenceGrantFrom struct {
+	// +kubebuilder:validation:Required
+	// Namespace is the namespace of the resources allowed to make references
+	Namespace string `json:"namespace"`
+
+	// +kubebuilder:validation:Required
+	// Group is the group of the resources allowed to make references, for example microsoft.network.infra.azure.com
+	Group string `json:"group"`
+
+	// +kubebuilder:validation:Required
+	// Kind is the kind of the resources allowed to make references, for example VirtualNetworksSubnet
+	Kind string `json:"kind"`
+}
+
+// ReferenceGrantTo identifies the resources which may be referred to
+type ReferenceGrantTo struct {
+	// +kubebuilder:validation:Required
+	// Group is the group of the resources which may be referred to
+	Group string `json:"group"`
+
+	// +kubebuilder:validation:Required
+	// Kind is the kind of the resources which may be referred to
+	Kind string `json:"kind"`
+
+	// Name is the name of the resource which may be referred to. If empty, any resource of the group and kind may be
+	// referred to.
+	Name string `json:"name,omitempty"`
+}
+
+// Allows returns true if the grant allows a resource of kind from in namespace fromNamespace to refer to the resource
+// of kind to called toName in the namespace of the grant
+func (grant *ReferenceGrant) Allows(fromNamespace string, from schema.GroupKind, to schema.GroupKind, toName string) bool {
+	fromAllowed := false
+	for _, f := range grant.Spec.From {
+		if f.Namespace == fromNamespace && f.Group == from.Group && f.Kind == from.Kind {
+			fromAllowed = true
+			break
+		}
+	}
+
+	if !fromAllowed {
+		return false
+	}
+
+	for _, t := range grant.Spec.To {
+		if t.Group == to.Group && t.Kind == to.Kind && (t.Name == "" || t.Name == toName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func init() {
+	SchemeBuilder.Register(&ReferenceGrant{}, &ReferenceGrantList{})
+}
@@ -0,0 +1,251 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package v20200601
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+type ResourceGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ResourceGroupSpec   `json:"spec,omitempty"`
+	Status            ResourceGroupStatus `json:"status,omitempty"`
+}
+
+var _ genruntime.KubernetesResource = &ResourceGroup{}
+
+// AzureName returns the Azure name of the resource
+func (rg *ResourceGroup) AzureName() string {
+	return rg.Spec.AzureName
+}
+
+// Owner returns the ResourceReference of the owner, or nil if there is no owner
+func (rg *ResourceGroup) Owner() *genruntime.ResourceReference {
+	return nil
+}
+
+var _ genruntime.LocatableResource = &ResourceGroup{}
+
+func (rg *ResourceGroup) Location() string {
+	return rg.Spec.Location
+}
+
+// +kubebuilder:webhook:path=/validate-microsoft-resources-infra-azure-com-v20200601-resourcegroup,mutating=false,sideEffects=None,matchPolicy=Exact,failurePolicy=fail,groups=microsoft.resources.infra.azure.com,resources=resourcegroups,verbs=create;update,versions=v20200601,name=validate.v20200601.resourcegroups.microsoft.resources.infra.azure.com
+
+var _ admission.Validator = &ResourceGroup{}
+
+// ValidateCreate validates the creation of the resource
+func (rg *ResourceGroup) ValidateCreate() error {
+	return genruntime.ValidateCreate(rg, nil)
+}
+
+// ValidateDelete validates the deletion of the resource
+func (rg *ResourceGroup) ValidateDelete() error {
+	return genruntime.ValidateDelete(rg)
+}
+
+// ValidateUpdate validates an update of the resource
+func (rg *ResourceGroup) ValidateUpdate(old runtime.Object) error {
+	return genruntime.ValidateUpdate(rg, old, []func(old runtime.Object) error{rg.validateImmutableProperties})
+}
+
+// validateImmutableProperties validates that the properties of the resource which can't be changed in Azure haven't been changed
+func (rg *ResourceGroup) validateImmutableProperties(old runtime.Object) error {
+	oldObj, ok := old.(*ResourceGroup)
+	if !ok {
+		return fmt.Errorf("unexpected type supplied for validateImmutableProperties() function. Expected *ResourceGroup, got %T", old)
+	}
+	if !genruntime.IsProvisioned(oldObj) {
+		return nil
+	}
+	err := genruntime.ValidateImmutableProperty("spec.azureName", oldObj.Spec.AzureName, rg.Spec.AzureName)
+	if err != nil {
+		return err
+	}
+	err = genruntime.ValidateImmutableProperty("spec.location", oldObj.Spec.Location, rg.Spec.Location)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// +kubebuilder:object:root=true
+type ResourceGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ResourceGroup `json:"items"`
+}
+
+type ResourceGroupStatus struct {
+	ID string `json:"id,omitempty"`
+
+	Name     string `json:"name,omitempty"`
+	Location string `json:"location,omitempty"`
+
+	// ManagedBy is the management group responsible for managing this group
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// Tags are user defined key value pairs
+	Tags map[string]string `json:"tags,omitempty"`
+
+	Properties *ResourceGroupStatusProperties `json:"properties,omitempty"` // TODO: Is this required or optional?
+
+	// Conditions describe the current state of the resource group
+	Conditions []genruntime.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the metadata.generation most recently acted on by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastAppliedSpecSignature is the signature of the spec most recently deployed to Azure
+	LastAppliedSpecSignature string `json:"lastAppliedSpecSignature,omitempty"`
+
+	// PendingSpecSignature is the signature of the spec currently being deployed to Azure, if a deployment is
+	// in flight
+	PendingSpecSignature string `json:"pendingSpecSignature,omitempty"`
+}
+
+var _ genruntime.ArmTransformer = &ResourceGroupStatus{}
+
+func (status *ResourceGroupStatus) CreateEmptyArmValue() interface{} {
+	return ResourceGroupStatusArm{}
+}
+
+// ConvertToArm converts from a Kubernetes CRD object to an ARM object
+func (status *ResourceGroupStatus) ConvertToArm(name string, resolver genruntime.ReferenceResolver) (interface{}, error) {
+	if status == nil {
+		return nil, nil
+	}
+	result := ResourceGroupStatusArm{}
+	result.ID = status.ID
+	result.Location = status.Location
+	result.ManagedBy = status.ManagedBy
+	result.Name = status.Name
+	result.Tags = status.Tags
+	if status.Properties != nil {
+		properties, err := status.Properties.ConvertToArm(name, resolver)
+		if err != nil {
+			return nil, err
+		}
+		propertiesTyped := properties.(ResourceGroupStatusPropertiesArm)
+		result.Properties = &propertiesTyped
+	}
+	return result, nil
+}
+
+// PopulateFromArm populates a Kubernetes CRD object from an Azure ARM object
+func (status *ResourceGroupStatus) PopulateFromArm(owner genruntime.KnownResourceReference, armInput interface{}) error {
+	typedInput, ok := armInput.(ResourceGroupStatusArm)
+	if !ok {
+		return fmt.Errorf("unexpected type supplied for PopulateFromArm() function. Expected ResourceGroupStatusArm, got %T", armInput)
+	}
+	status.ID = typedInput.ID
+	status.Location = typedInput.Location
+	status.ManagedBy = typedInput.ManagedBy
+	status.Name = typedInput.Name
+	status.Tags = typedInput.Tags
+	var err error
+	if typedInput.Properties != nil {
+		properties := ResourceGroupStatusProperties{}
+		err = properties.PopulateFromArm(owner, *typedInput.Properties)
+		if err != nil {
+			return err
+		}
+		status.Properties = &properties
+	}
+	return nil
+}
+
+type ResourceGroupStatusProperties struct {
+	ProvisioningState string `json:"provisioningState,omitempty"` // TODO: Wrong, needs to be in properties
+}
+
+var _ genruntime.ArmTransformer = &ResourceGroupStatusProperties{}
+
+func (p *ResourceGroupStatusProperties) CreateEmptyArmValue() interface{} {
+	return ResourceGroupStatusPropertiesArm{}
+}
+
+// ConvertToArm converts from a Kubernetes CRD object to an ARM object
+func (p *ResourceGroupStatusProperties) ConvertToArm(name string, resolver genruntime.ReferenceResolver) (interface{}, error) {
+	if p == nil {
+		return nil, nil
+	}
+	result := ResourceGroupStatusPropertiesArm{}
+	result.ProvisioningState = p.ProvisioningState
+	return result, nil
+}
+
+// PopulateFromArm populates a Kubernetes CRD object from an Azure ARM object
+func (p *ResourceGroupStatusProperties) PopulateFromArm(owner genruntime.KnownResourceReference, armInput interface{}) error {
+	typedInput, ok := armInput.(ResourceGroupStatusPropertiesArm)
+	if !ok {
+		return fmt.Errorf("unexpected type supplied for PopulateFromArm() function. Expected ResourceGroupStatusPropertiesArm, got %T", armInput)
+	}
+	p.ProvisioningState = typedInput.ProvisioningState
+	return nil
+}
+
+type ResourceGroupSpec struct {
+	//AzureName: The name of the resource in Azure. This is often the same as the name
+	//of the resource in Kubernetes but it doesn't have to be.
+	AzureName string `json:"azureName"`
+
+	// +kubebuilder:validation:Required
+	// Location is the Azure location for the group (eg westus2, southcentralus, etc...)
+	Location string `json:"location"`
+
+	// ManagedBy is the management group responsible for managing this group
+	ManagedBy string `json:"managedBy,omitempty"`
+
+	// Tags are user defined key value pairs
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+var _ genruntime.ArmTransformer = &ResourceGroupSpec{}
+
+func (spec *ResourceGroupSpec) CreateEmptyArmValue() interface{} {
+	return ResourceGroupSpecArm{}
+}
+
+// ConvertToArm converts from a Kubernetes CRD object to an ARM object
+func (spec *ResourceGroupSpec) ConvertToArm(name string, resolver genruntime.ReferenceResolver) (interface{}, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	result := ResourceGroupSpecArm{}
+	result.ApiVersion = "2020-06-01" // TODO: Update this to match what the codegenerated resources do with APIVersion eventually
+	result.Location = spec.Location
+	result.Name = name
+	result.ManagedBy = spec.ManagedBy
+	result.Tags = spec.Tags
+	result.Type = ResourceGroupTypeResourceGroup
+	return result, nil
+}
+
+// PopulateFromArm populates a Kubernetes CRD object from an Azure ARM object
+func (spec *ResourceGroupSpec) PopulateFromArm(owner genruntime.KnownResourceReference, armInput interface{}) error {
+	typedInput, ok := armInput.(ResourceGroupSpecArm)
+	if !ok {
+		return fmt.Errorf("unexpected type supplied for PopulateFromArm() function. Expected ResourceGroupSpecArm, got %T", armInput)
+	}
+	// spec.ApiVersion = typedInput.ApiVersion
+	spec.AzureName = genruntime.ExtractKubernetesResourceNameFromArmName(typedInput.Name)
+	spec.Location = typedInput.Location
+	spec.ManagedBy = typedInput.ManagedBy
+	spec.Tags = typedInput.Tags
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&ResourceGroup{}, &ResourceGroupList{})
+}
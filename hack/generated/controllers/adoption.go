@@ -0,0 +1,35 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"github.com/pkg/errors"
+)
+
+// AdoptionPolicy determines how the controller brings a resource which already exists in Azure (for example because
+// it was created by Terraform) under management, rather than deploying it from scratch
+type AdoptionPolicy string
+
+const (
+	// AdoptionPolicyNone deploys the spec to Azure without first checking whether the resource already exists
+	AdoptionPolicyNone = AdoptionPolicy("")
+	// AdoptionPolicyReview imports the state of the existing resource into status and reports how it differs from the
+	// spec. The spec is only deployed once the user confirms the adoption with the AdoptConfirmedAnnotation.
+	AdoptionPolicyReview = AdoptionPolicy("Review")
+	// AdoptionPolicyAuto imports the state of the existing resource into status and deploys the spec straight away
+	// if it differs from the existing resource
+	AdoptionPolicyAuto = AdoptionPolicy("Auto")
+)
+
+// validate returns an error if the policy is not valid
+func (p AdoptionPolicy) validate() error {
+	switch p {
+	case AdoptionPolicyNone, AdoptionPolicyReview, AdoptionPolicyAuto:
+		return nil
+	default:
+		return errors.Errorf("unknown adoption policy %q, expected %q or %q", p, AdoptionPolicyReview, AdoptionPolicyAuto)
+	}
+}
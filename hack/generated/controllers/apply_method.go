@@ -0,0 +1,92 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+)
+
+// ApplyMethod determines how the controller creates and updates resources in Azure
+type ApplyMethod string
+
+const (
+	// ApplyMethodDeployment wraps each resource in an ARM template deployment
+	ApplyMethodDeployment = ApplyMethod("Deployment")
+	// ApplyMethodDirect PUTs each resource directly at its ARM ID, following the long-running operation Azure starts
+	// to create or update it. This avoids using up the deployment history of resource groups and reports the errors
+	// of the resource provider as they are.
+	ApplyMethodDirect = ApplyMethod("Direct")
+)
+
+// applyMethodFor returns the apply method for the given kind of resource, which is the override for that kind if there
+// is one and the default otherwise
+func applyMethodFor(gk schema.GroupKind, defaultMethod ApplyMethod, overrides map[schema.GroupKind]ApplyMethod) ApplyMethod {
+	method, ok := overrides[gk]
+	if !ok {
+		method = defaultMethod
+	}
+
+	if method == "" {
+		method = ApplyMethodDeployment
+	}
+
+	return method
+}
+
+// ParseApplyMethodOverrides parses a comma separated list of per-kind apply methods, each of the form Kind.group=method,
+// for example "RoleAssignment.microsoft.authorization.infra.azure.com=Direct"
+func ParseApplyMethodOverrides(value string) (map[schema.GroupKind]ApplyMethod, error) {
+	result := make(map[schema.GroupKind]ApplyMethod)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("apply method override %q must be of the form Kind.group=method", item)
+		}
+
+		method := ApplyMethod(parts[1])
+		if err := method.validate(); err != nil {
+			return nil, errors.Wrapf(err, "apply method override %q", item)
+		}
+
+		result[schema.ParseGroupKind(parts[0])] = method
+	}
+
+	return result, nil
+}
+
+func (m ApplyMethod) validate() error {
+	switch m {
+	case "", ApplyMethodDeployment, ApplyMethodDirect:
+		return nil
+	default:
+		return errors.Errorf("unknown apply method %q, expected %q or %q", m, ApplyMethodDeployment, ApplyMethodDirect)
+	}
+}
+
+// applierFor returns an applier which applies resources using the given method, using the connection to Azure of
+// the given applier
+func applierFor(applier armclient.Applier, method ApplyMethod) (armclient.Applier, error) {
+	if method != ApplyMethodDirect {
+		return applier, nil
+	}
+
+	direct, ok := applier.(armclient.DirectApplier)
+	if !ok {
+		return nil, errors.Errorf("%T doesn't support applying resources directly", applier)
+	}
+
+	return direct.Direct(), nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+)
+
+func Test_ParseApplyMethodOverrides(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	overrides, err := ParseApplyMethodOverrides("RoleAssignment.microsoft.authorization.infra.azure.com=Direct, ResourceGroup.microsoft.resources.infra.azure.com=Deployment")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	roleAssignment := schema.GroupKind{Group: "microsoft.authorization.infra.azure.com", Kind: "RoleAssignment"}
+	resourceGroup := schema.GroupKind{Group: "microsoft.resources.infra.azure.com", Kind: "ResourceGroup"}
+	other := schema.GroupKind{Group: "microsoft.batch.infra.azure.com", Kind: "BatchAccount"}
+
+	g.Expect(applyMethodFor(roleAssignment, "", overrides)).To(Equal(ApplyMethodDirect))
+	g.Expect(applyMethodFor(resourceGroup, ApplyMethodDirect, overrides)).To(Equal(ApplyMethodDeployment))
+	g.Expect(applyMethodFor(other, "", overrides)).To(Equal(ApplyMethodDeployment))
+	g.Expect(applyMethodFor(other, ApplyMethodDirect, overrides)).To(Equal(ApplyMethodDirect))
+
+	_, err = ParseApplyMethodOverrides("ResourceGroup.microsoft.resources.infra.azure.com=Patch")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_ApplierFor(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	atc, err := armclient.NewAzureTemplateClient(autorest.NullAuthorizer{}, "1234")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	applier, err := applierFor(atc, ApplyMethodDeployment)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier).To(BeIdenticalTo(atc))
+
+	applier, err = applierFor(atc, ApplyMethodDirect)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier).To(BeAssignableToTypeOf(&armclient.DirectClient{}))
+	g.Expect(applier.SubscriptionID()).To(Equal("1234"))
+}
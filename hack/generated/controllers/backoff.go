@@ -0,0 +1,79 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type backoffState struct {
+	generation int64
+	attempts   int
+}
+
+// requeueBackoff tracks how many times in a row each resource has been requeued to poll Azure, so that
+// the delay between polls grows exponentially (up to a ceiling) for long running or stuck operations.
+// The count is reset whenever the spec of the resource changes (as indicated by its generation).
+type requeueBackoff struct {
+	base   time.Duration
+	max    time.Duration
+	factor float64
+
+	lock  sync.Mutex
+	state map[types.NamespacedName]backoffState
+}
+
+func newRequeueBackoff(base time.Duration, max time.Duration, factor float64) *requeueBackoff {
+	return &requeueBackoff{
+		base:   base,
+		max:    max,
+		factor: factor,
+		state:  make(map[types.NamespacedName]backoffState),
+	}
+}
+
+// Next records another attempt for the given resource and returns how long to wait before the next one
+func (b *requeueBackoff) Next(obj metav1.Object) time.Duration {
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	state, ok := b.state[key]
+	if !ok || state.generation != obj.GetGeneration() {
+		state = backoffState{generation: obj.GetGeneration()}
+	}
+
+	delay := b.delay(state.attempts)
+	state.attempts++
+	b.state[key] = state
+
+	return delay
+}
+
+// Forget resets the attempts for the given resource
+func (b *requeueBackoff) Forget(obj metav1.Object) {
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.state, key)
+}
+
+func (b *requeueBackoff) delay(attempts int) time.Duration {
+	delay := float64(b.base) * math.Pow(b.factor, float64(attempts))
+	if delay > float64(b.max) {
+		return b.max
+	}
+
+	return time.Duration(delay)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_RequeueBackoff_GrowsUpToCeiling(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backoff := newRequeueBackoff(time.Second, 10*time.Second, 2)
+	obj := &metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 1}
+
+	g.Expect(backoff.Next(obj)).To(Equal(1 * time.Second))
+	g.Expect(backoff.Next(obj)).To(Equal(2 * time.Second))
+	g.Expect(backoff.Next(obj)).To(Equal(4 * time.Second))
+	g.Expect(backoff.Next(obj)).To(Equal(8 * time.Second))
+	g.Expect(backoff.Next(obj)).To(Equal(10 * time.Second))
+	g.Expect(backoff.Next(obj)).To(Equal(10 * time.Second))
+}
+
+func Test_RequeueBackoff_IsPerResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backoff := newRequeueBackoff(time.Second, time.Minute, 2)
+	a := &metav1.ObjectMeta{Namespace: "ns", Name: "a"}
+	b := &metav1.ObjectMeta{Namespace: "ns", Name: "b"}
+
+	g.Expect(backoff.Next(a)).To(Equal(1 * time.Second))
+	g.Expect(backoff.Next(a)).To(Equal(2 * time.Second))
+	g.Expect(backoff.Next(b)).To(Equal(1 * time.Second))
+}
+
+func Test_RequeueBackoff_ResetsOnSpecChange(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backoff := newRequeueBackoff(time.Second, time.Minute, 2)
+	obj := &metav1.ObjectMeta{Namespace: "ns", Name: "a", Generation: 1}
+
+	g.Expect(backoff.Next(obj)).To(Equal(1 * time.Second))
+	g.Expect(backoff.Next(obj)).To(Equal(2 * time.Second))
+
+	obj.Generation = 2
+	g.Expect(backoff.Next(obj)).To(Equal(1 * time.Second))
+}
+
+func Test_RequeueBackoff_Forget(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backoff := newRequeueBackoff(time.Second, time.Minute, 2)
+	obj := &metav1.ObjectMeta{Namespace: "ns", Name: "a"}
+
+	g.Expect(backoff.Next(obj)).To(Equal(1 * time.Second))
+	g.Expect(backoff.Next(obj)).To(Equal(2 * time.Second))
+
+	backoff.Forget(obj)
+	g.Expect(backoff.Next(obj)).To(Equal(1 * time.Second))
+}
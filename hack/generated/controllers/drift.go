@@ -0,0 +1,131 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/Azure/k8s-infra/pkg/util/driftutil"
+)
+
+// DriftRemediation determines what the controller does when it finds that a resource in Azure no longer matches
+// its spec, for example because somebody changed it through the portal
+type DriftRemediation string
+
+const (
+	// DriftRemediationReport records the drift on the resource but leaves Azure alone
+	DriftRemediationReport = DriftRemediation("Report")
+	// DriftRemediationReapply redeploys the spec to Azure, undoing the drift
+	DriftRemediationReapply = DriftRemediation("Reapply")
+)
+
+// DriftDetectionPolicy configures periodic checks that resources in Azure still match their specs
+type DriftDetectionPolicy struct {
+	// Interval is how often each resource is checked. Zero disables drift detection.
+	Interval time.Duration
+	// Remediation is what to do when drift is found. Defaults to DriftRemediationReport.
+	Remediation DriftRemediation
+}
+
+// Enabled returns true if resources should be checked for drift
+func (p DriftDetectionPolicy) Enabled() bool {
+	return p.Interval > 0
+}
+
+// driftDetectionPolicyFor returns the policy for the given kind of resource, which is the override for that kind if
+// there is one and the default otherwise
+func driftDetectionPolicyFor(gk schema.GroupKind, defaultPolicy DriftDetectionPolicy, overrides map[schema.GroupKind]DriftDetectionPolicy) DriftDetectionPolicy {
+	policy, ok := overrides[gk]
+	if !ok {
+		policy = defaultPolicy
+	}
+
+	if policy.Remediation == "" {
+		policy.Remediation = DriftRemediationReport
+	}
+
+	return policy
+}
+
+// ParseDriftDetectionOverrides parses a comma separated list of per-kind drift detection policies, each of the form
+// Kind.group=interval[:remediation], for example "StorageAccount.microsoft.storage.infra.azure.com=10m:Reapply"
+func ParseDriftDetectionOverrides(value string) (map[schema.GroupKind]DriftDetectionPolicy, error) {
+	result := make(map[schema.GroupKind]DriftDetectionPolicy)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("drift detection override %q must be of the form Kind.group=interval[:remediation]", item)
+		}
+
+		settings := strings.SplitN(parts[1], ":", 2)
+		interval, err := time.ParseDuration(settings[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing interval of drift detection override %q", item)
+		}
+
+		policy := DriftDetectionPolicy{Interval: interval}
+		if len(settings) == 2 {
+			policy.Remediation = DriftRemediation(settings[1])
+			if err := policy.validate(); err != nil {
+				return nil, errors.Wrapf(err, "drift detection override %q", item)
+			}
+		}
+
+		result[schema.ParseGroupKind(parts[0])] = policy
+	}
+
+	return result, nil
+}
+
+func (p DriftDetectionPolicy) validate() error {
+	switch p.Remediation {
+	case "", DriftRemediationReport, DriftRemediationReapply:
+		return nil
+	default:
+		return errors.Errorf("unknown drift remediation %q, expected %q or %q", p.Remediation, DriftRemediationReport, DriftRemediationReapply)
+	}
+}
+
+// ignoredDriftFields are the top level fields of an ARM spec which either aren't returned by a GET of the resource
+// or are returned in a different form (for example the name of a child resource is qualified by its parents in the
+// spec but not in Azure's response)
+var ignoredDriftFields = map[string]bool{
+	"apiVersion": true,
+	"dependsOn":  true,
+	"name":       true,
+	"type":       true,
+}
+
+// findDrift compares the ARM spec of a resource with the live resource returned by Azure, and returns the paths
+// of the fields which differ, as described by driftutil.Compare
+func findDrift(spec interface{}, live map[string]interface{}) ([]string, error) {
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "serializing ARM spec")
+	}
+
+	var desired map[string]interface{}
+	err = json.Unmarshal(specBytes, &desired)
+	if err != nil {
+		return nil, errors.Wrap(err, "deserializing ARM spec")
+	}
+
+	for field := range ignoredDriftFields {
+		delete(desired, field)
+	}
+
+	return driftutil.Compare(desired, live), nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type testArmSpec struct {
+	ApiVersion string            `json:"apiVersion"`
+	Name       string            `json:"name"`
+	Location   string            `json:"location"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Properties map[string]interface{}
+}
+
+func liveResource(g *WithT, body string) map[string]interface{} {
+	live := make(map[string]interface{})
+	g.Expect(json.Unmarshal([]byte(body), &live)).To(Succeed())
+	return live
+}
+
+func Test_FindDrift_IgnoresNormalizedAndReadOnlyFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := testArmSpec{
+		ApiVersion: "2019-04-01",
+		Name:       "account/default",
+		Location:   "West US",
+		Properties: map[string]interface{}{"accessTier": "Hot"},
+	}
+
+	live := liveResource(g, `{
+		"id": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/account",
+		"name": "default",
+		"location": "westus",
+		"Properties": {"accessTier": "hot", "provisioningState": "Succeeded"}
+	}`)
+
+	drift, err := findDrift(spec, live)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(drift).To(BeEmpty())
+}
+
+func Test_FindDrift_ReportsChangedFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := testArmSpec{
+		Location: "westus",
+		Tags:     map[string]string{"team": "a"},
+		Properties: map[string]interface{}{
+			"supportsHttpsTrafficOnly": true,
+			"ipRules":                  []interface{}{"10.0.0.1"},
+		},
+	}
+
+	live := liveResource(g, `{
+		"location": "westus",
+		"tags": {"team": "b"},
+		"Properties": {"supportsHttpsTrafficOnly": false, "ipRules": ["10.0.0.1", "10.0.0.2"]}
+	}`)
+
+	drift, err := findDrift(spec, live)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(drift).To(Equal([]string{"Properties.ipRules", "Properties.supportsHttpsTrafficOnly", "tags.team"}))
+}
+
+func Test_ParseDriftDetectionOverrides(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	overrides, err := ParseDriftDetectionOverrides("StorageAccount.microsoft.storage.infra.azure.com=10m:Reapply, ResourceGroup.microsoft.resources.infra.azure.com=1h")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	storageAccount := schema.GroupKind{Group: "microsoft.storage.infra.azure.com", Kind: "StorageAccount"}
+	resourceGroup := schema.GroupKind{Group: "microsoft.resources.infra.azure.com", Kind: "ResourceGroup"}
+	other := schema.GroupKind{Group: "microsoft.batch.infra.azure.com", Kind: "BatchAccount"}
+	defaultPolicy := DriftDetectionPolicy{Interval: time.Minute}
+
+	g.Expect(driftDetectionPolicyFor(storageAccount, defaultPolicy, overrides)).To(Equal(DriftDetectionPolicy{Interval: 10 * time.Minute, Remediation: DriftRemediationReapply}))
+	g.Expect(driftDetectionPolicyFor(resourceGroup, defaultPolicy, overrides)).To(Equal(DriftDetectionPolicy{Interval: time.Hour, Remediation: DriftRemediationReport}))
+	g.Expect(driftDetectionPolicyFor(other, defaultPolicy, overrides)).To(Equal(DriftDetectionPolicy{Interval: time.Minute, Remediation: DriftRemediationReport}))
+
+	_, err = ParseDriftDetectionOverrides("StorageAccount.microsoft.storage.infra.azure.com=10m:Delete")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,261 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+// exportedValues holds the values to write to each secret or config map, keyed by the name of the secret or config
+// map and then by key
+type exportedValues map[string]map[string]string
+
+// exportSource returns the JSON (as unmarshalled into an interface{}) that values are exported from; the status of
+// the resource if action is empty, otherwise the result of the action
+type exportSource func(action string) (interface{}, error)
+
+// collectExportedValues looks up the value of each of the given exports
+func collectExportedValues(exports []genruntime.ExportedValue, source exportSource) (exportedValues, error) {
+	result := make(exportedValues)
+	for _, export := range exports {
+		from, err := source(export.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := lookupPath(from, export.Path)
+		if err != nil {
+			if export.Action != "" {
+				return nil, errors.Wrapf(err, "looking up %q in result of %s for %s/%s", export.Path, export.Action, export.Name, export.Key)
+			}
+
+			return nil, errors.Wrapf(err, "looking up %q in status for %s/%s", export.Path, export.Name, export.Key)
+		}
+
+		if _, ok := result[export.Name]; !ok {
+			result[export.Name] = make(map[string]string)
+		}
+
+		result[export.Name][export.Key] = value
+	}
+
+	return result, nil
+}
+
+// checkExportActions returns an error if any of the values requested by operatorSpec would be exported from an action
+// which isn't allowed. Actions are invoked with the credentials of the operator each time the resource is reconciled,
+// so only read-only list actions are allowed, and none at all on resources which are only observed.
+func checkExportActions(operatorSpec *genruntime.OperatorSpec, policy ReconcilePolicy) error {
+	for _, exports := range [][]genruntime.ExportedValue{operatorSpec.Secrets, operatorSpec.ConfigMaps} {
+		for _, export := range exports {
+			if export.Action == "" {
+				continue
+			}
+
+			if policy == ReconcilePolicyObserve {
+				return errors.Errorf(
+					"action %q for %s/%s can't be invoked on a resource with reconcile policy %s",
+					export.Action,
+					export.Name,
+					export.Key,
+					policy)
+			}
+
+			err := genruntime.ValidateExportAction(export.Action)
+			if err != nil {
+				return errors.Wrapf(err, "exporting %s/%s", export.Name, export.Key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookupPath returns the value at the given dot separated path within the given JSON. Strings are returned as they
+// are, any other value is returned as JSON.
+func lookupPath(value interface{}, path string) (string, error) {
+	current := value
+	for _, step := range strings.Split(path, ".") {
+		switch c := current.(type) {
+		case map[string]interface{}:
+			next, ok := c[step]
+			if !ok {
+				return "", errors.Errorf("no property %q", step)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(step)
+			if err != nil || index < 0 || index >= len(c) {
+				return "", errors.Errorf("no element %q in array of length %d", step, len(c))
+			}
+			current = c[index]
+		default:
+			return "", errors.Errorf("can't look up %q in %T", step, current)
+		}
+	}
+
+	switch c := current.(type) {
+	case nil:
+		return "", errors.Errorf("value is null")
+	case string:
+		return c, nil
+	default:
+		raw, err := json.Marshal(c)
+		if err != nil {
+			return "", err
+		}
+
+		return string(raw), nil
+	}
+}
+
+// statusJSON returns the status of the given object as JSON, unmarshalled into an interface{}
+func statusJSON(metaObj genruntime.MetaObject) (interface{}, error) {
+	raw, err := json.Marshal(metaObj)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return fields["status"], nil
+}
+
+// exportConflict is returned when values can't be exported into a secret or config map because one of that name
+// already exists and isn't controlled by the resource exporting the values
+type exportConflict struct {
+	kind string
+	name string
+}
+
+var _ error = &exportConflict{}
+
+func (e *exportConflict) Error() string {
+	return fmt.Sprintf("%s %q already exists and is not controlled by this resource", e.kind, e.name)
+}
+
+// writeExportedValues writes the given values into secrets and config maps in the namespace of owner, creating them
+// if need be. They're controlled by owner so are deleted along with it. Secrets and config maps which already exist
+// but aren't controlled by owner are left alone, and an exportConflict is returned.
+func writeExportedValues(
+	ctx context.Context,
+	kubeClient *kubeclient.Client,
+	owner genruntime.MetaObject,
+	secrets exportedValues,
+	configMaps exportedValues) error {
+
+	for _, name := range sortedNames(secrets) {
+		secret := &v1.Secret{}
+		err := writeControlledObject(ctx, kubeClient, owner, name, secret, func() {
+			if secret.Data == nil {
+				secret.Data = make(map[string][]byte)
+			}
+
+			for key, value := range secrets[name] {
+				secret.Data[key] = []byte(value)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedNames(configMaps) {
+		configMap := &v1.ConfigMap{}
+		err := writeControlledObject(ctx, kubeClient, owner, name, configMap, func() {
+			if configMap.Data == nil {
+				configMap.Data = make(map[string]string)
+			}
+
+			for key, value := range configMaps[name] {
+				configMap.Data[key] = value
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportedObject is a secret or config map values are exported into
+type exportedObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// writeControlledObject creates the named object in the namespace of owner with mutate applied, or applies mutate to
+// it if it already exists and is controlled by owner
+func writeControlledObject(
+	ctx context.Context,
+	kubeClient *kubeclient.Client,
+	owner genruntime.MetaObject,
+	name string,
+	obj exportedObject,
+	mutate func()) error {
+
+	kind := "config map"
+	if _, ok := obj.(*v1.Secret); ok {
+		kind = "secret"
+	}
+
+	key := types.NamespacedName{Namespace: owner.GetNamespace(), Name: name}
+	err := kubeClient.Client.Get(ctx, key, obj)
+	if apierrors.IsNotFound(err) {
+		obj.SetName(name)
+		obj.SetNamespace(owner.GetNamespace())
+		mutate()
+
+		err = controllerutil.SetControllerReference(owner, obj, kubeClient.Scheme)
+		if err != nil {
+			return errors.Wrapf(err, "setting owner of %s %q", kind, name)
+		}
+
+		return errors.Wrapf(kubeClient.Client.Create(ctx, obj), "creating %s %q", kind, name)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "getting %s %q", kind, name)
+	}
+
+	if !metav1.IsControlledBy(obj, owner) {
+		return &exportConflict{kind: kind, name: name}
+	}
+
+	mutate()
+	return errors.Wrapf(kubeClient.Client.Update(ctx, obj), "updating %s %q", kind, name)
+}
+
+func sortedNames(values exportedValues) []string {
+	result := make([]string, 0, len(values))
+	for name := range values {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,202 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+func exportSourceJSON(g *WithT, body string) interface{} {
+	var result interface{}
+	g.Expect(json.Unmarshal([]byte(body), &result)).To(Succeed())
+	return result
+}
+
+func Test_LookupPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	source := exportSourceJSON(g, `{
+		"primaryEndpoints": {"blob": "https://account.blob.core.windows.net/"},
+		"keys": [{"keyName": "key1", "value": "secret1"}, {"keyName": "key2", "value": "secret2"}],
+		"count": 3
+	}`)
+
+	g.Expect(lookupPath(source, "primaryEndpoints.blob")).To(Equal("https://account.blob.core.windows.net/"))
+	g.Expect(lookupPath(source, "keys.1.value")).To(Equal("secret2"))
+	g.Expect(lookupPath(source, "count")).To(Equal("3"))
+	g.Expect(lookupPath(source, "primaryEndpoints")).To(Equal(`{"blob":"https://account.blob.core.windows.net/"}`))
+
+	_, err := lookupPath(source, "keys.2.value")
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = lookupPath(source, "primaryEndpoints.queue")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_CheckExportActions_AllowsListActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	operatorSpec := &genruntime.OperatorSpec{
+		Secrets:    []genruntime.ExportedValue{{Name: "storage", Key: "key1", Action: "listKeys", Path: "keys.0.value"}},
+		ConfigMaps: []genruntime.ExportedValue{{Name: "storage", Key: "endpoint", Path: "primaryEndpoints.blob"}},
+	}
+
+	g.Expect(checkExportActions(operatorSpec, ReconcilePolicyManage)).To(Succeed())
+}
+
+func Test_CheckExportActions_RejectsActionsWhichChangeTheResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, action := range []string{"regenerateKey", "restart", "listKeys/../../other/regenerateKey"} {
+		operatorSpec := &genruntime.OperatorSpec{
+			Secrets: []genruntime.ExportedValue{{Name: "storage", Key: "key1", Action: action, Path: "keys.0.value"}},
+		}
+
+		g.Expect(checkExportActions(operatorSpec, ReconcilePolicyManage)).ToNot(Succeed(), action)
+	}
+}
+
+func Test_CheckExportActions_GivenObservedResource_RejectsActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	operatorSpec := &genruntime.OperatorSpec{
+		ConfigMaps: []genruntime.ExportedValue{{Name: "storage", Key: "endpoint", Path: "primaryEndpoints.blob"}},
+	}
+	g.Expect(checkExportActions(operatorSpec, ReconcilePolicyObserve)).To(Succeed())
+
+	operatorSpec.Secrets = []genruntime.ExportedValue{{Name: "storage", Key: "key1", Action: "listKeys", Path: "keys.0.value"}}
+	g.Expect(checkExportActions(operatorSpec, ReconcilePolicyObserve)).ToNot(Succeed())
+}
+
+func Test_CollectExportedValues_InvokesEachActionOnce(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	status := exportSourceJSON(g, `{"primaryEndpoints": {"blob": "https://account.blob.core.windows.net/"}}`)
+	listKeys := exportSourceJSON(g, `{"keys": [{"value": "secret1"}, {"value": "secret2"}]}`)
+
+	calls := make(map[string]int)
+	source := func(action string) (interface{}, error) {
+		calls[action]++
+		if action == "" {
+			return status, nil
+		}
+
+		return listKeys, nil
+	}
+
+	exports := []genruntime.ExportedValue{
+		{Name: "storage", Key: "endpoint", Path: "primaryEndpoints.blob"},
+		{Name: "storage", Key: "key1", Action: "listKeys", Path: "keys.0.value"},
+		{Name: "storage-backup", Key: "key2", Action: "listKeys", Path: "keys.1.value"},
+	}
+
+	values, err := collectExportedValues(exports, source)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(values).To(Equal(exportedValues{
+		"storage":        {"endpoint": "https://account.blob.core.windows.net/", "key1": "secret1"},
+		"storage-backup": {"key2": "secret2"},
+	}))
+	g.Expect(calls).To(HaveKeyWithValue("listKeys", 2))
+}
+
+func newExportTestScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = resources.AddToScheme(s)
+	return s
+}
+
+func newExportTestOwner() *resources.ResourceGroup {
+	return &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myrg",
+			Namespace: "team-a",
+			UID:       "uid",
+		},
+	}
+}
+
+func Test_WriteExportedValues_CreatesOwnedSecretsAndConfigMaps(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	s := newExportTestScheme()
+	owner := newExportTestOwner()
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "storage",
+			Namespace: "team-a",
+		},
+		Data: map[string][]byte{"other": []byte("kept")},
+	}
+	g.Expect(controllerutil.SetControllerReference(owner, existing, s)).To(Succeed())
+
+	kubeClient := kubeclient.NewClient(fake.NewFakeClientWithScheme(s, existing), s)
+
+	err := writeExportedValues(
+		ctx,
+		kubeClient,
+		owner,
+		exportedValues{"storage": {"key1": "secret1"}},
+		exportedValues{"storage-endpoints": {"blob": "https://account.blob.core.windows.net/"}})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var secret v1.Secret
+	g.Expect(kubeClient.Client.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "storage"}, &secret)).To(Succeed())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{"other": []byte("kept"), "key1": []byte("secret1")}))
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+	g.Expect(secret.OwnerReferences[0].Name).To(Equal("myrg"))
+
+	var configMap v1.ConfigMap
+	g.Expect(kubeClient.Client.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "storage-endpoints"}, &configMap)).To(Succeed())
+	g.Expect(configMap.Data).To(Equal(map[string]string{"blob": "https://account.blob.core.windows.net/"}))
+	g.Expect(configMap.OwnerReferences).To(HaveLen(1))
+}
+
+func Test_WriteExportedValues_GivenSecretNotControlledByOwner_LeavesItAlone(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	s := newExportTestScheme()
+	owner := newExportTestOwner()
+
+	existing := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "storage",
+			Namespace: "team-a",
+		},
+		Data: map[string][]byte{"key1": []byte("unrelated")},
+	}
+
+	kubeClient := kubeclient.NewClient(fake.NewFakeClientWithScheme(s, existing), s)
+
+	err := writeExportedValues(ctx, kubeClient, owner, exportedValues{"storage": {"key1": "secret1"}}, nil)
+	var conflict *exportConflict
+	g.Expect(errors.As(err, &conflict)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring(`secret "storage"`)))
+
+	var secret v1.Secret
+	g.Expect(kubeClient.Client.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "storage"}, &secret)).To(Succeed())
+	g.Expect(secret.Data).To(Equal(map[string][]byte{"key1": []byte("unrelated")}))
+	g.Expect(secret.OwnerReferences).To(BeEmpty())
+}
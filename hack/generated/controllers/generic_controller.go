@@ -33,6 +33,7 @@ import (
 	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/reflecthelpers"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/util/armresourceresolver"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/credentialresolver"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/util/patch"
 )
@@ -44,6 +45,10 @@ const (
 
 // TODO: We need to generate this
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infra.azure.com,resources=referencegrants,verbs=get;list;watch
 // +kubebuilder:rbac:groups=microsoft.resources.infra.azure.com,resources=resourcegroups,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=microsoft.resources.infra.azure.com,resources=resourcegroups/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=microsoft.storage.infra.azure.com,resources=storageaccounts,verbs=get;list;watch;create;update;patch;delete
@@ -61,6 +66,7 @@ type GenericReconciler struct {
 	ARMClient            armclient.Applier
 	KubeClient           *kubeclient.Client
 	ResourceResolver     *armresourceresolver.Resolver
+	CredentialResolver   *credentialresolver.Resolver
 	Recorder             record.EventRecorder
 	Name                 string
 	GVK                  schema.GroupVersionKind
@@ -68,6 +74,21 @@ type GenericReconciler struct {
 	RequeueDelay         time.Duration
 	RequeueDelayFast     time.Duration
 	CreateDeploymentName func(obj metav1.Object) (string, error)
+	DriftDetection       DriftDetectionPolicy
+	// ObserveInterval is how often the status of resources with ReconcilePolicyObserve is refreshed from Azure
+	ObserveInterval time.Duration
+	// ExportInterval is how often values exported from resources in their desired state are refreshed
+	ExportInterval time.Duration
+	// ResourceKinds are the kinds of resource managed by the generic controllers, any of which may be owned by a
+	// resource of this kind
+	ResourceKinds []schema.GroupVersionKind
+	// DefaultDeploymentLocation is where deployments of management group and tenant resources without a location of
+	// their own are stored
+	DefaultDeploymentLocation string
+	// ApplyMethod is how resources of this kind are created and updated in Azure
+	ApplyMethod ApplyMethod
+
+	backoff *requeueBackoff
 }
 
 type ReconcileAction string
@@ -79,6 +100,10 @@ const (
 	ReconcileActionMonitorDeployment = ReconcileAction("MonitorDeployment")
 	ReconcileActionBeginDelete       = ReconcileAction("BeginDelete")
 	ReconcileActionMonitorDelete     = ReconcileAction("MonitorDelete")
+	ReconcileActionDetectDrift       = ReconcileAction("DetectDrift")
+	ReconcileActionAdopt             = ReconcileAction("Adopt")
+	ReconcileActionObserve           = ReconcileAction("Observe")
+	ReconcileActionExportValues      = ReconcileAction("ExportValues")
 )
 
 type ReconcileActionFunc = func(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error)
@@ -87,9 +112,49 @@ type Options struct {
 	controller.Options
 
 	// options specific to our controller
-	RequeueDelay         time.Duration
+
+	// RequeueDelay is the initial delay before polling Azure again for a resource whose deployment or
+	// deletion is in progress, or whose owner isn't ready yet
+	RequeueDelay time.Duration
+	// MaxRequeueDelay is the ceiling on the delay between polls of a single resource
+	MaxRequeueDelay time.Duration
+	// RequeueBackoffFactor is the factor the delay between polls of a single resource grows by each time
+	RequeueBackoffFactor float64
+	// RequeueDelayFast is the delay used when moving directly on to the next stage of reconciliation
 	RequeueDelayFast     time.Duration
 	CreateDeploymentName func(obj metav1.Object) (string, error)
+
+	// ApplierFactory creates ARM clients for resources whose credentials are supplied by a Secret referenced
+	// from the resource or its namespace. If nil, only the default credentials are supported.
+	ApplierFactory credentialresolver.ApplierFactory
+	// ManagedIdentities lists the managed identities attached to the operator which credential Secrets in each
+	// namespace may use. Secrets requesting any other managed identity are rejected.
+	ManagedIdentities credentialresolver.ManagedIdentityAllowList
+	// ArmIds lists the ARM ID prefixes under which resources in each namespace may refer to Azure resources directly
+	// by ARM ID, rather than to resources in Kubernetes. References to any other ARM ID are rejected.
+	ArmIds armresourceresolver.ArmIdAllowList
+
+	// DriftDetection configures periodic checks that resources in Azure haven't been changed outside of
+	// Kubernetes. It is disabled by default.
+	DriftDetection DriftDetectionPolicy
+	// DriftDetectionOverrides configures drift detection for specific kinds of resource, in place of DriftDetection
+	DriftDetectionOverrides map[schema.GroupKind]DriftDetectionPolicy
+
+	// ObserveInterval is how often the status of resources with ReconcilePolicyObserve is refreshed from Azure
+	ObserveInterval time.Duration
+
+	// ExportInterval is how often the values requested by the operatorSpec of resources in their desired state are
+	// refreshed from Azure into secrets and config maps
+	ExportInterval time.Duration
+
+	// DefaultDeploymentLocation is where deployments of management group and tenant resources without a location of
+	// their own are stored
+	DefaultDeploymentLocation string
+
+	// ApplyMethod is how resources are created and updated in Azure. Defaults to ApplyMethodDeployment.
+	ApplyMethod ApplyMethod
+	// ApplyMethodOverrides configures the apply method for specific kinds of resource, in place of ApplyMethod
+	ApplyMethodOverrides map[schema.GroupKind]ApplyMethod
 }
 
 func (options *Options) setDefaults() {
@@ -98,10 +163,38 @@ func (options *Options) setDefaults() {
 		options.RequeueDelay = 5 * time.Second
 	}
 
+	// default the ceiling to 5 minutes
+	if options.MaxRequeueDelay == 0 {
+		options.MaxRequeueDelay = 5 * time.Minute
+	}
+
+	if options.MaxRequeueDelay < options.RequeueDelay {
+		options.MaxRequeueDelay = options.RequeueDelay
+	}
+
+	// default to doubling the delay each time
+	if options.RequeueBackoffFactor < 1 {
+		options.RequeueBackoffFactor = 2
+	}
+
 	if options.RequeueDelayFast == 0 {
 		options.RequeueDelayFast = 50 * time.Millisecond
 	}
 
+	// default to refreshing observed resources every 5 minutes
+	if options.ObserveInterval == 0 {
+		options.ObserveInterval = 5 * time.Minute
+	}
+
+	// default to refreshing exported values every 5 minutes
+	if options.ExportInterval == 0 {
+		options.ExportInterval = 5 * time.Minute
+	}
+
+	if options.DefaultDeploymentLocation == "" {
+		options.DefaultDeploymentLocation = "westus2"
+	}
+
 	// override deployment name generator, if provided
 	if options.CreateDeploymentName == nil {
 		options.CreateDeploymentName = createDeploymentName
@@ -111,9 +204,38 @@ func (options *Options) setDefaults() {
 func RegisterAll(mgr ctrl.Manager, applier armclient.Applier, objs []runtime.Object, log logr.Logger, options Options) []error {
 	options.setDefaults()
 
+	if err := options.DriftDetection.validate(); err != nil {
+		return []error{err}
+	}
+
+	if err := options.ApplyMethod.validate(); err != nil {
+		return []error{err}
+	}
+
+	// The credential resolver is shared between all controllers so that each set of credentials gets a single ARM client
+	credentialResolver := credentialresolver.NewResolver(
+		kubeclient.NewClient(mgr.GetClient(), mgr.GetScheme()),
+		applier,
+		options.ApplierFactory,
+		options.ManagedIdentities)
+
+	err := credentialResolver.ForgetOnChange(context.Background(), mgr.GetCache())
+	if err != nil {
+		return []error{err}
+	}
+
+	resourceKinds := make([]schema.GroupVersionKind, 0, len(objs))
+	for _, obj := range objs {
+		gvk, err := apiutil.GVKForObject(obj, mgr.GetScheme())
+		if err != nil {
+			return []error{errors.Wrapf(err, "creating GVK for obj %T", obj)}
+		}
+		resourceKinds = append(resourceKinds, gvk)
+	}
+
 	var errs []error
 	for _, obj := range objs {
-		if err := register(mgr, applier, obj, log, options); err != nil {
+		if err := register(mgr, applier, credentialResolver, resourceKinds, obj, log, options); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -121,7 +243,15 @@ func RegisterAll(mgr ctrl.Manager, applier armclient.Applier, objs []runtime.Obj
 	return errs
 }
 
-func register(mgr ctrl.Manager, applier armclient.Applier, obj runtime.Object, log logr.Logger, options Options) error {
+func register(
+	mgr ctrl.Manager,
+	applier armclient.Applier,
+	credentialResolver *credentialresolver.Resolver,
+	resourceKinds []schema.GroupVersionKind,
+	obj runtime.Object,
+	log logr.Logger,
+	options Options) error {
+
 	v, err := conversion.EnforcePtr(obj)
 	if err != nil {
 		return errors.Wrap(err, "obj was expected to be ptr but was not")
@@ -143,16 +273,24 @@ func register(mgr ctrl.Manager, applier armclient.Applier, obj runtime.Object, l
 	kubeClient := kubeclient.NewClient(mgr.GetClient(), mgr.GetScheme())
 
 	reconciler := &GenericReconciler{
-		ARMClient:            applier,
-		KubeClient:           kubeClient,
-		ResourceResolver:     armresourceresolver.NewResolver(kubeClient),
-		Name:                 t.Name(),
-		Log:                  log.WithName(controllerName),
-		Recorder:             mgr.GetEventRecorderFor(controllerName),
-		GVK:                  gvk,
-		RequeueDelay:         options.RequeueDelay,
-		RequeueDelayFast:     options.RequeueDelayFast,
-		CreateDeploymentName: options.CreateDeploymentName,
+		ARMClient:                 applier,
+		KubeClient:                kubeClient,
+		ResourceResolver:          armresourceresolver.NewResolver(kubeClient, options.ArmIds),
+		CredentialResolver:        credentialResolver,
+		Name:                      t.Name(),
+		Log:                       log.WithName(controllerName),
+		Recorder:                  mgr.GetEventRecorderFor(controllerName),
+		GVK:                       gvk,
+		RequeueDelay:              options.RequeueDelay,
+		RequeueDelayFast:          options.RequeueDelayFast,
+		CreateDeploymentName:      options.CreateDeploymentName,
+		DriftDetection:            driftDetectionPolicyFor(gvk.GroupKind(), options.DriftDetection, options.DriftDetectionOverrides),
+		ObserveInterval:           options.ObserveInterval,
+		ExportInterval:            options.ExportInterval,
+		ResourceKinds:             resourceKinds,
+		DefaultDeploymentLocation: options.DefaultDeploymentLocation,
+		ApplyMethod:               applyMethodFor(gvk.GroupKind(), options.ApplyMethod, options.ApplyMethodOverrides),
+		backoff:                   newRequeueBackoff(options.RequeueDelay, options.MaxRequeueDelay, options.RequeueBackoffFactor),
 	}
 
 	c, err := ctrl.NewControllerManagedBy(mgr).
@@ -199,7 +337,14 @@ func (gr *GenericReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, errors.Errorf("object is not a genruntime.MetaObject: %+v - type: %T", obj, obj)
 	}
 
-	objWrapper := NewReconcileMetadata(metaObj, log)
+	policy, err := gr.reconcilePolicy(ctx, metaObj)
+	if err != nil {
+		log.Error(err, "error resolving reconcile policy")
+		gr.Recorder.Event(metaObj, v1.EventTypeWarning, "ReconcilePolicyError", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	objWrapper := NewReconcileMetadata(metaObj, nil, policy, log)
 	action, actionFunc, err := gr.DetermineReconcileAction(objWrapper)
 
 	if err != nil {
@@ -208,13 +353,42 @@ func (gr *GenericReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	// Credentials are only resolved when they're needed, so that missing credentials don't prevent (for example)
+	// the deletion of a resource which was never created in Azure
+	if callsAzure(action, objWrapper) {
+		armClient, err := gr.CredentialResolver.ResolveApplier(ctx, metaObj)
+		if err != nil {
+			log.Error(err, "error resolving Azure credentials")
+			gr.Recorder.Event(metaObj, v1.EventTypeWarning, "CredentialError", err.Error())
+			return ctrl.Result{}, err
+		}
+
+		objWrapper.armClient, err = applierFor(armClient, gr.ApplyMethod)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	result, err := actionFunc(ctx, action, objWrapper)
 	if err != nil {
+		if retryAfter, ok := armclient.RetryAfterFromError(err); ok {
+			// Azure told us to back off (for example because we're being throttled), so do that rather
+			// than retrying on the controller's schedule
+			log.V(1).Info("Azure requested retry after delay", "action", action, "retryAfter", retryAfter, "error", err.Error())
+			gr.Recorder.Event(metaObj, v1.EventTypeWarning, "ReconcileActionRetry", err.Error())
+			return gr.requeueWithBackoff(objWrapper, retryAfter), nil
+		}
+
 		log.Error(err, "Error during reconcile", "action", action)
 		gr.Recorder.Event(metaObj, v1.EventTypeWarning, "ReconcileActionError", err.Error())
 		return ctrl.Result{}, err
 	}
 
+	if result.IsZero() {
+		// Nothing more to wait for, so the next time we need to poll Azure for this resource start from scratch
+		gr.backoff.Forget(metaObj)
+	}
+
 	return result, err
 }
 
@@ -228,12 +402,42 @@ func (gr *GenericReconciler) DetermineReconcileAction(data *ReconcileMetadata) (
 		return ReconcileActionBeginDelete, gr.StartDeleteOfResource, nil
 	}
 
+	if data.policy == ReconcilePolicyObserve {
+		// Owner references are still maintained so that the resource is garbage collected with its owner
+		if needsOwnerReference(data.metaObj) {
+			return ReconcileActionManageOwnership, gr.ManageOwnership, nil
+		}
+
+		return ReconcileActionObserve, gr.ObserveResource, nil
+	}
+
+	if data.GetDeploymentIdOrDefault() != "" && !data.IsTerminalProvisioningState() && state != armclient.DeletingProvisioningState {
+		// There is an ongoing deployment we need to monitor. If the spec has been changed since the deployment
+		// started, we redeploy once it has finished.
+		return ReconcileActionMonitorDeployment, gr.MonitorDeployment, nil
+	}
+
 	hasChanged, err := data.HasResourceSpecHashChanged()
 	if err != nil {
 		return ReconcileActionNoAction, NoAction, errors.Wrap(err, "comparing resource hash")
 	}
 
 	if !hasChanged && data.IsTerminalProvisioningState() {
+		if gr.DriftDetection.Enabled() && state == armclient.SucceededProvisioningState && data.GetResourceIdOrDefault() != "" {
+			return ReconcileActionDetectDrift, gr.DetectDrift, nil
+		}
+
+		if state == armclient.SucceededProvisioningState {
+			hasExports, err := hasExportedValues(data.metaObj)
+			if err != nil {
+				return ReconcileActionNoAction, NoAction, err
+			}
+
+			if hasExports {
+				return ReconcileActionExportValues, gr.ExportValues, nil
+			}
+		}
+
 		msg := fmt.Sprintf("resource spec has not changed and resource is in terminal state: %q", state)
 		data.log.V(1).Info(msg)
 		return ReconcileActionNoAction, NoAction, nil
@@ -243,27 +447,41 @@ func (gr *GenericReconciler) DetermineReconcileAction(data *ReconcileMetadata) (
 		return ReconcileActionNoAction, NoAction, errors.Errorf("resource is currently deleting; it can not be applied")
 	}
 
-	if data.GetDeploymentIdOrDefault() != "" {
-		// There is an ongoing deployment we need to monitor
-		return ReconcileActionMonitorDeployment, gr.MonitorDeployment, nil
-	}
-
-	// TODO: What do we do if somebody tries to change the owner of a resource?
-	// TODO: That's not allowed in Azure so we can't actually make the change, but
-	// TODO: we could interpret it as a commend to create a duplicate resource under the
-	// TODO: new owner (and orphan the old Azure resource?). Alternatively we could just put the
-	// TODO: Kubernetes resource into an error state
-	// TODO: See: https://github.com/Azure/k8s-infra/issues/274
+	// Changes to the owner or AzureName of a resource which exists in Azure can't be made, as Azure doesn't support
+	// moving or renaming resources. They're rejected by the validating webhook, and CreateDeployment puts the resource
+	// into an error state rather than creating a duplicate if one slips through.
+	// See: https://github.com/Azure/k8s-infra/issues/274
 	// Determine if we need to update ownership first
-	owner := data.metaObj.Owner()
-	if owner != nil && len(data.metaObj.GetOwnerReferences()) == 0 {
+	if needsOwnerReference(data.metaObj) {
 		// TODO: This could all be rolled into CreateDeployment if we wanted
 		return ReconcileActionManageOwnership, gr.ManageOwnership, nil
 	}
 
+	needsAdoption, err := data.NeedsAdoption()
+	if err != nil {
+		return ReconcileActionNoAction, NoAction, err
+	}
+
+	if needsAdoption {
+		return ReconcileActionAdopt, gr.AdoptResource, nil
+	}
+
 	return ReconcileActionBeginDeployment, gr.CreateDeployment, nil
 }
 
+// callsAzure returns true if performing the given action on the resource needs an ARM client
+func callsAzure(action ReconcileAction, data *ReconcileMetadata) bool {
+	switch action {
+	case ReconcileActionNoAction, ReconcileActionManageOwnership:
+		return false
+	case ReconcileActionBeginDelete:
+		// Nothing needs deleting from Azure if the resource was never created there or is being left behind
+		return data.policy.DeletesFromAzure() && data.GetResourceIdOrDefault() != ""
+	default:
+		return true
+	}
+}
+
 //////////////////////////////////////////
 // Actions
 //////////////////////////////////////////
@@ -279,50 +497,58 @@ func (gr *GenericReconciler) StartDeleteOfResource(
 	action ReconcileAction,
 	data *ReconcileMetadata) (ctrl.Result, error) {
 
+	if !data.policy.DeletesFromAzure() {
+		msg := fmt.Sprintf("Reconcile policy is %q, leaving resource in Azure", data.policy)
+		data.log.Info(msg)
+		gr.Recorder.Event(data.metaObj, v1.EventTypeNormal, string(action), msg)
+
+		if data.policy == ReconcilePolicyDetachOnDelete {
+			err := gr.detachOwnedResources(ctx, data.metaObj)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, gr.deleteResourceSucceeded(ctx, data)
+	}
+
 	msg := "Starting delete of resource"
 	data.log.Info(msg)
 	gr.Recorder.Event(data.metaObj, v1.EventTypeNormal, string(action), msg)
 
 	// If we have no resourceId to begin with, the Azure resource was never created
-	if data.GetResourceIdOrDefault() == "" {
+	id := data.GetResourceIdOrDefault()
+	if id == "" {
 		return ctrl.Result{}, gr.deleteResourceSucceeded(ctx, data)
 	}
 
-	// TODO: Drop this entirely in favor if calling the genruntime.MetaObject interface methods that
-	// TODO: return the data we need.
-	// TODO(matthchr): For now just emulate this with reflection
-	resource, err := gr.constructArmResource(ctx, data)
-
+	// The resource is deleted by the ID it was deployed with rather than by resolving its owners, as they may
+	// already have been deleted from Kubernetes without being deleted from Azure, for example if their reconcile
+	// policy is to detach on delete
+	apiVersion, err := reflecthelpers.GetApiVersion(data.metaObj)
 	if err != nil {
-		// If the error is that the owner isn't found, that probably
-		// means that the owner was deleted in Kubernetes. The current
-		// assumption is that that deletion has been propagated to Azure
-		// and so the child resource is already deleted.
-		var typedErr *armresourceresolver.OwnerNotFound
-		if errors.As(err, &typedErr) {
-			// TODO: We should confirm the above assumption by performing a HEAD on
-			// TODO: the resource in Azure. This requires GetApiVersion() on  metaObj which
-			// TODO: we don't currently have in the interface.
-			// gr.ARMClient.HeadResource(ctx, data.resourceId, data.metaObj.GetApiVersion())
-			return ctrl.Result{}, gr.deleteResourceSucceeded(ctx, data)
-		}
-
-		return ctrl.Result{}, errors.Wrapf(err, "couldn't convert to armResourceSpec")
+		return ctrl.Result{}, errors.Wrapf(err, "getting API version of %q", id)
 	}
 
+	var retryAfter time.Duration
 	err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
 		emptyStatus, err := reflecthelpers.NewEmptyArmResourceStatus(mutData.metaObj)
 		if err != nil {
-			return errors.Wrapf(err, "creating empty status for %q", resource.GetId())
+			return errors.Wrapf(err, "creating empty status for %q", id)
 		}
 
-		err = gr.ARMClient.BeginDeleteResource(ctx, resource.GetId(), resource.Spec().GetApiVersion(), emptyStatus)
-		if err != nil {
-			return errors.Wrapf(err, "deleting resource %q", resource.Spec().GetType())
+		retryAfter, err = data.armClient.BeginDeleteResource(ctx, id, apiVersion, emptyStatus)
+		if err != nil && !armclient.IsNotFound(err) {
+			return errors.Wrapf(err, "deleting resource %q", id)
 		}
 		data.SetResourceProvisioningState(armclient.DeletingProvisioningState)
 
-		return nil
+		err = mutData.SetCondition(genruntime.ConditionTypeDeleting, metav1.ConditionTrue, genruntime.ReasonDeleting, "Deleting resource from Azure")
+		if err != nil {
+			return err
+		}
+
+		return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonDeleting, "Deleting resource from Azure")
 	})
 
 	err = client.IgnoreNotFound(err)
@@ -331,9 +557,7 @@ func (gr *GenericReconciler) StartDeleteOfResource(
 	}
 
 	// delete has started, check back to seen when the finalizer can be removed
-	return ctrl.Result{
-		RequeueAfter: gr.RequeueDelay,
-	}, nil
+	return gr.requeueWithBackoff(data, retryAfter), nil
 }
 
 // MonitorDelete will call Azure to check if the resource still exists. If so, it will requeue, else,
@@ -347,20 +571,22 @@ func (gr *GenericReconciler) MonitorDelete(
 	data.log.Info(msg)
 	gr.Recorder.Event(data.metaObj, v1.EventTypeNormal, string(action), msg)
 
-	resource, err := gr.constructArmResource(ctx, data)
+	// As when starting the delete, the owners of the resource may no longer exist
+	id := data.GetResourceIdOrDefault()
+	apiVersion, err := reflecthelpers.GetApiVersion(data.metaObj)
 	if err != nil {
-		return ctrl.Result{}, errors.Wrapf(err, "converting to armResourceSpec")
+		return ctrl.Result{}, errors.Wrapf(err, "getting API version of %q", id)
 	}
 
 	// already deleting, just check to see if it still exists and if it's gone, remove finalizer
-	found, err := gr.ARMClient.HeadResource(ctx, resource.GetId(), resource.Spec().GetApiVersion())
+	found, err := data.armClient.HeadResource(ctx, id, apiVersion)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrap(err, "head resource")
 	}
 
 	if found {
 		data.log.V(0).Info("Found resource: continuing to wait for deletion...")
-		return ctrl.Result{RequeueAfter: gr.RequeueDelay}, nil
+		return gr.requeueWithBackoff(data, 0), nil
 	}
 
 	err = gr.deleteResourceSucceeded(ctx, data)
@@ -370,25 +596,82 @@ func (gr *GenericReconciler) MonitorDelete(
 }
 
 func (gr *GenericReconciler) CreateDeployment(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error) {
+	changes, err := gr.immutableFieldChanges(ctx, data)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "checking for changes to immutable fields")
+	}
+
+	if len(changes) > 0 {
+		return gr.rejectImmutableFieldChanges(ctx, data, changes)
+	}
+
 	deployment, err := gr.resourceSpecToDeployment(ctx, data)
+	if err != nil {
+		var secretErr *armresourceresolver.SecretNotFound
+		if errors.As(err, &secretErr) {
+			return gr.waitForSecret(ctx, data, secretErr)
+		}
+
+		var referenceErr *armresourceresolver.ReferenceNotReady
+		if errors.As(err, &referenceErr) {
+			return gr.waitForReference(ctx, data, referenceErr)
+		}
+
+		// Owners in other namespaces aren't checked by ManageOwnership, as they can't be set as owner references
+		var ownerErr *armresourceresolver.OwnerNotFound
+		if errors.As(err, &ownerErr) {
+			return gr.waitForOwner(ctx, data)
+		}
+
+		var grantErr *armresourceresolver.ReferenceNotGranted
+		if errors.As(err, &grantErr) {
+			return gr.waitForReferenceGrant(ctx, data, grantErr)
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	sig, err := data.SpecSignature()
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to compute resource spec hash")
+	}
+
+	oldSig, deployed, err := data.GetResourceSignature()
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if deployed && oldSig != sig {
+		data.log.Info("Resource spec has changed since it was last deployed", "oldSignature", oldSig, "newSignature", sig)
+		gr.Recorder.Eventf(
+			data.metaObj,
+			v1.EventTypeNormal,
+			"SpecChanged",
+			"Spec has changed since it was last deployed (signature %q -> %q), redeploying",
+			oldSig,
+			sig)
+	}
+
 	// Try to create deployment:
 	data.log.Info("Starting new deployment to Azure", "action", string(action))
-	err = gr.ARMClient.CreateDeployment(ctx, deployment)
+	err = data.armClient.CreateDeployment(ctx, deployment)
 
+	// The spec signature of the deployment we're now monitoring, if we started one
+	pendingSig := sig
 	if err != nil {
 		var reqErr *autorestAzure.RequestError
 		if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusConflict {
-			deployId, err := deployment.GetEntityPath()
+			// A deployment with this name is still running (most likely of an earlier version of the spec).
+			// Monitor it until it finishes; as it isn't deploying the current spec we'll redeploy afterwards.
+			deploymentId, err := deployment.GetId()
 			if err != nil {
-				// TODO: what if GetEntityPath doesn't work due to malformed deployment?
-				data.log.Info("Deployment already exists", "id", deployId)
+				return ctrl.Result{}, errors.Wrap(err, "deployment already exists")
 			}
 
-			// TODO: we need to diff the old/new deployment here and detect if we need to do a redeploy
+			data.log.Info("Deployment already exists", "id", deploymentId)
+			deployment.Id = deploymentId
+			deployment.Properties.ProvisioningState = armclient.AcceptedProvisioningState
+			pendingSig = ""
 		} else {
 			return ctrl.Result{}, err
 		}
@@ -398,6 +681,19 @@ func (gr *GenericReconciler) CreateDeployment(ctx context.Context, action Reconc
 	}
 
 	err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		// Only record the pending signature when it changes, so monitoring an existing deployment doesn't rewrite it
+		oldPendingSig, _, err := mutData.GetPendingResourceSignature()
+		if err != nil {
+			return err
+		}
+
+		if oldPendingSig != pendingSig {
+			err = mutData.SetPendingResourceSignature(pendingSig)
+			if err != nil {
+				return err
+			}
+		}
+
 		return mutData.Update(deployment, nil) // Status is always nil here
 	})
 
@@ -410,9 +706,7 @@ func (gr *GenericReconciler) CreateDeployment(ctx context.Context, action Reconc
 	result := ctrl.Result{}
 	// TODO: This is going to be common... need a wrapper/helper somehow?
 	if !deployment.IsTerminalProvisioningState() {
-		result = ctrl.Result{
-			RequeueAfter: gr.RequeueDelay,
-		}
+		result = gr.requeueWithBackoff(data, deployment.RetryAfter)
 	}
 	return result, err
 }
@@ -427,7 +721,7 @@ func (gr *GenericReconciler) MonitorDeployment(ctx context.Context, action Recon
 	var status genruntime.FromArmConverter
 	err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
 
-		deployment, err = gr.ARMClient.GetDeployment(ctx, deployment.Id)
+		deployment, err = data.armClient.GetDeployment(ctx, deployment.Id)
 		if err != nil {
 			return errors.Wrapf(err, "getting deployment %q from ARM", deployment.Id)
 		}
@@ -474,7 +768,7 @@ func (gr *GenericReconciler) MonitorDeployment(ctx context.Context, action Recon
 	if deployment.IsTerminalProvisioningState() && !data.GetShouldPreserveDeployment() {
 		data.log.Info("Deleting deployment", "ID", deployment.Id)
 		err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
-			err := gr.ARMClient.DeleteDeployment(ctx, deployment.Id)
+			err := data.armClient.DeleteDeployment(ctx, deployment.Id)
 			if err != nil {
 				return errors.Wrapf(err, "deleting deployment %q", deployment.Id)
 			}
@@ -496,42 +790,242 @@ func (gr *GenericReconciler) MonitorDeployment(ctx context.Context, action Recon
 		}
 	}
 
+	if deployment.IsSuccessful() {
+		err = gr.exportValues(ctx, data)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	result := ctrl.Result{}
 	// TODO: This is going to be common... need a wrapper/helper somehow?
 	if !deployment.IsTerminalProvisioningState() {
-		result = ctrl.Result{
-			RequeueAfter: gr.RequeueDelay,
-		}
+		result = gr.requeueWithBackoff(data, deployment.RetryAfter)
 	}
 	return result, err
 }
 
-func (gr *GenericReconciler) ManageOwnership(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error) {
-	data.log.V(1).Info("applying ownership", "action", action)
-	isOwnerReady, err := gr.isOwnerReady(ctx, data)
+// DetectDrift compares the resource in Azure with its spec, to find changes made outside of Kubernetes. Any drift
+// is recorded on the resource and, depending on the policy, undone by redeploying the spec. The check is repeated
+// periodically.
+func (gr *GenericReconciler) DetectDrift(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error) {
+	resource, err := gr.constructArmResource(ctx, data)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "converting to armResourceSpec")
+	}
+
+	var drift []string
+	live := make(map[string]interface{})
+	err = data.armClient.GetResource(ctx, resource.GetId(), resource.Spec().GetApiVersion(), &live)
+	switch {
+	case armclient.IsNotFound(err):
+		// The resource has been deleted out from under us
+		drift = []string{"id"}
+	case err != nil:
+		return ctrl.Result{}, errors.Wrapf(err, "getting resource with ID: %q", resource.GetId())
+	default:
+		drift, err = findDrift(resource.Spec(), live)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "comparing resource with ID: %q", resource.GetId())
+		}
+	}
+
+	result := ctrl.Result{RequeueAfter: gr.DriftDetection.Interval}
+
+	if len(drift) == 0 {
+		data.log.V(1).Info("No drift detected", "action", action, "id", resource.GetId())
+		err = gr.exportValues(ctx, data)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if data.GetDriftOrDefault() == "" {
+			return result, nil
+		}
+
+		err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+			mutData.SetDrift(nil)
+			return mutData.SetCondition(genruntime.ConditionTypeDriftDetected, metav1.ConditionFalse, genruntime.ReasonInSync, "")
+		})
+		return result, errors.Wrap(client.IgnoreNotFound(err), "patching")
+	}
+
+	msg := fmt.Sprintf("Resource in Azure no longer matches spec, changed fields: %s", strings.Join(drift, ", "))
+	data.log.Info("Drift detected", "action", action, "id", resource.GetId(), "fields", drift)
+	gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, "DriftDetected", msg)
+
+	err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		mutData.SetDrift(drift)
+		return mutData.SetCondition(genruntime.ConditionTypeDriftDetected, metav1.ConditionTrue, genruntime.ReasonDrifted, msg)
+	})
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), "patching")
+	}
+
+	if gr.DriftDetection.Remediation == DriftRemediationReapply {
+		return gr.CreateDeployment(ctx, action, data)
+	}
+
+	return result, nil
+}
+
+// ObserveResource refreshes the status of a resource managed by somebody else from Azure, without ever changing it.
+// The status is refreshed periodically.
+func (gr *GenericReconciler) ObserveResource(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error) {
+	resource, err := gr.constructUnresolvedArmResource(ctx, data)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "converting to armResourceSpec")
+	}
+
+	id := resource.GetId()
+	result := ctrl.Result{RequeueAfter: gr.ObserveInterval}
+
+	status, err := gr.getStatus(ctx, id, data)
+	if armclient.IsNotFound(err) {
+		msg := fmt.Sprintf("Observed resource %q does not exist in Azure", id)
+		data.log.Info(msg, "action", action)
+		gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, string(action), msg)
+
+		err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+			return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonNotFound, msg)
+		})
+		return result, errors.Wrap(client.IgnoreNotFound(err), "patching")
+	}
+
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "getting status from ARM")
+	}
+
+	data.log.V(1).Info("Refreshed status of observed resource", "action", action, "id", id)
+
+	err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		mutData.SetResourceId(id)
+
+		err := reflecthelpers.SetStatus(mutData.metaObj, status)
+		if err != nil {
+			return err
+		}
+
+		err = reflecthelpers.SetObservedGeneration(mutData.metaObj)
+		if err != nil {
+			return err
+		}
+
+		return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionTrue, genruntime.ReasonObserved, "")
+	})
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), "patching")
+	}
 
+	err = gr.exportValues(ctx, data)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if !isOwnerReady {
-		// TODO: We need to figure out how we're handing these sorts of errors.
-		// TODO: See https://github.com/Azure/k8s-infra/issues/274.
-		// TODO: For now just set an error so we at least see something
-		err := gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
-			mutData.SetResourceError(fmt.Sprintf("owner %s is not ready", data.metaObj.Owner().Name))
-			return nil
+	return result, nil
+}
+
+// ExportValues refreshes the values exported from a resource in its desired state into secrets and config maps. This
+// is repeated periodically, so that changes made in Azure (such as rotated keys) are picked up.
+func (gr *GenericReconciler) ExportValues(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error) {
+	err := gr.exportValues(ctx, data)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: gr.ExportInterval}, nil
+}
+
+// AdoptResource brings a resource which may already exist in Azure under management. The state of the existing
+// resource is imported into status and compared with the spec. If they match the resource is adopted as is, otherwise
+// the spec is only deployed once the adoption policy allows it.
+func (gr *GenericReconciler) AdoptResource(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error) {
+	resource, err := gr.constructArmResource(ctx, data)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "converting to armResourceSpec")
+	}
+
+	id := resource.GetId()
+	apiVersion := resource.Spec().GetApiVersion()
+
+	found, err := data.armClient.HeadResource(ctx, id, apiVersion)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "head resource with ID: %q", id)
+	}
+
+	if !found {
+		data.log.Info("No existing resource to adopt, deploying", "action", action, "id", id)
+		gr.Recorder.Eventf(data.metaObj, v1.EventTypeNormal, string(action), "No existing resource with ID %q to adopt, creating it", id)
+		return gr.CreateDeployment(ctx, action, data)
+	}
+
+	status, err := gr.getStatus(ctx, id, data)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "getting status from ARM")
+	}
+
+	live := make(map[string]interface{})
+	err = data.armClient.GetResource(ctx, id, apiVersion, &live)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "getting resource with ID: %q", id)
+	}
+
+	diff, err := findDrift(resource.Spec(), live)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "comparing resource with ID: %q", id)
+	}
+
+	if len(diff) == 0 {
+		data.log.Info("Adopted existing resource", "action", action, "id", id)
+		gr.Recorder.Eventf(data.metaObj, v1.EventTypeNormal, string(action), "Adopted existing resource with ID %q, which matches the spec", id)
+
+		err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+			return mutData.Adopt(id, status)
 		})
+		return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), "patching")
+	}
 
-		err = client.IgnoreNotFound(err)
+	msg := fmt.Sprintf("Existing resource differs from spec, changed fields: %s", strings.Join(diff, ", "))
+	data.log.Info("Existing resource differs from spec", "action", action, "id", id, "fields", diff)
+	gr.Recorder.Event(data.metaObj, v1.EventTypeNormal, string(action), msg)
+
+	policy, err := data.GetAdoptionPolicy()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if policy == AdoptionPolicyAuto || data.IsAdoptionConfirmed() {
+		return gr.CreateDeployment(ctx, action, data)
+	}
+
+	// Wait for the user to review the differences and confirm; doing so updates the resource, which triggers
+	// another reconcile
+	err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		err := reflecthelpers.SetStatus(mutData.metaObj, status)
 		if err != nil {
-			return ctrl.Result{}, errors.Wrap(err, "patching resource error")
+			return err
 		}
 
-		return ctrl.Result{
-			// TODO: We should consider a scaling backoff here, see: https://github.com/Azure/k8s-infra/issues/263
-			RequeueAfter: gr.RequeueDelay,
-		}, nil
+		return mutData.SetCondition(
+			genruntime.ConditionTypeReady,
+			metav1.ConditionFalse,
+			genruntime.ReasonAwaitingAdoption,
+			fmt.Sprintf("%s. Set annotation %s=true to deploy the spec", msg, AdoptConfirmedAnnotation))
+	})
+
+	return ctrl.Result{}, errors.Wrap(client.IgnoreNotFound(err), "patching")
+}
+
+func (gr *GenericReconciler) ManageOwnership(ctx context.Context, action ReconcileAction, data *ReconcileMetadata) (ctrl.Result, error) {
+	data.log.V(1).Info("applying ownership", "action", action)
+	isOwnerReady, err := gr.isOwnerReady(ctx, data)
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !isOwnerReady {
+		return gr.waitForOwner(ctx, data)
 	}
 
 	err = gr.applyOwnership(ctx, data)
@@ -549,6 +1043,215 @@ func (gr *GenericReconciler) ManageOwnership(ctx context.Context, action Reconci
 // Other helpers
 //////////////////////////////////////////
 
+// waitForOwner records that the resource can't be deployed until its owner exists, and requeues it
+func (gr *GenericReconciler) waitForOwner(ctx context.Context, data *ReconcileMetadata) (ctrl.Result, error) {
+	msg := fmt.Sprintf("Owner %s does not exist yet", data.metaObj.Owner().Name)
+	err := gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		err := mutData.SetCondition(genruntime.ConditionTypeOwnerReady, metav1.ConditionFalse, genruntime.ReasonWaitingForOwner, msg)
+		if err != nil {
+			return err
+		}
+
+		return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonWaitingForOwner, msg)
+	})
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "patching owner conditions")
+	}
+
+	return gr.requeueWithBackoff(data, 0), nil
+}
+
+// waitForReferenceGrant records that the resource can't be deployed until a ReferenceGrant allows it to refer to a
+// resource in another namespace, and requeues it
+func (gr *GenericReconciler) waitForReferenceGrant(ctx context.Context, data *ReconcileMetadata, grantErr *armresourceresolver.ReferenceNotGranted) (ctrl.Result, error) {
+	msg := grantErr.Error()
+	data.log.V(1).Info("Waiting for reference grant", "kind", grantErr.Kind, "reference", grantErr.ReferenceName, "armId", grantErr.ArmId)
+	gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, genruntime.ReasonReferenceNotGranted, msg)
+
+	err := gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonReferenceNotGranted, msg)
+	})
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "patching reference grant conditions")
+	}
+
+	return gr.requeueWithBackoff(data, 0), nil
+}
+
+// waitForSecret records that the resource can't be deployed until a secret it refers to exists, and requeues it
+func (gr *GenericReconciler) waitForSecret(ctx context.Context, data *ReconcileMetadata, secretErr *armresourceresolver.SecretNotFound) (ctrl.Result, error) {
+	msg := secretErr.Error()
+	data.log.V(1).Info("Waiting for secret", "secret", secretErr.SecretName, "key", secretErr.Key)
+	gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, genruntime.ReasonSecretNotFound, msg)
+
+	err := gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonSecretNotFound, msg)
+	})
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "patching secret conditions")
+	}
+
+	return gr.requeueWithBackoff(data, 0), nil
+}
+
+// waitForReference records that the resource can't be deployed until a resource it refers to has been deployed, and
+// requeues it
+func (gr *GenericReconciler) waitForReference(ctx context.Context, data *ReconcileMetadata, referenceErr *armresourceresolver.ReferenceNotReady) (ctrl.Result, error) {
+	msg := referenceErr.Error()
+	data.log.V(1).Info("Waiting for reference", "kind", referenceErr.Kind, "reference", referenceErr.ReferenceName)
+	gr.Recorder.Event(data.metaObj, v1.EventTypeNormal, genruntime.ReasonWaitingForReference, msg)
+
+	err := gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonWaitingForReference, msg)
+	})
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "patching reference conditions")
+	}
+
+	return gr.requeueWithBackoff(data, 0), nil
+}
+
+// exportValues writes the values requested by the operatorSpec of the resource into Kubernetes secrets and config
+// maps. This is done each time the resource is found to be in its desired state, so that the values are kept fresh.
+func (gr *GenericReconciler) exportValues(ctx context.Context, data *ReconcileMetadata) error {
+	operatorSpec, err := reflecthelpers.GetOperatorSpec(data.metaObj)
+	if err != nil {
+		return err
+	}
+
+	if operatorSpec == nil || (len(operatorSpec.Secrets) == 0 && len(operatorSpec.ConfigMaps) == 0) {
+		return nil
+	}
+
+	err = checkExportActions(operatorSpec, data.policy)
+	if err != nil {
+		// Retrying won't help until the spec is changed, so record the problem rather than failing
+		msg := fmt.Sprintf("Unable to export values: %s", err)
+		data.log.Info(msg)
+		gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, genruntime.ReasonExportActionNotAllowed, msg)
+		return gr.setExportedCondition(ctx, data, metav1.ConditionFalse, genruntime.ReasonExportActionNotAllowed, msg)
+	}
+
+	resource, err := gr.constructUnresolvedArmResource(ctx, data)
+	if err != nil {
+		return errors.Wrapf(err, "converting to armResourceSpec")
+	}
+
+	id := resource.GetId()
+	apiVersion := resource.Spec().GetApiVersion()
+
+	// Each action is only invoked once, however many values are exported from its result
+	results := make(map[string]interface{})
+	source := func(action string) (interface{}, error) {
+		if result, ok := results[action]; ok {
+			return result, nil
+		}
+
+		var result interface{}
+		if action == "" {
+			status, err := statusJSON(data.metaObj)
+			if err != nil {
+				return nil, errors.Wrap(err, "reading status")
+			}
+			result = status
+		} else {
+			err := data.armClient.InvokeResourceAction(ctx, id, action, apiVersion, &result)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		results[action] = result
+		return result, nil
+	}
+
+	failed := func(err error) error {
+		gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, "ExportFailed", err.Error())
+		return errors.Wrap(err, "exporting values")
+	}
+
+	secrets, err := collectExportedValues(operatorSpec.Secrets, source)
+	if err != nil {
+		return failed(err)
+	}
+
+	configMaps, err := collectExportedValues(operatorSpec.ConfigMaps, source)
+	if err != nil {
+		return failed(err)
+	}
+
+	err = writeExportedValues(ctx, gr.KubeClient, data.metaObj, secrets, configMaps)
+	var conflict *exportConflict
+	if errors.As(err, &conflict) {
+		// Retrying won't help until somebody removes the conflicting object, so record the problem rather than failing
+		msg := fmt.Sprintf("Unable to export values: %s", conflict)
+		data.log.Info(msg, "id", id)
+		gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, genruntime.ReasonExportConflict, msg)
+		return gr.setExportedCondition(ctx, data, metav1.ConditionFalse, genruntime.ReasonExportConflict, msg)
+	}
+
+	if err != nil {
+		return failed(err)
+	}
+
+	data.log.V(1).Info("Exported values", "id", id, "secrets", len(operatorSpec.Secrets), "configMaps", len(operatorSpec.ConfigMaps))
+	return gr.setExportedCondition(ctx, data, metav1.ConditionTrue, genruntime.ReasonSucceeded, "")
+}
+
+// setExportedCondition records the outcome of exporting values on the resource, if it has changed. Values are
+// exported on every reconcile of a resource in its desired state, so this avoids patching it each time.
+func (gr *GenericReconciler) setExportedCondition(
+	ctx context.Context,
+	data *ReconcileMetadata,
+	status metav1.ConditionStatus,
+	reason string,
+	message string) error {
+
+	conditions, err := genruntime.GetConditions(data.metaObj)
+	if err != nil {
+		return err
+	}
+
+	existing := genruntime.FindCondition(conditions, genruntime.ConditionTypeExported)
+	if existing != nil && existing.Status == status && existing.Reason == reason && existing.Message == message {
+		return nil
+	}
+
+	err = gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		return mutData.SetCondition(genruntime.ConditionTypeExported, status, reason, message)
+	})
+	return errors.Wrap(client.IgnoreNotFound(err), "patching exported condition")
+}
+
+// hasExportedValues returns true if the operatorSpec of the resource requests any values be exported
+func hasExportedValues(metaObj genruntime.MetaObject) (bool, error) {
+	operatorSpec, err := reflecthelpers.GetOperatorSpec(metaObj)
+	if err != nil {
+		return false, err
+	}
+
+	return operatorSpec != nil && (len(operatorSpec.Secrets) > 0 || len(operatorSpec.ConfigMaps) > 0), nil
+}
+
+// requeueWithBackoff returns a result which requeues the resource after a delay which grows each time the
+// resource is requeued, up to a ceiling. If Azure requested a longer delay, that is used instead.
+func (gr *GenericReconciler) requeueWithBackoff(data *ReconcileMetadata, retryAfter time.Duration) ctrl.Result {
+	delay := gr.backoff.Next(data.metaObj)
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+
+	return ctrl.Result{RequeueAfter: delay}
+}
+
 func (gr *GenericReconciler) constructArmResource(ctx context.Context, data *ReconcileMetadata) (genruntime.ArmResource, error) {
 	deployableSpec, err := reflecthelpers.ConvertResourceToDeployableResource(ctx, gr.ResourceResolver, data.metaObj)
 	if err != nil {
@@ -560,8 +1263,19 @@ func (gr *GenericReconciler) constructArmResource(ctx context.Context, data *Rec
 	return resource, nil
 }
 
+// constructUnresolvedArmResource is like constructArmResource but doesn't resolve the secrets and resources the
+// resource refers to, which may already have been deleted. The result must only be used for its ID and API version.
+func (gr *GenericReconciler) constructUnresolvedArmResource(ctx context.Context, data *ReconcileMetadata) (genruntime.ArmResource, error) {
+	deployableSpec, err := reflecthelpers.ConvertResourceToDeployableResourceWithoutReferences(ctx, gr.ResourceResolver, data.metaObj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting to armResourceSpec")
+	}
+
+	return genruntime.NewArmResource(deployableSpec.Spec(), nil, data.GetResourceIdOrDefault()), nil
+}
+
 func (gr *GenericReconciler) getStatus(ctx context.Context, id string, data *ReconcileMetadata) (genruntime.FromArmConverter, error) {
-	deployableSpec, err := reflecthelpers.ConvertResourceToDeployableResource(ctx, gr.ResourceResolver, data.metaObj)
+	deployableSpec, err := reflecthelpers.ConvertResourceToDeployableResourceWithoutReferences(ctx, gr.ResourceResolver, data.metaObj)
 	if err != nil {
 		return nil, err
 	}
@@ -573,7 +1287,7 @@ func (gr *GenericReconciler) getStatus(ctx context.Context, id string, data *Rec
 	}
 
 	// Get the resource
-	err = gr.ARMClient.GetResource(ctx, id, deployableSpec.Spec().GetApiVersion(), armStatus)
+	err = data.armClient.GetResource(ctx, id, deployableSpec.Spec().GetApiVersion(), armStatus)
 	if data.log.V(4).Enabled() {
 		statusBytes, err := json.Marshal(armStatus)
 		if err != nil {
@@ -596,7 +1310,8 @@ func (gr *GenericReconciler) getStatus(ctx context.Context, id string, data *Rec
 	var knownOwner genruntime.KnownResourceReference
 	if owner != nil {
 		knownOwner = genruntime.KnownResourceReference{
-			Name: owner.Name,
+			Name:      owner.Name,
+			Namespace: owner.Namespace,
 		}
 	}
 
@@ -633,27 +1348,48 @@ func (gr *GenericReconciler) resourceSpecToDeployment(ctx context.Context, data
 		}
 	}
 
-	deployment := gr.createDeployment(deploySpec, deploymentName, deploymentId)
-	return deployment, nil
+	return gr.createDeployment(data.armClient, deploySpec, deploymentName, deploymentId)
 }
 
 func (gr *GenericReconciler) createDeployment(
+	armClient armclient.Applier,
 	deploySpec genruntime.DeployableResource,
 	deploymentName string,
-	deploymentId string) *armclient.Deployment {
+	deploymentId string) (*armclient.Deployment, error) {
 
 	var deployment *armclient.Deployment
 	switch res := deploySpec.(type) {
 	case *genruntime.ResourceGroupResource:
-		deployment = gr.ARMClient.NewResourceGroupDeployment(
+		deployment = armClient.NewResourceGroupDeployment(
 			res.ResourceGroup(),
 			deploymentName,
 			res.Spec())
 	case *genruntime.SubscriptionResource:
-		deployment = gr.ARMClient.NewSubscriptionDeployment(
+		deployment = armClient.NewSubscriptionDeployment(
 			res.Location(),
 			deploymentName,
 			res.Spec())
+	case *genruntime.ManagementGroupResource:
+		deployment = armClient.NewManagementGroupDeployment(
+			res.ManagementGroupId(),
+			gr.deploymentLocation(res.Location()),
+			deploymentName,
+			res.Spec())
+	case *genruntime.TenantResource:
+		deployment = armClient.NewTenantDeployment(
+			gr.deploymentLocation(res.Location()),
+			deploymentName,
+			res.Spec())
+	case *genruntime.ExtensionResource:
+		var err error
+		deployment, err = armClient.NewExtensionDeployment(
+			res.Scope(),
+			gr.deploymentLocation(res.Location()),
+			deploymentName,
+			res.Spec())
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating deployment of extension resource onto %s", res.Scope())
+		}
 	default:
 		panic(fmt.Sprintf("unknown deployable resource kind: %T", deploySpec))
 	}
@@ -662,7 +1398,17 @@ func (gr *GenericReconciler) createDeployment(
 		deployment.Id = deploymentId
 	}
 
-	return deployment
+	return deployment, nil
+}
+
+// deploymentLocation returns the location to store a deployment made outside of a resource group in, which is the
+// location of the resources being deployed or, if they don't have one, the default deployment location
+func (gr *GenericReconciler) deploymentLocation(location string) string {
+	if location == "" {
+		return gr.DefaultDeploymentLocation
+	}
+
+	return location
 }
 
 func (gr *GenericReconciler) Patch(
@@ -692,9 +1438,21 @@ func (gr *GenericReconciler) Patch(
 	}, data.metaObj)
 }
 
+// needsOwnerReference returns true if the resource has an owner in its own namespace which hasn't yet been set as its
+// Kubernetes owner. Kubernetes doesn't support ownership across namespaces, so owners in other namespaces (and owners
+// given by ARM ID, which aren't in Kubernetes at all) are never set as owner references.
+func needsOwnerReference(metaObj genruntime.MetaObject) bool {
+	owner := metaObj.Owner()
+	if owner == nil || owner.IsDirectArmReference() || owner.NamespaceOrDefault(metaObj.GetNamespace()) != metaObj.GetNamespace() {
+		return false
+	}
+
+	return len(metaObj.GetOwnerReferences()) == 0
+}
+
 // isOwnerReady returns true if the owner is ready or if there is no owner required
 func (gr *GenericReconciler) isOwnerReady(ctx context.Context, data *ReconcileMetadata) (bool, error) {
-	_, err := gr.ResourceResolver.GetOwner(ctx, data.metaObj)
+	owner, err := gr.ResourceResolver.GetOwner(ctx, data.metaObj)
 	if err != nil {
 		var typedErr *armresourceresolver.OwnerNotFound
 		if errors.As(err, &typedErr) {
@@ -705,6 +1463,12 @@ func (gr *GenericReconciler) isOwnerReady(ctx context.Context, data *ReconcileMe
 		return false, errors.Wrap(err, "failed to get owner")
 	}
 
+	if owner != nil && !owner.GetDeletionTimestamp().IsZero() {
+		// Don't take a reference to an owner which is going away, it may have detached us deliberately
+		data.log.V(4).Info("Owner is being deleted", "name", owner.GetName())
+		return false, nil
+	}
+
 	return true, nil
 }
 
@@ -732,7 +1496,7 @@ func (gr *GenericReconciler) applyOwnership(ctx context.Context, data *Reconcile
 
 		mutData.log.V(4).Info("Set owner reference", "ownerGvk", ownerGvk, "ownerName", owner.GetName())
 
-		return nil
+		return mutData.SetCondition(genruntime.ConditionTypeOwnerReady, metav1.ConditionTrue, genruntime.ReasonOwnerExists, "")
 	})
 
 	if err != nil {
@@ -0,0 +1,124 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+const deleteTestResourceId = "/subscriptions/1234/resourceGroups/myrg"
+
+// deletingApplier records the resources deleted through it, and reports whether they still exist
+type deletingApplier struct {
+	armclient.Applier
+	deleteErr error
+	exists    bool
+	deleted   []string
+}
+
+func (a *deletingApplier) BeginDeleteResource(_ context.Context, id string, apiVersion string, _ genruntime.ArmResourceStatus) (time.Duration, error) {
+	a.deleted = append(a.deleted, id+"?api-version="+apiVersion)
+	return 0, a.deleteErr
+}
+
+func (a *deletingApplier) HeadResource(_ context.Context, _ string, _ string) (bool, error) {
+	return a.exists, nil
+}
+
+func newDeleteTestReconciler(g *WithT, applier armclient.Applier) (*GenericReconciler, *ReconcileMetadata) {
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+	g.Expect(resources.AddToScheme(s)).To(Succeed())
+
+	rg := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "myrg",
+			Namespace:  "team-a",
+			Finalizers: []string{GenericControllerFinalizer},
+		},
+		Spec: resources.ResourceGroupSpec{
+			AzureName: "myrg",
+			Location:  "westus",
+		},
+	}
+	fakeClient := fake.NewFakeClientWithScheme(s, rg)
+
+	// There's deliberately no ResourceResolver, as deleting a resource mustn't depend on its owners still existing
+	gr := &GenericReconciler{
+		KubeClient: kubeclient.NewClient(fakeClient, s),
+		Recorder:   record.NewFakeRecorder(10),
+		backoff:    newRequeueBackoff(time.Second, time.Minute, 2),
+	}
+
+	data := NewReconcileMetadata(rg, applier, ReconcilePolicyManage, ctrl.Log)
+	data.SetResourceId(deleteTestResourceId)
+
+	return gr, data
+}
+
+func Test_StartDeleteOfResource_DeletesByResourceId(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	applier := &deletingApplier{exists: true}
+	gr, data := newDeleteTestReconciler(g, applier)
+
+	_, err := gr.StartDeleteOfResource(ctx, ReconcileActionBeginDelete, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier.deleted).To(ConsistOf(deleteTestResourceId + "?api-version=2020-06-01"))
+	g.Expect(controllerutil.ContainsFinalizer(data.metaObj, GenericControllerFinalizer)).To(BeTrue())
+
+	// The finalizer is kept until Azure reports the resource is gone
+	_, err = gr.MonitorDelete(ctx, ReconcileActionMonitorDelete, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(controllerutil.ContainsFinalizer(data.metaObj, GenericControllerFinalizer)).To(BeTrue())
+
+	applier.exists = false
+	_, err = gr.MonitorDelete(ctx, ReconcileActionMonitorDelete, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(controllerutil.ContainsFinalizer(data.metaObj, GenericControllerFinalizer)).To(BeFalse())
+}
+
+func Test_StartDeleteOfResource_ResourceAlreadyGone(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	notFound := &azure.RequestError{
+		DetailedError: autorest.DetailedError{
+			Response:   &http.Response{StatusCode: http.StatusNotFound},
+			StatusCode: http.StatusNotFound,
+		},
+	}
+	applier := &deletingApplier{deleteErr: notFound}
+	gr, data := newDeleteTestReconciler(g, applier)
+
+	_, err := gr.StartDeleteOfResource(ctx, ReconcileActionBeginDelete, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier.deleted).To(HaveLen(1))
+
+	_, err = gr.MonitorDelete(ctx, ReconcileActionMonitorDelete, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(controllerutil.ContainsFinalizer(data.metaObj, GenericControllerFinalizer)).To(BeFalse())
+}
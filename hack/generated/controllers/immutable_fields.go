@@ -0,0 +1,81 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/armresourceresolver"
+)
+
+// immutableFieldChanges returns a description of each change made to the resource since it was created in Azure
+// which Azure doesn't allow, found by comparing the resource with the ARM ID it was created with. Changes like these
+// are rejected by the validating webhook, so are only found here if the webhook isn't installed.
+func (gr *GenericReconciler) immutableFieldChanges(ctx context.Context, data *ReconcileMetadata) ([]string, error) {
+	id := data.GetResourceIdOrDefault()
+	if id == "" {
+		// Not yet created in Azure, so anything can change
+		return nil, nil
+	}
+
+	var changes []string
+	lowerId := strings.ToLower(id)
+	if !strings.HasSuffix(lowerId, "/"+strings.ToLower(data.metaObj.AzureName())) {
+		changes = append(changes, fmt.Sprintf("AzureName was changed to %q", data.metaObj.AzureName()))
+	}
+
+	owner, err := gr.ResourceResolver.GetOwner(ctx, data.metaObj)
+	if err != nil {
+		// Deploying waits for the owner to exist and be granted, after which we check again
+		var ownerErr *armresourceresolver.OwnerNotFound
+		var grantErr *armresourceresolver.ReferenceNotGranted
+		if errors.As(err, &ownerErr) || errors.As(err, &grantErr) {
+			return changes, nil
+		}
+
+		return nil, err
+	}
+
+	if owner != nil {
+		ownerId := owner.GetAnnotations()[ResourceIdAnnotation]
+		if ownerId != "" && !strings.HasPrefix(lowerId, strings.ToLower(ownerId)+"/") {
+			changes = append(changes, fmt.Sprintf("owner was changed to %q", owner.GetName()))
+		}
+	}
+
+	return changes, nil
+}
+
+// rejectImmutableFieldChanges puts the resource into a terminal error state because it has been changed in ways that
+// can't be made in Azure. It isn't requeued; reverting the changes allows it to be reconciled again.
+func (gr *GenericReconciler) rejectImmutableFieldChanges(ctx context.Context, data *ReconcileMetadata, changes []string) (ctrl.Result, error) {
+	msg := fmt.Sprintf(
+		"%s, but the resource already exists in Azure as %s and can't be moved or renamed. Revert the change, or delete and recreate the resource.",
+		strings.Join(changes, " and "),
+		data.GetResourceIdOrDefault())
+	data.log.Info("Rejecting changes to immutable fields", "changes", changes)
+	gr.Recorder.Event(data.metaObj, v1.EventTypeWarning, genruntime.ReasonImmutableFieldChanged, msg)
+
+	err := gr.Patch(ctx, data, func(ctx context.Context, mutData *ReconcileMetadata) error {
+		return mutData.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonImmutableFieldChanged, msg)
+	})
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "patching immutable field conditions")
+	}
+
+	return ctrl.Result{}, nil
+}
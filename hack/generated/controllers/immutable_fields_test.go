@@ -0,0 +1,62 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/armresourceresolver"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+func Test_ImmutableFieldChanges_OnlyReportedOnceProvisioned(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = resources.AddToScheme(s)
+
+	gr := &GenericReconciler{
+		ResourceResolver: armresourceresolver.NewResolver(kubeclient.NewClient(fake.NewFakeClientWithScheme(s), s), nil),
+	}
+
+	rg := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myrg",
+			Namespace: "team-a",
+		},
+		Spec: resources.ResourceGroupSpec{
+			AzureName: "renamed",
+			Location:  "westus",
+		},
+	}
+	data := NewReconcileMetadata(rg, nil, ReconcilePolicyManage, ctrl.Log)
+
+	changes, err := gr.immutableFieldChanges(ctx, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changes).To(BeEmpty())
+
+	data.SetResourceId("/subscriptions/1234/resourceGroups/MyRG")
+	changes, err = gr.immutableFieldChanges(ctx, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changes).To(ConsistOf(`AzureName was changed to "renamed"`))
+
+	// Azure names aren't case sensitive
+	rg.Spec.AzureName = "myrg"
+	changes, err = gr.immutableFieldChanges(ctx, data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changes).To(BeEmpty())
+}
@@ -9,10 +9,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -23,13 +26,22 @@ import (
 )
 
 const (
+	// These annotations are the controller's own bookkeeping. The state of the resource is reported to users
+	// through the conditions in its status.
 	// TODO: Delete these later in favor of something in status?
 	DeploymentIdAnnotation   = "deployment-id.infra.azure.com"
 	DeploymentNameAnnotation = "deployment-name.infra.azure.com"
 	ResourceStateAnnotation  = "resource-state.infra.azure.com"
-	ResourceIdAnnotation     = "resource-id.infra.azure.com"
+	ResourceIdAnnotation     = genruntime.ResourceIdAnnotation
 	ResourceErrorAnnotation  = "resource-error.infra.azure.com"
-	ResourceSigAnnotationKey = "resource-sig.infra.azure.com"
+	// DriftDetectedAnnotation holds the fields of the resource which have been changed in Azure since it
+	// was last deployed, if drift detection is enabled and has found any
+	DriftDetectedAnnotation = "drift-detected.infra.azure.com"
+	// AdoptAnnotation holds the AdoptionPolicy of a resource which may already exist in Azure
+	AdoptAnnotation = "adopt.infra.azure.com"
+	// AdoptConfirmedAnnotation is set to "true" by the user to confirm that a resource being adopted with
+	// AdoptionPolicyReview should be deployed, changing the existing resource in Azure to match the spec
+	AdoptConfirmedAnnotation = "adopt-confirmed.infra.azure.com"
 	// PreserveDeploymentAnnotation is the key which tells the applier to keep or delete the deployment
 	PreserveDeploymentAnnotation = "x-preserve-deployment"
 )
@@ -38,12 +50,23 @@ const (
 type ReconcileMetadata struct {
 	log     logr.Logger
 	metaObj genruntime.MetaObject
+	// armClient is the client for the subscription and credentials this resource is managed with
+	armClient armclient.Applier
+	// policy determines what the controller may do to the resource in Azure
+	policy ReconcilePolicy
 }
 
-func NewReconcileMetadata(metaObj genruntime.MetaObject, log logr.Logger) *ReconcileMetadata {
+func NewReconcileMetadata(
+	metaObj genruntime.MetaObject,
+	armClient armclient.Applier,
+	policy ReconcilePolicy,
+	log logr.Logger) *ReconcileMetadata {
+
 	return &ReconcileMetadata{
-		metaObj: metaObj,
-		log:     log,
+		metaObj:   metaObj,
+		armClient: armClient,
+		policy:    policy,
+		log:       log,
 	}
 }
 
@@ -118,12 +141,118 @@ func (r *ReconcileMetadata) SetResourceError(error string) {
 	r.addAnnotation(ResourceErrorAnnotation, error)
 }
 
-func (r *ReconcileMetadata) SetResourceSignature(sig string) {
-	r.addAnnotation(ResourceSigAnnotationKey, sig)
+// SetResourceSignature records in status the signature of the spec most recently deployed to Azure
+func (r *ReconcileMetadata) SetResourceSignature(sig string) error {
+	return reflecthelpers.SetLastAppliedSpecSignature(r.metaObj, sig)
+}
+
+// GetResourceSignature returns the signature of the spec most recently deployed to Azure, and whether there is one
+func (r *ReconcileMetadata) GetResourceSignature() (string, bool, error) {
+	sig, err := reflecthelpers.GetLastAppliedSpecSignature(r.metaObj)
+	return sig, sig != "", err
+}
+
+// GetPendingResourceSignature returns the signature of the spec currently being deployed to Azure, and whether
+// there is one
+func (r *ReconcileMetadata) GetPendingResourceSignature() (string, bool, error) {
+	sig, err := reflecthelpers.GetPendingSpecSignature(r.metaObj)
+	return sig, sig != "", err
+}
+
+// SetPendingResourceSignature records in status the signature of the spec currently being deployed to Azure
+func (r *ReconcileMetadata) SetPendingResourceSignature(sig string) error {
+	return reflecthelpers.SetPendingSpecSignature(r.metaObj, sig)
+}
+
+// GetAdoptionPolicy returns the policy for adopting the resource if it already exists in Azure
+func (r *ReconcileMetadata) GetAdoptionPolicy() (AdoptionPolicy, error) {
+	policy := AdoptionPolicy(r.metaObj.GetAnnotations()[AdoptAnnotation])
+	err := policy.validate()
+	if err != nil {
+		return AdoptionPolicyNone, errors.Wrapf(err, "reading annotation %q", AdoptAnnotation)
+	}
+
+	return policy, nil
+}
+
+// IsAdoptionConfirmed returns true if the user has confirmed that the resource being adopted should be deployed
+func (r *ReconcileMetadata) IsAdoptionConfirmed() bool {
+	confirmed, err := strconv.ParseBool(r.metaObj.GetAnnotations()[AdoptConfirmedAnnotation])
+	return err == nil && confirmed
+}
+
+// NeedsAdoption returns true if the resource should be checked for an existing resource in Azure before it is
+// deployed. This is only the case before the resource has first been deployed.
+func (r *ReconcileMetadata) NeedsAdoption() (bool, error) {
+	policy, err := r.GetAdoptionPolicy()
+	if err != nil {
+		return false, err
+	}
+
+	_, deployed, err := r.GetResourceSignature()
+	if err != nil {
+		return false, err
+	}
+
+	return policy != AdoptionPolicyNone && !deployed && r.GetResourceIdOrDefault() == "", nil
+}
+
+// Adopt records that the resource in Azure with the given ID, whose state is given by status, already matches the
+// spec and so is now managed without having been deployed
+func (r *ReconcileMetadata) Adopt(id string, status genruntime.FromArmConverter) error {
+	controllerutil.AddFinalizer(r.metaObj, GenericControllerFinalizer)
+
+	sig, err := r.SpecSignature()
+	if err != nil {
+		return errors.Wrap(err, "failed to compute resource spec hash")
+	}
+
+	err = r.SetResourceSignature(sig)
+	if err != nil {
+		return err
+	}
+
+	r.SetResourceId(id)
+	r.SetResourceProvisioningState(armclient.SucceededProvisioningState)
+
+	err = reflecthelpers.SetStatus(r.metaObj, status)
+	if err != nil {
+		return err
+	}
+
+	err = reflecthelpers.SetObservedGeneration(r.metaObj)
+	if err != nil {
+		return err
+	}
+
+	return r.SetCondition(genruntime.ConditionTypeReady, metav1.ConditionTrue, genruntime.ReasonAdopted, "Adopted existing resource "+id)
+}
+
+func (r *ReconcileMetadata) GetDriftOrDefault() string {
+	return r.metaObj.GetAnnotations()[DriftDetectedAnnotation]
+}
+
+func (r *ReconcileMetadata) SetDrift(drift []string) {
+	r.addAnnotation(DriftDetectedAnnotation, strings.Join(drift, ","))
 }
 
+// SetCondition sets a condition in the status of the resource, replacing any existing condition of the same type
+func (r *ReconcileMetadata) SetCondition(conditionType genruntime.ConditionType, status metav1.ConditionStatus, reason string, message string) error {
+	err := reflecthelpers.SetCondition(r.metaObj, genruntime.NewCondition(conditionType, status, reason, message))
+	if err != nil {
+		return errors.Wrapf(err, "setting condition %q", conditionType)
+	}
+
+	return nil
+}
+
+// HasResourceSpecHashChanged returns true if the spec has changed since it was last deployed to Azure
 func (r *ReconcileMetadata) HasResourceSpecHashChanged() (bool, error) {
-	oldSig, exists := r.metaObj.GetAnnotations()[ResourceSigAnnotationKey]
+	oldSig, exists, err := r.GetResourceSignature()
+	if err != nil {
+		return false, err
+	}
+
 	if !exists {
 		// signature does not exist, so yes, it has changed
 		return true, nil
@@ -184,34 +313,100 @@ func (r *ReconcileMetadata) Update(
 
 	controllerutil.AddFinalizer(r.metaObj, GenericControllerFinalizer)
 
-	sig, err := r.SpecSignature()
-	if err != nil {
-		return errors.Wrap(err, "failed to compute resource spec hash")
-	}
-
 	r.SetDeploymentId(deployment.Id)
 	r.SetDeploymentName(deployment.Name)
 	// TODO: Do we want to just use Azure's annotations here? I bet we don't? We probably want to map
 	// TODO: them onto something more robust? For now just use Azure's though.
 	r.SetResourceProvisioningState(deployment.Properties.ProvisioningState)
-	r.SetResourceSignature(sig)
-	if deployment.IsTerminalProvisioningState() {
-		if deployment.Properties.ProvisioningState == armclient.FailedProvisioningState {
-			r.SetResourceError(deployment.Properties.Error.String())
-		} else if len(deployment.Properties.OutputResources) > 0 {
-			resourceId := deployment.Properties.OutputResources[0].ID
-			r.SetResourceId(resourceId)
-
-			if status != nil {
-				err = reflecthelpers.SetStatus(r.metaObj, status)
-				if err != nil {
-					return err
-				}
-			}
-		} else {
-			return errors.New("template deployment didn't have any output resources")
+	if !deployment.IsTerminalProvisioningState() {
+		return r.setProvisioningConditions(
+			metav1.ConditionTrue,
+			metav1.ConditionFalse,
+			genruntime.ReasonDeploying,
+			fmt.Sprintf("Deployment is in state %q", deployment.Properties.ProvisioningState))
+	}
+
+	// The spec which was being deployed is now the one in Azure (or at least, the one we last tried to put there).
+	// Note that this isn't necessarily the current spec, as it may have been changed while the deployment was
+	// in flight.
+	pendingSig, ok, err := r.GetPendingResourceSignature()
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		err = r.SetResourceSignature(pendingSig)
+		if err != nil {
+			return err
+		}
+
+		err = r.SetPendingResourceSignature("")
+		if err != nil {
+			return err
 		}
 	}
 
-	return nil
+	if deployment.Properties.ProvisioningState != armclient.SucceededProvisioningState {
+		msg := fmt.Sprintf("Deployment is in state %q", deployment.Properties.ProvisioningState)
+		if deployment.Properties.Error != nil {
+			msg = deployment.Properties.Error.String()
+			r.SetResourceError(msg)
+		}
+
+		return r.setProvisioningConditions(metav1.ConditionFalse, metav1.ConditionFalse, genruntime.ReasonFailed, msg)
+	}
+
+	if len(deployment.Properties.OutputResources) == 0 {
+		return errors.New("template deployment didn't have any output resources")
+	}
+
+	resourceId := deployment.Properties.OutputResources[0].ID
+	r.SetResourceId(resourceId)
+
+	if status != nil {
+		err := reflecthelpers.SetStatus(r.metaObj, status)
+		if err != nil {
+			return err
+		}
+	}
+
+	hasChanged, err := r.HasResourceSpecHashChanged()
+	if err != nil {
+		return errors.Wrap(err, "comparing resource hash")
+	}
+
+	if hasChanged {
+		// The spec was changed while the deployment was in flight, so what's in Azure isn't what was asked for yet
+		return r.setProvisioningConditions(
+			metav1.ConditionFalse,
+			metav1.ConditionFalse,
+			genruntime.ReasonDeploying,
+			"Spec has changed since the deployment started")
+	}
+
+	err = reflecthelpers.SetObservedGeneration(r.metaObj)
+	if err != nil {
+		return err
+	}
+
+	return r.setProvisioningConditions(
+		metav1.ConditionFalse,
+		metav1.ConditionTrue,
+		genruntime.ReasonSucceeded,
+		"")
+}
+
+// setProvisioningConditions sets the Provisioning and Ready conditions of the resource
+func (r *ReconcileMetadata) setProvisioningConditions(
+	provisioning metav1.ConditionStatus,
+	ready metav1.ConditionStatus,
+	reason string,
+	message string) error {
+
+	err := r.SetCondition(genruntime.ConditionTypeProvisioning, provisioning, reason, message)
+	if err != nil {
+		return err
+	}
+
+	return r.SetCondition(genruntime.ConditionTypeReady, ready, reason, message)
 }
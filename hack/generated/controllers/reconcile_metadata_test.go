@@ -0,0 +1,143 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+)
+
+func newTestDeployment(state armclient.ProvisioningState) *armclient.Deployment {
+	deployment := armclient.NewSubscriptionDeployment("sub", "westus", "deployment")
+	deployment.Id = "/subscriptions/sub/providers/Microsoft.Resources/deployments/deployment"
+	deployment.Properties.ProvisioningState = state
+	deployment.Properties.OutputResources = []armclient.OutputResource{{ID: "/subscriptions/sub/resourceGroups/rg"}}
+	return deployment
+}
+
+func Test_ReconcileMetadata_SpecChangedWhileDeploying_StillChangedAfterwards(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rg := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rg"},
+		Spec:       resources.ResourceGroupSpec{Location: "westus"},
+	}
+	data := NewReconcileMetadata(rg, nil, ReconcilePolicyManage, ctrl.Log)
+
+	changed, err := data.HasResourceSpecHashChanged()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+
+	// Start deploying the original spec
+	sig, err := data.SpecSignature()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(data.SetPendingResourceSignature(sig)).To(Succeed())
+	g.Expect(data.Update(newTestDeployment(armclient.AcceptedProvisioningState), nil)).To(Succeed())
+
+	_, ok, err := data.GetResourceSignature()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ok).To(BeFalse())
+	g.Expect(rg.Status.PendingSpecSignature).To(Equal(sig))
+
+	// Change the spec while the deployment is in flight
+	rg.Spec.Location = "eastus"
+
+	g.Expect(data.Update(newTestDeployment(armclient.SucceededProvisioningState), nil)).To(Succeed())
+
+	deployedSig, ok, err := data.GetResourceSignature()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ok).To(BeTrue())
+	g.Expect(deployedSig).To(Equal(sig))
+	g.Expect(rg.Status.LastAppliedSpecSignature).To(Equal(sig))
+	_, ok, err = data.GetPendingResourceSignature()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ok).To(BeFalse())
+
+	// The signatures are bookkeeping in status, not annotations
+	g.Expect(rg.Annotations).ToNot(HaveKey(ContainSubstring("sig")))
+
+	// The change made while deploying hasn't been deployed yet
+	changed, err = data.HasResourceSpecHashChanged()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+}
+
+func Test_ReconcileMetadata_Update_SetsConditions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rg := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rg", Generation: 2},
+		Spec:       resources.ResourceGroupSpec{Location: "westus"},
+	}
+	data := NewReconcileMetadata(rg, nil, ReconcilePolicyManage, ctrl.Log)
+
+	sig, err := data.SpecSignature()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(data.SetPendingResourceSignature(sig)).To(Succeed())
+	g.Expect(data.Update(newTestDeployment(armclient.AcceptedProvisioningState), nil)).To(Succeed())
+
+	g.Expect(genruntime.IsConditionTrue(rg.Status.Conditions, genruntime.ConditionTypeProvisioning)).To(BeTrue())
+	ready := genruntime.FindCondition(rg.Status.Conditions, genruntime.ConditionTypeReady)
+	g.Expect(ready).ToNot(BeNil())
+	g.Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(ready.Reason).To(Equal(genruntime.ReasonDeploying))
+	g.Expect(rg.Status.ObservedGeneration).To(BeZero())
+
+	g.Expect(data.Update(newTestDeployment(armclient.SucceededProvisioningState), &resources.ResourceGroupStatus{ID: "id"})).To(Succeed())
+
+	g.Expect(rg.Status.ID).To(Equal("id"))
+	g.Expect(rg.Status.ObservedGeneration).To(Equal(int64(2)))
+	g.Expect(genruntime.IsConditionTrue(rg.Status.Conditions, genruntime.ConditionTypeProvisioning)).To(BeFalse())
+	ready = genruntime.FindCondition(rg.Status.Conditions, genruntime.ConditionTypeReady)
+	g.Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(ready.Reason).To(Equal(genruntime.ReasonSucceeded))
+	g.Expect(ready.ObservedGeneration).To(Equal(int64(2)))
+}
+
+func Test_ReconcileMetadata_NeedsAdoption_OnlyBeforeFirstDeployment(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rg := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rg"},
+		Spec:       resources.ResourceGroupSpec{Location: "westus"},
+	}
+	data := NewReconcileMetadata(rg, nil, ReconcilePolicyManage, ctrl.Log)
+
+	needsAdoption, err := data.NeedsAdoption()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(needsAdoption).To(BeFalse())
+
+	rg.Annotations = map[string]string{AdoptAnnotation: string(AdoptionPolicyReview)}
+	needsAdoption, err = data.NeedsAdoption()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(needsAdoption).To(BeTrue())
+	g.Expect(data.IsAdoptionConfirmed()).To(BeFalse())
+
+	g.Expect(data.Adopt("/subscriptions/sub/resourceGroups/rg", &resources.ResourceGroupStatus{ID: "/subscriptions/sub/resourceGroups/rg"})).To(Succeed())
+
+	g.Expect(data.GetResourceIdOrDefault()).To(Equal("/subscriptions/sub/resourceGroups/rg"))
+	g.Expect(data.GetResourceProvisioningState()).To(Equal(armclient.SucceededProvisioningState))
+	g.Expect(genruntime.IsConditionTrue(rg.Status.Conditions, genruntime.ConditionTypeReady)).To(BeTrue())
+
+	changed, err := data.HasResourceSpecHashChanged()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+
+	needsAdoption, err = data.NeedsAdoption()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(needsAdoption).To(BeFalse())
+
+	rg.Annotations[AdoptAnnotation] = "Sometimes"
+	_, err = data.NeedsAdoption()
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,144 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReconcilePolicyAnnotation is the annotation, on either a resource or its namespace, containing the ReconcilePolicy
+// of the resource. The annotation on the resource takes precedence.
+const ReconcilePolicyAnnotation = "infra.azure.com/reconcile-policy"
+
+// ReconcilePolicy determines what the controller is allowed to do to the resource in Azure, in particular when its
+// Kubernetes resource is deleted
+type ReconcilePolicy string
+
+const (
+	// ReconcilePolicyManage deletes the resource from Azure when the Kubernetes resource is deleted. This is the default.
+	ReconcilePolicyManage = ReconcilePolicy("manage")
+	// ReconcilePolicySkipDelete leaves the resource in Azure when the Kubernetes resource is deleted. Kubernetes
+	// resources owned by it are deleted as usual, according to their own policies.
+	ReconcilePolicySkipDelete = ReconcilePolicy("skip-delete")
+	// ReconcilePolicyDetachOnDelete leaves the resource in Azure when the Kubernetes resource is deleted, and also
+	// detaches the Kubernetes resources it owns so that they aren't garbage collected (and so the resources they
+	// represent aren't deleted from Azure either).
+	ReconcilePolicyDetachOnDelete = ReconcilePolicy("detach-on-delete")
+	// ReconcilePolicyObserve never changes the resource in Azure, it only mirrors its state into status. This allows
+	// a resource managed by somebody else to be the owner of other resources.
+	ReconcilePolicyObserve = ReconcilePolicy("observe")
+)
+
+// ParseReconcilePolicy parses a ReconcilePolicy, treating an empty value as ReconcilePolicyManage
+func ParseReconcilePolicy(value string) (ReconcilePolicy, error) {
+	switch policy := ReconcilePolicy(value); policy {
+	case "":
+		return ReconcilePolicyManage, nil
+	case ReconcilePolicyManage, ReconcilePolicySkipDelete, ReconcilePolicyDetachOnDelete, ReconcilePolicyObserve:
+		return policy, nil
+	default:
+		return "", errors.Errorf(
+			"unknown reconcile policy %q, expected %q, %q, %q or %q",
+			value,
+			ReconcilePolicyManage,
+			ReconcilePolicySkipDelete,
+			ReconcilePolicyDetachOnDelete,
+			ReconcilePolicyObserve)
+	}
+}
+
+// DeletesFromAzure returns true if the resource in Azure should be deleted along with its Kubernetes resource
+func (p ReconcilePolicy) DeletesFromAzure() bool {
+	return p == ReconcilePolicyManage
+}
+
+// reconcilePolicy returns the ReconcilePolicy of the given resource, from either its own annotation or that of
+// its namespace
+func (gr *GenericReconciler) reconcilePolicy(ctx context.Context, obj metav1.Object) (ReconcilePolicy, error) {
+	if value, ok := obj.GetAnnotations()[ReconcilePolicyAnnotation]; ok && value != "" {
+		policy, err := ParseReconcilePolicy(value)
+		return policy, errors.Wrapf(err, "reading annotation %q of %s", ReconcilePolicyAnnotation, obj.GetName())
+	}
+
+	var namespace v1.Namespace
+	err := gr.KubeClient.Client.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, &namespace)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting namespace %s", obj.GetNamespace())
+	}
+
+	policy, err := ParseReconcilePolicy(namespace.GetAnnotations()[ReconcilePolicyAnnotation])
+	return policy, errors.Wrapf(err, "reading annotation %q of namespace %s", ReconcilePolicyAnnotation, namespace.Name)
+}
+
+// detachOwnedResources removes the owner references to the given resource from the resources it owns, so that
+// they aren't garbage collected when it is deleted
+func (gr *GenericReconciler) detachOwnedResources(ctx context.Context, owner metav1.Object) error {
+	for _, gvk := range gr.ResourceKinds {
+		listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+		list, err := gr.KubeClient.Scheme.New(listGVK)
+		if err != nil {
+			return errors.Wrapf(err, "creating list for %s", gvk)
+		}
+
+		err = gr.KubeClient.Client.List(ctx, list, client.InNamespace(owner.GetNamespace()))
+		if err != nil {
+			return errors.Wrapf(err, "listing %s", gvk)
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return errors.Wrapf(err, "extracting items of %s", listGVK)
+		}
+
+		for _, item := range items {
+			err = gr.detachFromOwner(ctx, item, owner)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// detachFromOwner removes any owner reference to owner from obj
+func (gr *GenericReconciler) detachFromOwner(ctx context.Context, obj runtime.Object, owner metav1.Object) error {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return errors.Wrapf(err, "accessing metadata of %T", obj)
+	}
+
+	var remaining []metav1.OwnerReference
+	for _, ref := range objMeta.GetOwnerReferences() {
+		if ref.UID != owner.GetUID() {
+			remaining = append(remaining, ref)
+		}
+	}
+
+	if len(remaining) == len(objMeta.GetOwnerReferences()) {
+		// Not owned by owner
+		return nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopyObject())
+	objMeta.SetOwnerReferences(remaining)
+
+	err = gr.KubeClient.Client.Patch(ctx, obj, patch)
+	if err != nil {
+		return errors.Wrapf(err, "detaching %s from owner %s", objMeta.GetName(), owner.GetName())
+	}
+
+	gr.Log.V(1).Info("Detached resource from owner", "name", objMeta.GetName(), "owner", owner.GetName())
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+func newPolicyTestReconciler(objs ...runtime.Object) *GenericReconciler {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = resources.AddToScheme(s)
+
+	return &GenericReconciler{
+		Log:           ctrl.Log,
+		KubeClient:    kubeclient.NewClient(fake.NewFakeClientWithScheme(s, objs...), s),
+		ResourceKinds: []schema.GroupVersionKind{resources.GroupVersion.WithKind("ResourceGroup")},
+	}
+}
+
+func newPolicyTestNamespace(policy ReconcilePolicy) *v1.Namespace {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	if policy != "" {
+		ns.Annotations = map[string]string{ReconcilePolicyAnnotation: string(policy)}
+	}
+
+	return ns
+}
+
+func Test_ReconcilePolicy_ResourceAnnotationTakesPrecedence(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	gr := newPolicyTestReconciler(newPolicyTestNamespace(ReconcilePolicySkipDelete))
+	rg := &resources.ResourceGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rg"}}
+
+	policy, err := gr.reconcilePolicy(ctx, rg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(policy).To(Equal(ReconcilePolicySkipDelete))
+	g.Expect(policy.DeletesFromAzure()).To(BeFalse())
+
+	rg.Annotations = map[string]string{ReconcilePolicyAnnotation: string(ReconcilePolicyManage)}
+	policy, err = gr.reconcilePolicy(ctx, rg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(policy.DeletesFromAzure()).To(BeTrue())
+
+	rg.Annotations[ReconcilePolicyAnnotation] = "retain"
+	_, err = gr.reconcilePolicy(ctx, rg)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_ReconcilePolicy_DefaultsToManage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gr := newPolicyTestReconciler(newPolicyTestNamespace(""))
+	rg := &resources.ResourceGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rg"}}
+
+	policy, err := gr.reconcilePolicy(context.Background(), rg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(policy).To(Equal(ReconcilePolicyManage))
+}
+
+func Test_DetachOwnedResources_RemovesOwnerReferences(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	owner := &resources.ResourceGroup{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "owner", UID: "owner-uid"}}
+	other := metav1.OwnerReference{Name: "other", UID: "other-uid"}
+	child := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "ns",
+			Name:            "child",
+			OwnerReferences: []metav1.OwnerReference{{Name: "owner", UID: "owner-uid"}, other},
+		},
+	}
+
+	gr := newPolicyTestReconciler(owner, child)
+	g.Expect(gr.detachOwnedResources(ctx, owner)).To(Succeed())
+
+	var updated resources.ResourceGroup
+	g.Expect(gr.KubeClient.Client.Get(ctx, types.NamespacedName{Namespace: "ns", Name: "child"}, &updated)).To(Succeed())
+	g.Expect(updated.OwnerReferences).To(Equal([]metav1.OwnerReference{other}))
+}
+
+func Test_DetermineReconcileAction_ObservePolicy_NeverDeploys(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gr := newPolicyTestReconciler()
+	rg := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rg"},
+		Spec:       resources.ResourceGroupSpec{Location: "westus"},
+	}
+
+	action, _, err := gr.DetermineReconcileAction(NewReconcileMetadata(rg, nil, ReconcilePolicyManage, ctrl.Log))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(action).To(Equal(ReconcileActionBeginDeployment))
+
+	action, _, err = gr.DetermineReconcileAction(NewReconcileMetadata(rg, nil, ReconcilePolicyObserve, ctrl.Log))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(action).To(Equal(ReconcileActionObserve))
+}
+
+func Test_DetermineReconcileAction_DeleteOfResourceNeverCreated_DoesNotCallAzure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gr := newPolicyTestReconciler()
+	now := metav1.Now()
+	rg := &resources.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "rg", DeletionTimestamp: &now},
+		Spec:       resources.ResourceGroupSpec{Location: "westus"},
+	}
+
+	data := NewReconcileMetadata(rg, nil, ReconcilePolicyManage, ctrl.Log)
+	action, _, err := gr.DetermineReconcileAction(data)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(action).To(Equal(ReconcileActionBeginDelete))
+	g.Expect(callsAzure(action, data)).To(BeFalse())
+
+	data.SetResourceId("/subscriptions/sub/resourceGroups/rg")
+	g.Expect(callsAzure(action, data)).To(BeTrue())
+}
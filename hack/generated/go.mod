@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/Azure/go-autorest/autorest v0.10.2
+	github.com/Azure/go-autorest/autorest/adal v0.8.3
 	github.com/Azure/go-autorest/autorest/azure/auth v0.4.2
 	github.com/Azure/go-autorest/autorest/date v0.2.0
 	github.com/Azure/k8s-infra v0.2.0
@@ -24,3 +25,5 @@ require (
 	k8s.io/klog/v2 v2.0.0
 	sigs.k8s.io/controller-runtime v0.6.2
 )
+
+replace github.com/Azure/k8s-infra => ../../
@@ -13,6 +13,8 @@ import (
 
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/armresourceresolver"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/credentialresolver"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -22,6 +24,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 
+	infra "github.com/Azure/k8s-infra/hack/generated/apis/infra/v1alpha1"
 	batch "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.batch/v20170901"
 	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
 	storage "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.storage/v20190401"
@@ -42,15 +45,56 @@ func init() {
 	_ = batch.AddToScheme(scheme)
 	_ = storage.AddToScheme(scheme)
 	_ = resources.AddToScheme(scheme)
+	_ = infra.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var credentialChain string
+	var driftDetection controllers.DriftDetectionPolicy
+	var driftRemediation string
+	var driftDetectionOverrides string
+	var observeInterval time.Duration
+	var defaultDeploymentLocation string
+	var applyMethod string
+	var applyMethodOverrides string
+	var managedIdentities string
+	var armIds string
+	cloudConfig := armclient.CloudConfigFromEnvironment()
+	credentialConfig := armclient.CredentialConfigFromEnvironment()
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controllers manager. Enabling this will ensure there is only one active controllers manager.")
+	flag.StringVar(&cloudConfig.Name, "azure-environment", cloudConfig.Name,
+		"The name of the Azure cloud to target, for example AzurePublicCloud, AzureChinaCloud or AzureUSGovernmentCloud.")
+	flag.StringVar(&cloudConfig.MetadataFile, "azure-environment-file", cloudConfig.MetadataFile,
+		"The path to a JSON file describing the endpoints of the Azure cloud to target.")
+	flag.StringVar(&cloudConfig.MetadataEndpoint, "azure-metadata-endpoint", cloudConfig.MetadataEndpoint,
+		"The Resource Manager endpoint of an Azure Stack Hub from which to load the cloud metadata.")
+	flag.StringVar(&credentialChain, "azure-credential-chain", "",
+		"Comma separated list of the credentials to try, in order. Valid values are workload-identity, managed-identity, client-certificate and client-secret. Defaults to all of them, in that order.")
+	flag.StringVar(&credentialConfig.ManagedIdentityClientID, "azure-managed-identity-client-id", credentialConfig.ManagedIdentityClientID,
+		"The client ID of the user assigned managed identity to authenticate as. If not set the system assigned identity is used.")
+	flag.StringVar(&managedIdentities, "azure-managed-identity-namespaces", "",
+		"Comma separated managed identities which credential secrets in each namespace may use, in the form namespace=clientID.")
+	flag.StringVar(&armIds, "arm-id-namespaces", "",
+		"Comma separated ARM ID prefixes under which resources in each namespace may refer to Azure resources by ARM ID, in the form namespace=/subscriptions/....")
+	flag.DurationVar(&driftDetection.Interval, "drift-detection-interval", 0,
+		"How often to check that resources in Azure haven't been changed outside of Kubernetes. Zero disables drift detection.")
+	flag.StringVar(&driftRemediation, "drift-remediation", string(controllers.DriftRemediationReport),
+		"What to do when a resource has drifted: Report records it on the resource, Reapply also redeploys the spec.")
+	flag.StringVar(&driftDetectionOverrides, "drift-detection-overrides", "",
+		"Comma separated drift detection settings for specific kinds of resource, in the form Kind.group=interval[:remediation].")
+	flag.DurationVar(&observeInterval, "observe-interval", 5*time.Minute,
+		"How often to refresh the status of resources whose reconcile policy is observe.")
+	flag.StringVar(&defaultDeploymentLocation, "default-deployment-location", "westus2",
+		"The location to store deployments of management group and tenant resources which don't have a location of their own.")
+	flag.StringVar(&applyMethod, "apply-method", string(controllers.ApplyMethodDeployment),
+		"How resources are created and updated in Azure: Deployment wraps each one in a template deployment, Direct PUTs it at its ID.")
+	flag.StringVar(&applyMethodOverrides, "apply-method-overrides", "",
+		"Comma separated apply methods for specific kinds of resource, in the form Kind.group=method.")
 	flag.Parse()
 
 	ctrl.SetLogger(klogr.New())
@@ -71,7 +115,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	authorizer, err := armclient.AuthorizerFromEnvironment()
+	cloudEnv, err := cloudConfig.Environment()
+	if err != nil {
+		setupLog.Error(err, "unable to determine Azure cloud environment")
+		os.Exit(1)
+	}
+
+	credentialConfig.Chain, err = armclient.ParseCredentialChain(credentialChain)
+	if err != nil {
+		setupLog.Error(err, "invalid credential chain")
+		os.Exit(1)
+	}
+
+	authorizer, err := credentialConfig.Authorizer(cloudEnv)
 	if err != nil {
 		setupLog.Error(err, "unable to get authorization settings")
 		os.Exit(1)
@@ -83,13 +139,56 @@ func main() {
 		os.Exit(1)
 	}
 
-	armApplier, err := armclient.NewAzureTemplateClient(authorizer, subID)
+	armApplier, err := armclient.NewAzureTemplateClient(authorizer, subID, armclient.WithEnvironment(cloudEnv))
 	if err != nil {
 		setupLog.Error(err, "failed to create ARM applier")
 		os.Exit(1)
 	}
 
-	if errs := controllers.RegisterAll(mgr, armApplier, controllers.KnownTypes, ctrl.Log.WithName("controllers"), concurrency(1)); errs != nil {
+	driftDetection.Remediation = controllers.DriftRemediation(driftRemediation)
+	driftDetectionPolicies, err := controllers.ParseDriftDetectionOverrides(driftDetectionOverrides)
+	if err != nil {
+		setupLog.Error(err, "invalid drift detection overrides")
+		os.Exit(1)
+	}
+
+	applyMethodsByKind, err := controllers.ParseApplyMethodOverrides(applyMethodOverrides)
+	if err != nil {
+		setupLog.Error(err, "invalid apply method overrides")
+		os.Exit(1)
+	}
+
+	managedIdentityAllowList, err := credentialresolver.ParseManagedIdentityAllowList(managedIdentities)
+	if err != nil {
+		setupLog.Error(err, "invalid managed identity namespaces")
+		os.Exit(1)
+	}
+
+	armIdAllowList, err := armresourceresolver.ParseArmIdAllowList(armIds)
+	if err != nil {
+		setupLog.Error(err, "invalid ARM ID namespaces")
+		os.Exit(1)
+	}
+
+	options := concurrency(1)
+	options.DriftDetection = driftDetection
+	options.DriftDetectionOverrides = driftDetectionPolicies
+	options.ObserveInterval = observeInterval
+	options.DefaultDeploymentLocation = defaultDeploymentLocation
+	options.ApplyMethod = controllers.ApplyMethod(applyMethod)
+	options.ApplyMethodOverrides = applyMethodsByKind
+	options.ManagedIdentities = managedIdentityAllowList
+	options.ArmIds = armIdAllowList
+	options.ApplierFactory = func(credential credentialresolver.Credential) (armclient.Applier, error) {
+		authorizer, err := credential.CredentialConfig.Authorizer(cloudEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		return armclient.NewAzureTemplateClient(authorizer, credential.SubscriptionID, armclient.WithEnvironment(cloudEnv))
+	}
+
+	if errs := controllers.RegisterAll(mgr, armApplier, controllers.KnownTypes, ctrl.Log.WithName("controllers"), options); errs != nil {
 		for _, err := range errs {
 			setupLog.Error(err, "failed to register gvk: %v")
 		}
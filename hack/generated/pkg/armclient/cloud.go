@@ -0,0 +1,112 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvironmentFilePathVar is the environment variable go-autorest uses to locate
+	// the JSON description of a custom cloud (for example an Azure Stack Hub)
+	EnvironmentFilePathVar = "AZURE_ENVIRONMENT_FILEPATH"
+
+	// MetadataEndpointVar is the environment variable containing the Resource Manager
+	// endpoint to query for cloud metadata
+	MetadataEndpointVar = "AZURE_METADATA_ENDPOINT"
+)
+
+// CloudConfig describes how to find the Azure cloud environment the operator targets.
+// At most one of Name, MetadataFile or MetadataEndpoint may be set. If none are set
+// the public Azure cloud is used.
+type CloudConfig struct {
+	// Name is the name of a well known cloud, such as AzurePublicCloud, AzureChinaCloud
+	// or AzureUSGovernmentCloud
+	Name string
+	// MetadataFile is the path to a JSON file containing an azure.Environment
+	MetadataFile string
+	// MetadataEndpoint is the Resource Manager endpoint of a cloud which serves its own
+	// metadata (such as an Azure Stack Hub), e.g. https://management.local.azurestack.external/
+	MetadataEndpoint string
+}
+
+// CloudConfigFromEnvironment builds a CloudConfig from the standard Azure environment variables
+func CloudConfigFromEnvironment() CloudConfig {
+	return CloudConfig{
+		Name:             os.Getenv("AZURE_ENVIRONMENT"),
+		MetadataFile:     os.Getenv(EnvironmentFilePathVar),
+		MetadataEndpoint: os.Getenv(MetadataEndpointVar),
+	}
+}
+
+// Environment resolves the cloud environment described by the config
+func (c CloudConfig) Environment() (azure.Environment, error) {
+	set := 0
+	for _, v := range []string{c.Name, c.MetadataFile, c.MetadataEndpoint} {
+		if v != "" {
+			set++
+		}
+	}
+
+	if set > 1 {
+		return azure.Environment{}, errors.Errorf(
+			"only one of cloud name (%q), metadata file (%q) or metadata endpoint (%q) may be specified",
+			c.Name,
+			c.MetadataFile,
+			c.MetadataEndpoint)
+	}
+
+	var env azure.Environment
+	var err error
+	switch {
+	case c.MetadataFile != "":
+		env, err = azure.EnvironmentFromFile(c.MetadataFile)
+		if err != nil {
+			return azure.Environment{}, errors.Wrapf(err, "loading cloud environment from %q", c.MetadataFile)
+		}
+	case c.MetadataEndpoint != "":
+		env, err = azure.EnvironmentFromURL(c.MetadataEndpoint)
+		if err != nil {
+			return azure.Environment{}, errors.Wrapf(err, "loading cloud environment from endpoint %q", c.MetadataEndpoint)
+		}
+	case c.Name != "":
+		env, err = azure.EnvironmentFromName(c.Name)
+		if err != nil {
+			return azure.Environment{}, errors.Wrapf(err, "unknown cloud %q", c.Name)
+		}
+	default:
+		env = azure.PublicCloud
+	}
+
+	if env.ResourceManagerEndpoint == "" {
+		return azure.Environment{}, errors.Errorf("cloud environment %q has no resource manager endpoint", env.Name)
+	}
+
+	if env.ActiveDirectoryEndpoint == "" {
+		return azure.Environment{}, errors.Errorf("cloud environment %q has no active directory endpoint", env.Name)
+	}
+
+	return env, nil
+}
+
+// resourceManagerHost returns the Resource Manager endpoint of the environment, with a trailing slash
+func resourceManagerHost(env azure.Environment) string {
+	return strings.TrimSuffix(env.ResourceManagerEndpoint, "/") + "/"
+}
+
+// tokenAudience returns the resource which tokens for the Resource Manager endpoint of the environment must be
+// issued for. Azure Stack Hub uses a different audience than the endpoint itself.
+func tokenAudience(env azure.Environment) string {
+	if env.TokenAudience != "" {
+		return env.TokenAudience
+	}
+
+	return env.ResourceManagerEndpoint
+}
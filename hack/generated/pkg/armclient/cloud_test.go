@@ -0,0 +1,97 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+)
+
+func Test_CloudConfig_Default_IsPublicCloud(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	env, err := armclient.CloudConfig{}.Environment()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(env.Name).To(Equal(azure.PublicCloud.Name))
+}
+
+func Test_CloudConfig_ByName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	env, err := armclient.CloudConfig{Name: "AzureChinaCloud"}.Environment()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(env.ResourceManagerEndpoint).To(Equal(azure.ChinaCloud.ResourceManagerEndpoint))
+
+	_, err = armclient.CloudConfig{Name: "NotACloud"}.Environment()
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_CloudConfig_MultipleSources_IsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := armclient.CloudConfig{Name: "AzureChinaCloud", MetadataFile: "cloud.json"}.Environment()
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_CloudConfig_FromFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "cloud")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	expected := azure.USGovernmentCloud
+	expected.Name = "MyCloud"
+	content, err := json.Marshal(expected)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	path := filepath.Join(dir, "cloud.json")
+	g.Expect(ioutil.WriteFile(path, content, 0600)).To(Succeed())
+
+	env, err := armclient.CloudConfig{MetadataFile: path}.Environment()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(env).To(Equal(expected))
+}
+
+func Test_CloudConfig_FromMetadataEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/endpoints" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{
+			"galleryEndpoint": "https://gallery.local.azurestack.external/",
+			"graphEndpoint": "https://graph.local.azurestack.external/",
+			"authentication": {
+				"loginEndpoint": "https://login.local.azurestack.external/adfs/",
+				"audiences": ["https://management.adfs.azurestack.local/1234"]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	env, err := armclient.CloudConfig{MetadataEndpoint: server.URL}.Environment()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(env.ResourceManagerEndpoint).To(Equal(server.URL))
+	g.Expect(env.ActiveDirectoryEndpoint).To(Equal("https://login.local.azurestack.external/adfs/"))
+	g.Expect(env.TokenAudience).To(Equal("https://management.adfs.azurestack.local/1234"))
+
+	client := armclient.NewClient(nil, env)
+	g.Expect(client.Host).To(Equal(server.URL + "/"))
+}
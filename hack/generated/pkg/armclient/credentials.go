@@ -0,0 +1,265 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// CredentialSource is a way of obtaining an AAD token for the operator
+type CredentialSource string
+
+const (
+	// WorkloadIdentityCredential exchanges a federated (e.g. Kubernetes service account) token read from a file
+	// for an AAD token
+	WorkloadIdentityCredential = CredentialSource("workload-identity")
+	// ManagedIdentityCredential requests a token from the Instance Metadata Service (IMDS)
+	ManagedIdentityCredential = CredentialSource("managed-identity")
+	// ClientCertificateCredential authenticates as a service principal using a PKCS#12 certificate
+	ClientCertificateCredential = CredentialSource("client-certificate")
+	// ClientSecretCredential authenticates as a service principal using a client secret
+	ClientSecretCredential = CredentialSource("client-secret")
+)
+
+const (
+	// FederatedTokenFileVar is the environment variable containing the path of the federated token file
+	FederatedTokenFileVar = "AZURE_FEDERATED_TOKEN_FILE"
+	// ManagedIdentityClientIDVar is the environment variable containing the client ID of a user assigned identity
+	ManagedIdentityClientIDVar = "AZURE_MANAGED_IDENTITY_CLIENT_ID"
+
+	// managedIdentityProbeTimeout bounds how long we wait for IMDS before moving on to the next credential
+	managedIdentityProbeTimeout = 10 * time.Second
+)
+
+// DefaultCredentialChain is the order in which credentials are tried if no chain is specified. Operators who would
+// rather a configured service principal is used in place of the managed identity of the node should specify a chain
+// with --azure-credential-chain.
+var DefaultCredentialChain = []CredentialSource{
+	WorkloadIdentityCredential,
+	ManagedIdentityCredential,
+	ClientCertificateCredential,
+	ClientSecretCredential,
+}
+
+// CredentialConfig contains the settings used to build a chain of credentials.
+// Credentials which are not configured are skipped; the first credential able to obtain
+// a token is used.
+type CredentialConfig struct {
+	// Chain is the ordered list of credential sources to try. If empty, DefaultCredentialChain is used
+	Chain []CredentialSource
+
+	TenantID            string
+	ClientID            string
+	ClientSecret        string
+	CertificatePath     string
+	CertificatePassword string
+
+	// FederatedTokenFile is the path of a file containing a federated token, such as a projected
+	// service account token. It is re-read every time the AAD token is refreshed.
+	FederatedTokenFile string
+
+	// ManagedIdentityClientID is the client ID of a user assigned managed identity. If empty, the
+	// system assigned identity is used
+	ManagedIdentityClientID string
+	// ManagedIdentityEndpoint overrides the IMDS token endpoint
+	ManagedIdentityEndpoint string
+
+	// Sender overrides the HTTP sender used to fetch tokens
+	Sender adal.Sender
+}
+
+// CredentialConfigFromEnvironment builds a CredentialConfig from the standard Azure environment variables
+func CredentialConfigFromEnvironment() CredentialConfig {
+	return CredentialConfig{
+		TenantID:                os.Getenv(auth.TenantID),
+		ClientID:                os.Getenv(auth.ClientID),
+		ClientSecret:            os.Getenv(auth.ClientSecret),
+		CertificatePath:         os.Getenv(auth.CertificatePath),
+		CertificatePassword:     os.Getenv(auth.CertificatePassword),
+		FederatedTokenFile:      os.Getenv(FederatedTokenFileVar),
+		ManagedIdentityClientID: os.Getenv(ManagedIdentityClientIDVar),
+	}
+}
+
+// ParseCredentialChain parses a comma separated list of credential sources
+func ParseCredentialChain(chain string) ([]CredentialSource, error) {
+	if chain == "" {
+		return nil, nil
+	}
+
+	var result []CredentialSource
+	for _, s := range strings.Split(chain, ",") {
+		source := CredentialSource(strings.TrimSpace(s))
+		switch source {
+		case WorkloadIdentityCredential, ManagedIdentityCredential, ClientCertificateCredential, ClientSecretCredential:
+			result = append(result, source)
+		default:
+			return nil, errors.Errorf("unknown credential source %q", s)
+		}
+	}
+
+	return result, nil
+}
+
+// Authorizer walks the credential chain and returns an authorizer for the first credential which is configured
+// and able to obtain a token for the Resource Manager endpoint of the given cloud. The returned authorizer
+// refreshes its token automatically.
+func (c CredentialConfig) Authorizer(env azure.Environment) (autorest.Authorizer, error) {
+	chain := c.Chain
+	if len(chain) == 0 {
+		chain = DefaultCredentialChain
+	}
+
+	var errs []error
+	for _, source := range chain {
+		spt, err := c.servicePrincipalToken(source, env)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s", source))
+			continue
+		}
+
+		if spt == nil {
+			// Not configured
+			continue
+		}
+
+		if c.Sender != nil {
+			spt.SetSender(c.Sender)
+		}
+
+		if err := c.ensureToken(source, spt); err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s: acquiring token", source))
+			continue
+		}
+
+		return autorest.NewBearerAuthorizer(spt), nil
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.Errorf("no credentials configured, tried: %v", chain)
+	}
+
+	return nil, kerrors.NewAggregate(errs)
+}
+
+// hasClientSecret returns true if we've been given the details of a service principal with a client secret
+func (c CredentialConfig) hasClientSecret() bool {
+	return c.ClientSecret != "" && c.ClientID != "" && c.TenantID != ""
+}
+
+// servicePrincipalToken returns a token for the given source, or nil if the source is not configured
+func (c CredentialConfig) servicePrincipalToken(source CredentialSource, env azure.Environment) (*adal.ServicePrincipalToken, error) {
+	resource := tokenAudience(env)
+
+	switch source {
+	case WorkloadIdentityCredential:
+		if c.FederatedTokenFile == "" || c.ClientID == "" || c.TenantID == "" {
+			return nil, nil
+		}
+
+		oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, c.TenantID)
+		if err != nil {
+			return nil, err
+		}
+
+		return adal.NewServicePrincipalTokenWithSecret(
+			*oauthConfig,
+			c.ClientID,
+			resource,
+			&federatedTokenSecret{tokenFile: c.FederatedTokenFile})
+
+	case ManagedIdentityCredential:
+		endpoint := c.ManagedIdentityEndpoint
+		if endpoint == "" {
+			var err error
+			endpoint, err = adal.GetMSIEndpoint()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if c.ManagedIdentityClientID != "" {
+			return adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(endpoint, resource, c.ManagedIdentityClientID)
+		}
+
+		return adal.NewServicePrincipalTokenFromMSI(endpoint, resource)
+
+	case ClientCertificateCredential:
+		if c.CertificatePath == "" || c.ClientID == "" || c.TenantID == "" {
+			return nil, nil
+		}
+
+		config := auth.NewClientCertificateConfig(c.CertificatePath, c.CertificatePassword, c.ClientID, c.TenantID)
+		config.AADEndpoint = env.ActiveDirectoryEndpoint
+		config.Resource = resource
+		return config.ServicePrincipalToken()
+
+	case ClientSecretCredential:
+		if !c.hasClientSecret() {
+			return nil, nil
+		}
+
+		config := auth.NewClientCredentialsConfig(c.ClientID, c.ClientSecret, c.TenantID)
+		config.AADEndpoint = env.ActiveDirectoryEndpoint
+		config.Resource = resource
+		return config.ServicePrincipalToken()
+
+	default:
+		return nil, errors.Errorf("unknown credential source %q", source)
+	}
+}
+
+// ensureToken acquires the initial token, so that a misconfigured credential falls through to the next one
+// in the chain at startup rather than failing on the first request
+func (c CredentialConfig) ensureToken(source CredentialSource, spt *adal.ServicePrincipalToken) error {
+	ctx := context.Background()
+	if source == ManagedIdentityCredential {
+		// IMDS isn't available outside of Azure, don't wait around for it
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, managedIdentityProbeTimeout)
+		defer cancel()
+	}
+
+	return spt.EnsureFreshWithContext(ctx)
+}
+
+// federatedTokenSecret implements adal.ServicePrincipalSecret by presenting the contents of a
+// federated token file as a client assertion
+type federatedTokenSecret struct {
+	tokenFile string
+}
+
+var _ adal.ServicePrincipalSecret = &federatedTokenSecret{}
+
+// SetAuthenticationValues populates the token request with the current federated token. The file is
+// read every time as the token is rotated by the kubelet.
+func (s *federatedTokenSecret) SetAuthenticationValues(_ *adal.ServicePrincipalToken, v *url.Values) error {
+	token, err := ioutil.ReadFile(s.tokenFile)
+	if err != nil {
+		return errors.Wrapf(err, "reading federated token file %q", s.tokenFile)
+	}
+
+	v.Set("client_assertion", strings.TrimSpace(string(token)))
+	v.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s federatedTokenSecret) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("marshalling federatedTokenSecret is not supported")
+}
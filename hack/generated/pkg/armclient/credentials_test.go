@@ -0,0 +1,201 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+)
+
+const testTenantID = "00000000-0000-0000-0000-000000000001"
+
+// tokenServer is a stub for both the AAD token endpoint and IMDS
+type tokenServer struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	requests []*http.Request
+	forms    []map[string][]string
+	imdsUp   bool
+	issued   int
+}
+
+func newTokenServer(imdsUp bool) *tokenServer {
+	ts := &tokenServer{imdsUp: imdsUp}
+	ts.Server = httptest.NewServer(http.HandlerFunc(ts.serve))
+	return ts
+}
+
+func (ts *tokenServer) serve(w http.ResponseWriter, r *http.Request) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	_ = r.ParseForm()
+	ts.requests = append(ts.requests, r)
+	ts.forms = append(ts.forms, r.PostForm)
+
+	if r.URL.Path == "/imds/token" && !ts.imdsUp {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ts.issued++
+	expiresOn := time.Now().Add(time.Hour).Unix()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":"3600","expires_on":"%s","resource":"%s","token_type":"Bearer"}`,
+		ts.issued,
+		strconv.FormatInt(expiresOn, 10),
+		r.Form.Get("resource"))
+}
+
+func (ts *tokenServer) paths() []string {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	var result []string
+	for _, r := range ts.requests {
+		result = append(result, r.URL.Path)
+	}
+	return result
+}
+
+func (ts *tokenServer) environment() azure.Environment {
+	env := azure.PublicCloud
+	env.ActiveDirectoryEndpoint = ts.URL + "/"
+	return env
+}
+
+func authorizationHeader(g *WithT, authorizer autorest.Authorizer) string {
+	req, err := autorest.Prepare(&http.Request{}, authorizer.WithAuthorization())
+	g.Expect(err).ToNot(HaveOccurred())
+	return req.Header.Get("Authorization")
+}
+
+func Test_CredentialChain_PrefersWorkloadIdentity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := newTokenServer(true)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "credentials")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	g.Expect(ioutil.WriteFile(tokenFile, []byte("federated-token\n"), 0600)).To(Succeed())
+
+	config := armclient.CredentialConfig{
+		TenantID:                testTenantID,
+		ClientID:                "client",
+		ClientSecret:            "secret",
+		FederatedTokenFile:      tokenFile,
+		ManagedIdentityEndpoint: server.URL + "/imds/token",
+	}
+
+	authorizer, err := config.Authorizer(server.environment())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(authorizationHeader(g, authorizer)).To(Equal("Bearer token-1"))
+
+	g.Expect(server.paths()).To(Equal([]string{"/" + testTenantID + "/oauth2/token"}))
+	g.Expect(server.forms[0]["client_assertion"]).To(Equal([]string{"federated-token"}))
+	g.Expect(server.forms[0]["client_secret"]).To(BeEmpty())
+	g.Expect(server.forms[0]["resource"]).To(Equal([]string{azure.PublicCloud.TokenAudience}))
+}
+
+func Test_CredentialChain_UsesManagedIdentity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := newTokenServer(true)
+	defer server.Close()
+
+	config := armclient.CredentialConfig{
+		TenantID:                testTenantID,
+		ClientID:                "client",
+		ClientSecret:            "secret",
+		ManagedIdentityClientID: "user-assigned",
+		ManagedIdentityEndpoint: server.URL + "/imds/token",
+	}
+
+	authorizer, err := config.Authorizer(server.environment())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(authorizationHeader(g, authorizer)).To(Equal("Bearer token-1"))
+
+	g.Expect(server.paths()).To(Equal([]string{"/imds/token"}))
+	g.Expect(server.requests[0].URL.Query().Get("client_id")).To(Equal("user-assigned"))
+	g.Expect(server.requests[0].Header.Get("Metadata")).To(Equal("true"))
+}
+
+func Test_CredentialChain_FallsBackToClientSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := newTokenServer(false)
+	defer server.Close()
+
+	config := armclient.CredentialConfig{
+		TenantID:                testTenantID,
+		ClientID:                "client",
+		ClientSecret:            "secret",
+		ManagedIdentityEndpoint: server.URL + "/imds/token",
+	}
+
+	authorizer, err := config.Authorizer(server.environment())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(authorizationHeader(g, authorizer)).To(Equal("Bearer token-1"))
+
+	g.Expect(server.paths()).To(Equal([]string{"/imds/token", "/" + testTenantID + "/oauth2/token"}))
+	g.Expect(server.forms[1]["client_secret"]).To(Equal([]string{"secret"}))
+}
+
+func Test_DefaultCredentialChain_Order(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(armclient.DefaultCredentialChain).To(Equal([]armclient.CredentialSource{
+		armclient.WorkloadIdentityCredential,
+		armclient.ManagedIdentityCredential,
+		armclient.ClientCertificateCredential,
+		armclient.ClientSecretCredential,
+	}))
+}
+
+func Test_CredentialChain_RespectsConfiguredChain(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := newTokenServer(true)
+	defer server.Close()
+
+	chain, err := armclient.ParseCredentialChain("client-secret")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	config := armclient.CredentialConfig{
+		Chain:                   chain,
+		ManagedIdentityEndpoint: server.URL + "/imds/token",
+	}
+
+	_, err = config.Authorizer(server.environment())
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(server.paths()).To(BeEmpty())
+}
+
+func Test_ParseCredentialChain_RejectsUnknownSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := armclient.ParseCredentialChain("managed-identity,password")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,377 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+)
+
+const (
+	asyncOperationHeader = "Azure-AsyncOperation"
+	locationHeader       = "Location"
+)
+
+// DirectClient is an Applier which PUTs resources directly at their ARM IDs, rather than wrapping each one in a
+// template deployment. This doesn't use up the deployment history of the resource group and reports the errors of
+// the resource provider as they are, rather than wrapped in a failed deployment.
+//
+// The deployments created by DirectClient only describe the resource to PUT. Once created, the ID of the deployment
+// identifies the resource and the long-running operation Azure started to create or update it (if any), rather than
+// a deployment in Azure, so it is only meaningful to the DirectClient.
+type DirectClient struct {
+	*AzureTemplateClient
+}
+
+var _ Applier = &DirectClient{}
+
+// NewDirectClient creates a DirectClient which uses the same connection to Azure as the given template client for
+// everything other than applying resources
+func NewDirectClient(atc *AzureTemplateClient) *DirectClient {
+	return &DirectClient{AzureTemplateClient: atc}
+}
+
+// Direct returns an Applier which uses the same connection to Azure as the template client but applies resources
+// directly rather than through deployments
+func (atc *AzureTemplateClient) Direct() Applier {
+	return NewDirectClient(atc)
+}
+
+// DirectApplier is implemented by Appliers which can also apply resources directly rather than through deployments
+type DirectApplier interface {
+	Direct() Applier
+}
+
+// CreateDeployment PUTs the resource in the deployment at its ARM ID, and updates the deployment with the state of
+// the resource and the operation to poll for the outcome of the PUT, if it didn't complete immediately.
+func (dc *DirectClient) CreateDeployment(ctx context.Context, deployment *Deployment) error {
+	resourceId, spec, err := directResourceId(deployment)
+	if err != nil {
+		return err
+	}
+
+	body, err := directResourceBody(spec)
+	if err != nil {
+		return err
+	}
+
+	op := directOperation{
+		resourceId: resourceId,
+		apiVersion: spec.GetApiVersion(),
+	}
+
+	var result directResourceState
+	resp, err := dc.RawClient.PutResource(ctx, op.resourceIdWithApiVersion(), body, &result)
+	if err != nil {
+		var reqErr *azure.RequestError
+		if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusConflict {
+			// Unlike a template deployment there's nothing to monitor, the resource is busy with some other operation
+			return errors.Errorf("resource %s is busy with another operation: %s", resourceId, reqErr.Error())
+		}
+
+		return err
+	}
+
+	state := result.provisioningState()
+	switch {
+	case resp.Header.Get(asyncOperationHeader) != "":
+		op.pollingHeader = asyncOperationHeader
+		op.pollingURL = resp.Header.Get(asyncOperationHeader)
+		state = AcceptedProvisioningState
+	case resp.StatusCode == http.StatusAccepted && resp.Header.Get(locationHeader) != "":
+		op.pollingHeader = locationHeader
+		op.pollingURL = resp.Header.Get(locationHeader)
+		state = AcceptedProvisioningState
+	}
+
+	op.updateDeployment(deployment, state, nil)
+	deployment.RetryAfter = RetryAfter(resp)
+	return nil
+}
+
+// GetDeployment polls the operation tracking the PUT of the resource, or the resource itself if Azure didn't start
+// one, and returns a deployment giving the state of the resource
+func (dc *DirectClient) GetDeployment(ctx context.Context, deploymentId string) (*Deployment, error) {
+	if !isDirectOperation(deploymentId) {
+		// A template deployment started before the resource was switched to being applied directly
+		return dc.AzureTemplateClient.GetDeployment(ctx, deploymentId)
+	}
+
+	op, name, err := parseDirectOperation(deploymentId)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := &Deployment{
+		ARMMeta:    ARMMeta{Name: name},
+		Properties: &DeploymentProperties{},
+	}
+
+	switch op.pollingHeader {
+	case asyncOperationHeader:
+		var status asyncOperationStatus
+		resp, err := dc.RawClient.PollOperation(ctx, op.pollingURL, &status)
+		if err != nil {
+			return nil, errors.Wrapf(err, "polling operation on %s", op.resourceId)
+		}
+
+		state := status.provisioningState()
+		if IsTerminalProvisioningState(state) {
+			// There's nothing left to poll
+			op.pollingHeader = ""
+			op.pollingURL = ""
+		}
+
+		op.updateDeployment(deployment, state, status.Error)
+		deployment.RetryAfter = RetryAfter(resp)
+
+	case locationHeader:
+		// Once the operation is complete, some resource providers return the resource
+		var result directResourceState
+		resp, err := dc.RawClient.PollOperation(ctx, op.pollingURL, &result)
+		if err != nil {
+			// The operation failed, which Azure reports as an error response to the poll
+			var reqErr *azure.RequestError
+			if errors.As(err, &reqErr) && reqErr.ServiceError != nil && reqErr.StatusCode != http.StatusTooManyRequests {
+				op.pollingHeader = ""
+				op.pollingURL = ""
+				op.updateDeployment(deployment, FailedProvisioningState, &DeploymentError{
+					Code:    reqErr.ServiceError.Code,
+					Message: reqErr.ServiceError.Message,
+				})
+				return deployment, nil
+			}
+
+			return nil, errors.Wrapf(err, "polling operation on %s", op.resourceId)
+		}
+
+		state := AcceptedProvisioningState
+		if resp.StatusCode != http.StatusAccepted {
+			state = SucceededProvisioningState
+			op.pollingHeader = ""
+			op.pollingURL = ""
+		}
+
+		op.updateDeployment(deployment, state, nil)
+		deployment.RetryAfter = RetryAfter(resp)
+
+	default:
+		// Azure accepted the PUT without starting an operation to poll, so the resource reports its own progress
+		var result directResourceState
+		retryAfter, err := dc.RawClient.PollResource(ctx, op.resourceIdWithApiVersion(), &result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting %s", op.resourceId)
+		}
+
+		op.updateDeployment(deployment, result.provisioningState(), nil)
+		deployment.RetryAfter = retryAfter
+	}
+
+	return deployment, nil
+}
+
+// DeleteDeployment does nothing, as resources applied directly have no deployment in Azure to clean up
+func (dc *DirectClient) DeleteDeployment(ctx context.Context, deploymentId string) error {
+	if !isDirectOperation(deploymentId) {
+		// A template deployment started before the resource was switched to being applied directly
+		return dc.AzureTemplateClient.DeleteDeployment(ctx, deploymentId)
+	}
+
+	return nil
+}
+
+// directResourceId returns the ARM ID that the single resource in the deployment would be created at by the
+// deployment, and the resource itself
+func directResourceId(deployment *Deployment) (string, genruntime.ArmResourceSpec, error) {
+	if deployment.Properties == nil || deployment.Properties.Template == nil || len(deployment.Properties.Template.Resources) != 1 {
+		return "", nil, errors.Errorf("deployment %s must contain exactly one resource to be applied directly", deployment.Name)
+	}
+
+	switch resource := deployment.Properties.Template.Resources[0].(type) {
+	case extensionResource:
+		return genruntime.ExtensionResourceId(resource.scope, resource.GetType(), resource.GetName()), resource.ArmResourceSpec, nil
+
+	case genruntime.ArmResourceSpec:
+		// Resources are nested beneath the scope they're deployed at in the same way as extension resources
+		var scope string
+		switch deployment.Scope {
+		case ResourceGroupScope:
+			scope = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", deployment.SubscriptionId, deployment.ResourceGroup)
+		case SubscriptionScope:
+			if strings.EqualFold(resource.GetType(), "Microsoft.Resources/resourceGroups") {
+				// Resource groups are the exception, as they have no provider namespace in their ID
+				return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", deployment.SubscriptionId, resource.GetName()), resource, nil
+			}
+			scope = fmt.Sprintf("/subscriptions/%s", deployment.SubscriptionId)
+		case ManagementGroupScope:
+			scope = fmt.Sprintf("/providers/Microsoft.Management/managementGroups/%s", deployment.ManagementGroupId)
+		case TenantScope:
+			scope = "/"
+		default:
+			return "", nil, errors.Errorf("unknown deployment scope %q", deployment.Scope)
+		}
+
+		return genruntime.ExtensionResourceId(scope, resource.GetType(), resource.GetName()), resource, nil
+
+	default:
+		return "", nil, errors.Errorf("deployment %s contains a %T rather than an ARM resource", deployment.Name, resource)
+	}
+}
+
+// templateOnlyFields are the top level fields of an ARM spec which only have meaning in a deployment template. When
+// PUTting the resource directly the API version is part of the URL and the type and name are part of the ID.
+var templateOnlyFields = []string{"apiVersion", "dependsOn", "name", "type"}
+
+// directResourceBody returns the body of the PUT of the resource
+func directResourceBody(spec genruntime.ArmResourceSpec) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing %s", spec.GetName())
+	}
+
+	var body map[string]json.RawMessage
+	err = json.Unmarshal(raw, &body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resource %s must serialize to a JSON object", spec.GetName())
+	}
+
+	for _, field := range templateOnlyFields {
+		delete(body, field)
+	}
+
+	return body, nil
+}
+
+// directResourceState is the part of a resource returned by Azure which says whether it's still being provisioned
+type directResourceState struct {
+	Properties struct {
+		ProvisioningState ProvisioningState `json:"provisioningState,omitempty"`
+	} `json:"properties,omitempty"`
+}
+
+// provisioningState returns the provisioning state of the resource. Resources which don't report one are
+// provisioned as soon as they've been accepted.
+func (r directResourceState) provisioningState() ProvisioningState {
+	if r.Properties.ProvisioningState == "" {
+		return SucceededProvisioningState
+	}
+
+	return normalizeProvisioningState(r.Properties.ProvisioningState)
+}
+
+// asyncOperationStatus is the response to a poll of an Azure-AsyncOperation URL, see
+// https://docs.microsoft.com/en-us/azure/azure-resource-manager/management/async-operations
+type asyncOperationStatus struct {
+	Status ProvisioningState `json:"status,omitempty"`
+	Error  *DeploymentError  `json:"error,omitempty"`
+}
+
+func (s asyncOperationStatus) provisioningState() ProvisioningState {
+	if s.Status == "" {
+		return AcceptedProvisioningState
+	}
+
+	return normalizeProvisioningState(s.Status)
+}
+
+// normalizeProvisioningState maps the states a resource provider reports onto the states of a deployment. Canceled
+// operations didn't provision the resource, so are reported as failed.
+func normalizeProvisioningState(state ProvisioningState) ProvisioningState {
+	switch {
+	case strings.EqualFold(string(state), string(SucceededProvisioningState)):
+		return SucceededProvisioningState
+	case strings.EqualFold(string(state), string(FailedProvisioningState)),
+		strings.EqualFold(string(state), "Canceled"):
+		return FailedProvisioningState
+	default:
+		return state
+	}
+}
+
+// directOperation is what the ID of a deployment created by the DirectClient refers to: the resource which was PUT
+// and, while Azure is still working on it, the URL to poll for the outcome
+type directOperation struct {
+	resourceId    string
+	apiVersion    string
+	pollingHeader string
+	pollingURL    string
+}
+
+const (
+	directApiVersionParam    = "api-version"
+	directPollingHeaderParam = "polling-header"
+	directPollingURLParam    = "polling-url"
+	directNameParam          = "name"
+)
+
+func (op directOperation) resourceIdWithApiVersion() string {
+	return fmt.Sprintf("%s?%s=%s", op.resourceId, directApiVersionParam, url.QueryEscape(op.apiVersion))
+}
+
+// updateDeployment updates the deployment with the operation and the state of the resource
+func (op directOperation) updateDeployment(deployment *Deployment, state ProvisioningState, deploymentErr *DeploymentError) {
+	query := url.Values{}
+	query.Set(directApiVersionParam, op.apiVersion)
+	query.Set(directNameParam, deployment.Name)
+	if op.pollingURL != "" {
+		query.Set(directPollingHeaderParam, op.pollingHeader)
+		query.Set(directPollingURLParam, op.pollingURL)
+	}
+
+	deployment.Id = op.resourceId + "?" + query.Encode()
+	if deployment.Properties == nil {
+		deployment.Properties = &DeploymentProperties{}
+	}
+
+	deployment.Properties.ProvisioningState = state
+	deployment.Properties.Error = deploymentErr
+	deployment.Properties.OutputResources = nil
+	if state == SucceededProvisioningState {
+		deployment.Properties.OutputResources = []OutputResource{{ID: op.resourceId}}
+	}
+}
+
+// isDirectOperation returns true if the deployment ID was created by the DirectClient rather than being the ID of a
+// template deployment in Azure
+func isDirectOperation(deploymentId string) bool {
+	return strings.Contains(deploymentId, "?")
+}
+
+// parseDirectOperation parses the ID of a deployment created by the DirectClient, returning the operation and the
+// name of the deployment
+func parseDirectOperation(deploymentId string) (directOperation, string, error) {
+	parts := strings.SplitN(deploymentId, "?", 2)
+	if len(parts) != 2 {
+		return directOperation{}, "", errors.Errorf("%q was not created by applying a resource directly", deploymentId)
+	}
+
+	query, err := url.ParseQuery(parts[1])
+	if err != nil {
+		return directOperation{}, "", errors.Wrapf(err, "parsing %q", deploymentId)
+	}
+
+	op := directOperation{
+		resourceId:    parts[0],
+		apiVersion:    query.Get(directApiVersionParam),
+		pollingHeader: query.Get(directPollingHeaderParam),
+		pollingURL:    query.Get(directPollingURLParam),
+	}
+
+	if op.apiVersion == "" {
+		return directOperation{}, "", errors.Errorf("%q was not created by applying a resource directly", deploymentId)
+	}
+
+	return op, query.Get(directNameParam), nil
+}
@@ -0,0 +1,242 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+)
+
+type fakeArmSpec struct {
+	ApiVersion string            `json:"apiVersion"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Location   string            `json:"location"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+func (s fakeArmSpec) GetApiVersion() string { return s.ApiVersion }
+func (s fakeArmSpec) GetName() string       { return s.Name }
+func (s fakeArmSpec) GetType() string       { return s.Type }
+
+func newTestDirectClient(g *WithT, server *httptest.Server) *armclient.DirectClient {
+	env := azure.PublicCloud
+	env.ResourceManagerEndpoint = server.URL
+	atc, err := armclient.NewAzureTemplateClient(autorest.NullAuthorizer{}, "1234", armclient.WithEnvironment(env))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return armclient.NewDirectClient(atc)
+}
+
+func Test_DirectClient_FollowsAsyncOperation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	const accountPath = "/subscriptions/1234/resourceGroups/myrg/providers/Microsoft.Storage/storageAccounts/mystorage"
+	operationStatus := "InProgress"
+	var body map[string]interface{}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == accountPath:
+			g.Expect(r.URL.Query().Get("api-version")).To(Equal("2019-04-01"))
+			g.Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			w.Header().Set("Azure-AsyncOperation", server.URL+"/operations/1")
+			w.Header().Set("Retry-After", "10")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"properties": {"provisioningState": "Creating"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/operations/1":
+			_, _ = w.Write([]byte(`{"status": "` + operationStatus + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestDirectClient(g, server)
+	deployment := client.NewResourceGroupDeployment("myrg", "mydeployment", fakeArmSpec{
+		ApiVersion: "2019-04-01",
+		Name:       "mystorage",
+		Type:       "Microsoft.Storage/storageAccounts",
+		Location:   "westus",
+	})
+
+	g.Expect(client.CreateDeployment(ctx, deployment)).To(Succeed())
+	g.Expect(deployment.IsTerminalProvisioningState()).To(BeFalse())
+	g.Expect(deployment.RetryAfter.Seconds()).To(Equal(10.0))
+	g.Expect(deployment.Name).To(Equal("mydeployment"))
+
+	// Only the resource itself is sent, not the parts of it that belong to a template
+	g.Expect(body).To(HaveKeyWithValue("location", "westus"))
+	g.Expect(body).ToNot(HaveKey("apiVersion"))
+	g.Expect(body).ToNot(HaveKey("name"))
+	g.Expect(body).ToNot(HaveKey("type"))
+
+	polled, err := client.GetDeployment(ctx, deployment.Id)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(polled.IsTerminalProvisioningState()).To(BeFalse())
+	g.Expect(polled.Id).To(Equal(deployment.Id))
+	g.Expect(polled.Name).To(Equal("mydeployment"))
+
+	operationStatus = "Succeeded"
+	polled, err = client.GetDeployment(ctx, polled.Id)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(polled.IsSuccessful()).To(BeTrue())
+
+	id, err := polled.ResourceID()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal(accountPath))
+
+	// There's no deployment to clean up
+	g.Expect(client.DeleteDeployment(ctx, polled.Id)).To(Succeed())
+}
+
+func Test_DirectClient_ReportsFailedAsyncOperation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/subscriptions/1234/resourceGroups/myrg":
+			w.Header().Set("Azure-AsyncOperation", server.URL+"/operations/1")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodGet && r.URL.Path == "/operations/1":
+			_, _ = w.Write([]byte(`{"status": "Failed", "error": {"code": "InvalidLocation", "message": "nowhere isn't a location"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestDirectClient(g, server)
+	deployment := client.NewSubscriptionDeployment("nowhere", "mydeployment", fakeArmSpec{
+		ApiVersion: "2020-06-01",
+		Name:       "myrg",
+		Type:       "Microsoft.Resources/resourceGroups",
+		Location:   "nowhere",
+	})
+
+	g.Expect(client.CreateDeployment(ctx, deployment)).To(Succeed())
+
+	polled, err := client.GetDeployment(ctx, deployment.Id)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(polled.IsTerminalProvisioningState()).To(BeTrue())
+	g.Expect(polled.IsSuccessful()).To(BeFalse())
+	g.Expect(polled.Properties.Error.Code).To(Equal("InvalidLocation"))
+}
+
+func Test_DirectClient_PollsResourceWithoutOperation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	const lockPath = "/subscriptions/1234/resourceGroups/myrg/providers/Microsoft.Authorization/locks/mylock"
+	provisioningState := "Updating"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case lockPath:
+			w.Header().Set("Retry-After", "5")
+			_, _ = w.Write([]byte(`{"properties": {"provisioningState": "` + provisioningState + `"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestDirectClient(g, server)
+	deployment, err := client.NewExtensionDeployment("/subscriptions/1234/resourceGroups/myrg", "", "mydeployment", fakeArmSpec{
+		ApiVersion: "2016-09-01",
+		Name:       "mylock",
+		Type:       "Microsoft.Authorization/locks",
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(client.CreateDeployment(ctx, deployment)).To(Succeed())
+	g.Expect(deployment.IsTerminalProvisioningState()).To(BeFalse())
+
+	polled, err := client.GetDeployment(ctx, deployment.Id)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(polled.IsTerminalProvisioningState()).To(BeFalse())
+	g.Expect(polled.RetryAfter).To(Equal(5 * time.Second))
+
+	provisioningState = "Succeeded"
+	polled, err = client.GetDeployment(ctx, deployment.Id)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	id, err := polled.ResourceID()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal(lockPath))
+}
+
+func Test_TemplateClient_GetDeployment_ReturnsRetryAfter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	const deploymentPath = "/subscriptions/1234/providers/Microsoft.Resources/deployments/mydeployment"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == deploymentPath:
+			g.Expect(r.URL.Query().Get("api-version")).ToNot(BeEmpty())
+			w.Header().Set("Retry-After", "15")
+			_, _ = w.Write([]byte(`{"id": "` + deploymentPath + `", "name": "mydeployment", "properties": {"provisioningState": "Running"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	env := azure.PublicCloud
+	env.ResourceManagerEndpoint = server.URL
+	client, err := armclient.NewAzureTemplateClient(autorest.NullAuthorizer{}, "1234", armclient.WithEnvironment(env))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	deployment, err := client.GetDeployment(ctx, deploymentPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deployment.IsTerminalProvisioningState()).To(BeFalse())
+	g.Expect(deployment.RetryAfter).To(Equal(15 * time.Second))
+}
+
+func Test_TemplateClient_InvokeResourceAction_OnlyInvokesListActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	const accountPath = "/subscriptions/1234/resourceGroups/myrg/providers/Microsoft.Storage/storageAccounts/myaccount"
+
+	var posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted = append(posted, r.URL.Path)
+		_, _ = w.Write([]byte(`{"keys": [{"value": "secret"}]}`))
+	}))
+	defer server.Close()
+
+	env := azure.PublicCloud
+	env.ResourceManagerEndpoint = server.URL
+	client, err := armclient.NewAzureTemplateClient(autorest.NullAuthorizer{}, "1234", armclient.WithEnvironment(env))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var result interface{}
+	g.Expect(client.InvokeResourceAction(ctx, accountPath, "listKeys", "2019-04-01", &result)).To(Succeed())
+	g.Expect(posted).To(Equal([]string{accountPath + "/listKeys"}))
+
+	for _, action := range []string{"regenerateKey", "../otherAccount/listKeys", "listKeys?api-version=2019-04-01"} {
+		g.Expect(client.InvokeResourceAction(ctx, accountPath, action, "2019-04-01", &result)).ToNot(Succeed(), action)
+	}
+
+	g.Expect(posted).To(HaveLen(1))
+}
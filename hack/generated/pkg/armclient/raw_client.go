@@ -24,8 +24,8 @@ type Client struct {
 
 const UserAgent = "k8sinfra-generated"
 
-// NewClient creates a new raw client
-func NewClient(authorizer autorest.Authorizer) *Client {
+// NewClient creates a new raw client targeting the Resource Manager endpoint of the given cloud environment
+func NewClient(authorizer autorest.Authorizer, env azure.Environment) *Client {
 
 	autorestClient := autorest.NewClientWithUserAgent(UserAgent)
 	// Disable retries by default
@@ -34,7 +34,7 @@ func NewClient(authorizer autorest.Authorizer) *Client {
 
 	c := &Client{
 		Client: autorestClient,
-		Host:   azure.PublicCloud.ResourceManagerEndpoint, // TODO: We need to support other endpoints
+		Host:   resourceManagerHost(env),
 	}
 
 	return c
@@ -107,15 +107,73 @@ func (c *Client) PutDeployment(ctx context.Context, deployment *Deployment) erro
 		return err
 	}
 
+	deployment.RetryAfter = RetryAfter(resp)
+
 	return nil
 }
 
 func (c *Client) GetResource(ctx context.Context, resourceID string, resource interface{}) error {
+	_, err := c.getResource(ctx, resourceID, resource)
+	return err
+}
+
+// PollResource will make an HTTP GET call to the resourceID and fill the resource with the response, like
+// GetResource, also returning the delay Azure asked us to wait before getting it again, if any.
+func (c *Client) PollResource(ctx context.Context, resourceID string, resource interface{}) (time.Duration, error) {
+	resp, err := c.getResource(ctx, resourceID, resource)
+	if err != nil {
+		return 0, err
+	}
+
+	return RetryAfter(resp), nil
+}
+
+func (c *Client) getResource(ctx context.Context, resourceID string, resource interface{}) (*http.Response, error) {
 
 	preparer := autorest.CreatePreparer(
 		autorest.AsContentType("application/json"))
 
 	req, err := c.newRequest(ctx, http.MethodGet, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = preparer.Prepare(req)
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	// The linter below doesn't realize that the response is closed in the course of
+	// the autorest.Respond call below, suppressing the false positive.
+	// nolint:bodyclose
+	resp, err := c.Send(req)
+
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(resource),
+		autorest.ByClosing())
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PostResource will make an HTTP POST call to the resourceID, which identifies an action on a resource (such as
+// listKeys), and fill result with the response.
+func (c *Client) PostResource(ctx context.Context, resourceID string, result interface{}) error {
+	preparer := autorest.CreatePreparer(
+		autorest.AsContentType("application/json"))
+
+	req, err := c.newRequest(ctx, http.MethodPost, resourceID)
 	if err != nil {
 		return err
 	}
@@ -139,7 +197,7 @@ func (c *Client) GetResource(ctx context.Context, resourceID string, resource in
 	err = autorest.Respond(
 		resp,
 		azure.WithErrorUnlessStatusCode(http.StatusOK),
-		autorest.ByUnmarshallingJSON(resource),
+		autorest.ByUnmarshallingJSON(result),
 		autorest.ByClosing())
 	if err != nil {
 		tab.For(ctx).Error(err)
@@ -150,20 +208,21 @@ func (c *Client) GetResource(ctx context.Context, resourceID string, resource in
 }
 
 // DeleteResource will make an HTTP DELETE call to the resourceId and attempt to fill the resource with the response.
-// If the body of the response is empty, the resource will be nil.
-func (c *Client) DeleteResource(ctx context.Context, resourceID string, resource interface{}) error {
+// If the body of the response is empty, the resource will be nil. If the delete is accepted but not yet complete,
+// the delay Azure asked us to wait before checking on it is returned.
+func (c *Client) DeleteResource(ctx context.Context, resourceID string, resource interface{}) (time.Duration, error) {
 	preparer := autorest.CreatePreparer(
 		autorest.AsContentType("application/json"))
 
 	req, err := c.newRequest(ctx, http.MethodDelete, resourceID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	req, err = preparer.Prepare(req)
 	if err != nil {
 		tab.For(ctx).Error(err)
-		return err
+		return 0, err
 	}
 
 	// The linter below doesn't realize that the response is closed in the course of
@@ -173,7 +232,7 @@ func (c *Client) DeleteResource(ctx context.Context, resourceID string, resource
 
 	if err != nil {
 		tab.For(ctx).Error(err)
-		return err
+		return 0, err
 	}
 
 	err = autorest.Respond(
@@ -185,14 +244,96 @@ func (c *Client) DeleteResource(ctx context.Context, resourceID string, resource
 	if err != nil {
 		if IsNotFound(err) {
 			// you asked it to be gone, well, it is.
-			return nil
+			return 0, nil
 		}
 
 		tab.For(ctx).Error(err)
-		return err
+		return 0, err
 	}
 
-	return nil
+	return RetryAfter(resp), nil
+}
+
+// PutResource will make an HTTP PUT of resource to the resourceID, and fill result with the response. The response is
+// returned so that the caller can follow any long-running operation Azure started to create or update the resource.
+func (c *Client) PutResource(ctx context.Context, resourceID string, resource interface{}, result interface{}) (*http.Response, error) {
+	preparer := autorest.CreatePreparer(
+		autorest.AsContentType("application/json"),
+		autorest.WithJSON(resource))
+
+	req, err := c.newRequest(ctx, http.MethodPut, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = preparer.Prepare(req)
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	// The linter below doesn't realize that the response is closed in the course of
+	// the autorest.Respond call below, suppressing the false positive.
+	// nolint:bodyclose
+	resp, err := c.Send(req)
+
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusCreated, http.StatusAccepted),
+		autorest.ByUnmarshallingJSON(result),
+		autorest.ByClosing())
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PollOperation will make an HTTP GET call to the URL (from an Azure-AsyncOperation or Location header) which tracks a
+// long-running operation, and fill result with the response. The response is returned so that the caller can tell
+// whether the operation has finished.
+func (c *Client) PollOperation(ctx context.Context, operationURL string, result interface{}) (*http.Response, error) {
+	preparer := autorest.CreatePreparer(
+		autorest.AsContentType("application/json"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, operationURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = preparer.Prepare(req)
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	// The linter below doesn't realize that the response is closed in the course of
+	// the autorest.Respond call below, suppressing the false positive.
+	// nolint:bodyclose
+	resp, err := c.Send(req)
+
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	err = autorest.Respond(
+		resp,
+		azure.WithErrorUnlessStatusCode(http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent),
+		autorest.ByUnmarshallingJSON(result),
+		autorest.ByClosing())
+	if err != nil {
+		tab.For(ctx).Error(err)
+		return nil, err
+	}
+
+	return resp, nil
 }
 
 func (c *Client) newRequest(ctx context.Context, method string, entityPath string) (*http.Request, error) {
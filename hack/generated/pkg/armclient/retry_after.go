@@ -0,0 +1,59 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+const retryAfterHeader = "Retry-After"
+
+// RetryAfter returns the delay requested by the Retry-After header of the response, or zero if there
+// isn't one. Both the delay-seconds and HTTP-date forms of the header are supported.
+func RetryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	value := resp.Header.Get(retryAfterHeader)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		delay := time.Until(at)
+		if delay < 0 {
+			return 0
+		}
+		return delay
+	}
+
+	return 0
+}
+
+// RetryAfterFromError returns the delay requested by Azure in the response which caused the given error
+// (for example when we are being throttled), or false if Azure didn't specify one.
+func RetryAfterFromError(err error) (time.Duration, bool) {
+	var typedError *azure.RequestError
+	if !errors.As(err, &typedError) || typedError.Response == nil {
+		return 0, false
+	}
+
+	delay := RetryAfter(typedError.Response)
+	return delay, delay > 0
+}
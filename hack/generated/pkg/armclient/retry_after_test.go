@@ -0,0 +1,64 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+)
+
+func responseWithRetryAfter(value string) *http.Response {
+	resp := &http.Response{Header: http.Header{}}
+	if value != "" {
+		resp.Header.Set("Retry-After", value)
+	}
+	return resp
+}
+
+func Test_RetryAfter_Seconds(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(armclient.RetryAfter(responseWithRetryAfter("17"))).To(Equal(17 * time.Second))
+	g.Expect(armclient.RetryAfter(responseWithRetryAfter(""))).To(BeZero())
+	g.Expect(armclient.RetryAfter(responseWithRetryAfter("soon"))).To(BeZero())
+	g.Expect(armclient.RetryAfter(nil)).To(BeZero())
+}
+
+func Test_RetryAfter_HTTPDate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	delay := armclient.RetryAfter(responseWithRetryAfter(at))
+	g.Expect(delay).To(BeNumerically(">", 50*time.Second))
+	g.Expect(delay).To(BeNumerically("<=", time.Minute))
+}
+
+func Test_RetryAfterFromError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	throttled := &azure.RequestError{
+		DetailedError: autorest.DetailedError{
+			StatusCode: http.StatusTooManyRequests,
+			Response:   responseWithRetryAfter("30"),
+		},
+	}
+
+	delay, ok := armclient.RetryAfterFromError(pkgerrors.Wrap(throttled, "creating deployment"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(delay).To(Equal(30 * time.Second))
+
+	_, ok = armclient.RetryAfterFromError(errors.New("boom"))
+	g.Expect(ok).To(BeFalse())
+}
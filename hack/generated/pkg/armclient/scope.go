@@ -0,0 +1,91 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+)
+
+// NewDeploymentContaining creates a deployment at the scope which contains the resource with the given ARM ID: its
+// resource group, subscription, management group or the tenant. The location is where the deployment itself is
+// stored, and is ignored for resource group deployments.
+func NewDeploymentContaining(id string, location string, deploymentName string, resources ...interface{}) (*Deployment, error) {
+	if id == "/" {
+		return NewTenantDeployment(location, deploymentName, resources...), nil
+	}
+
+	segments := strings.Split(strings.Trim(id, "/"), "/")
+	if !strings.HasPrefix(id, "/") || len(segments)%2 != 0 {
+		return nil, errors.Errorf("%q is not a valid ARM ID", id)
+	}
+
+	switch {
+	case strings.EqualFold(segments[0], "subscriptions"):
+		if len(segments) >= 4 && strings.EqualFold(segments[2], "resourceGroups") {
+			return NewResourceGroupDeployment(segments[1], segments[3], deploymentName, resources...), nil
+		}
+
+		return NewSubscriptionDeployment(segments[1], location, deploymentName, resources...), nil
+
+	case len(segments) >= 4 &&
+		strings.EqualFold(segments[0], "providers") &&
+		strings.EqualFold(segments[1], "Microsoft.Management") &&
+		strings.EqualFold(segments[2], "managementGroups"):
+		return NewManagementGroupDeployment(segments[3], location, deploymentName, resources...), nil
+
+	case strings.EqualFold(segments[0], "providers"):
+		return NewTenantDeployment(location, deploymentName, resources...), nil
+
+	default:
+		return nil, errors.Errorf("couldn't determine the scope containing %q", id)
+	}
+}
+
+// extensionResource is an extension resource (such as a role assignment or lock) in a deployment template. Extension
+// resources are applied to another resource, given by the scope.
+type extensionResource struct {
+	genruntime.ArmResourceSpec
+	scope string
+}
+
+var _ json.Marshaler = extensionResource{}
+
+// MarshalJSON adds the scope to the JSON of the resource spec
+func (r extensionResource) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal(r.ArmResourceSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, errors.Wrapf(err, "extension resource %s must marshal to a JSON object", r.GetName())
+	}
+
+	fields["scope"], err = json.Marshal(r.scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+// createExtensionResourceIdTemplate returns the outputs for a deployment of an extension resource, which give its
+// ARM ID. The ID is nested beneath the resource it's applied to so is known before deploying it.
+func createExtensionResourceIdTemplate(scope string, resourceSpec genruntime.ArmResourceSpec) map[string]Output {
+	return map[string]Output{
+		"resourceId": {
+			Type:  "string",
+			Value: genruntime.ExtensionResourceId(scope, resourceSpec.GetType(), resourceSpec.GetName()),
+		},
+	}
+}
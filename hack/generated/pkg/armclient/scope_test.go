@@ -0,0 +1,73 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armclient_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+)
+
+func Test_NewDeploymentContaining(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := []struct {
+		id       string
+		expected string
+	}{
+		{
+			id:       "/subscriptions/1234/resourceGroups/myrg/providers/Microsoft.Storage/storageAccounts/mystorage",
+			expected: "/subscriptions/1234/resourcegroups/myrg/providers/Microsoft.Resources/deployments/deployment",
+		},
+		{
+			id:       "/subscriptions/1234/resourceGroups/myrg",
+			expected: "/subscriptions/1234/resourcegroups/myrg/providers/Microsoft.Resources/deployments/deployment",
+		},
+		{
+			id:       "/subscriptions/1234",
+			expected: "/subscriptions/1234/providers/Microsoft.Resources/deployments/deployment",
+		},
+		{
+			id:       "/providers/Microsoft.Management/managementGroups/mymg/providers/Microsoft.Authorization/policyDefinitions/mypolicy",
+			expected: "/providers/Microsoft.Management/managementGroups/mymg/providers/Microsoft.Resources/deployments/deployment",
+		},
+		{
+			id:       "/providers/Microsoft.Management/managementGroups/mymg",
+			expected: "/providers/Microsoft.Management/managementGroups/mymg/providers/Microsoft.Resources/deployments/deployment",
+		},
+		{
+			id:       "/",
+			expected: "/providers/Microsoft.Resources/deployments/deployment",
+		},
+	}
+
+	for _, c := range cases {
+		deployment, err := armclient.NewDeploymentContaining(c.id, "westus", "deployment")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		id, err := deployment.GetId()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(id).To(Equal(c.expected), c.id)
+	}
+
+	_, err := armclient.NewDeploymentContaining("subscriptions/1234", "westus", "deployment")
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = armclient.NewDeploymentContaining("/subscriptions/1234/resourceGroups", "westus", "deployment")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_NewTenantDeployment_RequiresLocation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := armclient.NewTenantDeployment("", "deployment").GetId()
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = armclient.NewManagementGroupDeployment("mymg", "", "deployment").GetId()
+	g.Expect(err).To(HaveOccurred())
+}
@@ -8,6 +8,7 @@ package armclient
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -16,9 +17,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/Azure/go-autorest/autorest"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
-	kerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 // TODO: Naming?
@@ -28,14 +28,21 @@ type Applier interface {
 	GetDeployment(ctx context.Context, deploymentId string) (*Deployment, error)
 	NewResourceGroupDeployment(resourceGroup string, deploymentName string, resourceSpec genruntime.ArmResourceSpec) *Deployment
 	NewSubscriptionDeployment(location string, deploymentName string, resourceSpec genruntime.ArmResourceSpec) *Deployment
+	NewManagementGroupDeployment(managementGroupId string, location string, deploymentName string, resourceSpec genruntime.ArmResourceSpec) *Deployment
+	NewTenantDeployment(location string, deploymentName string, resourceSpec genruntime.ArmResourceSpec) *Deployment
+	NewExtensionDeployment(scope string, location string, deploymentName string, resourceSpec genruntime.ArmResourceSpec) (*Deployment, error)
 
 	SubscriptionID() string
 
 	// TODO: These functions take an empty status and fill it out with the response from Azure (rather than as
 	// TODO: the return type. I don't love that pattern but don't have a better one either.
-	BeginDeleteResource(ctx context.Context, id string, apiVersion string, status genruntime.ArmResourceStatus) error
+	BeginDeleteResource(ctx context.Context, id string, apiVersion string, status genruntime.ArmResourceStatus) (time.Duration, error)
 	GetResource(ctx context.Context, id string, apiVersion string, status genruntime.ArmResourceStatus) error
 	HeadResource(ctx context.Context, id string, apiVersion string) (bool, error)
+
+	// InvokeResourceAction calls the named POST action (such as listKeys) on a resource, filling result with
+	// the response
+	InvokeResourceAction(ctx context.Context, id string, action string, apiVersion string, result interface{}) error
 }
 
 type AzureTemplateClient struct {
@@ -107,8 +114,9 @@ type RetryConfig struct {
 }
 
 type ClientConfig struct {
-	Logger  logr.Logger
-	Retries *RetryConfig
+	Logger      logr.Logger
+	Retries     *RetryConfig
+	Environment azure.Environment
 }
 
 type AzureTemplateClientOption func(config *ClientConfig) *ClientConfig
@@ -129,6 +137,14 @@ func WithRetries(retries *RetryConfig) func(*ClientConfig) *ClientConfig {
 	}
 }
 
+// WithEnvironment sets the cloud environment the client talks to. If not supplied, the public cloud is used.
+func WithEnvironment(env azure.Environment) func(*ClientConfig) *ClientConfig {
+	return func(cfg *ClientConfig) *ClientConfig {
+		cfg.Environment = env
+		return cfg
+	}
+}
+
 func WithDefaultRetries() func(*ClientConfig) *ClientConfig {
 	return WithRetries(&RetryConfig{
 		Attempts:   5,
@@ -137,46 +153,24 @@ func WithDefaultRetries() func(*ClientConfig) *ClientConfig {
 	})
 }
 
-func AuthorizerFromEnvironment() (autorest.Authorizer, error) {
-	envSettings, err := auth.GetSettingsFromEnvironment()
-	if err != nil {
-		return nil, err
-	}
-
-	// the previous never returns an error, so we must do
-	// the checks ourselves…
-	// see: https://github.com/Azure/go-autorest/issues/580
-	var errs []error
-	requiredEnvVars := []string{auth.SubscriptionID, auth.ClientSecret, auth.ClientID, auth.TenantID}
-	// TODO: this doesn't support, for example, MSI auth
-	for _, requiredEnvVar := range requiredEnvVars {
-		if envSettings.Values[requiredEnvVar] == "" {
-			errs = append(errs, errors.Errorf("environment variable %s must be set", requiredEnvVar))
-		}
-	}
-
-	if len(errs) > 0 {
-		return nil, kerrors.NewAggregate(errs)
-	}
-
-	authorizer, err := envSettings.GetAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	return authorizer, nil
+// AuthorizerFromEnvironment creates an authorizer using the default credential chain, configured from the
+// standard Azure environment variables. Tokens are requested from the authority of the given cloud environment
+// with the Resource Manager endpoint of that cloud as the audience.
+func AuthorizerFromEnvironment(env azure.Environment) (autorest.Authorizer, error) {
+	return CredentialConfigFromEnvironment().Authorizer(env)
 }
 
 func NewAzureTemplateClient(authorizer autorest.Authorizer, subID string, opts ...AzureTemplateClientOption) (*AzureTemplateClient, error) {
 	cfg := &ClientConfig{
-		Logger: ctrl.Log.WithName("azure_template_client"),
+		Logger:      ctrl.Log.WithName("azure_template_client"),
+		Environment: azure.PublicCloud,
 	}
 
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
-	rawClient := NewClient(authorizer)
+	rawClient := NewClient(authorizer, cfg.Environment)
 
 	if cfg.Retries != nil {
 		rawClient = rawClient.WithExponentialRetries(
@@ -214,7 +208,7 @@ func (atc *AzureTemplateClient) CreateDeployment(ctx context.Context, deployment
 
 // DeleteDeployment deletes a deployment. If the deployment doesn't exist it does not return an error
 func (atc *AzureTemplateClient) DeleteDeployment(ctx context.Context, deploymentId string) error {
-	err := atc.RawClient.DeleteResource(ctx, idWithAPIVersion(deploymentId), nil)
+	_, err := atc.RawClient.DeleteResource(ctx, idWithAPIVersion(deploymentId), nil)
 
 	// NotFound is a success
 	if IsNotFound(err) {
@@ -224,25 +218,21 @@ func (atc *AzureTemplateClient) DeleteDeployment(ctx context.Context, deployment
 	return err
 }
 
+// GetDeployment gets the current state of the deployment, including how long Azure asked us to wait before polling
+// it again
 func (atc *AzureTemplateClient) GetDeployment(ctx context.Context, deploymentId string) (*Deployment, error) {
 	var deployment Deployment
-	err := atc.RawClient.GetResource(ctx, idWithAPIVersion(deploymentId), &deployment)
+	retryAfter, err := atc.RawClient.PollResource(ctx, idWithAPIVersion(deploymentId), &deployment)
 	if err != nil {
 		return nil, err
 	}
 
+	deployment.RetryAfter = retryAfter
 	return &deployment, nil
 }
 
 func createResourceIdTemplate(resourceSpec genruntime.ArmResourceSpec) map[string]Output {
-	resourceName := resourceSpec.GetName()
-	names := strings.Split(resourceName, "/")
-	formattedNames := make([]string, len(names))
-	for i, name := range names {
-		formattedNames[i] = fmt.Sprintf("'%s'", name)
-	}
-
-	resourceIdTemplateFunction := fmt.Sprintf("resourceId('%s', %s)", resourceSpec.GetType(), strings.Join(formattedNames, ", "))
+	resourceIdTemplateFunction := fmt.Sprintf("resourceId('%s', %s)", resourceSpec.GetType(), formatResourceNames(resourceSpec.GetName()))
 	result := map[string]Output{
 		"resourceId": {
 			Type:  "string",
@@ -253,6 +243,18 @@ func createResourceIdTemplate(resourceSpec genruntime.ArmResourceSpec) map[strin
 	return result
 }
 
+// formatResourceNames formats each segment of the full name of a resource as a template string literal, separated
+// by commas, for use in the resourceId template functions
+func formatResourceNames(resourceName string) string {
+	names := strings.Split(resourceName, "/")
+	formattedNames := make([]string, len(names))
+	for i, name := range names {
+		formattedNames[i] = fmt.Sprintf("'%s'", name)
+	}
+
+	return strings.Join(formattedNames, ", ")
+}
+
 func (atc *AzureTemplateClient) NewResourceGroupDeployment(resourceGroup string, deploymentName string, resourceSpec genruntime.ArmResourceSpec) *Deployment {
 	deployment := NewResourceGroupDeployment(atc.subscriptionID, resourceGroup, deploymentName, resourceSpec)
 	deployment.Properties.Template.Outputs = createResourceIdTemplate(resourceSpec)
@@ -265,22 +267,59 @@ func (atc *AzureTemplateClient) NewSubscriptionDeployment(location string, deplo
 	return deployment
 }
 
+func (atc *AzureTemplateClient) NewManagementGroupDeployment(
+	managementGroupId string,
+	location string,
+	deploymentName string,
+	resourceSpec genruntime.ArmResourceSpec) *Deployment {
+
+	deployment := NewManagementGroupDeployment(managementGroupId, location, deploymentName, resourceSpec)
+	deployment.Properties.Template.Outputs = createResourceIdTemplate(resourceSpec)
+	return deployment
+}
+
+func (atc *AzureTemplateClient) NewTenantDeployment(location string, deploymentName string, resourceSpec genruntime.ArmResourceSpec) *Deployment {
+	deployment := NewTenantDeployment(location, deploymentName, resourceSpec)
+	deployment.Properties.Template.Outputs = createResourceIdTemplate(resourceSpec)
+	return deployment
+}
+
+// NewExtensionDeployment creates a deployment of an extension resource onto the resource with the ARM ID scope. The
+// deployment is made at the scope containing that resource.
+func (atc *AzureTemplateClient) NewExtensionDeployment(
+	scope string,
+	location string,
+	deploymentName string,
+	resourceSpec genruntime.ArmResourceSpec) (*Deployment, error) {
+
+	deployment, err := NewDeploymentContaining(scope, location, deploymentName, extensionResource{resourceSpec, scope})
+	if err != nil {
+		return nil, err
+	}
+
+	deployment.Properties.Template.Outputs = createExtensionResourceIdTemplate(scope, resourceSpec)
+	return deployment, nil
+}
+
+// BeginDeleteResource starts the deletion of the resource, returning the delay Azure asked us to wait before
+// checking on the progress of the delete (or zero if it didn't say).
 func (atc *AzureTemplateClient) BeginDeleteResource(
 	ctx context.Context,
 	id string,
 	apiVersion string,
-	status genruntime.ArmResourceStatus) error {
+	status genruntime.ArmResourceStatus) (time.Duration, error) {
 
 	if id == "" {
-		return errors.Errorf("resource ID cannot be empty")
+		return 0, errors.Errorf("resource ID cannot be empty")
 	}
 
 	path := fmt.Sprintf("%s?api-version=%s", id, apiVersion)
-	if err := atc.RawClient.DeleteResource(ctx, path, &status); err != nil {
-		return errors.Wrapf(err, "failed deleting %s", id)
+	retryAfter, err := atc.RawClient.DeleteResource(ctx, path, &status)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed deleting %s", id)
 	}
 
-	return nil
+	return retryAfter, nil
 }
 
 // HeadResource checks to see if the resource exists
@@ -306,6 +345,33 @@ func (atc *AzureTemplateClient) HeadResource(ctx context.Context, id string, api
 	}
 }
 
+// InvokeResourceAction calls the named POST action (such as listKeys) on the resource, filling result with the response.
+// Only read-only list actions may be invoked.
+func (atc *AzureTemplateClient) InvokeResourceAction(
+	ctx context.Context,
+	id string,
+	action string,
+	apiVersion string,
+	result interface{}) error {
+
+	if id == "" {
+		return errors.Errorf("resource ID cannot be empty")
+	}
+
+	err := genruntime.ValidateExportAction(action)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("%s/%s?api-version=%s", id, url.PathEscape(action), url.QueryEscape(apiVersion))
+	err = atc.RawClient.PostResource(ctx, path, result)
+	if err != nil {
+		return errors.Wrapf(err, "failed invoking %s on %s", action, id)
+	}
+
+	return nil
+}
+
 func MakeArmResourceId(subscriptionId string, segments ...string) (string, error) {
 	// There should be an even number of segments
 	if len(segments)%2 != 0 {
@@ -29,7 +29,7 @@ func Test_NewResourceGroupDeployment(t *testing.T) {
 	g.Expect(err).ToNot(HaveOccurred())
 
 	resourceGroup := testContext.NewTestResourceGroup()
-	resourceGroupSpec, err := resourceGroup.Spec.ConvertToArm(resourceGroup.Name)
+	resourceGroupSpec, err := resourceGroup.Spec.ConvertToArm(resourceGroup.Name, nil)
 	g.Expect(err).ToNot(HaveOccurred())
 
 	typedResourceGroupSpec := resourceGroupSpec.(resources.ResourceGroupSpecArm)
@@ -59,7 +59,7 @@ func Test_NewResourceGroupDeployment(t *testing.T) {
 	log.Printf("Created resource: %s\n", id)
 
 	// Delete the RG
-	err = testContext.AzureClient.BeginDeleteResource(ctx, id, typedResourceGroupSpec.ApiVersion, nil)
+	_, err = testContext.AzureClient.BeginDeleteResource(ctx, id, typedResourceGroupSpec.ApiVersion, nil)
 	g.Expect(err).ToNot(HaveOccurred())
 
 	// Ensure that the resource group is deleted
@@ -86,7 +86,7 @@ func Test_NewResourceGroupDeployment_Error(t *testing.T) {
 		},
 	}
 
-	resourceGroupSpec, err := resourceGroup.Spec.ConvertToArm(rgName)
+	resourceGroupSpec, err := resourceGroup.Spec.ConvertToArm(rgName, nil)
 	g.Expect(err).ToNot(HaveOccurred())
 
 	deployment := armclient.NewSubscriptionDeployment(
@@ -8,6 +8,8 @@ package armclient
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest/date"
 	"github.com/Azure/k8s-infra/pkg/zips/duration"
@@ -22,6 +24,9 @@ type (
 		Location       string `json:"location,omitempty"`
 		ResourceGroup  string `json:"-"` // TODO: I feel like these should be being serialized?
 		SubscriptionId string `json:"-"`
+		// ManagementGroupId is the ID (not the display name) of the management group for deployments at
+		// management group scope
+		ManagementGroupId string `json:"-"`
 	}
 
 	ProvisioningState string
@@ -74,12 +79,17 @@ type (
 		ARMMeta    `json:",inline"`
 		Scope      DeploymentScope `json:"-"`
 		Properties *DeploymentProperties
+
+		// RetryAfter is the delay Azure asked for before polling the deployment again, if any
+		RetryAfter time.Duration `json:"-"`
 	}
 )
 
 const (
-	ResourceGroupScope DeploymentScope = "resourceGroup"
-	SubscriptionScope  DeploymentScope = "subscription"
+	ResourceGroupScope   DeploymentScope = "resourceGroup"
+	SubscriptionScope    DeploymentScope = "subscription"
+	ManagementGroupScope DeploymentScope = "managementGroup"
+	TenantScope          DeploymentScope = "tenant"
 
 	RequestContentDetailLevel            DetailLevel = "requestContent"
 	ResponseContentDetailLevel           DetailLevel = "responseContent"
@@ -100,27 +110,14 @@ func NewResourceGroupDeployment(
 	deploymentName string,
 	resources ...interface{}) *Deployment {
 
-	return &Deployment{
-		Scope: ResourceGroupScope,
-		Properties: &DeploymentProperties{
-			DeploymentSpec: DeploymentSpec{
-				DebugSetting: &DebugSetting{
-					DetailLevel: RequestAndResponseContentDetailLevel,
-				},
-				Mode: IncrementalDeploymentMode,
-				Template: &Template{
-					Schema:         "https://schema.management.azure.com/schemas/2015-01-01/deploymentTemplate.json#",
-					ContentVersion: "1.0.0.0",
-					Resources:      resources,
-				},
-			},
-		},
-		ARMMeta: ARMMeta{
-			Name:           deploymentName,
-			ResourceGroup:  groupName,
-			SubscriptionId: subscriptionId,
-		},
-	}
+	deployment := newTemplateDeployment(
+		ResourceGroupScope,
+		"https://schema.management.azure.com/schemas/2015-01-01/deploymentTemplate.json#",
+		deploymentName,
+		resources)
+	deployment.ResourceGroup = groupName
+	deployment.SubscriptionId = subscriptionId
+	return deployment
 }
 
 func NewSubscriptionDeployment(
@@ -129,8 +126,58 @@ func NewSubscriptionDeployment(
 	deploymentName string,
 	resources ...interface{}) *Deployment {
 
+	deployment := newTemplateDeployment(
+		SubscriptionScope,
+		"https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#",
+		deploymentName,
+		resources)
+	deployment.Location = location
+	deployment.SubscriptionId = subscriptionID
+	return deployment
+}
+
+// NewManagementGroupDeployment creates a deployment of resources (such as policy definitions) into the management
+// group with the given ID. The location is where the deployment itself is stored.
+func NewManagementGroupDeployment(
+	managementGroupId string,
+	location string,
+	deploymentName string,
+	resources ...interface{}) *Deployment {
+
+	deployment := newTemplateDeployment(
+		ManagementGroupScope,
+		"https://schema.management.azure.com/schemas/2019-08-01/managementGroupDeploymentTemplate.json#",
+		deploymentName,
+		resources)
+	deployment.Location = location
+	deployment.ManagementGroupId = managementGroupId
+	return deployment
+}
+
+// NewTenantDeployment creates a deployment of resources (such as management groups) into the tenant. The location
+// is where the deployment itself is stored.
+func NewTenantDeployment(
+	location string,
+	deploymentName string,
+	resources ...interface{}) *Deployment {
+
+	deployment := newTemplateDeployment(
+		TenantScope,
+		"https://schema.management.azure.com/schemas/2019-08-01/tenantDeploymentTemplate.json#",
+		deploymentName,
+		resources)
+	deployment.Location = location
+	return deployment
+}
+
+func newTemplateDeployment(
+	scope DeploymentScope,
+	schema string,
+	deploymentName string,
+	resources []interface{}) *Deployment {
+
 	return &Deployment{
-		Scope: SubscriptionScope,
+		Scope: scope,
 		Properties: &DeploymentProperties{
 			DeploymentSpec: DeploymentSpec{
 				DebugSetting: &DebugSetting{
@@ -138,16 +185,14 @@ func NewSubscriptionDeployment(
 				},
 				Mode: IncrementalDeploymentMode,
 				Template: &Template{
-					Schema:         "https://schema.management.azure.com/schemas/2018-05-01/subscriptionDeploymentTemplate.json#",
+					Schema:         schema,
 					ContentVersion: "1.0.0.0",
 					Resources:      resources,
 				},
 			},
 		},
 		ARMMeta: ARMMeta{
-			Name:           deploymentName,
-			Location:       location,
-			SubscriptionId: subscriptionID,
+			Name: deploymentName,
 		},
 	}
 }
@@ -185,6 +230,15 @@ func (d *Deployment) GetEntityPath() (string, error) {
 			d.SubscriptionId,
 			d.ResourceGroup,
 			d.Name)
+	case ManagementGroupScope:
+		entityPath = fmt.Sprintf(
+			"providers/Microsoft.Management/managementGroups/%s/providers/Microsoft.Resources/deployments/%s?api-version=2019-10-01",
+			d.ManagementGroupId,
+			d.Name)
+	case TenantScope:
+		entityPath = fmt.Sprintf(
+			"providers/Microsoft.Resources/deployments/%s?api-version=2019-10-01",
+			d.Name)
 	default:
 		return "", errors.Errorf("unknown scope %s", d.Scope)
 	}
@@ -192,6 +246,16 @@ func (d *Deployment) GetEntityPath() (string, error) {
 	return entityPath, nil
 }
 
+// GetId returns the ARM ID of the deployment
+func (d *Deployment) GetId() (string, error) {
+	entityPath, err := d.GetEntityPath()
+	if err != nil {
+		return "", err
+	}
+
+	return "/" + strings.Split(entityPath, "?")[0], nil
+}
+
 func (d *Deployment) Validate() error {
 	switch d.Scope {
 	case SubscriptionScope:
@@ -202,6 +266,14 @@ func (d *Deployment) Validate() error {
 		if d.SubscriptionId == "" || d.Name == "" || d.ResourceGroup == "" {
 			return errors.Errorf("validate: require subscription ID, name and resource group to not be empty")
 		}
+	case ManagementGroupScope:
+		if d.ManagementGroupId == "" || d.Name == "" || d.Location == "" {
+			return errors.Errorf("validate: require management group ID, name and location to not be empty")
+		}
+	case TenantScope:
+		if d.Name == "" || d.Location == "" {
+			return errors.Errorf("validate: require name and location to not be empty")
+		}
 	}
 
 	return nil
@@ -12,7 +12,8 @@ import (
 type ToArmConverter interface {
 	// name is the "a/b/c" name for the owner. For example this would be "VNet1" when deploying subnet1
 	// or myaccount when creating Batch pool1
-	ConvertToArm(name string) (interface{}, error)
+	// resolver is used to look up the values of any secrets referred to by the resource
+	ConvertToArm(name string, resolver ReferenceResolver) (interface{}, error)
 }
 
 type FromArmConverter interface {
@@ -6,6 +6,8 @@
 package genruntime
 
 import (
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -39,6 +41,30 @@ type KubernetesResource interface {
 	// SetStatus(status interface{})
 }
 
+// ResourceScope is the scope in Azure at which a resource is deployed
+type ResourceScope string
+
+const (
+	// ResourceScopeResourceGroup resources are deployed within a resource group. This is the scope of most resources.
+	ResourceScopeResourceGroup = ResourceScope("resourceGroup")
+	// ResourceScopeSubscription resources, such as resource groups, are deployed directly within a subscription
+	ResourceScopeSubscription = ResourceScope("subscription")
+	// ResourceScopeManagementGroup resources, such as policy definitions, are deployed within a management group
+	ResourceScopeManagementGroup = ResourceScope("managementGroup")
+	// ResourceScopeTenant resources, such as management groups, are deployed directly within the tenant
+	ResourceScopeTenant = ResourceScope("tenant")
+	// ResourceScopeExtension resources, such as role assignments and locks, are deployed onto another resource of any
+	// kind, which owns them
+	ResourceScopeExtension = ResourceScope("extension")
+)
+
+// ScopedResource is implemented by resources which declare the scope they're deployed at. Resources which don't
+// implement it are deployed within the resource group or subscription at the root of their owner hierarchy.
+type ScopedResource interface {
+	// Scope returns the scope the resource is deployed at
+	Scope() ResourceScope
+}
+
 // ArmResourceSpec is an ARM resource specification. This interface contains
 // methods to access properties common to all ARM Resource Specs. An Azure
 // Deployment is made of these.
@@ -111,6 +137,29 @@ func NewDeployableSubscriptionResource(location string, spec ArmResourceSpec) *S
 	}
 }
 
+func NewDeployableManagementGroupResource(managementGroupId string, location string, spec ArmResourceSpec) *ManagementGroupResource {
+	return &ManagementGroupResource{
+		managementGroupId: managementGroupId,
+		location:          location,
+		spec:              spec,
+	}
+}
+
+func NewDeployableTenantResource(location string, spec ArmResourceSpec) *TenantResource {
+	return &TenantResource{
+		location: location,
+		spec:     spec,
+	}
+}
+
+func NewDeployableExtensionResource(scope string, location string, spec ArmResourceSpec) *ExtensionResource {
+	return &ExtensionResource{
+		scope:    scope,
+		location: location,
+		spec:     spec,
+	}
+}
+
 // ResourceGroupResource represents a resource which can be deployed to Azure inside of a
 // resource group.
 type ResourceGroupResource struct {
@@ -144,3 +193,90 @@ func (r *SubscriptionResource) Location() string {
 func (r *SubscriptionResource) Spec() ArmResourceSpec {
 	return r.spec
 }
+
+// ManagementGroupResource represents a resource which can be deployed to Azure inside of a management group.
+// The location is where the deployment is stored, and may be empty if none of the resources involved has one.
+type ManagementGroupResource struct {
+	managementGroupId string
+	location          string
+	spec              ArmResourceSpec
+}
+
+var _ DeployableResource = &ManagementGroupResource{}
+
+func (r *ManagementGroupResource) ManagementGroupId() string {
+	return r.managementGroupId
+}
+
+func (r *ManagementGroupResource) Location() string {
+	return r.location
+}
+
+func (r *ManagementGroupResource) Spec() ArmResourceSpec {
+	return r.spec
+}
+
+// TenantResource represents a resource which can be deployed to Azure directly in the tenant.
+// The location is where the deployment is stored, and may be empty if none of the resources involved has one.
+type TenantResource struct {
+	location string
+	spec     ArmResourceSpec
+}
+
+var _ DeployableResource = &TenantResource{}
+
+func (r *TenantResource) Location() string {
+	return r.location
+}
+
+func (r *TenantResource) Spec() ArmResourceSpec {
+	return r.spec
+}
+
+// ExtensionResource represents a resource (such as a role assignment or lock) which is deployed to Azure onto
+// another resource, identified by its ARM ID. The location is where the deployment is stored if the other resource
+// isn't inside of a resource group, and may be empty if none of the resources involved has one.
+type ExtensionResource struct {
+	scope    string
+	location string
+	spec     ArmResourceSpec
+}
+
+var _ DeployableResource = &ExtensionResource{}
+
+// Scope returns the ARM ID of the resource the extension resource is applied to
+func (r *ExtensionResource) Scope() string {
+	return r.scope
+}
+
+func (r *ExtensionResource) Location() string {
+	return r.location
+}
+
+func (r *ExtensionResource) Spec() ArmResourceSpec {
+	return r.spec
+}
+
+// Id returns the ARM ID of the extension resource, which is nested beneath the resource it's applied to
+func (r *ExtensionResource) Id() string {
+	return ExtensionResourceId(r.scope, r.spec.GetType(), r.spec.GetName())
+}
+
+// ExtensionResourceId returns the ARM ID of an extension resource of the given type and name applied to the resource
+// with the ARM ID scope: {scope}/providers/{type}/{name}. Child extension resources have types and names with more
+// than one segment, which are interleaved as usual.
+func ExtensionResourceId(scope string, resourceType string, name string) string {
+	typeSegments := strings.Split(resourceType, "/")
+	nameSegments := strings.Split(name, "/")
+
+	// The first segment of the type is the provider namespace, which doesn't have a name
+	segments := []string{strings.TrimSuffix(scope, "/"), "providers", typeSegments[0]}
+	for i, typeSegment := range typeSegments[1:] {
+		segments = append(segments, typeSegment)
+		if i < len(nameSegments) {
+			segments = append(segments, nameSegments[i])
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_ExtensionResourceId(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	owner := "/subscriptions/1234/resourceGroups/myrg/providers/Microsoft.Storage/storageAccounts/mystorage"
+
+	g.Expect(ExtensionResourceId(owner, "Microsoft.Authorization/locks", "mylock")).To(
+		Equal(owner + "/providers/Microsoft.Authorization/locks/mylock"))
+
+	// Resources at the top of the hierarchy have IDs ending in a slash
+	g.Expect(ExtensionResourceId("/", "Microsoft.Authorization/roleAssignments", "myassignment")).To(
+		Equal("/providers/Microsoft.Authorization/roleAssignments/myassignment"))
+
+	// Child extension resources interleave their types and names
+	g.Expect(ExtensionResourceId(owner, "Microsoft.Security/advancedThreatProtectionSettings/children", "current/mychild")).To(
+		Equal(owner + "/providers/Microsoft.Security/advancedThreatProtectionSettings/current/children/mychild"))
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a Condition
+type ConditionType string
+
+const (
+	// ConditionTypeReady is True once the resource has been successfully deployed to Azure and its current spec
+	// has been applied
+	ConditionTypeReady = ConditionType("Ready")
+	// ConditionTypeProvisioning is True while a deployment of the resource to Azure is in progress
+	ConditionTypeProvisioning = ConditionType("Provisioning")
+	// ConditionTypeOwnerReady is True once the owner of the resource (if it has one) exists
+	ConditionTypeOwnerReady = ConditionType("OwnerReady")
+	// ConditionTypeDeleting is True while the resource is being deleted from Azure
+	ConditionTypeDeleting = ConditionType("Deleting")
+	// ConditionTypeDriftDetected is True if the resource in Azure has been changed outside of Kubernetes
+	ConditionTypeDriftDetected = ConditionType("DriftDetected")
+	// ConditionTypeExported is True once the values requested by the operatorSpec of the resource have been written
+	// into secrets and config maps
+	ConditionTypeExported = ConditionType("Exported")
+)
+
+// Reasons used for conditions set by the generic controller
+const (
+	ReasonSucceeded              = "Succeeded"
+	ReasonFailed                 = "Failed"
+	ReasonDeploying              = "Deploying"
+	ReasonWaitingForOwner        = "WaitingForOwner"
+	ReasonOwnerExists            = "OwnerExists"
+	ReasonDeleting               = "Deleting"
+	ReasonDrifted                = "Drifted"
+	ReasonInSync                 = "InSync"
+	ReasonAdopted                = "Adopted"
+	ReasonAwaitingAdoption       = "AwaitingAdoptionConfirmation"
+	ReasonObserved               = "Observed"
+	ReasonNotFound               = "NotFound"
+	ReasonSecretNotFound         = "SecretNotFound"
+	ReasonWaitingForReference    = "WaitingForReference"
+	ReasonImmutableFieldChanged  = "ImmutableFieldChanged"
+	ReasonReferenceNotGranted    = "ReferenceNotGranted"
+	ReasonExportConflict         = "ExportConflict"
+	ReasonExportActionNotAllowed = "ExportActionNotAllowed"
+)
+
+// Condition describes one aspect of the current state of a resource. It has the same shape as metav1.Condition
+// (which isn't available in the version of apimachinery we're using), so that standard tooling such as
+// kubectl wait --for=condition=Ready works.
+type Condition struct {
+	// Type of the condition
+	// +kubebuilder:validation:Required
+	Type ConditionType `json:"type"`
+
+	// Status of the condition, one of True, False or Unknown
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	Status metav1.ConditionStatus `json:"status"`
+
+	// ObservedGeneration is the metadata.generation of the resource the condition was set based on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastTransitionTime is the last time the condition changed from one status to another
+	// +kubebuilder:validation:Required
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// Reason is a CamelCase identifier for the reason for the condition's last transition
+	// +kubebuilder:validation:Required
+	Reason string `json:"reason"`
+
+	// Message is a human readable message with details about the transition
+	Message string `json:"message,omitempty"`
+}
+
+// NewCondition creates a new Condition of the given type
+func NewCondition(conditionType ConditionType, status metav1.ConditionStatus, reason string, message string) Condition {
+	return Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// DeepCopyInto copies the receiver into out
+func (c *Condition) DeepCopyInto(out *Condition) {
+	*out = *c
+	c.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy creates a copy of the receiver
+func (c *Condition) DeepCopy() *Condition {
+	if c == nil {
+		return nil
+	}
+	out := new(Condition)
+	c.DeepCopyInto(out)
+	return out
+}
+
+// FindCondition returns the condition of the given type, or nil if there isn't one
+func FindCondition(conditions []Condition, conditionType ConditionType) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// SetCondition adds the given condition to conditions, replacing any existing condition of the same type.
+// The LastTransitionTime is only updated if the status of the condition has changed.
+func SetCondition(conditions []Condition, condition Condition) []Condition {
+	existing := FindCondition(conditions, condition.Type)
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		return append(conditions, condition)
+	}
+
+	if existing.Status != condition.Status {
+		existing.Status = condition.Status
+		existing.LastTransitionTime = condition.LastTransitionTime
+		if existing.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+	existing.ObservedGeneration = condition.ObservedGeneration
+
+	return conditions
+}
+
+// IsConditionTrue returns true if there is a condition of the given type with status True
+func IsConditionTrue(conditions []Condition, conditionType ConditionType) bool {
+	condition := FindCondition(conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
+
+// GetConditions returns the conditions in the status of the given resource. Resources without a Conditions field
+// in their status have no conditions.
+func GetConditions(metaObj MetaObject) ([]Condition, error) {
+	val := reflect.ValueOf(metaObj).Elem()
+	if val.Kind() != reflect.Struct {
+		return nil, errors.Errorf("metaObj kind was not struct")
+	}
+
+	status := val.FieldByName("Status")
+	if !status.IsValid() || status.Kind() != reflect.Struct {
+		return nil, errors.Errorf("couldn't find status field on type %T", metaObj)
+	}
+
+	field := status.FieldByName("Conditions")
+	if !field.IsValid() {
+		return nil, nil
+	}
+
+	conditions, ok := field.Interface().([]Condition)
+	if !ok {
+		return nil, errors.Errorf("status conditions of %T were of type %s, not []genruntime.Condition", metaObj, field.Type())
+	}
+
+	return conditions, nil
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// OperatorSpec configures how the operator handles a resource, as opposed to how the resource is configured in Azure.
+type OperatorSpec struct {
+	// Secrets lists values of the resource to write into Kubernetes secrets, such as access keys
+	Secrets []ExportedValue `json:"secrets,omitempty"`
+
+	// ConfigMaps lists values of the resource to write into Kubernetes config maps, such as endpoints
+	ConfigMaps []ExportedValue `json:"configMaps,omitempty"`
+}
+
+// ExportedValue identifies a value of a resource in Azure and the key of a Kubernetes secret or config map to write it
+// to. The secret or config map is created in the namespace of the resource, is owned by the resource and is refreshed
+// each time the resource is reconciled.
+type ExportedValue struct {
+	// Name is the name of the secret or config map to write the value to
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Key is the key within the secret or config map to write the value to
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+
+	// Action is the name of a POST action on the resource in Azure (such as listKeys) whose result holds the value.
+	// Only list actions, which read values without changing the resource, are allowed. If empty, the value is read
+	// from the status of the resource.
+	// +kubebuilder:validation:Pattern="^list[A-Za-z]*$"
+	Action string `json:"action,omitempty"`
+
+	// Path is the dot separated path of the value, using JSON property names, within the status of the resource or
+	// the result of Action. Elements of arrays are selected by index, for example keys.0.value
+	// +kubebuilder:validation:Required
+	Path string `json:"path"`
+}
+
+// exportActionPattern matches the names of the actions values may be exported from. Actions are invoked every time
+// the resource is reconciled, so only list actions (such as listKeys and listConnectionStrings) are allowed; others
+// such as regenerateKey or restart would change the resource each time.
+var exportActionPattern = regexp.MustCompile("^list[A-Za-z]*$")
+
+// ValidateExportAction returns an error unless action is the name of a read-only list action, such as listKeys. This
+// also ensures the action can't escape the path of the resource it's invoked on.
+func ValidateExportAction(action string) error {
+	if !exportActionPattern.MatchString(action) {
+		return errors.Errorf("action %q is not allowed, only list actions such as listKeys can be used to export values", action)
+	}
+
+	return nil
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *OperatorSpec) DeepCopyInto(out *OperatorSpec) {
+	*out = *in
+	if in.Secrets != nil {
+		out.Secrets = make([]ExportedValue, len(in.Secrets))
+		copy(out.Secrets, in.Secrets)
+	}
+
+	if in.ConfigMaps != nil {
+		out.ConfigMaps = make([]ExportedValue, len(in.ConfigMaps))
+		copy(out.ConfigMaps, in.ConfigMaps)
+	}
+}
+
+// DeepCopy creates a copy of the receiver
+func (in *OperatorSpec) DeepCopy() *OperatorSpec {
+	if in == nil {
+		return nil
+	}
+
+	out := new(OperatorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
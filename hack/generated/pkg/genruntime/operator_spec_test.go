@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_ValidateExportAction_AllowsListActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ValidateExportAction("listKeys")).To(Succeed())
+	g.Expect(ValidateExportAction("listConnectionStrings")).To(Succeed())
+}
+
+func Test_ValidateExportAction_RejectsOtherActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, action := range []string{
+		"",
+		"regenerateKey",
+		"restart",
+		"failover",
+		"listKeys/../regenerateKey",
+		"../../otherAccount/listKeys",
+		"listKeys?api-version=2019-04-01",
+		"list Keys",
+	} {
+		g.Expect(ValidateExportAction(action)).ToNot(Succeed(), action)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PropertyBag holds the JSON of properties which other versions of a resource have but this version does not, keyed
+// by their dotted path within the spec or status, so that they aren't lost when converting through this version.
+// Only the spec and status of a resource have a property bag; values of nested properties are kept in it by path.
+type PropertyBag map[string]string
+
+// NewPropertyBag returns a new property bag holding the entries of the given bags. Later bags take precedence.
+func NewPropertyBag(bags ...PropertyBag) PropertyBag {
+	result := make(PropertyBag)
+	for _, bag := range bags {
+		for key, value := range bag {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// Add stores the JSON of value in the bag at the given path, replacing anything already there. Empty values (those
+// omitted from JSON by omitempty) aren't stored, so that they don't linger in the bag of a version without them.
+func (bag PropertyBag) Add(path string, value interface{}) error {
+	if isEmptyValue(reflect.ValueOf(value)) {
+		delete(bag, path)
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrapf(err, "adding %q to property bag", path)
+	}
+
+	bag[path] = string(data)
+	return nil
+}
+
+// Pull removes the value at the given path from the bag, unmarshalling it into target. If there's no value at the
+// path, target is left unchanged. If the value doesn't fit target (because the shape of the property differs between
+// versions), it's left in the bag for a version it does fit.
+func (bag PropertyBag) Pull(path string, target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return errors.Errorf("pulling %q from property bag requires a non-nil pointer, not %T", path, target)
+	}
+
+	value, ok := bag[path]
+	if !ok {
+		return nil
+	}
+
+	// Decode into a new value so that target is untouched if the value doesn't fit
+	decoded := reflect.New(ptr.Elem().Type())
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(decoded.Interface()); err != nil {
+		// Doesn't fit, so keep it for a version that it does
+		return nil
+	}
+
+	ptr.Elem().Set(decoded.Elem())
+	delete(bag, path)
+	return nil
+}
+
+// PropertyPath returns the path of a value nested within the one at the given path, given the JSON names of the
+// properties (or the indexes or keys of the elements) leading to it
+func PropertyPath(path string, keys ...interface{}) string {
+	parts := make([]string, 0, len(keys)+1)
+	if path != "" {
+		parts = append(parts, path)
+	}
+
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprint(key))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// isEmptyValue returns true if the value would be omitted from JSON by omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// DeepCopyInto copies the receiver into out
+func (in PropertyBag) DeepCopyInto(out *PropertyBag) {
+	*out = make(PropertyBag, len(in))
+	for key, value := range in {
+		(*out)[key] = value
+	}
+}
+
+// DeepCopy creates a copy of the receiver
+func (in PropertyBag) DeepCopy() PropertyBag {
+	if in == nil {
+		return nil
+	}
+
+	out := new(PropertyBag)
+	in.DeepCopyInto(out)
+	return *out
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_PropertyPath_JoinsKeys(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(PropertyPath("", "size")).To(Equal("size"))
+	g.Expect(PropertyPath("spec", "parts", 2, "name")).To(Equal("spec.parts.2.name"))
+}
+
+func Test_PropertyBag_Add_StoresJson(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	size := 3
+	bag := NewPropertyBag()
+	g.Expect(bag.Add("size", &size)).To(Succeed())
+	g.Expect(bag.Add("colour", "blue")).To(Succeed())
+
+	g.Expect(bag).To(Equal(PropertyBag{"size": "3", "colour": `"blue"`}))
+}
+
+func Test_PropertyBag_Add_SkipsEmptyValues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var size *int
+	bag := PropertyBag{"size": "3"}
+	g.Expect(bag.Add("size", size)).To(Succeed())
+	g.Expect(bag.Add("colour", "")).To(Succeed())
+	g.Expect(bag.Add("parts", []string{})).To(Succeed())
+
+	g.Expect(bag).To(BeEmpty())
+}
+
+func Test_PropertyBag_Pull_RemovesValue(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bag := PropertyBag{"size": "3"}
+	var size *int
+	g.Expect(bag.Pull("size", &size)).To(Succeed())
+
+	g.Expect(size).ToNot(BeNil())
+	g.Expect(*size).To(Equal(3))
+	g.Expect(bag).To(BeEmpty())
+}
+
+func Test_PropertyBag_Pull_MissingValue_LeavesTargetAlone(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bag := NewPropertyBag()
+	size := 5
+	g.Expect(bag.Pull("size", &size)).To(Succeed())
+
+	g.Expect(size).To(Equal(5))
+}
+
+func Test_PropertyBag_Pull_ValueOfDifferentShape_StaysInBag(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bag := PropertyBag{"size": `{"width":3}`}
+	var size int
+	g.Expect(bag.Pull("size", &size)).To(Succeed())
+
+	g.Expect(size).To(Equal(0))
+	g.Expect(bag).To(HaveKey("size"))
+}
+
+func Test_PropertyBag_Pull_RequiresPointer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bag := PropertyBag{"size": "3"}
+	var size int
+	g.Expect(bag.Pull("size", size)).ToNot(Succeed())
+}
+
+func Test_NewPropertyBag_CopiesBags(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	original := PropertyBag{"size": "3"}
+	bag := NewPropertyBag(original)
+	delete(bag, "size")
+
+	g.Expect(original).To(HaveKey("size"))
+}
+
+/*
+ * Two versions of a widget, converted in the way the code generator does. The hub has no colour, and the spoke has
+ * no weight and no options.
+ */
+
+type widgetSpoke struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              widgetSpokeSpec `json:"spec,omitempty"`
+}
+
+type widgetSpokeSpec struct {
+	Colour      *string           `json:"colour,omitempty"`
+	Parts       []widgetSpokePart `json:"parts,omitempty"`
+	PropertyBag PropertyBag       `json:"propertyBag,omitempty"`
+	Size        *int              `json:"size,omitempty"`
+}
+
+type widgetSpokePart struct {
+	Name *string `json:"name,omitempty"`
+}
+
+type widgetHub struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              widgetHubSpec `json:"spec,omitempty"`
+}
+
+type widgetHubSpec struct {
+	Options     *widgetHubOptions `json:"options,omitempty"`
+	Parts       []widgetHubPart   `json:"parts,omitempty"`
+	PropertyBag PropertyBag       `json:"propertyBag,omitempty"`
+	Size        *int              `json:"size,omitempty"`
+	Weight      *int              `json:"weight,omitempty"`
+}
+
+type widgetHubOptions struct {
+	Shiny *bool `json:"shiny,omitempty"`
+}
+
+type widgetHubPart struct {
+	Name   *string `json:"name,omitempty"`
+	Serial *string `json:"serial,omitempty"`
+}
+
+func (widget *widgetSpoke) convertTo(destination *widgetHub) error {
+	destination.ObjectMeta = *widget.ObjectMeta.DeepCopy()
+	specBag := NewPropertyBag(widget.Spec.PropertyBag)
+	if err := widget.Spec.convertTo(&destination.Spec, specBag, ""); err != nil {
+		return err
+	}
+	destination.Spec.PropertyBag = specBag
+	return nil
+}
+
+func (widget *widgetSpoke) convertFrom(source *widgetHub) error {
+	widget.ObjectMeta = *source.ObjectMeta.DeepCopy()
+	specBag := NewPropertyBag(source.Spec.PropertyBag)
+	if err := widget.Spec.convertFrom(&source.Spec, specBag, ""); err != nil {
+		return err
+	}
+	widget.Spec.PropertyBag = specBag
+	return nil
+}
+
+func (spec *widgetSpokeSpec) convertTo(destination *widgetHubSpec, bag PropertyBag, path string) error {
+	if err := bag.Add(PropertyPath(path, "colour"), spec.Colour); err != nil {
+		return err
+	}
+	if err := bag.Pull(PropertyPath(path, "options"), &destination.Options); err != nil {
+		return err
+	}
+	if spec.Parts != nil {
+		partsList := make([]widgetHubPart, len(spec.Parts))
+		for partsIndex, partsItem := range spec.Parts {
+			if err := partsItem.convertTo(&partsList[partsIndex], bag, PropertyPath(path, "parts", partsIndex)); err != nil {
+				return err
+			}
+		}
+		destination.Parts = partsList
+	}
+	destination.Size = spec.Size
+	if err := bag.Pull(PropertyPath(path, "weight"), &destination.Weight); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (spec *widgetSpokeSpec) convertFrom(source *widgetHubSpec, bag PropertyBag, path string) error {
+	if err := bag.Pull(PropertyPath(path, "colour"), &spec.Colour); err != nil {
+		return err
+	}
+	if err := bag.Add(PropertyPath(path, "options"), source.Options); err != nil {
+		return err
+	}
+	if source.Parts != nil {
+		partsList := make([]widgetSpokePart, len(source.Parts))
+		for partsIndex, partsItem := range source.Parts {
+			if err := partsList[partsIndex].convertFrom(&partsItem, bag, PropertyPath(path, "parts", partsIndex)); err != nil {
+				return err
+			}
+		}
+		spec.Parts = partsList
+	}
+	spec.Size = source.Size
+	if err := bag.Add(PropertyPath(path, "weight"), source.Weight); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (part *widgetSpokePart) convertTo(destination *widgetHubPart, bag PropertyBag, path string) error {
+	destination.Name = part.Name
+	if err := bag.Pull(PropertyPath(path, "serial"), &destination.Serial); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (part *widgetSpokePart) convertFrom(source *widgetHubPart, bag PropertyBag, path string) error {
+	part.Name = source.Name
+	if err := bag.Add(PropertyPath(path, "serial"), source.Serial); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Test_PropertyBag_SpokeToHubToSpoke_RoundTripsWithoutLoss(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	colour := "blue"
+	size := 3
+	name := "cog"
+	original := &widgetSpoke{
+		ObjectMeta: metav1.ObjectMeta{Name: "widget", Namespace: "ns"},
+		Spec: widgetSpokeSpec{
+			Colour: &colour,
+			Parts:  []widgetSpokePart{{Name: &name}},
+			Size:   &size,
+		},
+	}
+
+	var hub widgetHub
+	g.Expect(original.convertTo(&hub)).To(Succeed())
+	g.Expect(hub.Name).To(Equal("widget"))
+	g.Expect(*hub.Spec.Size).To(Equal(3))
+	g.Expect(hub.Spec.PropertyBag).To(Equal(PropertyBag{"colour": `"blue"`}))
+
+	var actual widgetSpoke
+	g.Expect(actual.convertFrom(&hub)).To(Succeed())
+	g.Expect(actual.ObjectMeta).To(Equal(original.ObjectMeta))
+	g.Expect(actual.Spec.Colour).To(Equal(original.Spec.Colour))
+	g.Expect(actual.Spec.Parts).To(Equal(original.Spec.Parts))
+	g.Expect(actual.Spec.Size).To(Equal(original.Spec.Size))
+	g.Expect(actual.Spec.PropertyBag).To(BeEmpty())
+}
+
+func Test_PropertyBag_HubToSpokeToHub_RoundTripsWithoutLoss(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	size := 3
+	weight := 7
+	shiny := true
+	name := "cog"
+	serial := "A123"
+	original := &widgetHub{
+		ObjectMeta: metav1.ObjectMeta{Name: "widget", Namespace: "ns"},
+		Spec: widgetHubSpec{
+			Options: &widgetHubOptions{Shiny: &shiny},
+			Parts:   []widgetHubPart{{Name: &name, Serial: &serial}},
+			Size:    &size,
+			Weight:  &weight,
+		},
+	}
+
+	var spoke widgetSpoke
+	g.Expect(spoke.convertFrom(original)).To(Succeed())
+	g.Expect(spoke.Spec.PropertyBag).To(Equal(PropertyBag{
+		"options":        `{"shiny":true}`,
+		"parts.0.serial": `"A123"`,
+		"weight":         "7",
+	}))
+
+	var actual widgetHub
+	g.Expect(spoke.convertTo(&actual)).To(Succeed())
+	g.Expect(actual.ObjectMeta).To(Equal(original.ObjectMeta))
+	g.Expect(actual.Spec.Options).To(Equal(original.Spec.Options))
+	g.Expect(actual.Spec.Parts).To(Equal(original.Spec.Parts))
+	g.Expect(actual.Spec.Size).To(Equal(original.Spec.Size))
+	g.Expect(actual.Spec.Weight).To(Equal(original.Spec.Weight))
+	g.Expect(actual.Spec.PropertyBag).To(BeEmpty())
+}
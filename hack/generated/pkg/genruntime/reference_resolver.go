@@ -0,0 +1,18 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+// ResourceIdAnnotation is the annotation holding the ID in Azure of a resource, once it has been deployed
+const ResourceIdAnnotation = "resource-id.infra.azure.com"
+
+// ReferenceResolver looks up the values a resource refers to but doesn't hold itself, while it's converted to ARM
+type ReferenceResolver interface {
+	// ResolveSecret returns the value held in the referenced secret
+	ResolveSecret(ref SecretReference) (string, error)
+
+	// ResolveResourceReference returns the ARM ID of the referenced resource
+	ResolveResourceReference(ref ResourceReference) (string, error)
+}
@@ -5,33 +5,108 @@
 
 package genruntime
 
-import "reflect"
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
 
 // KnownResourceReference is a resource reference to a known type.
 type KnownResourceReference struct {
 	// This is the name of the Kubernetes resource to reference.
 	Name string `json:"name"`
 
-	// References across namespaces are not supported.
+	// Namespace is the namespace of the Kubernetes resource to reference. If empty, the resource is in the same
+	// namespace as the resource referring to it. Referring to a resource in another namespace requires a
+	// ReferenceGrant in that namespace allowing it.
+	// Note that ownership across namespaces in Kubernetes is not allowed, so resources owned by a resource in
+	// another namespace aren't garbage collected when their owner is deleted.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ArbitraryOwnerReference is the owner of an extension resource (such as a role assignment or lock), which can be a
+// resource of any kind so names its group and kind as well as its name. Resources which aren't managed through
+// Kubernetes, such as subscriptions, can instead be given by their ID in Azure.
+type ArbitraryOwnerReference struct {
+	// The group of the owning resource.
+	Group string `json:"group,omitempty"`
+	// The kind of the owning resource.
+	Kind string `json:"kind,omitempty"`
+	// The name of the Kubernetes resource which owns the extension resource.
+	Name string `json:"name,omitempty"`
+	// The namespace of the owning resource. If empty, the owner is in the same namespace as the extension resource.
+	// Referring to a resource in another namespace requires a ReferenceGrant in that namespace allowing it.
+	Namespace string `json:"namespace,omitempty"`
+
+	// ArmId is the ID in Azure of the resource the extension resource is applied to, used in place of Group, Kind and
+	// Name to apply it to a resource which isn't managed through Kubernetes.
+	ArmId string `json:"armId,omitempty"`
+}
 
-	// Note that ownership across namespaces in Kubernetes is not allowed, but technically resource
-	// references are. There are RBAC considerations here though so probably easier to just start by
-	// disallowing cross-namespace references for now
+// AsResourceReference returns the more general ResourceReference to the owner
+func (ref ArbitraryOwnerReference) AsResourceReference() *ResourceReference {
+	return &ResourceReference{
+		Group:     ref.Group,
+		Kind:      ref.Kind,
+		Name:      ref.Name,
+		Namespace: ref.Namespace,
+		ArmId:     ref.ArmId,
+	}
 }
 
+// ResourceReference refers to another resource, either by its Kubernetes group, kind, name and optionally namespace
+// or, for resources not managed through Kubernetes, by its ID in Azure.
 type ResourceReference struct {
 	// The group of the referenced resource.
-	Group string `json:"group"`
+	Group string `json:"group,omitempty"`
 	// The kind of the referenced resource.
-	Kind string `json:"kind"`
+	Kind string `json:"kind,omitempty"`
 	// The name of the referenced resource.
-	Name string `json:"name"`
+	Name string `json:"name,omitempty"`
+	// The namespace of the referenced resource. If empty, the resource is in the same namespace as the resource
+	// referring to it. Referring to a resource in another namespace requires a ReferenceGrant in that namespace
+	// allowing it.
+	Namespace string `json:"namespace,omitempty"`
+
+	// ArmId is the ID in Azure of the referenced resource, used in place of Group, Kind and Name to refer to resources
+	// which aren't managed through Kubernetes.
+	ArmId string `json:"armId,omitempty"`
 
 	// Note: Version is not required here because references are all about linking one Kubernetes
 	// resource to another, and Kubernetes resources are uniquely identified by group, kind, (optionally namespace) and
 	// name - the versions are just giving a different view on the same resource
 }
 
+// NamespaceOrDefault returns the namespace of the referenced resource, which is defaultNamespace (the namespace of
+// the resource referring to it) unless set explicitly
+func (ref ResourceReference) NamespaceOrDefault(defaultNamespace string) string {
+	if ref.Namespace == "" {
+		return defaultNamespace
+	}
+
+	return ref.Namespace
+}
+
+// IsDirectArmReference returns true if the reference is to the ID of a resource in Azure rather than to a resource
+// in Kubernetes
+func (ref ResourceReference) IsDirectArmReference() bool {
+	return ref.ArmId != ""
+}
+
+// Validate checks that the reference is either to a resource in Azure or to a resource in Kubernetes, but not both
+func (ref ResourceReference) Validate() error {
+	hasKubernetesReference := ref.Group != "" || ref.Kind != "" || ref.Name != "" || ref.Namespace != ""
+	if ref.IsDirectArmReference() && hasKubernetesReference {
+		return errors.Errorf("reference must have either armId or group, kind and name, but not both")
+	}
+
+	if !ref.IsDirectArmReference() && (ref.Group == "" || ref.Kind == "" || ref.Name == "") {
+		return errors.Errorf("reference must have either armId or all of group, kind and name")
+	}
+
+	return nil
+}
+
 // LookupOwnerGroupKind looks up an owners group and kind annotations using reflection.
 // This is primarily used to convert from a KnownResourceReference to the more general
 // ResourceReference
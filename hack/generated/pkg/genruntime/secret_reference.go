@@ -0,0 +1,19 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+// SecretReference is a reference to a value held in a Kubernetes secret, used in place of sensitive properties
+// (such as passwords) so that their values aren't stored in the resource itself.
+// The secret must be in the same namespace as the resource referring to it.
+type SecretReference struct {
+	// Name is the name of the Kubernetes secret
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Key is the key within the Kubernetes secret of the value to use
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+}
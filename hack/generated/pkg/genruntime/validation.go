@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Validator is implemented by resources which have hand-written validations to run in addition to the generated
+// ones. The methods belong in a separate file alongside the generated code for the resource, so they survive it
+// being regenerated.
+type Validator interface {
+	// CreateValidations returns the validations to run when the resource is created
+	CreateValidations() []func() error
+
+	// UpdateValidations returns the validations to run when the resource is updated. Each is given the resource as
+	// it was before the update.
+	UpdateValidations() []func(old runtime.Object) error
+
+	// DeleteValidations returns the validations to run when the resource is deleted
+	DeleteValidations() []func() error
+}
+
+// OneOf is implemented by generated types which represent a discriminated union (JSON OneOf), whose properties are
+// mutually exclusive
+type OneOf interface {
+	// ValidateOneOf returns an error if more than one property is set
+	ValidateOneOf() error
+}
+
+// ValidateCreate runs the given generated validations for the creation of obj, along with the hand-written ones if
+// obj implements Validator. All failures are returned.
+func ValidateCreate(obj interface{}, validations []func() error) error {
+	if validator, ok := obj.(Validator); ok {
+		validations = append(validations, validator.CreateValidations()...)
+	}
+
+	var errs []error
+	for _, validation := range validations {
+		errs = append(errs, validation())
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// ValidateUpdate runs the given generated validations for an update of obj from old, along with the hand-written
+// ones if obj implements Validator. All failures are returned.
+func ValidateUpdate(obj interface{}, old runtime.Object, validations []func(old runtime.Object) error) error {
+	if validator, ok := obj.(Validator); ok {
+		validations = append(validations, validator.UpdateValidations()...)
+	}
+
+	var errs []error
+	for _, validation := range validations {
+		errs = append(errs, validation(old))
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// ValidateDelete runs the hand-written validations for the deletion of obj, if it implements Validator. All
+// failures are returned.
+func ValidateDelete(obj interface{}) error {
+	validator, ok := obj.(Validator)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for _, validation := range validator.DeleteValidations() {
+		errs = append(errs, validation())
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// IsProvisioned returns true if the resource has been created in Azure, after which properties which Azure doesn't
+// allow to be changed can no longer be changed.
+func IsProvisioned(obj metav1.Object) bool {
+	return obj.GetAnnotations()[ResourceIdAnnotation] != ""
+}
+
+// ValidateImmutableProperty returns an error if the value of the property at path differs between old and new.
+// It's used for properties which Azure doesn't allow to be changed once the resource has been created, so should
+// only be called once IsProvisioned returns true.
+func ValidateImmutableProperty(path string, old interface{}, new interface{}) error {
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+
+	return errors.Errorf(
+		"%s can't be changed once the resource exists in Azure; delete and recreate the resource instead",
+		path)
+}
+
+// ValidateImmutableNestedProperty returns an error if the value of the property at path differs between the old and
+// new specs. The property is found by following the named fields from each spec; if any of the objects along the way
+// is missing, the property is treated as not being set.
+func ValidateImmutableNestedProperty(path string, oldSpec interface{}, newSpec interface{}, fields ...string) error {
+	return ValidateImmutableProperty(path, nestedField(oldSpec, fields), nestedField(newSpec, fields))
+}
+
+// nestedField returns the value found by following the named fields from value, or nil if it isn't set
+func nestedField(value interface{}, fields []string) interface{} {
+	v := reflect.ValueOf(value)
+	for _, field := range fields {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return nil
+		}
+
+		v = v.FieldByName(field)
+		if !v.IsValid() {
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	return v.Interface()
+}
+
+// ValidateOneOf returns an error if more than one of the properties of a discriminated union is set. properties
+// maps the JSON name of each property to whether it is set.
+func ValidateOneOf(properties map[string]bool) error {
+	var set []string
+	for name, isSet := range properties {
+		if isSet {
+			set = append(set, name)
+		}
+	}
+
+	if len(set) <= 1 {
+		return nil
+	}
+
+	sort.Strings(set)
+	return errors.Errorf("only one of %s may be set", strings.Join(set, ", "))
+}
+
+// ValidateOneOfProperties checks every discriminated union (JSON OneOf) within value, which is found at path,
+// returning all failures
+func ValidateOneOfProperties(path string, value interface{}) error {
+	return kerrors.NewAggregate(validateOneOfs(path, reflect.ValueOf(value)))
+}
+
+func validateOneOfs(path string, value reflect.Value) []error {
+	var errs []error
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			errs = validateOneOfs(path, value.Elem())
+		}
+
+	case reflect.Struct:
+		if oneOf, ok := value.Interface().(OneOf); ok {
+			err := oneOf.ValidateOneOf()
+			if err != nil {
+				errs = append(errs, errors.Wrap(err, path))
+			}
+		}
+
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported
+				continue
+			}
+
+			errs = append(errs, validateOneOfs(path+"."+jsonName(field), value.Field(i))...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			errs = append(errs, validateOneOfs(fmt.Sprintf("%s[%d]", path, i), value.Index(i))...)
+		}
+
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			errs = append(errs, validateOneOfs(fmt.Sprintf("%s[%v]", path, iter.Key()), iter.Value())...)
+		}
+	}
+
+	return errs
+}
+
+// jsonName returns the name of the given field when serialized to JSON
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package genruntime
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type gadgetSize struct {
+	Capacity   *int             `json:"capacity,omitempty"`
+	Dimensions *gadgetDimension `json:"dimensions,omitempty"`
+}
+
+func (size gadgetSize) ValidateOneOf() error {
+	return ValidateOneOf(map[string]bool{
+		"capacity":   size.Capacity != nil,
+		"dimensions": size.Dimensions != nil,
+	})
+}
+
+type gadgetDimension struct {
+	Height int `json:"height"`
+	Width  int `json:"width"`
+}
+
+type gadgetSpec struct {
+	Location string                `json:"location"`
+	Size     *gadgetSize           `json:"size,omitempty"`
+	Spares   []gadgetSize          `json:"spares,omitempty"`
+	Named    map[string]gadgetSize `json:"named,omitempty"`
+}
+
+// gadget has hand-written validations
+type gadget struct {
+	runtime.Object
+	Spec    gadgetSpec
+	deletes int
+}
+
+var _ Validator = &gadget{}
+
+func (g *gadget) CreateValidations() []func() error {
+	return []func() error{
+		func() error {
+			if g.Spec.Location == "" {
+				return errors.New("location is required")
+			}
+			return nil
+		},
+	}
+}
+
+func (g *gadget) UpdateValidations() []func(old runtime.Object) error {
+	return nil
+}
+
+func (g *gadget) DeleteValidations() []func() error {
+	return []func() error{
+		func() error {
+			g.deletes++
+			return nil
+		},
+	}
+}
+
+func Test_ValidateOneOfProperties_ReportsEachUnionWithMoreThanOnePropertySet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	one := 1
+	both := gadgetSize{Capacity: &one, Dimensions: &gadgetDimension{Height: 1, Width: 1}}
+	spec := gadgetSpec{
+		Size:   &gadgetSize{Capacity: &one},
+		Spares: []gadgetSize{{}, both},
+		Named:  map[string]gadgetSize{"big": both},
+	}
+
+	err := ValidateOneOfProperties("spec", spec)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("spec.spares[1]: only one of capacity, dimensions may be set"))
+	g.Expect(err.Error()).To(ContainSubstring("spec.named[big]: only one of capacity, dimensions may be set"))
+
+	spec.Spares = nil
+	spec.Named = nil
+	g.Expect(ValidateOneOfProperties("spec", spec)).To(Succeed())
+}
+
+func Test_ValidateImmutableProperty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	westus := "westus"
+	alsoWestus := "westus"
+	eastus := "eastus"
+
+	g.Expect(ValidateImmutableProperty("spec.location", &westus, &alsoWestus)).To(Succeed())
+	g.Expect(ValidateImmutableProperty("spec.location", &westus, &eastus)).To(MatchError(
+		"spec.location can't be changed once the resource exists in Azure; delete and recreate the resource instead"))
+	g.Expect(ValidateImmutableProperty("spec.location", nil, &eastus)).ToNot(Succeed())
+}
+
+func Test_ValidateImmutableNestedProperty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	one := 1
+	two := 2
+	withCapacity := func(capacity *int) gadgetSpec {
+		return gadgetSpec{Size: &gadgetSize{Capacity: capacity}}
+	}
+
+	validate := func(old gadgetSpec, new gadgetSpec) error {
+		return ValidateImmutableNestedProperty("spec.size.capacity", old, new, "Size", "Capacity")
+	}
+
+	g.Expect(validate(withCapacity(&one), withCapacity(&one))).To(Succeed())
+	g.Expect(validate(withCapacity(&one), withCapacity(&two))).To(MatchError(ContainSubstring("spec.size.capacity can't be changed")))
+	g.Expect(validate(withCapacity(&one), gadgetSpec{})).ToNot(Succeed())
+	// A missing object along the way is the same as the property not being set
+	g.Expect(validate(gadgetSpec{}, withCapacity(nil))).To(Succeed())
+}
+
+func Test_IsProvisioned(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	obj := &metav1.ObjectMeta{}
+	g.Expect(IsProvisioned(obj)).To(BeFalse())
+
+	obj.Annotations = map[string]string{ResourceIdAnnotation: ""}
+	g.Expect(IsProvisioned(obj)).To(BeFalse())
+
+	obj.Annotations[ResourceIdAnnotation] = "/subscriptions/1234/resourceGroups/deployed"
+	g.Expect(IsProvisioned(obj)).To(BeTrue())
+}
+
+func Test_ValidateCreate_RunsHandWrittenValidations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	obj := &gadget{}
+	generated := func() error { return errors.New("generated validation failed") }
+
+	err := ValidateCreate(obj, []func() error{generated})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("generated validation failed"))
+	g.Expect(err.Error()).To(ContainSubstring("location is required"))
+
+	obj.Spec.Location = "westus"
+	g.Expect(ValidateCreate(obj, nil)).To(Succeed())
+
+	g.Expect(ValidateDelete(obj)).To(Succeed())
+	g.Expect(obj.deletes).To(Equal(1))
+}
@@ -22,26 +22,28 @@ func ConvertResourceToDeployableResource(
 	ctx context.Context,
 	resolver *armresourceresolver.Resolver,
 	metaObject genruntime.MetaObject) (genruntime.DeployableResource, error) {
+	return convertResourceToDeployableResource(ctx, resolver, metaObject, resolver.ReferenceResolverFor(ctx, metaObject))
+}
 
-	metaObjReflector := reflect.Indirect(reflect.ValueOf(metaObject))
-	if !metaObjReflector.IsValid() {
-		return nil, errors.Errorf("couldn't indirect %T", metaObject)
-	}
-
-	specField := metaObjReflector.FieldByName("Spec")
-	if !specField.IsValid() {
-		return nil, errors.Errorf("couldn't find spec field on type %T", metaObject)
-	}
-
-	// Spec fields are values, we want a ptr
-	specFieldPtr := reflect.New(specField.Type())
-	specFieldPtr.Elem().Set(specField)
+// ConvertResourceToDeployableResourceWithoutReferences is like ConvertResourceToDeployableResource, but leaves the
+// secrets and other resources referenced by the resource unresolved. The result has the right ID and API version but
+// must not be deployed; it's for use when the references may no longer exist, such as while deleting the resource.
+func ConvertResourceToDeployableResourceWithoutReferences(
+	ctx context.Context,
+	resolver *armresourceresolver.Resolver,
+	metaObject genruntime.MetaObject) (genruntime.DeployableResource, error) {
+	return convertResourceToDeployableResource(ctx, resolver, metaObject, unresolvedReferences{})
+}
 
-	spec := specFieldPtr.Interface()
+func convertResourceToDeployableResource(
+	ctx context.Context,
+	resolver *armresourceresolver.Resolver,
+	metaObject genruntime.MetaObject,
+	referenceResolver genruntime.ReferenceResolver) (genruntime.DeployableResource, error) {
 
-	armTransformer, ok := spec.(genruntime.ArmTransformer)
-	if !ok {
-		return nil, errors.Errorf("spec was of type %T which doesn't implement genruntime.ArmTransformer", spec)
+	armTransformer, err := getArmTransformer(metaObject)
+	if err != nil {
+		return nil, err
 	}
 
 	resourceHierarchy, err := resolver.ResolveResourceHierarchy(ctx, metaObject)
@@ -49,7 +51,7 @@ func ConvertResourceToDeployableResource(
 		return nil, err
 	}
 
-	armSpec, err := armTransformer.ConvertToArm(resourceHierarchy.FullAzureName())
+	armSpec, err := armTransformer.ConvertToArm(resourceHierarchy.FullAzureName(), referenceResolver)
 	if err != nil {
 		return nil, errors.Wrapf(err, "transforming resource %s to ARM", metaObject.GetName())
 	}
@@ -59,25 +61,106 @@ func ConvertResourceToDeployableResource(
 		return nil, errors.Errorf("casting armSpec of type %T to genruntime.ArmResourceSpec", armSpec)
 	}
 
-	// We have different deployment models for Subscription rooted vs ResourceGroup rooted resources
+	// We have different deployment models for each root of the hierarchy
 	rootKind := resourceHierarchy.RootKind()
-	if rootKind == armresourceresolver.ResourceHierarchyRootResourceGroup {
+	switch rootKind {
+	case armresourceresolver.ResourceHierarchyRootResourceGroup:
 		rg, err := resourceHierarchy.ResourceGroup()
 		if err != nil {
 			return nil, errors.Wrapf(err, "getting resource group")
 		}
 		return genruntime.NewDeployableResourceGroupResource(rg, typedArmSpec), nil
-	} else if rootKind == armresourceresolver.ResourceHierarchyRootSubscription {
+	case armresourceresolver.ResourceHierarchyRootSubscription:
 		location, err := resourceHierarchy.Location()
 		if err != nil {
 			return nil, errors.Wrapf(err, "getting location")
 		}
 		return genruntime.NewDeployableSubscriptionResource(location, typedArmSpec), nil
-	} else {
+	case armresourceresolver.ResourceHierarchyRootManagementGroup:
+		managementGroup, err := resourceHierarchy.ManagementGroup()
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting management group")
+		}
+		return genruntime.NewDeployableManagementGroupResource(
+			managementGroup,
+			resourceHierarchy.DeploymentLocation(),
+			typedArmSpec), nil
+	case armresourceresolver.ResourceHierarchyRootTenant:
+		return genruntime.NewDeployableTenantResource(resourceHierarchy.DeploymentLocation(), typedArmSpec), nil
+	case armresourceresolver.ResourceHierarchyRootExtension:
+		scope, err := resourceHierarchy.ExtensionScope()
+		if err != nil {
+			return nil, err
+		}
+		return genruntime.NewDeployableExtensionResource(
+			scope,
+			resourceHierarchy.DeploymentLocation(),
+			typedArmSpec), nil
+	default:
 		return nil, errors.Errorf("unknown resource hierarchy root kind %s", rootKind)
 	}
 }
 
+// GetApiVersion returns the ARM API version of the given resource. Unlike ConvertResourceToDeployableResource it
+// doesn't look up the owners of the resource, so can be used once they've been deleted.
+func GetApiVersion(metaObject genruntime.MetaObject) (string, error) {
+	armTransformer, err := getArmTransformer(metaObject)
+	if err != nil {
+		return "", err
+	}
+
+	armSpec, err := armTransformer.ConvertToArm(metaObject.AzureName(), unresolvedReferences{})
+	if err != nil {
+		return "", errors.Wrapf(err, "transforming resource %s to ARM", metaObject.GetName())
+	}
+
+	typedArmSpec, ok := armSpec.(genruntime.ArmResourceSpec)
+	if !ok {
+		return "", errors.Errorf("casting armSpec of type %T to genruntime.ArmResourceSpec", armSpec)
+	}
+
+	return typedArmSpec.GetApiVersion(), nil
+}
+
+// getArmTransformer returns the spec of the given resource, which converts the resource to ARM
+func getArmTransformer(metaObject genruntime.MetaObject) (genruntime.ArmTransformer, error) {
+	metaObjReflector := reflect.Indirect(reflect.ValueOf(metaObject))
+	if !metaObjReflector.IsValid() {
+		return nil, errors.Errorf("couldn't indirect %T", metaObject)
+	}
+
+	specField := metaObjReflector.FieldByName("Spec")
+	if !specField.IsValid() {
+		return nil, errors.Errorf("couldn't find spec field on type %T", metaObject)
+	}
+
+	// Spec fields are values, we want a ptr
+	specFieldPtr := reflect.New(specField.Type())
+	specFieldPtr.Elem().Set(specField)
+
+	spec := specFieldPtr.Interface()
+
+	armTransformer, ok := spec.(genruntime.ArmTransformer)
+	if !ok {
+		return nil, errors.Errorf("spec was of type %T which doesn't implement genruntime.ArmTransformer", spec)
+	}
+
+	return armTransformer, nil
+}
+
+// unresolvedReferences is a genruntime.ReferenceResolver which resolves every reference to an empty value
+type unresolvedReferences struct{}
+
+var _ genruntime.ReferenceResolver = unresolvedReferences{}
+
+func (unresolvedReferences) ResolveSecret(_ genruntime.SecretReference) (string, error) {
+	return "", nil
+}
+
+func (unresolvedReferences) ResolveResourceReference(_ genruntime.ResourceReference) (string, error) {
+	return "", nil
+}
+
 // NewEmptyArmResourceStatus creates an empty genruntime.ArmResourceStatus from a genruntime.MetaObject
 // (a Kubernetes representation of a resource), which can be filled by a call to Azure
 func NewEmptyArmResourceStatus(metaObject genruntime.MetaObject) (genruntime.ArmResourceStatus, error) {
@@ -130,11 +213,161 @@ func SetStatus(metaObj genruntime.MetaObject, status interface{}) error {
 
 	field := val.FieldByName("Status")
 	statusVal := reflect.ValueOf(status).Elem()
+
+	// Conditions, ObservedGeneration and the spec signatures are owned by the controller rather than Azure,
+	// so carry them over to the new status
+	for _, name := range []string{"Conditions", "ObservedGeneration", "LastAppliedSpecSignature", "PendingSpecSignature"} {
+		existing := field.FieldByName(name)
+		updated := statusVal.FieldByName(name)
+		if existing.IsValid() && updated.IsValid() && updated.CanSet() {
+			updated.Set(existing)
+		}
+	}
+
 	field.Set(statusVal)
 
 	return nil
 }
 
+// SetCondition sets the given condition in the status of the given object, replacing any existing condition of
+// the same type. The condition is stamped with the generation of the object. Objects without a Conditions field
+// in their status are left unchanged.
+func SetCondition(metaObj genruntime.MetaObject, condition genruntime.Condition) error {
+	field, err := getStatusField(metaObj, "Conditions")
+	if err != nil {
+		return err
+	}
+
+	if !field.IsValid() {
+		return nil
+	}
+
+	conditions, ok := field.Interface().([]genruntime.Condition)
+	if !ok {
+		return errors.Errorf("status conditions of %T were of type %s, not []genruntime.Condition", metaObj, field.Type())
+	}
+
+	condition.ObservedGeneration = metaObj.GetGeneration()
+	field.Set(reflect.ValueOf(genruntime.SetCondition(conditions, condition)))
+
+	return nil
+}
+
+// SetObservedGeneration records the generation of the given object as the one most recently acted on by the
+// controller. Objects without an ObservedGeneration field in their status are left unchanged.
+func SetObservedGeneration(metaObj genruntime.MetaObject) error {
+	field, err := getStatusField(metaObj, "ObservedGeneration")
+	if err != nil {
+		return err
+	}
+
+	if !field.IsValid() {
+		return nil
+	}
+
+	if field.Kind() != reflect.Int64 {
+		return errors.Errorf("status observedGeneration of %T was of type %s, not int64", metaObj, field.Type())
+	}
+
+	field.SetInt(metaObj.GetGeneration())
+
+	return nil
+}
+
+// GetLastAppliedSpecSignature returns the signature of the spec most recently deployed to Azure, or "" if it has
+// never been deployed
+func GetLastAppliedSpecSignature(metaObj genruntime.MetaObject) (string, error) {
+	return getStatusString(metaObj, "LastAppliedSpecSignature")
+}
+
+// SetLastAppliedSpecSignature records the signature of the spec most recently deployed to Azure
+func SetLastAppliedSpecSignature(metaObj genruntime.MetaObject, sig string) error {
+	return setStatusString(metaObj, "LastAppliedSpecSignature", sig)
+}
+
+// GetPendingSpecSignature returns the signature of the spec currently being deployed to Azure, or "" if there is
+// no deployment of a known spec in flight
+func GetPendingSpecSignature(metaObj genruntime.MetaObject) (string, error) {
+	return getStatusString(metaObj, "PendingSpecSignature")
+}
+
+// SetPendingSpecSignature records the signature of the spec currently being deployed to Azure
+func SetPendingSpecSignature(metaObj genruntime.MetaObject, sig string) error {
+	return setStatusString(metaObj, "PendingSpecSignature", sig)
+}
+
+// getStatusString returns the value of the named string field of the status of the given object. Unlike
+// conditions, the field is required: without it the controller couldn't tell whether the spec had been deployed.
+func getStatusString(metaObj genruntime.MetaObject, name string) (string, error) {
+	field, err := getStatusField(metaObj, name)
+	if err != nil {
+		return "", err
+	}
+
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return "", errors.Errorf("couldn't find string field %s in status of type %T", name, metaObj)
+	}
+
+	return field.String(), nil
+}
+
+// setStatusString sets the value of the named string field of the status of the given object
+func setStatusString(metaObj genruntime.MetaObject, name string, value string) error {
+	field, err := getStatusField(metaObj, name)
+	if err != nil {
+		return err
+	}
+
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return errors.Errorf("couldn't find string field %s in status of type %T", name, metaObj)
+	}
+
+	field.SetString(value)
+
+	return nil
+}
+
+// getStatusField returns the named field of the status of the given object, or the zero reflect.Value if there
+// is no such field
+func getStatusField(metaObj genruntime.MetaObject, name string) (reflect.Value, error) {
+	val := reflect.ValueOf(metaObj).Elem()
+	if val.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.Errorf("metaObj kind was not struct")
+	}
+
+	status := val.FieldByName("Status")
+	if !status.IsValid() || status.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.Errorf("couldn't find status field on type %T", metaObj)
+	}
+
+	return status.FieldByName(name), nil
+}
+
+// GetOperatorSpec returns the operatorSpec of the given object, or nil if it doesn't have one
+func GetOperatorSpec(metaObj genruntime.MetaObject) (*genruntime.OperatorSpec, error) {
+	val := reflect.ValueOf(metaObj).Elem()
+	if val.Kind() != reflect.Struct {
+		return nil, errors.Errorf("metaObj kind was not struct")
+	}
+
+	spec := val.FieldByName("Spec")
+	if !spec.IsValid() || spec.Kind() != reflect.Struct {
+		return nil, errors.Errorf("couldn't find spec field on type %T", metaObj)
+	}
+
+	field := spec.FieldByName("OperatorSpec")
+	if !field.IsValid() {
+		return nil, nil
+	}
+
+	operatorSpec, ok := field.Interface().(*genruntime.OperatorSpec)
+	if !ok {
+		return nil, errors.Errorf("spec operatorSpec of %T was of type %s, not *genruntime.OperatorSpec", metaObj, field.Type())
+	}
+
+	return operatorSpec, nil
+}
+
 func HasStatus(metaObj genruntime.MetaObject) (bool, error) {
 	ptr := reflect.ValueOf(metaObj)
 	val := ptr.Elem()
@@ -73,7 +73,7 @@ func Test_ConvertResourceToDeployableResource(t *testing.T) {
 	g.Expect(fakeClient.Create(ctx, rg)).To(Succeed())
 	account := createDummyResource()
 	g.Expect(fakeClient.Create(ctx, account)).To(Succeed())
-	resolver := armresourceresolver.NewResolver(kubeclient.NewClient(fakeClient, s))
+	resolver := armresourceresolver.NewResolver(kubeclient.NewClient(fakeClient, s), nil)
 
 	resource, err := ConvertResourceToDeployableResource(ctx, resolver, account)
 	g.Expect(err).ToNot(HaveOccurred())
@@ -87,6 +87,15 @@ func Test_ConvertResourceToDeployableResource(t *testing.T) {
 
 }
 
+func Test_GetApiVersion_DoesNotNeedOwner(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// The owner of the account doesn't exist, as happens when it's deleted before the account
+	apiVersion, err := GetApiVersion(createDummyResource())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(apiVersion).To(Equal("apiVersion"))
+}
+
 func Test_NewStatus(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -9,6 +9,7 @@ import (
 	"os"
 
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
 	"github.com/pkg/errors"
@@ -18,13 +19,24 @@ import (
 // instantiating it requires HTTP calls
 var cachedAuthorizer autorest.Authorizer
 var cachedSubID string
+var cachedEnvironment azure.Environment
 
 func getAuthorizer() (autorest.Authorizer, string, error) {
 	if cachedAuthorizer != nil {
 		return cachedAuthorizer, cachedSubID, nil
 	}
 
-	authorizer, err := armclient.AuthorizerFromEnvironment()
+	env, err := getEnvironment()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Tests are run from developer machines as well as CI, so only use a service principal rather than
+	// probing for a managed identity
+	credentialConfig := armclient.CredentialConfigFromEnvironment()
+	credentialConfig.Chain = []armclient.CredentialSource{armclient.ClientSecretCredential}
+
+	authorizer, err := credentialConfig.Authorizer(env)
 	if err != nil {
 		return nil, "", errors.Wrapf(err, "creating authorizer")
 	}
@@ -38,3 +50,17 @@ func getAuthorizer() (autorest.Authorizer, string, error) {
 	cachedSubID = subscriptionID
 	return authorizer, subscriptionID, nil
 }
+
+func getEnvironment() (azure.Environment, error) {
+	if cachedEnvironment.Name != "" {
+		return cachedEnvironment, nil
+	}
+
+	env, err := armclient.CloudConfigFromEnvironment().Environment()
+	if err != nil {
+		return azure.Environment{}, errors.Wrapf(err, "determining cloud environment")
+	}
+
+	cachedEnvironment = env
+	return env, nil
+}
@@ -21,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	infra "github.com/Azure/k8s-infra/hack/generated/apis/infra/v1alpha1"
 	batch "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.batch/v20170901"
 	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
 	storage "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.storage/v20190401"
@@ -175,6 +176,7 @@ func CreateScheme() *runtime.Scheme {
 	_ = batch.AddToScheme(scheme)
 	_ = storage.AddToScheme(scheme)
 	_ = resources.AddToScheme(scheme)
+	_ = infra.AddToScheme(scheme)
 
 	return scheme
 }
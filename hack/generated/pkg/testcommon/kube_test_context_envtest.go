@@ -84,7 +84,8 @@ func createEnvtestContext(perTestContext PerTestContext) (*KubeBaseTestContext,
 				result := uuid.NewSHA1(uuid.Nil, []byte(perTestContext.TestName+"/"+obj.GetNamespace()+"/"+obj.GetName()))
 				return fmt.Sprintf("k8s_%s", result.String()), nil
 			},
-			RequeueDelay: requeueDelay,
+			RequeueDelay:    requeueDelay,
+			MaxRequeueDelay: requeueDelay,
 		})
 
 	if errs != nil {
@@ -62,3 +62,131 @@ func (e *OwnerNotFound) Format(s fmt.State, verb rune) {
 		_, _ = fmt.Fprintf(s, "%q", e.Error())
 	}
 }
+
+type SecretNotFound struct {
+	SecretName types.NamespacedName
+	Key        string
+	cause      error
+}
+
+func NewSecretNotFoundError(secretName types.NamespacedName, key string, cause error) *SecretNotFound {
+	return &SecretNotFound{
+		SecretName: secretName,
+		Key:        key,
+		cause:      cause,
+	}
+}
+
+var _ error = &SecretNotFound{}
+
+func (e *SecretNotFound) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("secret %s does not exist", e.SecretName)
+	}
+
+	return fmt.Sprintf("secret %s does not contain key %q", e.SecretName, e.Key)
+}
+
+func (e *SecretNotFound) Is(err error) bool {
+	var typedErr *SecretNotFound
+	if errors.As(err, &typedErr) {
+		return e.SecretName == typedErr.SecretName && e.Key == typedErr.Key
+	}
+	return false
+}
+
+func (e *SecretNotFound) Cause() error {
+	return e.cause
+}
+
+// ReferenceNotReady is returned when a resource refers to another resource which either doesn't exist or hasn't been
+// deployed to Azure yet, so its ARM ID isn't known
+type ReferenceNotReady struct {
+	ReferenceName types.NamespacedName
+	Kind          string
+	reason        string
+	cause         error
+}
+
+func NewReferenceNotReadyError(referenceName types.NamespacedName, kind string, reason string, cause error) *ReferenceNotReady {
+	return &ReferenceNotReady{
+		ReferenceName: referenceName,
+		Kind:          kind,
+		reason:        reason,
+		cause:         cause,
+	}
+}
+
+var _ error = &ReferenceNotReady{}
+
+func (e *ReferenceNotReady) Error() string {
+	return fmt.Sprintf("%s %s %s", e.Kind, e.ReferenceName, e.reason)
+}
+
+func (e *ReferenceNotReady) Is(err error) bool {
+	var typedErr *ReferenceNotReady
+	if errors.As(err, &typedErr) {
+		return e.ReferenceName == typedErr.ReferenceName && e.Kind == typedErr.Kind
+	}
+	return false
+}
+
+func (e *ReferenceNotReady) Cause() error {
+	return e.cause
+}
+
+// ReferenceNotGranted is returned when a resource refers to a resource in another namespace, either as its owner or
+// through a resource reference, without a ReferenceGrant in that namespace allowing it. It is also returned when a
+// resource refers to an ARM ID which the operator doesn't allow resources in its namespace to use.
+type ReferenceNotGranted struct {
+	From          types.NamespacedName
+	ReferenceName types.NamespacedName
+	Kind          string
+	ArmId         string
+}
+
+func NewReferenceNotGrantedError(from types.NamespacedName, referenceName types.NamespacedName, kind string) *ReferenceNotGranted {
+	return &ReferenceNotGranted{
+		From:          from,
+		ReferenceName: referenceName,
+		Kind:          kind,
+	}
+}
+
+// NewArmIdNotGrantedError creates a ReferenceNotGranted error for a reference to a resource by its ARM ID
+func NewArmIdNotGrantedError(from types.NamespacedName, armId string) *ReferenceNotGranted {
+	return &ReferenceNotGranted{
+		From:  from,
+		ArmId: armId,
+	}
+}
+
+var _ error = &ReferenceNotGranted{}
+
+func (e *ReferenceNotGranted) Error() string {
+	if e.ArmId != "" {
+		return fmt.Sprintf(
+			"%s can't refer to %s as the operator doesn't allow ARM IDs under it for namespace %s",
+			e.From,
+			e.ArmId,
+			e.From.Namespace)
+	}
+
+	return fmt.Sprintf(
+		"%s can't refer to %s %s as no ReferenceGrant in namespace %s allows it",
+		e.From,
+		e.Kind,
+		e.ReferenceName,
+		e.ReferenceName.Namespace)
+}
+
+func (e *ReferenceNotGranted) Is(err error) bool {
+	var typedErr *ReferenceNotGranted
+	if errors.As(err, &typedErr) {
+		return e.From == typedErr.From &&
+			e.ReferenceName == typedErr.ReferenceName &&
+			e.Kind == typedErr.Kind &&
+			e.ArmId == typedErr.ArmId
+	}
+	return false
+}
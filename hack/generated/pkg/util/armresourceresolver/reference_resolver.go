@@ -0,0 +1,91 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armresourceresolver
+
+import (
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+)
+
+// ResolveResourceReference returns the ARM ID of the referenced resource. A reference to a resource in Kubernetes is
+// resolved to the ARM ID that resource was deployed with, so the resource must have been successfully deployed to
+// Azure, otherwise this returns a ReferenceNotReady error. If the resource is in another namespace which doesn't grant
+// access to it, or is an ARM ID the operator doesn't allow for this namespace, this returns a ReferenceNotGranted
+// error.
+func (r *objectReferenceResolver) ResolveResourceReference(ref genruntime.ResourceReference) (string, error) {
+	err := ref.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	if ref.IsDirectArmReference() {
+		err = r.resolver.checkArmIdGrant(r.obj, ref.ArmId)
+		if err != nil {
+			return "", err
+		}
+
+		return ref.ArmId, nil
+	}
+
+	gvk, err := r.resolver.findGVK(&ref)
+	if err != nil {
+		return "", err
+	}
+
+	refName := types.NamespacedName{
+		Namespace: ref.NamespaceOrDefault(r.namespace),
+		Name:      ref.Name,
+	}
+
+	err = r.resolver.checkReferenceGrant(r.ctx, r.obj, gvk, refName)
+	if err != nil {
+		return "", err
+	}
+
+	obj, err := r.client.GetObject(r.ctx, refName, gvk)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", errors.WithStack(NewReferenceNotReadyError(refName, ref.Kind, "does not exist", err))
+		}
+
+		return "", errors.Wrapf(err, "couldn't get %s %s", ref.Kind, refName)
+	}
+
+	metaObj, ok := obj.(genruntime.MetaObject)
+	if !ok {
+		return "", errors.Errorf("%s %s (%s) was not of type genruntime.MetaObject", ref.Kind, refName, gvk)
+	}
+
+	ready, err := isReady(metaObj)
+	if err != nil {
+		return "", errors.Wrapf(err, "checking whether %s %s is ready", ref.Kind, refName)
+	}
+
+	id := metaObj.GetAnnotations()[genruntime.ResourceIdAnnotation]
+	if !ready || id == "" {
+		return "", errors.WithStack(NewReferenceNotReadyError(refName, ref.Kind, "has not been deployed to Azure yet", nil))
+	}
+
+	return id, nil
+}
+
+// isReady returns true if the given resource has a Ready condition with status True. Resources without conditions
+// are considered ready, as only the presence of their ARM ID can be checked.
+func isReady(metaObj genruntime.MetaObject) (bool, error) {
+	conditions, err := genruntime.GetConditions(metaObj)
+	if err != nil {
+		return false, err
+	}
+
+	if conditions == nil {
+		return true, nil
+	}
+
+	return genruntime.IsConditionTrue(conditions, genruntime.ConditionTypeReady), nil
+}
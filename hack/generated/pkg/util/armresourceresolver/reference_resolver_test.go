@@ -0,0 +1,179 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armresourceresolver
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infra "github.com/Azure/k8s-infra/hack/generated/apis/infra/v1alpha1"
+	resources "github.com/Azure/k8s-infra/hack/generated/apis/microsoft.resources/v20200601"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+func Test_ResolveResourceReference(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	g.Expect(resources.AddToScheme(s)).To(Succeed())
+
+	deployed := createResourceGroup("deployed")
+	deployed.Namespace = "ns"
+	deployed.Annotations = map[string]string{
+		genruntime.ResourceIdAnnotation: "/subscriptions/1234/resourceGroups/deployed",
+	}
+	deployed.Status.Conditions = []genruntime.Condition{
+		genruntime.NewCondition(genruntime.ConditionTypeReady, metav1.ConditionTrue, genruntime.ReasonSucceeded, ""),
+	}
+
+	deploying := createResourceGroup("deploying")
+	deploying.Namespace = "ns"
+	deploying.Status.Conditions = []genruntime.Condition{
+		genruntime.NewCondition(genruntime.ConditionTypeReady, metav1.ConditionFalse, genruntime.ReasonDeploying, ""),
+	}
+
+	armIds := ArmIdAllowList{"ns": {"/subscriptions/1234"}}
+	resolver := NewResolver(kubeclient.NewClient(fake.NewFakeClientWithScheme(s, deployed, deploying), s), armIds)
+	refResolver := resolver.ReferenceResolverFor(ctx, deployed)
+
+	group := resources.GroupVersion.Group
+	refTo := func(name string) genruntime.ResourceReference {
+		return genruntime.ResourceReference{Group: group, Kind: ResourceGroupKind, Name: name}
+	}
+
+	id, err := refResolver.ResolveResourceReference(refTo("deployed"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal("/subscriptions/1234/resourceGroups/deployed"))
+
+	id, err = refResolver.ResolveResourceReference(genruntime.ResourceReference{ArmId: "/subscriptions/1234/resourceGroups/elsewhere"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal("/subscriptions/1234/resourceGroups/elsewhere"))
+
+	var notReady *ReferenceNotReady
+	_, err = refResolver.ResolveResourceReference(refTo("deploying"))
+	g.Expect(errors.As(err, &notReady)).To(BeTrue())
+	g.Expect(notReady.ReferenceName.Name).To(Equal("deploying"))
+
+	_, err = refResolver.ResolveResourceReference(refTo("missing"))
+	g.Expect(errors.As(err, &notReady)).To(BeTrue())
+	g.Expect(notReady.ReferenceName.Name).To(Equal("missing"))
+
+	// A reference must either be to a resource in Kubernetes or to an ARM ID, not both
+	both := refTo("deployed")
+	both.ArmId = "/subscriptions/1234/resourceGroups/deployed"
+	_, err = refResolver.ResolveResourceReference(both)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.As(err, &notReady)).To(BeFalse())
+}
+
+func Test_ResolveResourceReference_AcrossNamespacesRequiresGrant(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	g.Expect(resources.AddToScheme(s)).To(Succeed())
+	g.Expect(infra.AddToScheme(s)).To(Succeed())
+
+	shared := createResourceGroup("shared")
+	shared.Namespace = "platform"
+	shared.Annotations = map[string]string{
+		genruntime.ResourceIdAnnotation: "/subscriptions/1234/resourceGroups/shared",
+	}
+
+	private := createResourceGroup("private")
+	private.Namespace = "platform"
+	private.Annotations = map[string]string{
+		genruntime.ResourceIdAnnotation: "/subscriptions/1234/resourceGroups/private",
+	}
+
+	group := resources.GroupVersion.Group
+	grant := &infra.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "team-a"},
+		Spec: infra.ReferenceGrantSpec{
+			From: []infra.ReferenceGrantFrom{{Namespace: "team-a", Group: group, Kind: ResourceGroupKind}},
+			To:   []infra.ReferenceGrantTo{{Group: group, Kind: ResourceGroupKind, Name: "shared"}},
+		},
+	}
+
+	resolver := NewResolver(kubeclient.NewClient(fake.NewFakeClientWithScheme(s, shared, private, grant), s), nil)
+	refTo := func(name string) genruntime.ResourceReference {
+		return genruntime.ResourceReference{Group: group, Kind: ResourceGroupKind, Name: name, Namespace: "platform"}
+	}
+
+	referrer := createResourceGroup("referrer")
+	referrer.Namespace = "team-a"
+
+	id, err := resolver.ReferenceResolverFor(ctx, referrer).ResolveResourceReference(refTo("shared"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal("/subscriptions/1234/resourceGroups/shared"))
+
+	// The grant only covers the shared resource group
+	var notGranted *ReferenceNotGranted
+	_, err = resolver.ReferenceResolverFor(ctx, referrer).ResolveResourceReference(refTo("private"))
+	g.Expect(errors.As(err, &notGranted)).To(BeTrue())
+	g.Expect(notGranted.ReferenceName.Name).To(Equal("private"))
+
+	// The grant only covers resources in the team-a namespace
+	referrer.Namespace = "team-b"
+	_, err = resolver.ReferenceResolverFor(ctx, referrer).ResolveResourceReference(refTo("shared"))
+	g.Expect(errors.As(err, &notGranted)).To(BeTrue())
+	g.Expect(notGranted.From.Namespace).To(Equal("team-b"))
+
+	// References within a namespace never need a grant
+	referrer.Namespace = "platform"
+	id, err = resolver.ReferenceResolverFor(ctx, referrer).ResolveResourceReference(refTo("private"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal("/subscriptions/1234/resourceGroups/private"))
+}
+
+func Test_ResolveResourceReference_ArmIdRequiresGrant(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	g.Expect(resources.AddToScheme(s)).To(Succeed())
+
+	armIds := ArmIdAllowList{"team-a": {"/subscriptions/1234/resourceGroups/team-a"}}
+	resolver := NewResolver(kubeclient.NewClient(fake.NewFakeClientWithScheme(s), s), armIds)
+
+	referrer := createResourceGroup("referrer")
+	referrer.Namespace = "team-a"
+	refTo := func(armId string) genruntime.ResourceReference {
+		return genruntime.ResourceReference{ArmId: armId}
+	}
+
+	allowed := "/subscriptions/1234/resourceGroups/TEAM-A/providers/Microsoft.Storage/storageAccounts/mine"
+	id, err := resolver.ReferenceResolverFor(ctx, referrer).ResolveResourceReference(refTo(allowed))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal(allowed))
+
+	// Resources outside the allowed prefix, including those whose names merely start with it, aren't allowed
+	var notGranted *ReferenceNotGranted
+	for _, armId := range []string{
+		"/subscriptions/1234/resourceGroups/team-b",
+		"/subscriptions/1234/resourceGroups/team-a-private",
+		"/subscriptions/1234/resourceGroups/team-a/../team-b",
+		"/subscriptions/5678",
+	} {
+		_, err = resolver.ReferenceResolverFor(ctx, referrer).ResolveResourceReference(refTo(armId))
+		g.Expect(errors.As(err, &notGranted)).To(BeTrue(), armId)
+		g.Expect(notGranted.ArmId).To(Equal(armId))
+	}
+
+	// The prefix is only allowed for the team-a namespace
+	referrer.Namespace = "team-b"
+	_, err = resolver.ReferenceResolverFor(ctx, referrer).ResolveResourceReference(refTo(allowed))
+	g.Expect(errors.As(err, &notGranted)).To(BeTrue())
+	g.Expect(notGranted.From.Namespace).To(Equal("team-b"))
+}
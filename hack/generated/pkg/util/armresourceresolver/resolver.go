@@ -14,7 +14,10 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
+	infra "github.com/Azure/k8s-infra/hack/generated/apis/infra/v1alpha1"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
 	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
 )
@@ -22,12 +25,18 @@ import (
 type ResourceHierarchyRoot string
 
 const (
-	ResourceHierarchyRootResourceGroup = ResourceHierarchyRoot("ResourceGroup")
-	ResourceHierarchyRootSubscription  = ResourceHierarchyRoot("Subscription")
+	ResourceHierarchyRootResourceGroup   = ResourceHierarchyRoot("ResourceGroup")
+	ResourceHierarchyRootSubscription    = ResourceHierarchyRoot("Subscription")
+	ResourceHierarchyRootManagementGroup = ResourceHierarchyRoot("ManagementGroup")
+	ResourceHierarchyRootTenant          = ResourceHierarchyRoot("Tenant")
+	ResourceHierarchyRootExtension       = ResourceHierarchyRoot("Extension")
 )
 
 // If we wanted to type-assert we'd have to solve some circular dependency problems... for now this is ok.
-const ResourceGroupKind = "ResourceGroup"
+const (
+	ResourceGroupKind   = "ResourceGroup"
+	ManagementGroupKind = "ManagementGroup"
+)
 
 type ResourceHierarchy []genruntime.MetaObject
 
@@ -43,6 +52,62 @@ func (h ResourceHierarchy) ResourceGroup() (string, error) {
 	return resourceGroup.GetName(), nil
 }
 
+// ManagementGroup returns the ID of the management group that the hierarchy is in, or an error if the hierarchy is
+// not rooted in a management group.
+func (h ResourceHierarchy) ManagementGroup() (string, error) {
+	rootKind := h.RootKind()
+	if rootKind != ResourceHierarchyRootManagementGroup {
+		return "", errors.Errorf("not rooted by a management group: %s", rootKind)
+	}
+
+	// The name of a management group in Azure is its ID
+	managementGroup := h[0]
+	return managementGroup.AzureName(), nil
+}
+
+// ExtensionScope returns the ARM ID of the resource that the extension resource at the bottom of the hierarchy is
+// applied to, or an error if the hierarchy isn't for an extension resource. Returns a ReferenceNotReady error if the
+// owner of the extension resource hasn't been deployed to Azure yet.
+func (h ResourceHierarchy) ExtensionScope() (string, error) {
+	rootKind := h.RootKind()
+	if rootKind != ResourceHierarchyRootExtension {
+		return "", errors.Errorf("not an extension resource: %s", rootKind)
+	}
+
+	// An extension resource applied to a resource by its ARM ID has no owner in Kubernetes
+	extension := h[len(h)-1]
+	if owner := extension.Owner(); owner != nil && owner.IsDirectArmReference() {
+		return owner.ArmId, nil
+	}
+
+	if len(h) < 2 {
+		return "", errors.Errorf("extension resource %s has no owner", h[0].GetName())
+	}
+
+	owner := h[len(h)-2]
+	id := owner.GetAnnotations()[genruntime.ResourceIdAnnotation]
+	if id == "" {
+		ownerName := types.NamespacedName{Namespace: owner.GetNamespace(), Name: owner.GetName()}
+		kind := owner.GetObjectKind().GroupVersionKind().Kind
+		return "", errors.WithStack(NewReferenceNotReadyError(ownerName, kind, "has not been deployed to Azure yet", nil))
+	}
+
+	return id, nil
+}
+
+// DeploymentLocation returns the location of the resource at the bottom of the hierarchy, or of the nearest resource
+// above it which has one. Deployments made outside of a resource group are stored in this location. Returns "" if
+// none of the resources has a location, as is the case for most management group and tenant resources.
+func (h ResourceHierarchy) DeploymentLocation() string {
+	for i := len(h) - 1; i >= 0; i-- {
+		if locatable, ok := h[i].(genruntime.LocatableResource); ok && locatable.Location() != "" {
+			return locatable.Location()
+		}
+	}
+
+	return ""
+}
+
 // Location returns the location root of the hierarchy, or an error
 // if the root is not a subscription.
 func (h ResourceHierarchy) Location() (string, error) {
@@ -71,10 +136,13 @@ func (h ResourceHierarchy) FullAzureName() string {
 
 	var resources ResourceHierarchy
 	switch rootKind {
-	case ResourceHierarchyRootResourceGroup:
+	case ResourceHierarchyRootResourceGroup, ResourceHierarchyRootManagementGroup:
 		resources = h[1:]
-	case ResourceHierarchyRootSubscription:
+	case ResourceHierarchyRootSubscription, ResourceHierarchyRootTenant:
 		resources = h
+	case ResourceHierarchyRootExtension:
+		// Extension resources are deployed onto their owner by its ARM ID, so only need their own name
+		resources = h[len(h)-1:]
 	default:
 		panic(fmt.Sprintf("unknown root kind: %s", rootKind))
 	}
@@ -87,14 +155,24 @@ func (h ResourceHierarchy) FullAzureName() string {
 }
 
 func (h ResourceHierarchy) RootKind() ResourceHierarchyRoot {
-	// There are 3 cases here:
+	// There are 6 cases here:
+	// 0. The resource at the bottom of the hierarchy declares that it's an extension resource. This is extension
+	//    rooted, whatever its owner is.
 	// 1. The hierarchy is comprised solely of a resource group. This is subscription rooted.
-	// 1. The hierarchy has multiple entries and roots up to a resource group. This is RG rooted.
-	// 2. The hierarchy has multiple entries and doesn't root up to a resource group. This is subscription rooted.
+	// 2. The hierarchy has multiple entries and roots up to a resource group. This is RG rooted.
+	// 3. The hierarchy is comprised solely of a management group, or roots up to a resource which declares that it's
+	//    deployed at tenant scope. This is tenant rooted.
+	// 4. The hierarchy has multiple entries and roots up to a management group. This is management group rooted.
+	// 5. Anything else is subscription rooted.
 
 	if len(h) == 0 {
 		panic("resource hierarchy cannot be len 0")
 	}
+
+	if scoped, ok := h[len(h)-1].(genruntime.ScopedResource); ok && scoped.Scope() == genruntime.ResourceScopeExtension {
+		return ResourceHierarchyRootExtension
+	}
+
 	gvk := h[0].GetObjectKind().GroupVersionKind()
 	if gvk.Kind == ResourceGroupKind {
 		if len(h) == 1 { // Just resource group
@@ -103,16 +181,77 @@ func (h ResourceHierarchy) RootKind() ResourceHierarchyRoot {
 		return ResourceHierarchyRootResourceGroup
 	}
 
+	if gvk.Kind == ManagementGroupKind {
+		if len(h) == 1 { // Just management group
+			return ResourceHierarchyRootTenant
+		}
+		return ResourceHierarchyRootManagementGroup
+	}
+
+	if scoped, ok := h[0].(genruntime.ScopedResource); ok && scoped.Scope() == genruntime.ResourceScopeTenant {
+		return ResourceHierarchyRootTenant
+	}
+
 	return ResourceHierarchyRootSubscription
 }
 
+// ArmIdAllowList maps each namespace to the ARM ID prefixes, such as a subscription or resource group, under which
+// resources in that namespace may refer to Azure resources directly by ARM ID. References by ARM ID bypass
+// ReferenceGrants, so without this any tenant could attach resources to anything the operator can reach.
+type ArmIdAllowList map[string][]string
+
+// ParseArmIdAllowList parses a comma separated list of namespace=prefix pairs. A namespace may be listed more than
+// once to allow it several prefixes.
+func ParseArmIdAllowList(value string) (ArmIdAllowList, error) {
+	result := make(ArmIdAllowList)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || !strings.HasPrefix(parts[1], "/") {
+			return nil, errors.Errorf("ARM ID prefix %q must be of the form namespace=/subscriptions/...", item)
+		}
+
+		result[parts[0]] = append(result[parts[0]], parts[1])
+	}
+
+	return result, nil
+}
+
+// Allows returns true if resources in the given namespace may refer to the given ARM ID. ARM IDs are compared
+// case-insensitively, a segment at a time, so /subscriptions/12 doesn't allow /subscriptions/1234.
+func (l ArmIdAllowList) Allows(namespace string, armId string) bool {
+	id := strings.ToLower(armId)
+	for _, segment := range strings.Split(id, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+
+	for _, allowed := range l[namespace] {
+		prefix := strings.TrimSuffix(strings.ToLower(allowed), "/")
+		if id == prefix || strings.HasPrefix(id, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
 type Resolver struct {
 	client *kubeclient.Client
+	armIds ArmIdAllowList
 }
 
-func NewResolver(client *kubeclient.Client) *Resolver {
+// NewResolver creates a new Resolver. Resources may only refer directly to the ARM IDs allowed for their namespace
+// by armIds.
+func NewResolver(client *kubeclient.Client, armIds ArmIdAllowList) *Resolver {
 	return &Resolver{
 		client: client,
+		armIds: armIds,
 	}
 }
 
@@ -120,16 +259,15 @@ func NewResolver(client *kubeclient.Client) *Resolver {
 // resources, with the uppermost parent at position 0 and the resource itself at position len(slice)-1
 func (r *Resolver) ResolveResourceHierarchy(ctx context.Context, obj genruntime.MetaObject) (ResourceHierarchy, error) {
 
-	owner := obj.Owner()
-	if owner == nil {
-		return ResourceHierarchy{obj}, nil
-	}
-
 	ownerMeta, err := r.GetOwner(ctx, obj)
 	if err != nil {
 		return nil, err
 	}
 
+	if ownerMeta == nil {
+		return ResourceHierarchy{obj}, nil
+	}
+
 	owners, err := r.ResolveResourceHierarchy(ctx, ownerMeta)
 	if err != nil {
 		return nil, errors.Wrapf(err, "getting owners for %s", ownerMeta.GetName())
@@ -140,7 +278,9 @@ func (r *Resolver) ResolveResourceHierarchy(ctx context.Context, obj genruntime.
 
 // GetOwner returns the MetaObject for the given resources owner. If the resource is supposed to have
 // an owner but doesn't, this returns an OwnerNotFound error. If the resource is not supposed
-// to have an owner (for example, ResourceGroup), returns nil.
+// to have an owner (for example, ResourceGroup), or its owner is given by ARM ID rather than being a resource
+// in Kubernetes, returns nil. If the owner is in another namespace which doesn't grant the resource access, or
+// is an ARM ID the resource's namespace isn't allowed to use, this returns a ReferenceNotGranted error.
 func (r *Resolver) GetOwner(ctx context.Context, obj genruntime.MetaObject) (genruntime.MetaObject, error) {
 	owner := obj.Owner()
 
@@ -148,19 +288,33 @@ func (r *Resolver) GetOwner(ctx context.Context, obj genruntime.MetaObject) (gen
 		return nil, nil
 	}
 
+	err := owner.Validate()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid owner of %s", obj.GetName())
+	}
+
+	if owner.IsDirectArmReference() {
+		return nil, r.checkArmIdGrant(obj, owner.ArmId)
+	}
+
 	ownerGvk, err := r.findGVK(owner)
 	if err != nil {
 		return nil, err
 	}
 
-	// Kubernetes doesn't support cross-namespace ownership, and all Azure resources are
-	// namespaced, so it should be safe to assume that the owner is in the same namespace as
-	// obj. See https://kubernetes.io/docs/concepts/workloads/controllers/garbage-collection/
+	// Owners are in the same namespace as obj unless another namespace is given explicitly. Kubernetes doesn't
+	// support cross-namespace ownership, so owners in other namespaces are only used to locate the resource in Azure.
+	// See https://kubernetes.io/docs/concepts/workloads/controllers/garbage-collection/
 	ownerNamespacedName := types.NamespacedName{
-		Namespace: obj.GetNamespace(),
+		Namespace: owner.NamespaceOrDefault(obj.GetNamespace()),
 		Name:      owner.Name,
 	}
 
+	err = r.checkReferenceGrant(ctx, obj, ownerGvk, ownerNamespacedName)
+	if err != nil {
+		return nil, err
+	}
+
 	ownerObj, err := r.client.GetObject(ctx, ownerNamespacedName, ownerGvk)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -179,25 +333,69 @@ func (r *Resolver) GetOwner(ctx context.Context, obj genruntime.MetaObject) (gen
 	return ownerMeta, nil
 }
 
-func (r *Resolver) findGVK(owner *genruntime.ResourceReference) (schema.GroupVersionKind, error) {
-	var ownerGvk schema.GroupVersionKind
+// checkReferenceGrant returns a ReferenceNotGranted error unless obj may refer to the resource of kind refGvk called
+// refName. References within a namespace are always allowed, while references to another namespace need a
+// ReferenceGrant in that namespace allowing them.
+func (r *Resolver) checkReferenceGrant(
+	ctx context.Context,
+	obj genruntime.MetaObject,
+	refGvk schema.GroupVersionKind,
+	refName types.NamespacedName) error {
+
+	if refName.Namespace == obj.GetNamespace() {
+		return nil
+	}
+
+	objGvk, err := apiutil.GVKForObject(obj, r.client.Scheme)
+	if err != nil {
+		return errors.Wrapf(err, "finding kind of %s", obj.GetName())
+	}
+
+	var grants infra.ReferenceGrantList
+	err = r.client.Client.List(ctx, &grants, client.InNamespace(refName.Namespace))
+	if err != nil {
+		return errors.Wrapf(err, "listing ReferenceGrants in namespace %s", refName.Namespace)
+	}
+
+	for i := range grants.Items {
+		if grants.Items[i].Allows(obj.GetNamespace(), objGvk.GroupKind(), refGvk.GroupKind(), refName.Name) {
+			return nil
+		}
+	}
+
+	from := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	return errors.WithStack(NewReferenceNotGrantedError(from, refName, refGvk.Kind))
+}
+
+// checkArmIdGrant returns a ReferenceNotGranted error unless obj may refer directly to the given ARM ID
+func (r *Resolver) checkArmIdGrant(obj genruntime.MetaObject, armId string) error {
+	if r.armIds.Allows(obj.GetNamespace(), armId) {
+		return nil
+	}
+
+	from := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	return errors.WithStack(NewArmIdNotGrantedError(from, armId))
+}
+
+func (r *Resolver) findGVK(ref *genruntime.ResourceReference) (schema.GroupVersionKind, error) {
+	var refGvk schema.GroupVersionKind
 	found := false
 	// TODO: We need to find the specific storage version GVK...
 	for gvk := range r.client.Scheme.AllKnownTypes() {
-		if gvk.Group == owner.Group && gvk.Kind == owner.Kind {
+		if gvk.Group == ref.Group && gvk.Kind == ref.Kind {
 			if !found {
-				ownerGvk = gvk
+				refGvk = gvk
 				found = true
 			} else {
-				return ownerGvk, errors.Errorf("owner group: %s, kind: %s has multiple possible schemes registered", owner.Group, owner.Kind)
+				return refGvk, errors.Errorf("group: %s, kind: %s has multiple possible schemes registered", ref.Group, ref.Kind)
 			}
 		}
 	}
 
 	// TODO: We should do this on process launch probably since we can check based on the AllKnownTypes() collection
 	if !found {
-		return ownerGvk, errors.Errorf("couldn't find owner %+v in scheme", owner)
+		return refGvk, errors.Errorf("couldn't find %+v in scheme", ref)
 	}
 
-	return ownerGvk, nil
+	return refGvk, nil
 }
@@ -26,7 +26,7 @@ import (
 
 func NewTestResolver(s *runtime.Scheme) *Resolver {
 	fakeClient := fake.NewFakeClientWithScheme(s)
-	return NewResolver(kubeclient.NewClient(fakeClient, s))
+	return NewResolver(kubeclient.NewClient(fakeClient, s), nil)
 }
 
 func createResourceGroup(name string) *resources.ResourceGroup {
@@ -247,3 +247,129 @@ func Test_ResourceHierarchy_ResourceGroup_NestedResource(t *testing.T) {
 	g.Expect(rg).To(Equal(resourceGroupName))
 	g.Expect(hierarchy.FullAzureName()).To(Equal(fmt.Sprintf("%s/%s", hierarchy[1].AzureName(), hierarchy[2].AzureName())))
 }
+
+func createManagementGroup(name string) *resources.ResourceGroup {
+	// There's no management group resource yet, but only the kind of the root of the hierarchy matters
+	mg := createResourceGroup(name)
+	mg.Kind = ManagementGroupKind
+	mg.Spec.Location = ""
+	return mg
+}
+
+func Test_ResourceHierarchy_ManagementGroupOnly(t *testing.T) {
+	g := NewWithT(t)
+
+	managementGroupName := "mymg"
+
+	hierarchy := ResourceHierarchy{createManagementGroup(managementGroupName)}
+
+	g.Expect(hierarchy.RootKind()).To(Equal(ResourceHierarchyRootTenant))
+	g.Expect(hierarchy.FullAzureName()).To(Equal(managementGroupName))
+	g.Expect(hierarchy.DeploymentLocation()).To(BeEmpty())
+
+	// This is expected to fail
+	_, err := hierarchy.ManagementGroup()
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_ResourceHierarchy_ManagementGroup_TopLevelResource(t *testing.T) {
+	g := NewWithT(t)
+
+	managementGroupName := "mymg"
+	name := "myresource"
+
+	child := createResourceGroup(name)
+	child.Spec.Location = "westus"
+	hierarchy := ResourceHierarchy{createManagementGroup(managementGroupName), child}
+
+	g.Expect(hierarchy.RootKind()).To(Equal(ResourceHierarchyRootManagementGroup))
+
+	mg, err := hierarchy.ManagementGroup()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mg).To(Equal(managementGroupName))
+	g.Expect(hierarchy.FullAzureName()).To(Equal(name))
+	g.Expect(hierarchy.DeploymentLocation()).To(Equal("westus"))
+}
+
+// extensionResource is a resource which declares that it's an extension resource. There's no generated extension
+// resource yet, but only the scope declared by the resource matters.
+type extensionResource struct {
+	*resources.ResourceGroup
+}
+
+func (extensionResource) Scope() genruntime.ResourceScope {
+	return genruntime.ResourceScopeExtension
+}
+
+func Test_ResourceHierarchy_ExtensionResource(t *testing.T) {
+	g := NewWithT(t)
+
+	resourceGroupName := "myrg"
+	resourceName := "myresource"
+	lockName := "mylock"
+
+	rg, owner := createResourceGroupRootedResource(resourceGroupName, resourceName)
+	lock := extensionResource{createResourceGroup(lockName)}
+	hierarchy := ResourceHierarchy{rg, owner, lock}
+
+	g.Expect(hierarchy.RootKind()).To(Equal(ResourceHierarchyRootExtension))
+	g.Expect(hierarchy.FullAzureName()).To(Equal(lockName))
+
+	// The scope isn't known until the owner has been deployed
+	var notReady *ReferenceNotReady
+	_, err := hierarchy.ExtensionScope()
+	g.Expect(errors.As(err, &notReady)).To(BeTrue())
+	g.Expect(notReady.ReferenceName.Name).To(Equal(resourceName))
+
+	ownerId := "/subscriptions/1234/resourceGroups/myrg/providers/Microsoft.Batch/batchAccounts/myresource"
+	owner.SetAnnotations(map[string]string{genruntime.ResourceIdAnnotation: ownerId})
+
+	scope, err := hierarchy.ExtensionScope()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(scope).To(Equal(ownerId))
+
+	// This is expected to fail
+	_, err = hierarchy.ResourceGroup()
+	g.Expect(err).To(HaveOccurred())
+}
+
+// armScopedExtensionResource is an extension resource applied to a resource by its ARM ID
+type armScopedExtensionResource struct {
+	extensionResource
+	scope string
+}
+
+func (r armScopedExtensionResource) Owner() *genruntime.ResourceReference {
+	return &genruntime.ResourceReference{ArmId: r.scope}
+}
+
+func Test_ResourceHierarchy_ExtensionResourceWithArmIdScope(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	subscriptionId := "/subscriptions/1234"
+	lock := armScopedExtensionResource{
+		extensionResource: extensionResource{createResourceGroup("mylock")},
+		scope:             subscriptionId,
+	}
+
+	// Resources may only be applied to ARM IDs the operator allows for their namespace
+	var notGranted *ReferenceNotGranted
+	_, err := NewTestResolver(runtime.NewScheme()).ResolveResourceHierarchy(ctx, lock)
+	g.Expect(errors.As(err, &notGranted)).To(BeTrue())
+	g.Expect(notGranted.ArmId).To(Equal(subscriptionId))
+
+	// The scope isn't in Kubernetes, so nothing needs to be looked up
+	armIds := ArmIdAllowList{lock.GetNamespace(): {subscriptionId}}
+	resolver := NewResolver(kubeclient.NewClient(fake.NewFakeClientWithScheme(runtime.NewScheme()), runtime.NewScheme()), armIds)
+	hierarchy, err := resolver.ResolveResourceHierarchy(ctx, lock)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(hierarchy).To(HaveLen(1))
+
+	g.Expect(hierarchy.RootKind()).To(Equal(ResourceHierarchyRootExtension))
+	g.Expect(hierarchy.FullAzureName()).To(Equal("mylock"))
+
+	scope, err := hierarchy.ExtensionScope()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(scope).To(Equal(subscriptionId))
+}
@@ -0,0 +1,67 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armresourceresolver
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+// objectReferenceResolver resolves the references made by a single resource
+type objectReferenceResolver struct {
+	ctx       context.Context
+	resolver  *Resolver
+	client    *kubeclient.Client
+	obj       genruntime.MetaObject
+	namespace string
+}
+
+var _ genruntime.ReferenceResolver = &objectReferenceResolver{}
+
+// ReferenceResolverFor returns a genruntime.ReferenceResolver for the references made by obj, for use while
+// converting obj to ARM
+func (r *Resolver) ReferenceResolverFor(ctx context.Context, obj genruntime.MetaObject) genruntime.ReferenceResolver {
+	return &objectReferenceResolver{
+		ctx:       ctx,
+		resolver:  r,
+		client:    r.client,
+		obj:       obj,
+		namespace: obj.GetNamespace(),
+	}
+}
+
+// ResolveSecret returns the value held in the referenced secret, which must be in the same namespace as the resource.
+// If the secret or the key within it doesn't exist, this returns a SecretNotFound error.
+func (r *objectReferenceResolver) ResolveSecret(ref genruntime.SecretReference) (string, error) {
+	secretName := types.NamespacedName{
+		Namespace: r.namespace,
+		Name:      ref.Name,
+	}
+
+	var secret v1.Secret
+	err := r.client.Client.Get(r.ctx, secretName, &secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", errors.WithStack(NewSecretNotFoundError(secretName, ref.Key, err))
+		}
+
+		return "", errors.Wrapf(err, "couldn't get secret %s", secretName)
+	}
+
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", errors.WithStack(NewSecretNotFoundError(secretName, ref.Key, nil))
+	}
+
+	return string(value), nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package armresourceresolver
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/genruntime"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+func Test_ResolveSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "creds"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	resolver := NewResolver(kubeclient.NewClient(fake.NewFakeClientWithScheme(s, secret), s), nil)
+
+	rg := createResourceGroup("rg")
+	rg.Namespace = "ns"
+	refResolver := resolver.ReferenceResolverFor(ctx, rg)
+
+	value, err := refResolver.ResolveSecret(genruntime.SecretReference{Name: "creds", Key: "password"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(value).To(Equal("hunter2"))
+
+	var notFound *SecretNotFound
+	_, err = refResolver.ResolveSecret(genruntime.SecretReference{Name: "creds", Key: "username"})
+	g.Expect(errors.As(err, &notFound)).To(BeTrue())
+	g.Expect(notFound.Key).To(Equal("username"))
+
+	_, err = refResolver.ResolveSecret(genruntime.SecretReference{Name: "missing", Key: "password"})
+	g.Expect(errors.As(err, &notFound)).To(BeTrue())
+	g.Expect(notFound.SecretName.Name).To(Equal("missing"))
+
+	// Secrets are only looked up in the namespace of the resource
+	rg.Namespace = "other"
+	_, err = resolver.ReferenceResolverFor(ctx, rg).ResolveSecret(genruntime.SecretReference{Name: "creds", Key: "password"})
+	g.Expect(errors.As(err, &notFound)).To(BeTrue())
+}
@@ -0,0 +1,314 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package credentialresolver
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+const (
+	// CredentialFromAnnotation is the annotation, on either a resource or its namespace, containing the name of
+	// the Secret holding the credentials to use for that resource. The Secret must be in the same namespace as
+	// the resource. An annotation on the resource takes precedence over one on the namespace.
+	CredentialFromAnnotation = "infra.azure.com/credential-from"
+
+	// ManagedIdentityClientIDKey is the key of the client ID of a user assigned managed identity within
+	// a credential Secret
+	ManagedIdentityClientIDKey = armclient.ManagedIdentityClientIDVar
+)
+
+// Credential is the subscription and identity used to manage a set of resources
+type Credential struct {
+	// SecretName is the Secret this credential was read from
+	SecretName types.NamespacedName
+	// SubscriptionID is the subscription the resources are deployed to
+	SubscriptionID string
+	// CredentialConfig describes how to authenticate
+	CredentialConfig armclient.CredentialConfig
+}
+
+// ApplierFactory creates an Applier for the given credential
+type ApplierFactory func(credential Credential) (armclient.Applier, error)
+
+// ManagedIdentityAllowList maps each namespace to the client IDs of the managed identities attached to the operator
+// which credential Secrets in that namespace may use. Managed identities aren't secret, so without this any tenant
+// could act as any identity attached to the operator.
+type ManagedIdentityAllowList map[string][]string
+
+// ParseManagedIdentityAllowList parses a comma separated list of namespace=clientID pairs. A namespace may be
+// listed more than once to allow it several identities.
+func ParseManagedIdentityAllowList(value string) (ManagedIdentityAllowList, error) {
+	result := make(ManagedIdentityAllowList)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("managed identity %q must be of the form namespace=clientID", item)
+		}
+
+		result[parts[0]] = append(result[parts[0]], parts[1])
+	}
+
+	return result, nil
+}
+
+// Allows returns true if credential Secrets in the given namespace may use the managed identity with the given
+// client ID
+func (l ManagedIdentityAllowList) Allows(namespace string, clientID string) bool {
+	for _, allowed := range l[namespace] {
+		if strings.EqualFold(allowed, clientID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type cachedApplier struct {
+	resourceVersion string
+	applier         armclient.Applier
+}
+
+// Resolver determines the Applier to use for a resource, based on the credential Secret referenced by
+// the resource or its namespace. Appliers are cached per Secret and recreated when the Secret changes.
+type Resolver struct {
+	client         *kubeclient.Client
+	defaultApplier armclient.Applier
+	newApplier     ApplierFactory
+	identities     ManagedIdentityAllowList
+
+	lock     sync.Mutex
+	appliers map[types.NamespacedName]cachedApplier
+}
+
+// NewResolver creates a new Resolver. Resources without a credential annotation use the default applier. Credential
+// Secrets may only use the managed identities allowed for their namespace by identities.
+func NewResolver(
+	client *kubeclient.Client,
+	defaultApplier armclient.Applier,
+	newApplier ApplierFactory,
+	identities ManagedIdentityAllowList) *Resolver {
+	return &Resolver{
+		client:         client,
+		defaultApplier: defaultApplier,
+		newApplier:     newApplier,
+		identities:     identities,
+		appliers:       make(map[types.NamespacedName]cachedApplier),
+	}
+}
+
+// ResolveApplier returns the Applier to use when reconciling the given resource
+func (r *Resolver) ResolveApplier(ctx context.Context, obj metav1.Object) (armclient.Applier, error) {
+	secretName, err := r.credentialSecretName(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if secretName == nil {
+		return r.defaultApplier, nil
+	}
+
+	var secret v1.Secret
+	err = r.client.Client.Get(ctx, *secretName, &secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.Forget(*secretName)
+		}
+
+		return nil, errors.Wrapf(err, "getting credential secret %s", *secretName)
+	}
+
+	if r.newApplier == nil {
+		return nil, errors.Errorf("resource %s/%s requests credentials from secret %s but per-namespace credentials are not enabled",
+			obj.GetNamespace(),
+			obj.GetName(),
+			*secretName)
+	}
+
+	if applier, ok := r.cachedApplier(*secretName, secret.ResourceVersion); ok {
+		return applier, nil
+	}
+
+	// Either we've not seen this secret before or it has been rotated. Creating the applier may fetch a token, so
+	// it's done without holding the lock to avoid blocking the resolution of other credentials.
+	credential, err := CredentialFromSecret(&secret, r.identities)
+	if err != nil {
+		return nil, err
+	}
+
+	applier, err := r.newApplier(credential)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating ARM client for credential secret %s", *secretName)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Another reconcile may have created an applier for the same version of the secret in the meantime; if so, use
+	// that one so that each set of credentials has a single ARM client
+	if cached, ok := r.appliers[*secretName]; ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.applier, nil
+	}
+
+	r.appliers[*secretName] = cachedApplier{
+		resourceVersion: secret.ResourceVersion,
+		applier:         applier,
+	}
+
+	return applier, nil
+}
+
+// cachedApplier returns the cached Applier for the given version of a Secret, if there is one
+func (r *Resolver) cachedApplier(secretName types.NamespacedName, resourceVersion string) (armclient.Applier, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cached, ok := r.appliers[secretName]
+	if !ok || cached.resourceVersion != resourceVersion {
+		return nil, false
+	}
+
+	return cached.applier, true
+}
+
+// Forget removes any cached Applier for the given Secret
+func (r *Resolver) Forget(secretName types.NamespacedName) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.appliers, secretName)
+}
+
+// ForgetOnChange removes the cached Applier for a Secret whenever that Secret is changed or deleted, so that stale
+// credentials aren't kept around until the next time they're needed
+func (r *Resolver) ForgetOnChange(ctx context.Context, informers cache.Informers) error {
+	informer, err := informers.GetInformer(ctx, &v1.Secret{})
+	if err != nil {
+		return errors.Wrap(err, "getting secret informer")
+	}
+
+	forget := func(obj interface{}) {
+		if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+
+		if secret, ok := obj.(*v1.Secret); ok {
+			r.Forget(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name})
+		}
+	}
+
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Resyncs deliver updates for unchanged secrets, which don't need to be forgotten
+			oldSecret, oldOk := oldObj.(*v1.Secret)
+			newSecret, newOk := newObj.(*v1.Secret)
+			if oldOk && newOk && oldSecret.ResourceVersion == newSecret.ResourceVersion {
+				return
+			}
+
+			forget(newObj)
+		},
+		DeleteFunc: forget,
+	})
+
+	return nil
+}
+
+// credentialSecretName returns the name of the credential Secret for the given resource, or nil if the
+// resource uses the default credentials
+func (r *Resolver) credentialSecretName(ctx context.Context, obj metav1.Object) (*types.NamespacedName, error) {
+	if name, ok := obj.GetAnnotations()[CredentialFromAnnotation]; ok && name != "" {
+		return &types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}, nil
+	}
+
+	var namespace v1.Namespace
+	err := r.client.Client.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, &namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting namespace %s", obj.GetNamespace())
+	}
+
+	if name, ok := namespace.GetAnnotations()[CredentialFromAnnotation]; ok && name != "" {
+		return &types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}, nil
+	}
+
+	return nil, nil
+}
+
+// CredentialFromSecret reads a Credential from a Secret. The Secret uses the same keys as the standard Azure
+// environment variables: AZURE_SUBSCRIPTION_ID is required, along with either AZURE_TENANT_ID, AZURE_CLIENT_ID
+// and AZURE_CLIENT_SECRET for a service principal or AZURE_MANAGED_IDENTITY_CLIENT_ID for a user assigned
+// managed identity. A managed identity is only accepted if identities allows it for the Secret's namespace.
+func CredentialFromSecret(secret *v1.Secret, identities ManagedIdentityAllowList) (Credential, error) {
+	secretName := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+
+	value := func(key string) string {
+		return string(secret.Data[key])
+	}
+
+	result := Credential{
+		SecretName:     secretName,
+		SubscriptionID: value(auth.SubscriptionID),
+		CredentialConfig: armclient.CredentialConfig{
+			TenantID:                value(auth.TenantID),
+			ClientID:                value(auth.ClientID),
+			ClientSecret:            value(auth.ClientSecret),
+			ManagedIdentityClientID: value(ManagedIdentityClientIDKey),
+		},
+	}
+
+	if result.SubscriptionID == "" {
+		return Credential{}, errors.Errorf("credential secret %s is missing %s", secretName, auth.SubscriptionID)
+	}
+
+	// Only ever use the identity described by the secret, never fall back to the operator's own identity
+	switch {
+	case result.CredentialConfig.ClientSecret != "":
+		if result.CredentialConfig.TenantID == "" || result.CredentialConfig.ClientID == "" {
+			return Credential{}, errors.Errorf(
+				"credential secret %s must specify %s and %s alongside %s",
+				secretName,
+				auth.TenantID,
+				auth.ClientID,
+				auth.ClientSecret)
+		}
+		result.CredentialConfig.Chain = []armclient.CredentialSource{armclient.ClientSecretCredential}
+	case result.CredentialConfig.ManagedIdentityClientID != "":
+		if !identities.Allows(secret.Namespace, result.CredentialConfig.ManagedIdentityClientID) {
+			return Credential{}, errors.Errorf(
+				"credential secret %s requests managed identity %s, which is not allowed for namespace %s",
+				secretName,
+				result.CredentialConfig.ManagedIdentityClientID,
+				secret.Namespace)
+		}
+		result.CredentialConfig.Chain = []armclient.CredentialSource{armclient.ManagedIdentityCredential}
+	default:
+		return Credential{}, errors.Errorf(
+			"credential secret %s must specify either %s or %s",
+			secretName,
+			auth.ClientSecret,
+			ManagedIdentityClientIDKey)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,268 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package credentialresolver
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Azure/k8s-infra/hack/generated/pkg/armclient"
+	"github.com/Azure/k8s-infra/hack/generated/pkg/util/kubeclient"
+)
+
+const testNamespace = "team-a"
+
+type fakeApplier struct {
+	armclient.Applier
+	subscriptionID string
+}
+
+func (f *fakeApplier) SubscriptionID() string {
+	return f.subscriptionID
+}
+
+func newTestResolver(objs ...runtime.Object) (*Resolver, *int) {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	fakeClient := fake.NewFakeClientWithScheme(s, objs...)
+
+	created := 0
+	factory := func(credential Credential) (armclient.Applier, error) {
+		created++
+		return &fakeApplier{subscriptionID: credential.SubscriptionID}, nil
+	}
+
+	identities := ManagedIdentityAllowList{testNamespace: {"identity"}}
+	return NewResolver(kubeclient.NewClient(fakeClient, s), &fakeApplier{subscriptionID: "default"}, factory, identities), &created
+}
+
+func newNamespace(credentialSecret string) *v1.Namespace {
+	ns := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testNamespace,
+		},
+	}
+
+	if credentialSecret != "" {
+		ns.Annotations = map[string]string{CredentialFromAnnotation: credentialSecret}
+	}
+
+	return ns
+}
+
+func newCredentialSecret(name string, subscriptionID string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Data: map[string][]byte{
+			"AZURE_SUBSCRIPTION_ID": []byte(subscriptionID),
+			"AZURE_TENANT_ID":       []byte("tenant"),
+			"AZURE_CLIENT_ID":       []byte("client"),
+			"AZURE_CLIENT_SECRET":   []byte("secret"),
+		},
+	}
+}
+
+func newResource(credentialSecret string) metav1.Object {
+	obj := &metav1.ObjectMeta{
+		Name:      "myresource",
+		Namespace: testNamespace,
+	}
+
+	if credentialSecret != "" {
+		obj.Annotations = map[string]string{CredentialFromAnnotation: credentialSecret}
+	}
+
+	return obj
+}
+
+func Test_ResolveApplier_NoAnnotation_UsesDefault(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	resolver, created := newTestResolver(newNamespace(""))
+
+	applier, err := resolver.ResolveApplier(ctx, newResource(""))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier.SubscriptionID()).To(Equal("default"))
+	g.Expect(*created).To(Equal(0))
+}
+
+func Test_ResolveApplier_NamespaceAnnotation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	resolver, created := newTestResolver(
+		newNamespace("team-credentials"),
+		newCredentialSecret("team-credentials", "team-sub"))
+
+	applier, err := resolver.ResolveApplier(ctx, newResource(""))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier.SubscriptionID()).To(Equal("team-sub"))
+
+	// The applier is cached
+	again, err := resolver.ResolveApplier(ctx, newResource(""))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(again).To(BeIdenticalTo(applier))
+	g.Expect(*created).To(Equal(1))
+}
+
+func Test_ResolveApplier_ResourceAnnotationTakesPrecedence(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	resolver, _ := newTestResolver(
+		newNamespace("team-credentials"),
+		newCredentialSecret("team-credentials", "team-sub"),
+		newCredentialSecret("resource-credentials", "resource-sub"))
+
+	applier, err := resolver.ResolveApplier(ctx, newResource("resource-credentials"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier.SubscriptionID()).To(Equal("resource-sub"))
+}
+
+func Test_ResolveApplier_SecretRotated_RecreatesApplier(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	secret := newCredentialSecret("team-credentials", "team-sub")
+	resolver, created := newTestResolver(newNamespace("team-credentials"), secret)
+
+	_, err := resolver.ResolveApplier(ctx, newResource(""))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Rotate the secret
+	g.Expect(resolver.client.Client.Get(ctx, types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, secret)).To(Succeed())
+	secret.Data["AZURE_SUBSCRIPTION_ID"] = []byte("new-sub")
+	g.Expect(resolver.client.Client.Update(ctx, secret)).To(Succeed())
+
+	applier, err := resolver.ResolveApplier(ctx, newResource(""))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applier.SubscriptionID()).To(Equal("new-sub"))
+	g.Expect(*created).To(Equal(2))
+}
+
+func Test_ForgetOnChange_ForgetsChangedAndDeletedSecrets(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	secret := newCredentialSecret("team-credentials", "team-sub")
+	secret.ResourceVersion = "1"
+	resolver, _ := newTestResolver(newNamespace("team-credentials"), secret)
+	secretName := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+
+	informers := &informertest.FakeInformers{}
+	g.Expect(resolver.ForgetOnChange(ctx, informers)).To(Succeed())
+	informer, err := informers.FakeInformerFor(&v1.Secret{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	resolve := func() {
+		_, err := resolver.ResolveApplier(ctx, newResource(""))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(resolver.appliers).To(HaveKey(secretName))
+	}
+
+	// A resync doesn't change the secret
+	resolve()
+	informer.Update(secret, secret)
+	g.Expect(resolver.appliers).To(HaveKey(secretName))
+
+	changed := secret.DeepCopy()
+	changed.ResourceVersion = "2"
+	informer.Update(secret, changed)
+	g.Expect(resolver.appliers).ToNot(HaveKey(secretName))
+
+	resolve()
+	informer.Delete(secret)
+	g.Expect(resolver.appliers).ToNot(HaveKey(secretName))
+}
+
+func Test_ResolveApplier_MissingSecret_IsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	resolver, _ := newTestResolver(newNamespace("team-credentials"))
+
+	_, err := resolver.ResolveApplier(ctx, newResource(""))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_CredentialFromSecret_RequiresIdentity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	secret := newCredentialSecret("team-credentials", "team-sub")
+	delete(secret.Data, "AZURE_CLIENT_SECRET")
+
+	identities := ManagedIdentityAllowList{testNamespace: {"identity"}}
+
+	_, err := CredentialFromSecret(secret, identities)
+	g.Expect(err).To(HaveOccurred())
+
+	secret.Data[ManagedIdentityClientIDKey] = []byte("identity")
+	credential, err := CredentialFromSecret(secret, identities)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(credential.CredentialConfig.Chain).To(Equal([]armclient.CredentialSource{armclient.ManagedIdentityCredential}))
+}
+
+func Test_CredentialFromSecret_ManagedIdentityNotAllowed_IsRejected(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	secret := newCredentialSecret("team-credentials", "team-sub")
+	delete(secret.Data, "AZURE_CLIENT_SECRET")
+	secret.Data[ManagedIdentityClientIDKey] = []byte("operator-identity")
+
+	// Not listed at all
+	_, err := CredentialFromSecret(secret, nil)
+	g.Expect(err).To(HaveOccurred())
+
+	// Listed, but only for another namespace
+	_, err = CredentialFromSecret(secret, ManagedIdentityAllowList{"team-b": {"operator-identity"}})
+	g.Expect(err).To(HaveOccurred())
+
+	// Namespace listed, but with another identity
+	_, err = CredentialFromSecret(secret, ManagedIdentityAllowList{testNamespace: {"identity"}})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_ResolveApplier_ManagedIdentityNotAllowed_IsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	secret := newCredentialSecret("team-credentials", "team-sub")
+	delete(secret.Data, "AZURE_CLIENT_SECRET")
+	secret.Data[ManagedIdentityClientIDKey] = []byte("operator-identity")
+
+	resolver, created := newTestResolver(newNamespace("team-credentials"), secret)
+
+	_, err := resolver.ResolveApplier(ctx, newResource(""))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(*created).To(Equal(0))
+}
+
+func Test_ParseManagedIdentityAllowList(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	identities, err := ParseManagedIdentityAllowList("team-a=id-1, team-a=id-2,team-b=id-3")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(identities.Allows("team-a", "id-1")).To(BeTrue())
+	g.Expect(identities.Allows("team-a", "ID-2")).To(BeTrue())
+	g.Expect(identities.Allows("team-a", "id-3")).To(BeFalse())
+	g.Expect(identities.Allows("team-b", "id-3")).To(BeTrue())
+
+	_, err = ParseManagedIdentityAllowList("team-a")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/apidiff"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/codegen"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/xcobra"
+)
+
+// NewDiffCommand creates a new cobra Command when invoked from the command line
+func NewDiffCommand() (*cobra.Command, error) {
+	var format string
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "diff <before-config> <after-config>",
+		Short: "report changes to the generated API surface between two configurations or schema snapshots",
+		Long: "Runs the code generation pipeline once for each configuration, without writing any code, and reports " +
+			"the resources, properties, enum values and validations which were added, removed or changed. " +
+			"Fails if any of the changes are breaking.",
+		Args: cobra.ExactArgs(2),
+		Run: xcobra.RunWithCtx(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			if format != "markdown" && format != "json" {
+				return errors.Errorf("unknown format %q, expected markdown or json", format)
+			}
+
+			before, err := generateTypes(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			after, err := generateTypes(ctx, args[1])
+			if err != nil {
+				return err
+			}
+
+			changelog := apidiff.Compare(before, after)
+
+			err = writeChangelog(changelog, format, outputFile)
+			if err != nil {
+				return err
+			}
+
+			if breaking := changelog.BreakingChanges(); len(breaking) > 0 {
+				return errors.Errorf("found %d breaking changes", len(breaking))
+			}
+
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&format, "format", "markdown", "format of the changelog, either markdown or json")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "file to write the changelog to, instead of stdout")
+
+	return cmd, nil
+}
+
+// writeChangelog writes the changelog in the given format to outputFile, or to stdout if no file is given
+func writeChangelog(changelog *apidiff.Changelog, format string, outputFile string) error {
+	write := changelog.WriteMarkdown
+	if format == "json" {
+		write = changelog.WriteJSON
+	}
+
+	if outputFile == "" {
+		return errors.Wrap(write(os.Stdout), "unable to write changelog")
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create %q", outputFile)
+	}
+
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "unable to write changelog to %q", outputFile)
+	}
+
+	// Closing flushes the changelog, so failing to close means it's incomplete
+	err = file.Close()
+	if err != nil {
+		return errors.Wrapf(err, "unable to write changelog to %q", outputFile)
+	}
+
+	return nil
+}
+
+// generateTypes runs the code generation pipeline for the given configuration file, returning the types that would
+// be exported
+func generateTypes(ctx context.Context, configFile string) (astmodel.Types, error) {
+	cg, err := codegen.NewCodeGeneratorFromConfigFile(configFile)
+	if err != nil {
+		klog.Errorf("Error creating code generator: %v\n", err)
+		return nil, err
+	}
+
+	types, err := cg.GenerateTypes(ctx)
+	if err != nil {
+		klog.Errorf("Error during code generation for %q:\n%v\n", configFile, err)
+		stackTrace := findDeepestTrace(err)
+		if stackTrace != nil {
+			klog.V(4).Infof("%+v", stackTrace)
+		}
+		return nil, err
+	}
+
+	return types, nil
+}
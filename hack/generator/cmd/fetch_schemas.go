@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/schemasource"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/xcobra"
+)
+
+// NewFetchSchemasCommand creates a new cobra Command when invoked from the command line
+func NewFetchSchemasCommand() (*cobra.Command, error) {
+	var cacheDir string
+
+	cmd := &cobra.Command{
+		Use:   "fetch-schemas <config>",
+		Short: "download the JSON schema used by a configuration, and everything it references, into a local cache",
+		Long: "Downloads the JSON schema used by the configuration, and everything it references, into the " +
+			"content-addressed cache given by --cache or by schemaSource.cache in the configuration. " +
+			"Code generation can then run without network access.",
+		Args: cobra.ExactArgs(1),
+		Run: xcobra.RunWithCtx(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			configuration, err := config.LoadConfiguration(args[0])
+			if err != nil {
+				klog.Errorf("Error loading configuration: %v\n", err)
+				return err
+			}
+
+			if configuration.SpecSource == config.SpecSourceSwagger {
+				return errors.Errorf("no JSON schema is used when specSource is %q, so there is nothing to fetch", config.SpecSourceSwagger)
+			}
+
+			if cacheDir == "" {
+				cacheDir = configuration.SchemaSource.Cache
+			}
+
+			if cacheDir == "" {
+				return errors.New("no cache specified, use --cache or set schemaSource.cache in the configuration")
+			}
+
+			cacheDir, err = filepath.Abs(cacheDir)
+			if err != nil {
+				return err
+			}
+
+			cache := schemasource.NewCacheSource(cacheDir)
+			source := schemasource.WithRewrites(
+				cache.Populate(schemasource.NewNetworkSource()),
+				configuration.SchemaURLRewrites)
+
+			klog.V(0).Infof("Fetching JSON schema %q into %s", configuration.SchemaURL, cache)
+
+			// Compiling the schema loads every document it references
+			_, err = schemasource.Load(ctx, source, configuration.SchemaURL)
+			if err != nil {
+				klog.Errorf("Error fetching schemas:\n%v\n", err)
+				return err
+			}
+
+			klog.Info("Finished")
+
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&cacheDir, "cache", "", "directory of the cache to populate, overriding schemaSource.cache in the configuration")
+
+	return cmd, nil
+}
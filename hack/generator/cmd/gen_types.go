@@ -18,6 +18,12 @@ import (
 
 // NewGenTypesCommand creates a new cobra Command when invoked from the command line
 func NewGenTypesCommand() (*cobra.Command, error) {
+	var stopAfter string
+	var skip []string
+	var dumpAfter []string
+	var dumpFormat string
+	var dumpDir string
+
 	cmd := &cobra.Command{
 		// TODO: there's not great support for required
 		// TODO: arguments in cobra so this is the best we get... see:
@@ -34,6 +40,25 @@ func NewGenTypesCommand() (*cobra.Command, error) {
 				return err
 			}
 
+			err = cg.RemoveStages(skip...)
+			if err != nil {
+				return err
+			}
+
+			if stopAfter != "" {
+				err = cg.StopAfterStage(stopAfter)
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(dumpAfter) > 0 {
+				err = cg.DumpTypesAfterStages(dumpDir, codegen.DumpFormat(dumpFormat), dumpAfter...)
+				if err != nil {
+					return err
+				}
+			}
+
 			err = cg.Generate(ctx)
 			if err != nil {
 				klog.Errorf("Error during code generation:\n%v\n", err)
@@ -48,6 +73,12 @@ func NewGenTypesCommand() (*cobra.Command, error) {
 		}),
 	}
 
+	cmd.Flags().StringVar(&stopAfter, "stop-after", "", "id of the pipeline stage after which to stop, without running later stages")
+	cmd.Flags().StringSliceVar(&skip, "skip", nil, "ids of pipeline stages to skip")
+	cmd.Flags().StringSliceVar(&dumpAfter, "dump-after", nil, "ids of pipeline stages after which to dump the types, or * for every stage")
+	cmd.Flags().StringVar(&dumpFormat, "dump-format", string(codegen.DumpAsText), "format of the dumped types, either text or json")
+	cmd.Flags().StringVar(&dumpDir, "dump-dir", ".", "directory to write the dumped types to")
+
 	return cmd, nil
 }
 
@@ -33,6 +33,8 @@ func newRootCommand() (*cobra.Command, error) {
 
 	cmdFuncs := []func() (*cobra.Command, error){
 		NewGenTypesCommand,
+		NewDiffCommand,
+		NewFetchSchemasCommand,
 	}
 
 	for _, f := range cmdFuncs {
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package apidiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ChangeKind describes what happened to part of the API surface
+type ChangeKind string
+
+const (
+	Added   = ChangeKind("Added")
+	Removed = ChangeKind("Removed")
+	Changed = ChangeKind("Changed")
+)
+
+// Change is a single difference between two versions of the API surface
+type Change struct {
+	// Kind of change
+	Kind ChangeKind `json:"kind"`
+	// Subject is the resource, type, property or enum value that changed, e.g. microsoft.batch/v20170901/BatchAccount
+	Subject string `json:"subject"`
+	// Description of the change, for human consumption
+	Description string `json:"description"`
+	// Breaking is true if existing resources or clients may stop working because of the change
+	Breaking bool `json:"breaking"`
+}
+
+// Changelog is the set of changes between two versions of the API surface
+type Changelog struct {
+	Changes []Change `json:"changes"`
+}
+
+// add records a change in the changelog
+func (log *Changelog) add(kind ChangeKind, subject string, breaking bool, format string, args ...interface{}) {
+	log.Changes = append(log.Changes, Change{
+		Kind:        kind,
+		Subject:     subject,
+		Description: fmt.Sprintf(format, args...),
+		Breaking:    breaking,
+	})
+}
+
+// sort orders the changes by subject so that the output is stable
+func (log *Changelog) sort() {
+	sort.SliceStable(log.Changes, func(i, j int) bool {
+		left := log.Changes[i]
+		right := log.Changes[j]
+		if left.Subject != right.Subject {
+			return left.Subject < right.Subject
+		}
+
+		return left.Description < right.Description
+	})
+}
+
+// BreakingChanges returns the changes which are breaking
+func (log *Changelog) BreakingChanges() []Change {
+	var result []Change
+	for _, change := range log.Changes {
+		if change.Breaking {
+			result = append(result, change)
+		}
+	}
+
+	return result
+}
+
+// HasBreakingChanges returns true if any of the changes are breaking, false otherwise
+func (log *Changelog) HasBreakingChanges() bool {
+	return len(log.BreakingChanges()) > 0
+}
+
+// WriteJSON writes the changelog to the given writer as JSON
+func (log *Changelog) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// WriteMarkdown writes the changelog to the given writer as markdown, listing the breaking changes first
+func (log *Changelog) WriteMarkdown(w io.Writer) error {
+	var breaking []Change
+	var other []Change
+	for _, change := range log.Changes {
+		if change.Breaking {
+			breaking = append(breaking, change)
+		} else {
+			other = append(other, change)
+		}
+	}
+
+	var buffer strings.Builder
+	buffer.WriteString("# API changes\n")
+
+	if len(log.Changes) == 0 {
+		buffer.WriteString("\nNo changes.\n")
+	}
+
+	writeMarkdownSection(&buffer, "Breaking changes", breaking)
+	writeMarkdownSection(&buffer, "Other changes", other)
+
+	_, err := io.WriteString(w, buffer.String())
+	return err
+}
+
+func writeMarkdownSection(buffer *strings.Builder, title string, changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	buffer.WriteString(fmt.Sprintf("\n## %s\n\n", title))
+	for _, change := range changes {
+		buffer.WriteString(fmt.Sprintf("- **%s** `%s`: %s\n", change.Kind, change.Subject, change.Description))
+	}
+}
@@ -0,0 +1,292 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package apidiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+)
+
+// Compare returns the changes to the API surface between the before and after sets of types, which are the final
+// results of two runs of the code generation pipeline. Only the types users interact with are compared; ARM types and
+// storage types are implementation details and are ignored.
+func Compare(before astmodel.Types, after astmodel.Types) *Changelog {
+	result := &Changelog{}
+
+	before = apiSurface(before)
+	after = apiSurface(after)
+
+	for name, def := range before {
+		if _, ok := after[name]; !ok {
+			// Removing a resource breaks everyone using it; removing any other type is only visible via the
+			// properties that used to refer to it, which are reported on their own
+			result.add(Removed, subject(name), isResource(def), "%s removed", describeDefinition(def))
+		}
+	}
+
+	for name, def := range after {
+		if _, ok := before[name]; !ok {
+			result.add(Added, subject(name), false, "%s added", describeDefinition(def))
+		}
+	}
+
+	for name, afterDef := range after {
+		if beforeDef, ok := before[name]; ok {
+			compareTypes(result, subject(name), beforeDef.Type(), afterDef.Type())
+		}
+	}
+
+	result.sort()
+	return result
+}
+
+// apiSurface returns the types which users of the generated resources interact with
+func apiSurface(types astmodel.Types) astmodel.Types {
+	return types.Where(func(def astmodel.TypeDefinition) bool {
+		return !astmodel.IsStoragePackageReference(def.Name().PackageReference) &&
+			!astmodel.ArmFlag.IsOn(def.Type())
+	})
+}
+
+func compareTypes(log *Changelog, subject string, before astmodel.Type, after astmodel.Type) {
+	before = unwrapFlags(before)
+	after = unwrapFlags(after)
+
+	switch b := before.(type) {
+	case *astmodel.ResourceType:
+		if a, ok := after.(*astmodel.ResourceType); ok {
+			compareResources(log, subject, b, a)
+			return
+		}
+	case *astmodel.ObjectType:
+		if a, ok := after.(*astmodel.ObjectType); ok {
+			compareObjects(log, subject, b, a)
+			return
+		}
+	case *astmodel.EnumType:
+		if a, ok := after.(*astmodel.EnumType); ok {
+			compareEnums(log, subject, b, a)
+			return
+		}
+	case astmodel.ValidatedType:
+		if a, ok := after.(astmodel.ValidatedType); ok {
+			compareValidations(log, subject, b.Validations(), a.Validations())
+			compareTypes(log, subject, b.ElementType(), a.ElementType())
+			return
+		}
+	default:
+		if astmodel.TypeEquals(before, after) {
+			return
+		}
+	}
+
+	log.add(Changed, subject, true, "type changed from %s to %s", describeType(before), describeType(after))
+}
+
+func compareResources(log *Changelog, subject string, before *astmodel.ResourceType, after *astmodel.ResourceType) {
+	if !astmodel.TypeEquals(before.SpecType(), after.SpecType()) {
+		log.add(Changed, subject, true, "spec changed from %s to %s", describeType(before.SpecType()), describeType(after.SpecType()))
+	}
+
+	if !astmodel.TypeEquals(before.StatusType(), after.StatusType()) {
+		// Status is read only, so only losing it altogether is breaking
+		log.add(Changed, subject, after.StatusType() == nil, "status changed from %s to %s", describeType(before.StatusType()), describeType(after.StatusType()))
+	}
+
+	beforeOwner := describeOwner(before.Owner())
+	afterOwner := describeOwner(after.Owner())
+	if beforeOwner != afterOwner {
+		log.add(Changed, subject, true, "owner changed from %s to %s", beforeOwner, afterOwner)
+	}
+}
+
+func compareObjects(log *Changelog, subject string, before *astmodel.ObjectType, after *astmodel.ObjectType) {
+	for _, beforeProp := range before.Properties() {
+		propSubject := subject + "." + string(beforeProp.PropertyName())
+		afterProp, ok := after.Property(beforeProp.PropertyName())
+		if !ok {
+			log.add(Removed, propSubject, true, "property removed")
+			continue
+		}
+
+		compareProperties(log, propSubject, beforeProp, afterProp)
+	}
+
+	for _, afterProp := range after.Properties() {
+		if _, ok := before.Property(afterProp.PropertyName()); !ok {
+			propSubject := subject + "." + string(afterProp.PropertyName())
+			if afterProp.IsRequired() {
+				// Existing resources won't have a value for it
+				log.add(Added, propSubject, true, "required property added")
+			} else {
+				log.add(Added, propSubject, false, "optional property added")
+			}
+		}
+	}
+}
+
+func compareProperties(log *Changelog, subject string, before *astmodel.PropertyDefinition, after *astmodel.PropertyDefinition) {
+	if before.JsonName() != after.JsonName() {
+		log.add(Changed, subject, true, "JSON name changed from %q to %q", before.JsonName(), after.JsonName())
+	}
+
+	if !before.IsRequired() && after.IsRequired() {
+		log.add(Changed, subject, true, "property is now required")
+	} else if before.IsRequired() && !after.IsRequired() {
+		log.add(Changed, subject, false, "property is now optional")
+	}
+
+	beforeType, beforeValidations := splitPropertyType(before.PropertyType())
+	afterType, afterValidations := splitPropertyType(after.PropertyType())
+	compareValidations(log, subject, beforeValidations, afterValidations)
+	if !astmodel.TypeEquals(beforeType, afterType) {
+		log.add(Changed, subject, true, "type changed from %s to %s", describeType(beforeType), describeType(afterType))
+	}
+}
+
+func compareEnums(log *Changelog, subject string, before *astmodel.EnumType, after *astmodel.EnumType) {
+	if !astmodel.TypeEquals(before.BaseType(), after.BaseType()) {
+		log.add(Changed, subject, true, "enum base type changed from %s to %s", describeType(before.BaseType()), describeType(after.BaseType()))
+	}
+
+	beforeValues := enumValues(before)
+	afterValues := enumValues(after)
+
+	for _, option := range before.Options() {
+		if !afterValues[option.Value] {
+			log.add(Removed, subject, true, "enum value %q removed", option.Value)
+		}
+	}
+
+	for _, option := range after.Options() {
+		if !beforeValues[option.Value] {
+			log.add(Added, subject, false, "enum value %q added", option.Value)
+		}
+	}
+}
+
+// compareValidations reports validation rules which were added or removed. Any new rule is treated as breaking, as
+// existing resources may not satisfy it; a rule that was relaxed shows up as one removed and one added.
+func compareValidations(log *Changelog, subject string, before astmodel.Validations, after astmodel.Validations) {
+	beforeRules := validationRules(before)
+	afterRules := validationRules(after)
+
+	for _, rule := range sortedRules(beforeRules) {
+		if !afterRules[rule] {
+			log.add(Removed, subject, false, "validation %s removed", rule)
+		}
+	}
+
+	for _, rule := range sortedRules(afterRules) {
+		if !beforeRules[rule] {
+			log.add(Added, subject, true, "validation %s added", rule)
+		}
+	}
+}
+
+// splitPropertyType separates the type of a property from any validations applied directly to it, ignoring whether
+// it's optional as that's reported separately
+func splitPropertyType(t astmodel.Type) (astmodel.Type, astmodel.Validations) {
+	if optional, ok := t.(*astmodel.OptionalType); ok {
+		t = optional.Element()
+	}
+
+	if validated, ok := t.(astmodel.ValidatedType); ok {
+		return validated.ElementType(), validated.Validations()
+	}
+
+	return t, nil
+}
+
+func unwrapFlags(t astmodel.Type) astmodel.Type {
+	for {
+		flagged, ok := t.(*astmodel.FlaggedType)
+		if !ok {
+			return t
+		}
+
+		t = flagged.Element()
+	}
+}
+
+func enumValues(enum *astmodel.EnumType) map[string]bool {
+	result := make(map[string]bool)
+	for _, option := range enum.Options() {
+		result[option.Value] = true
+	}
+
+	return result
+}
+
+func validationRules(validations astmodel.Validations) map[string]bool {
+	result := make(map[string]bool)
+	if validations == nil {
+		return result
+	}
+
+	for _, validation := range validations.ToKubeBuilderValidations() {
+		rule := strings.TrimPrefix(astmodel.GenerateKubebuilderComment(validation), "// +kubebuilder:validation:")
+		result[rule] = true
+	}
+
+	return result
+}
+
+func sortedRules(rules map[string]bool) []string {
+	var result []string
+	for rule := range rules {
+		result = append(result, rule)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+func isResource(def astmodel.TypeDefinition) bool {
+	return astmodel.IsResourceType(unwrapFlags(def.Type()))
+}
+
+// subject returns a concise, stable identifier for the named type
+func subject(name astmodel.TypeName) string {
+	if local, ok := name.PackageReference.AsLocalPackage(); ok {
+		return fmt.Sprintf("%s/%s/%s", local.Group(), local.Version(), name.Name())
+	}
+
+	return name.String()
+}
+
+func describeDefinition(def astmodel.TypeDefinition) string {
+	switch unwrapFlags(def.Type()).(type) {
+	case *astmodel.ResourceType:
+		return "resource"
+	case *astmodel.EnumType:
+		return "enum"
+	default:
+		return "type"
+	}
+}
+
+func describeType(t astmodel.Type) string {
+	switch typ := t.(type) {
+	case nil:
+		return "(none)"
+	case astmodel.TypeName:
+		return typ.Name()
+	default:
+		return typ.String()
+	}
+}
+
+func describeOwner(owner *astmodel.TypeName) string {
+	if owner == nil {
+		return "(none)"
+	}
+
+	return owner.Name()
+}
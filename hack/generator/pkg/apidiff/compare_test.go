@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package apidiff
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+)
+
+var testPackage = astmodel.MakeLocalPackageReference("microsoft.test", "v20200101")
+
+func makeTypes(defs ...astmodel.TypeDefinition) astmodel.Types {
+	result := make(astmodel.Types)
+	result.AddAll(defs)
+	return result
+}
+
+func makeObject(name string, properties ...*astmodel.PropertyDefinition) astmodel.TypeDefinition {
+	return astmodel.MakeTypeDefinition(
+		astmodel.MakeTypeName(testPackage, name),
+		astmodel.NewObjectType().WithProperties(properties...))
+}
+
+func makeEnum(name string, values ...string) astmodel.TypeDefinition {
+	var options []astmodel.EnumValue
+	for _, v := range values {
+		options = append(options, astmodel.EnumValue{Identifier: strings.Title(v), Value: v})
+	}
+
+	return astmodel.MakeTypeDefinition(
+		astmodel.MakeTypeName(testPackage, name),
+		astmodel.NewEnumType(astmodel.StringType, options))
+}
+
+func makeProperty(name string, t astmodel.Type) *astmodel.PropertyDefinition {
+	return astmodel.NewPropertyDefinition(astmodel.PropertyName(name), strings.ToLower(name), t)
+}
+
+func Test_Compare_IdenticalTypes_HasNoChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	types := makeTypes(
+		makeObject("Person", makeProperty("Name", astmodel.StringType).MakeRequired()),
+		makeEnum("Colour", "red", "green"))
+
+	changelog := Compare(types, types)
+	g.Expect(changelog.Changes).To(BeEmpty())
+	g.Expect(changelog.HasBreakingChanges()).To(BeFalse())
+}
+
+func Test_Compare_Properties(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	before := makeTypes(makeObject("Person",
+		makeProperty("Name", astmodel.StringType).MakeRequired(),
+		makeProperty("Age", astmodel.IntType).MakeOptional(),
+		makeProperty("Nickname", astmodel.StringType).MakeOptional()))
+	after := makeTypes(makeObject("Person",
+		makeProperty("Name", astmodel.StringType).MakeOptional(),
+		makeProperty("Age", astmodel.StringType).MakeOptional(),
+		makeProperty("Email", astmodel.StringType).MakeOptional(),
+		makeProperty("Id", astmodel.StringType).MakeRequired()))
+
+	changelog := Compare(before, after)
+	g.Expect(changelog.Changes).To(ConsistOf(
+		Change{Kind: Changed, Subject: "microsoft.test/v20200101/Person.Age", Description: "type changed from int to string", Breaking: true},
+		Change{Kind: Added, Subject: "microsoft.test/v20200101/Person.Email", Description: "optional property added"},
+		Change{Kind: Added, Subject: "microsoft.test/v20200101/Person.Id", Description: "required property added", Breaking: true},
+		Change{Kind: Changed, Subject: "microsoft.test/v20200101/Person.Name", Description: "property is now optional"},
+		Change{Kind: Removed, Subject: "microsoft.test/v20200101/Person.Nickname", Description: "property removed", Breaking: true}))
+}
+
+func Test_Compare_EnumValues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	before := makeTypes(makeEnum("Colour", "red", "green"))
+	after := makeTypes(makeEnum("Colour", "red", "blue"))
+
+	changelog := Compare(before, after)
+	g.Expect(changelog.Changes).To(ConsistOf(
+		Change{Kind: Removed, Subject: "microsoft.test/v20200101/Colour", Description: `enum value "green" removed`, Breaking: true},
+		Change{Kind: Added, Subject: "microsoft.test/v20200101/Colour", Description: `enum value "blue" added`}))
+}
+
+func Test_Compare_Validations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	maxLength := func(n int64) astmodel.Type {
+		return astmodel.MakeValidatedType(astmodel.StringType, astmodel.StringValidations{MaxLength: &n})
+	}
+
+	before := makeTypes(makeObject("Person", makeProperty("Name", maxLength(10)).MakeRequired()))
+	after := makeTypes(makeObject("Person", makeProperty("Name", maxLength(5)).MakeRequired()))
+
+	changelog := Compare(before, after)
+	g.Expect(changelog.Changes).To(ConsistOf(
+		Change{Kind: Removed, Subject: "microsoft.test/v20200101/Person.Name", Description: "validation MaxLength=10 removed"},
+		Change{Kind: Added, Subject: "microsoft.test/v20200101/Person.Name", Description: "validation MaxLength=5 added", Breaking: true}))
+}
+
+func Test_Compare_Types_IgnoresArmAndStorageTypes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	armType := makeObject("PersonArm")
+	armType = armType.WithType(astmodel.ArmFlag.ApplyTo(armType.Type()))
+	storageType := astmodel.MakeTypeDefinition(
+		astmodel.MakeTypeName(astmodel.MakeStoragePackageReference(testPackage), "Person"),
+		astmodel.NewObjectType())
+
+	before := makeTypes(makeObject("Person"), makeObject("Address"), armType, storageType)
+	after := makeTypes(makeObject("Person"), makeEnum("Colour", "red"))
+
+	changelog := Compare(before, after)
+	g.Expect(changelog.Changes).To(ConsistOf(
+		Change{Kind: Removed, Subject: "microsoft.test/v20200101/Address", Description: "type removed"},
+		Change{Kind: Added, Subject: "microsoft.test/v20200101/Colour", Description: "enum added"}))
+}
+
+func Test_Changelog_WriteMarkdown_ListsBreakingChangesFirst(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	changelog := &Changelog{}
+	changelog.add(Added, "microsoft.test/v20200101/Person.Email", false, "optional property added")
+	changelog.add(Removed, "microsoft.test/v20200101/Person.Nickname", true, "property removed")
+
+	var buffer strings.Builder
+	g.Expect(changelog.WriteMarkdown(&buffer)).To(Succeed())
+	g.Expect(buffer.String()).To(Equal(`# API changes
+
+## Breaking changes
+
+- **Removed** ` + "`microsoft.test/v20200101/Person.Nickname`" + `: property removed
+
+## Other changes
+
+- **Added** ` + "`microsoft.test/v20200101/Person.Email`" + `: optional property added
+`))
+}
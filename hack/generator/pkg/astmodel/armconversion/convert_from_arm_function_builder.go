@@ -49,6 +49,11 @@ func newConvertFromArmFunctionBuilder(
 	result.propertyConversionHandlers = []propertyConversionHandler{
 		result.namePropertyHandler,
 		result.ownerPropertyHandler,
+		result.secretPropertyHandler,
+		result.resourceReferencePropertyHandler,
+		result.operatorSpecPropertyHandler,
+		result.statusConditionsPropertyHandler,
+		result.propertyBagPropertyHandler,
 		result.propertiesWithSameNameAndTypeHandler,
 		result.propertiesWithSameNameButDifferentTypeHandler(),
 	}
@@ -83,16 +88,15 @@ func (builder *convertFromArmBuilder) functionDeclaration() *ast.FuncDecl {
 func (builder *convertFromArmBuilder) functionBodyStatements() []ast.Stmt {
 	var result []ast.Stmt
 
-	// perform a type assert and check its results
-	result = append(result, builder.assertInputTypeIsArm()...)
-
 	// Do all of the assignments for each property
-	result = append(
-		result,
-		generateTypeConversionAssignments(
-			builder.armType,
-			builder.kubeType,
-			builder.propertyConversionHandler)...)
+	assignments := generateTypeConversionAssignments(
+		builder.armType,
+		builder.kubeType,
+		builder.propertyConversionHandler)
+
+	// perform a type assert and check its results
+	result = append(result, builder.assertInputTypeIsArm(usesIdent(assignments, builder.typedInputIdent))...)
+	result = append(result, assignments...)
 
 	// Return nil error if we make it to the end
 	result = append(
@@ -106,16 +110,23 @@ func (builder *convertFromArmBuilder) functionBodyStatements() []ast.Stmt {
 	return result
 }
 
-func (builder *convertFromArmBuilder) assertInputTypeIsArm() []ast.Stmt {
+// assertInputTypeIsArm generates code to check the input is of the ARM type; the typed input is only kept if it's
+// needed, as not every property can be populated from Azure
+func (builder *convertFromArmBuilder) assertInputTypeIsArm(needsTypedInput bool) []ast.Stmt {
 	var result []ast.Stmt
 
 	fmtPackage := builder.codeGenerationContext.MustGetImportedPackageName(astmodel.FmtReference)
 
+	typedInputIdent := "_"
+	if needsTypedInput {
+		typedInputIdent = builder.typedInputIdent
+	}
+
 	// perform a type assert
 	result = append(
 		result,
 		astbuilder.TypeAssert(
-			ast.NewIdent(builder.typedInputIdent),
+			ast.NewIdent(typedInputIdent),
 			ast.NewIdent(builder.inputIdent),
 			ast.NewIdent(builder.armTypeIdent)))
 
@@ -177,6 +188,17 @@ func (builder *convertFromArmBuilder) ownerPropertyHandler(
 		return nil
 	}
 
+	if toProp.PropertyType().Equals(astmodel.ArbitraryOwnerReferenceTypeName) {
+		// The owner passed in doesn't say what kind of resource an extension resource is applied to
+		result := &ast.EmptyStmt{
+			Implicit: true,
+		}
+		result.Decs.Before = ast.NewLine
+		result.Decs.Start.Append(fmt.Sprintf("// no assignment for property '%s' as the kind of resource it extends can't be recovered from Azure", toProp.PropertyName()))
+
+		return []ast.Stmt{result}
+	}
+
 	result := astbuilder.SimpleAssignment(
 		&ast.SelectorExpr{
 			X:   ast.NewIdent(builder.receiverIdent),
@@ -187,6 +209,180 @@ func (builder *convertFromArmBuilder) ownerPropertyHandler(
 	return []ast.Stmt{result}
 }
 
+// secretPropertyHandler leaves properties held in secrets alone; Azure doesn't return their values and even if it did,
+// the reference to the secret is what belongs in the Kubernetes object
+func (builder *convertFromArmBuilder) secretPropertyHandler(
+	toProp *astmodel.PropertyDefinition,
+	_ *astmodel.ObjectType) []ast.Stmt {
+
+	propertyType := toProp.PropertyType()
+	if optionalType, ok := propertyType.(*astmodel.OptionalType); ok {
+		propertyType = optionalType.Element()
+	}
+
+	if !propertyType.Equals(astmodel.SecretReferenceTypeName) {
+		return nil
+	}
+
+	result := &ast.EmptyStmt{
+		Implicit: true,
+	}
+	result.Decs.Before = ast.NewLine
+	result.Decs.Start.Append(fmt.Sprintf("// no assignment for property '%s' as its value is held in a secret", toProp.PropertyName()))
+
+	return []ast.Stmt{result}
+}
+
+// resourceReferencePropertyHandler generates code to hold the ARM ID of another resource in a reference to it, as the
+// Kubernetes group, kind and name of the resource can't be recovered from Azure, like:
+//	if <input>.<prop> != nil {
+//		<receiver>.<prop> = &genruntime.ResourceReference{ArmId: *<input>.<prop>}
+//	}
+func (builder *convertFromArmBuilder) resourceReferencePropertyHandler(
+	toProp *astmodel.PropertyDefinition,
+	fromType *astmodel.ObjectType) []ast.Stmt {
+
+	toPropType := toProp.PropertyType()
+	_, isOptionalTo := toPropType.(*astmodel.OptionalType)
+	if isOptionalTo {
+		toPropType = toPropType.(*astmodel.OptionalType).Element()
+	}
+
+	if !toPropType.Equals(astmodel.ResourceReferenceTypeName) {
+		return nil
+	}
+
+	fromProp, ok := fromType.Property(toProp.PropertyName())
+	if !ok {
+		return nil
+	}
+
+	_, isOptionalFrom := fromProp.PropertyType().(*astmodel.OptionalType)
+
+	var armId ast.Expr = &ast.SelectorExpr{
+		X:   ast.NewIdent(builder.typedInputIdent),
+		Sel: ast.NewIdent(string(fromProp.PropertyName())),
+	}
+	if isOptionalFrom {
+		armId = &ast.StarExpr{X: armId}
+	}
+
+	var value ast.Expr = &ast.CompositeLit{
+		Type: &ast.SelectorExpr{
+			X:   ast.NewIdent(astmodel.GenRuntimePackageName),
+			Sel: ast.NewIdent(astmodel.ResourceReferenceTypeName.Name()),
+		},
+		Elts: []ast.Expr{
+			&ast.KeyValueExpr{
+				Key:   ast.NewIdent("ArmId"),
+				Value: armId,
+			},
+		},
+	}
+	if isOptionalTo {
+		value = astbuilder.AddrOf(value)
+	}
+
+	assignment := astbuilder.SimpleAssignment(
+		&ast.SelectorExpr{
+			X:   ast.NewIdent(builder.receiverIdent),
+			Sel: ast.NewIdent(string(toProp.PropertyName())),
+		},
+		token.ASSIGN,
+		value)
+
+	if !isOptionalFrom {
+		return []ast.Stmt{assignment}
+	}
+
+	return []ast.Stmt{
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.SelectorExpr{
+					X:   ast.NewIdent(builder.typedInputIdent),
+					Sel: ast.NewIdent(string(fromProp.PropertyName())),
+				},
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{assignment},
+			},
+		},
+	}
+}
+
+// operatorSpecPropertyHandler leaves the operatorSpec alone as it configures the operator rather than Azure, so
+// has no counterpart in the ARM object
+func (builder *convertFromArmBuilder) operatorSpecPropertyHandler(
+	toProp *astmodel.PropertyDefinition,
+	_ *astmodel.ObjectType) []ast.Stmt {
+
+	if toProp.PropertyName() != builder.idFactory.CreatePropertyName(astmodel.OperatorSpecProperty, astmodel.Exported) || !builder.isSpecType {
+		return nil
+	}
+
+	result := &ast.EmptyStmt{
+		Implicit: true,
+	}
+	result.Decs.Before = ast.NewLine
+	result.Decs.Start.Append("// no assignment for property 'OperatorSpec' as it configures the operator rather than Azure")
+
+	return []ast.Stmt{result}
+}
+
+// propertyBagPropertyHandler leaves the property bag alone, as it holds the values of properties from other versions
+// of the resource and has no counterpart in the ARM object
+func (builder *convertFromArmBuilder) propertyBagPropertyHandler(
+	toProp *astmodel.PropertyDefinition,
+	fromType *astmodel.ObjectType) []ast.Stmt {
+
+	if !toProp.HasName(astmodel.PropertyBagProperty) {
+		return nil
+	}
+
+	if _, ok := fromType.Property(toProp.PropertyName()); ok {
+		// Azure has a property of the same name, so it's not one we added
+		return nil
+	}
+
+	result := &ast.EmptyStmt{
+		Implicit: true,
+	}
+	result.Decs.Before = ast.NewLine
+	result.Decs.Start.Append("// no assignment for property 'PropertyBag' as it holds properties from other versions of the resource")
+
+	return []ast.Stmt{result}
+}
+
+// statusConditionsPropertyHandler leaves the conditions, observed generation and spec signatures of a status alone,
+// as they're maintained by the controller and have no counterpart in the ARM object
+func (builder *convertFromArmBuilder) statusConditionsPropertyHandler(
+	toProp *astmodel.PropertyDefinition,
+	fromType *astmodel.ObjectType) []ast.Stmt {
+
+	if builder.isSpecType {
+		return nil
+	}
+
+	if !isControllerStatusProperty(toProp) {
+		return nil
+	}
+
+	if _, ok := fromType.Property(toProp.PropertyName()); ok {
+		// Azure has a property of the same name, so it's not one we added
+		return nil
+	}
+
+	result := &ast.EmptyStmt{
+		Implicit: true,
+	}
+	result.Decs.Before = ast.NewLine
+	result.Decs.Start.Append(fmt.Sprintf("// no assignment for property '%s' as it's maintained by the controller", toProp.PropertyName()))
+
+	return []ast.Stmt{result}
+}
+
 func (builder *convertFromArmBuilder) propertiesWithSameNameAndTypeHandler(
 	toProp *astmodel.PropertyDefinition,
 	fromType *astmodel.ObjectType) []ast.Stmt {
@@ -583,3 +779,27 @@ func (builder *convertFromArmBuilder) convertComplexTypeNameProperty(
 
 	return results
 }
+
+// usesIdent returns true if any of the given statements refer to the named identifier
+func usesIdent(stmts []ast.Stmt, name string) bool {
+	found := false
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == name {
+				found = true
+			}
+
+			return !found
+		})
+	}
+
+	return found
+}
+
+// isControllerStatusProperty returns true if the property is one added to the status for use by the controller
+func isControllerStatusProperty(prop *astmodel.PropertyDefinition) bool {
+	return prop.HasName(astmodel.ConditionsProperty) ||
+		prop.HasName(astmodel.ObservedGenerationProperty) ||
+		prop.HasName(astmodel.LastAppliedSpecSignatureProperty) ||
+		prop.HasName(astmodel.PendingSpecSignatureProperty)
+}
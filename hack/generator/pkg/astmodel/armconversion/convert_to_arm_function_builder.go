@@ -17,6 +17,7 @@ import (
 var KubernetesResourceInterfaceName astmodel.TypeName = astmodel.MakeTypeName(astmodel.GenRuntimeReference, "KubernetesResource")
 
 const nameParameterString = "name"
+const resolverParameterString = "resolver"
 
 type convertToArmBuilder struct {
 	conversionBuilder
@@ -47,6 +48,8 @@ func newConvertToArmFunctionBuilder(
 	result.propertyConversionHandlers = []propertyConversionHandler{
 		result.namePropertyHandler,
 		result.typePropertyHandler,
+		result.referencePropertyHandler(astmodel.SecretReferenceTypeName, "ResolveSecret"),
+		result.referencePropertyHandler(astmodel.ResourceReferenceTypeName, "ResolveResourceReference"),
 		result.propertiesWithSameNameAndTypeHandler,
 		result.propertiesWithSameNameButDifferentTypeHandler,
 	}
@@ -65,6 +68,12 @@ func (builder *convertToArmBuilder) functionDeclaration() *ast.FuncDecl {
 	}
 
 	fn.AddParameter(nameParameterString, ast.NewIdent("string"))
+	fn.AddParameter(
+		resolverParameterString,
+		&ast.SelectorExpr{
+			X:   ast.NewIdent(astmodel.GenRuntimePackageName),
+			Sel: ast.NewIdent("ReferenceResolver"),
+		})
 	fn.AddReturns("interface{}", "error")
 	fn.AddComments("converts from a Kubernetes CRD object to an ARM object")
 
@@ -168,6 +177,87 @@ func (builder *convertToArmBuilder) typePropertyHandler(
 
 }
 
+// referencePropertyHandler returns a handler which generates code to look up the value of a property holding a
+// reference of the given type (such as to a secret), using the given method of the resolver, like:
+//	if <receiver>.<prop> != nil {
+//		<prop>, err := resolver.<resolveMethod>(*<receiver>.<prop>)
+//		if err != nil {
+//			return nil, err
+//		}
+//		result.<prop> = &<prop>
+//	}
+func (builder *convertToArmBuilder) referencePropertyHandler(
+	referenceType astmodel.TypeName,
+	resolveMethod string) propertyConversionHandler {
+
+	return func(toProp *astmodel.PropertyDefinition, fromType *astmodel.ObjectType) []ast.Stmt {
+		fromProp, ok := fromType.Property(toProp.PropertyName())
+		if !ok {
+			return nil
+		}
+
+		fromPropType := fromProp.PropertyType()
+		optionalFrom, isOptionalFrom := fromPropType.(*astmodel.OptionalType)
+		if isOptionalFrom {
+			fromPropType = optionalFrom.Element()
+		}
+
+		if !fromPropType.Equals(referenceType) {
+			return nil
+		}
+
+		localIdent := builder.idFactory.CreateIdentifier(string(toProp.PropertyName()), astmodel.NotExported)
+
+		var source ast.Expr = &ast.SelectorExpr{
+			X:   ast.NewIdent(builder.receiverIdent),
+			Sel: ast.NewIdent(string(fromProp.PropertyName())),
+		}
+		if isOptionalFrom {
+			source = &ast.StarExpr{X: source}
+		}
+
+		var value ast.Expr = ast.NewIdent(localIdent)
+		if _, isOptionalTo := toProp.PropertyType().(*astmodel.OptionalType); isOptionalTo {
+			value = astbuilder.AddrOf(value)
+		}
+
+		stmts := []ast.Stmt{
+			astbuilder.SimpleAssignmentWithErr(
+				ast.NewIdent(localIdent),
+				token.DEFINE,
+				astbuilder.CallQualifiedFunc(resolverParameterString, resolveMethod, source)),
+			astbuilder.CheckErrorAndReturn(ast.NewIdent("nil")),
+			astbuilder.SimpleAssignment(
+				&ast.SelectorExpr{
+					X:   ast.NewIdent(builder.resultIdent),
+					Sel: ast.NewIdent(string(toProp.PropertyName())),
+				},
+				token.ASSIGN,
+				value),
+		}
+
+		if !isOptionalFrom {
+			return stmts
+		}
+
+		return []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X: &ast.SelectorExpr{
+						X:   ast.NewIdent(builder.receiverIdent),
+						Sel: ast.NewIdent(string(fromProp.PropertyName())),
+					},
+					Op: token.NEQ,
+					Y:  ast.NewIdent("nil"),
+				},
+				Body: &ast.BlockStmt{
+					List: stmts,
+				},
+			},
+		}
+	}
+}
+
 func (builder *convertToArmBuilder) propertiesWithSameNameAndTypeHandler(
 	toProp *astmodel.PropertyDefinition,
 	fromType *astmodel.ObjectType) []ast.Stmt {
@@ -532,6 +622,7 @@ func callToArmFunction(source ast.Expr, destination ast.Expr, methodName string)
 				},
 				Args: []ast.Expr{
 					ast.NewIdent(nameParameterString),
+					ast.NewIdent(resolverParameterString),
 				},
 			},
 		},
@@ -52,6 +52,20 @@ func (ft *FlaggedType) HasFlag(flag TypeFlag) bool {
 	return ok
 }
 
+// Flags returns the flags applied to the underlying type, in order
+func (ft *FlaggedType) Flags() []TypeFlag {
+	var result []TypeFlag
+	for f := range ft.flags {
+		result = append(result, f)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+
+	return result
+}
+
 // WithFlag returns a new FlaggedType with the specified flag added
 func (ft *FlaggedType) WithFlag(flag TypeFlag) *FlaggedType {
 	return NewFlaggedType(ft, flag)
@@ -131,16 +145,8 @@ func (ft *FlaggedType) String() string {
 	result.WriteString(ft.element.String())
 
 	if len(ft.flags) > 0 {
-		var flags []TypeFlag
-		for f := range ft.flags {
-			flags = append(flags, f)
-		}
-		sort.Slice(flags, func(i, j int) bool {
-			return flags[i] < flags[j]
-		})
-
 		result.WriteRune('[')
-		for i, f := range flags {
+		for i, f := range ft.Flags() {
 			if i > 0 {
 				result.WriteRune('|')
 			}
@@ -17,9 +17,16 @@ import (
 
 // These are some magical field names which we're going to use or generate
 const (
-	AzureNameProperty = "AzureName"
-	SetAzureNameFunc  = "SetAzureName"
-	OwnerProperty     = "Owner"
+	AzureNameProperty                = "AzureName"
+	SetAzureNameFunc                 = "SetAzureName"
+	OwnerProperty                    = "Owner"
+	ScopeFunc                        = "Scope"
+	OperatorSpecProperty             = "OperatorSpec"
+	ConditionsProperty               = "Conditions"
+	ObservedGenerationProperty       = "ObservedGeneration"
+	LastAppliedSpecSignatureProperty = "LastAppliedSpecSignature"
+	PendingSpecSignatureProperty     = "PendingSpecSignature"
+	PropertyBagProperty              = "PropertyBag"
 )
 
 // AddKubernetesResourceInterfaceImpls adds the required interfaces for
@@ -71,11 +78,27 @@ func AddKubernetesResourceInterfaceImpls(
 		asFunc:    ownerFunction,
 	}
 
+	if r.IsExtension() {
+		getOwnerProperty.asFunc = extensionOwnerFunction
+	}
+
 	r = r.WithInterface(NewInterfaceImplementation(
 		MakeTypeName(GenRuntimeReference, "KubernetesResource"),
 		getAzureNameProperty,
 		getOwnerProperty))
 
+	if r.IsExtension() {
+		// Extension resources are deployed to the scope of whatever resource owns them
+		r = r.WithInterface(NewInterfaceImplementation(
+			MakeTypeName(GenRuntimeReference, "ScopedResource"),
+			&objectFunction{
+				name:      ScopeFunc,
+				o:         spec,
+				idFactory: idFactory,
+				asFunc:    extensionScopeFunction,
+			}))
+	}
+
 	if setNameFunction != nil {
 		// this function applies to Spec not the resource
 		// re-fetch the spec ObjectType since the getAzureNameFunctionsForType
@@ -102,6 +125,13 @@ func AddKubernetesResourceInterfaceImpls(
 		r = r.WithInterface(generateDefaulter(resourceName, spec, idFactory))
 	}
 
+	validator, err := generateValidator(resourceName, spec, idFactory, types)
+	if err != nil {
+		return nil, err
+	}
+
+	r = r.WithInterface(validator)
+
 	return r, nil
 }
 
@@ -109,6 +139,21 @@ var admissionPackageReference PackageReference = MakeExternalPackageReference("s
 var DefaulterInterfaceName = MakeTypeName(admissionPackageReference, "Defaulter")
 
 func generateDefaulter(resourceName TypeName, spec *ObjectType, idFactory IdentifierFactory) *InterfaceImplementation {
+	annotation := webhookAnnotation(resourceName, "default", true)
+
+	return NewInterfaceImplementation(
+		DefaulterInterfaceName,
+		&objectFunction{
+			name:      "Default",
+			o:         spec,
+			idFactory: idFactory,
+			asFunc:    defaultAzureNameFunction,
+		}).WithAnnotation(annotation)
+}
+
+// webhookAnnotation returns the kubebuilder annotation declaring a mutating or validating webhook for the resource.
+// namePrefix distinguishes the names of the webhooks of a resource.
+func webhookAnnotation(resourceName TypeName, namePrefix string, mutating bool) string {
 	lpr, _ := resourceName.PackageReference.AsLocalPackage()
 
 	group := lpr.group              // e.g. "microsoft.network.infra.azure.com"
@@ -119,32 +164,29 @@ func generateDefaulter(resourceName TypeName, spec *ObjectType, idFactory Identi
 	nonPluralResource := strings.ToLower(resource)
 	resource = strings.ToLower(resource) + "s" // TODO: this should come from resource?
 
+	pathPrefix := "validate"
+	if mutating {
+		pathPrefix = "mutate"
+	}
+
 	// e.g. "mutate-microsoft-network-infra-azure-com-v1-backendaddresspool"
 	// note that this must match _exactly_ how controller-runtime generates the path
 	// or it will not work!
-	path := fmt.Sprintf("/mutate-%s-%s-%s", strings.ReplaceAll(group, ".", "-"), version, nonPluralResource)
+	path := fmt.Sprintf("/%s-%s-%s-%s", pathPrefix, strings.ReplaceAll(group, ".", "-"), version, nonPluralResource)
 
 	// e.g.  "default.v123.backendaddresspool.infra.azure.com"
-	name := fmt.Sprintf("default.%s.%s.%s", version, resource, group)
+	name := fmt.Sprintf("%s.%s.%s.%s", namePrefix, version, resource, group)
 
-	annotation := fmt.Sprintf(
-		"+kubebuilder:webhook:path=%s,mutating=true,sideEffects=None,"+
+	return fmt.Sprintf(
+		"+kubebuilder:webhook:path=%s,mutating=%t,sideEffects=None,"+
 			"matchPolicy=Exact,failurePolicy=fail,groups=%s,resources=%s,"+
 			"verbs=create;update,versions=%s,name=%s",
 		path,
+		mutating,
 		group,
 		resource,
 		version,
 		name)
-
-	return NewInterfaceImplementation(
-		DefaulterInterfaceName,
-		&objectFunction{
-			name:      "Default",
-			o:         spec,
-			idFactory: idFactory,
-			asFunc:    defaultAzureNameFunction,
-		}).WithAnnotation(annotation)
 }
 
 // note that this can, as a side-effect, update the resource type
@@ -216,10 +258,11 @@ func getAzureNameFunctionsForType(r **ResourceType, spec *ObjectType, t Type, ty
 // objectFunction is a simple helper that implements the Function interface. It is intended for use for functions
 // that only need information about the object they are operating on
 type objectFunction struct {
-	name      string
-	o         *ObjectType
-	idFactory IdentifierFactory
-	asFunc    asFuncType
+	name             string
+	o                *ObjectType
+	idFactory        IdentifierFactory
+	asFunc           asFuncType
+	requiredPackages []PackageReference
 }
 
 type asFuncType func(f *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl
@@ -357,8 +400,13 @@ func (k *objectFunction) Name() string {
 }
 
 func (k *objectFunction) RequiredPackageReferences() *PackageReferenceSet {
-	// We only require GenRuntime
-	return NewPackageReferenceSet(GenRuntimeReference)
+	// We always require GenRuntime
+	result := NewPackageReferenceSet(GenRuntimeReference)
+	for _, ref := range k.requiredPackages {
+		result.AddReference(ref)
+	}
+
+	return result
 }
 
 func (k *objectFunction) References() TypeNameSet {
@@ -380,7 +428,7 @@ func (k *objectFunction) Equals(f Function) bool {
 
 // IsKubernetesResourceProperty returns true if the supplied property name is one of our "magical" names
 func IsKubernetesResourceProperty(name PropertyName) bool {
-	return name == AzureNameProperty || name == OwnerProperty
+	return name == AzureNameProperty || name == OwnerProperty || name == OperatorSpecProperty
 }
 
 // ownerFunction returns a function that returns the owner of the resource
@@ -434,6 +482,92 @@ func ownerFunction(k *objectFunction, codeGenerationContext *CodeGenerationConte
 	return fn.DefineFunc()
 }
 
+// extensionOwnerFunction returns a function that returns the owner of an extension resource, which names the group and
+// kind of its owner itself as it can be applied to a resource of any kind
+func extensionOwnerFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Params: nil,
+		Returns: []*ast.Field{
+			{
+				Type: &ast.StarExpr{
+					X: &ast.SelectorExpr{
+						X:   ast.NewIdent(GenRuntimePackageName),
+						Sel: ast.NewIdent("ResourceReference"),
+					},
+				},
+			},
+		},
+		Body: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X: &ast.SelectorExpr{
+								X: &ast.SelectorExpr{
+									X:   ast.NewIdent(receiverIdent),
+									Sel: ast.NewIdent("Spec"),
+								},
+								Sel: ast.NewIdent(OwnerProperty),
+							},
+							Sel: ast.NewIdent("AsResourceReference"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	fn.AddComments("returns the ResourceReference of the resource this extension resource is applied to")
+
+	return fn.DefineFunc()
+}
+
+// extensionScopeFunction returns a function that returns the extension scope, used by the runtime to deploy the
+// resource as a nested resource of its owner
+func extensionScopeFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Params: nil,
+		Returns: []*ast.Field{
+			{
+				Type: &ast.SelectorExpr{
+					X:   ast.NewIdent(GenRuntimePackageName),
+					Sel: ast.NewIdent("ResourceScope"),
+				},
+			},
+		},
+		Body: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.SelectorExpr{
+						X:   ast.NewIdent(GenRuntimePackageName),
+						Sel: ast.NewIdent("ResourceScopeExtension"),
+					},
+				},
+			},
+		},
+	}
+
+	fn.AddComments("returns the scope of the resource, which is always an extension of another resource")
+
+	return fn.DefineFunc()
+}
+
 func lookupGroupAndKindStmt(
 	groupIdent string,
 	kindIdent string,
@@ -464,6 +598,16 @@ func createResourceReference(
 	kindIdent string,
 	specSelector *ast.SelectorExpr) ast.Expr {
 
+	ownerField := func(name string) ast.Expr {
+		return &ast.SelectorExpr{
+			X: &ast.SelectorExpr{
+				X:   ast.Clone(specSelector).(ast.Expr),
+				Sel: ast.NewIdent(OwnerProperty),
+			},
+			Sel: ast.NewIdent(name),
+		}
+	}
+
 	return astbuilder.AddrOf(
 		&ast.CompositeLit{
 			Type: &ast.SelectorExpr{
@@ -472,14 +616,12 @@ func createResourceReference(
 			},
 			Elts: []ast.Expr{
 				&ast.KeyValueExpr{
-					Key: ast.NewIdent("Name"),
-					Value: &ast.SelectorExpr{
-						X: &ast.SelectorExpr{
-							X:   specSelector,
-							Sel: ast.NewIdent(OwnerProperty),
-						},
-						Sel: ast.NewIdent("Name"),
-					},
+					Key:   ast.NewIdent("Name"),
+					Value: ownerField("Name"),
+				},
+				&ast.KeyValueExpr{
+					Key:   ast.NewIdent("Namespace"),
+					Value: ownerField("Namespace"),
 				},
 				&ast.KeyValueExpr{
 					Key:   ast.NewIdent("Group"),
@@ -432,6 +432,17 @@ func (objectType *ObjectType) WithFunction(function Function) *ObjectType {
 	return result
 }
 
+// WithoutFunction removes the function with the specified name
+func (objectType *ObjectType) WithoutFunction(name string) *ObjectType {
+	if !objectType.HasFunctionWithName(name) {
+		return objectType
+	}
+
+	result := objectType.copy()
+	delete(result.functions, name)
+	return result
+}
+
 // WithInterface creates a new ObjectType that's a copy with an interface implementation attached
 func (objectType *ObjectType) WithInterface(iface *InterfaceImplementation) *ObjectType {
 	// Create a copy of objectType to preserve immutability
@@ -536,6 +536,8 @@ func (o ObjectSerializationTestCase) createIndependentGenerator(
 		return astbuilder.CallQualifiedFunc(genPackage, "UInt32")
 	case IntType:
 		return astbuilder.CallQualifiedFunc(genPackage, "Int")
+	case Int64Type:
+		return astbuilder.CallQualifiedFunc(genPackage, "Int64")
 	case FloatType:
 		return astbuilder.CallQualifiedFunc(genPackage, "Float32")
 	case BoolType:
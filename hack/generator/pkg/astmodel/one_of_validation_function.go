@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package astmodel
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astbuilder"
+	ast "github.com/dave/dst"
+)
+
+const OneOfValidationFunctionName string = "ValidateOneOf"
+
+// OneOfValidationFunction is a function for checking that at most one property of a discriminated union
+// (types with only mutually exclusive properties) is set, so that it implements genruntime.OneOf
+type OneOfValidationFunction struct {
+	oneOfObject *ObjectType
+	idFactory   IdentifierFactory
+}
+
+// NewOneOfValidationFunction creates a new OneOfValidationFunction struct
+func NewOneOfValidationFunction(oneOfObject *ObjectType, idFactory IdentifierFactory) *OneOfValidationFunction {
+	return &OneOfValidationFunction{oneOfObject, idFactory}
+}
+
+// Ensure OneOfValidationFunction implements Function interface correctly
+var _ Function = (*OneOfValidationFunction)(nil)
+
+func (f *OneOfValidationFunction) Name() string {
+	return OneOfValidationFunctionName
+}
+
+// Equals determines if this function is equal to the passed in function
+func (f *OneOfValidationFunction) Equals(other Function) bool {
+	if o, ok := other.(*OneOfValidationFunction); ok {
+		return f.oneOfObject.Equals(o.oneOfObject)
+	}
+
+	return false
+}
+
+// References returns the set of references for the underlying object.
+func (f *OneOfValidationFunction) References() TypeNameSet {
+	// Defer this check to the owning object as we only refer to its properties and it
+	return f.oneOfObject.References()
+}
+
+// AsFunc returns the function as a go ast
+func (f *OneOfValidationFunction) AsFunc(
+	codeGenerationContext *CodeGenerationContext,
+	receiver TypeName) *ast.FuncDecl {
+
+	genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(GenRuntimeReference)
+
+	receiverName := f.idFactory.CreateIdentifier(receiver.name, NotExported)
+
+	var elements []ast.Expr
+	for _, property := range f.oneOfObject.Properties() {
+		elements = append(elements, &ast.KeyValueExpr{
+			Key: astbuilder.StringLiteral(property.JsonName()),
+			Value: &ast.BinaryExpr{
+				X: &ast.SelectorExpr{
+					X:   ast.NewIdent(receiverName),
+					Sel: ast.NewIdent(string(property.propertyName)),
+				},
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Decs: ast.KeyValueExprDecorations{
+				NodeDecs: ast.NodeDecs{
+					Before: ast.NewLine,
+					After:  ast.NewLine,
+				},
+			},
+		})
+	}
+
+	isSet := &ast.CompositeLit{
+		Type: &ast.MapType{
+			Key:   ast.NewIdent("string"),
+			Value: ast.NewIdent("bool"),
+		},
+		Elts: elements,
+	}
+
+	fn := &astbuilder.FuncDetails{
+		Name:          f.Name(),
+		ReceiverIdent: receiverName,
+		ReceiverType:  receiver.AsType(codeGenerationContext),
+		Body: []ast.Stmt{
+			astbuilder.Returns(astbuilder.CallQualifiedFunc(genRuntimePackage, "ValidateOneOf", isSet)),
+		},
+	}
+
+	fn.AddComments(fmt.Sprintf(
+		"returns an error if more than one property is set, because %s represents a discriminated union (JSON OneOf)",
+		receiver.name))
+	fn.AddReturns("error")
+	return fn.DefineFunc()
+}
+
+// RequiredPackageReferences returns a list of packages required by this
+func (f *OneOfValidationFunction) RequiredPackageReferences() *PackageReferenceSet {
+	return NewPackageReferenceSet(GenRuntimeReference)
+}
@@ -17,6 +17,9 @@ type PrimitiveType struct {
 // IntType represents a Go integer type
 var IntType = &PrimitiveType{"int"}
 
+// Int64Type represents a Go int64 type
+var Int64Type = &PrimitiveType{"int64"}
+
 // UInt64Type represents a Go uint64 type
 var UInt64Type = &PrimitiveType{"uint64"}
 
@@ -23,6 +23,9 @@ type PropertyDefinition struct {
 	propertyType Type
 	description  string
 	isRequired   bool
+	isReadOnly   bool
+	isImmutable  bool
+	isSecret     bool
 	tags         map[string][]string
 }
 
@@ -46,6 +49,11 @@ func (property *PropertyDefinition) PropertyName() PropertyName {
 	return property.propertyName
 }
 
+// JsonName returns the name of the property when serialized to JSON
+func (property *PropertyDefinition) JsonName() string {
+	return property.tags["json"][0]
+}
+
 // PropertyType returns the data type of the property
 func (property *PropertyDefinition) PropertyType() Type {
 	return property.propertyType
@@ -208,6 +216,59 @@ func (property *PropertyDefinition) IsRequired() bool {
 	return property.isRequired
 }
 
+// MakeReadOnly returns a new PropertyDefinition that is marked as read-only, as its value is only ever set by the
+// server
+func (property *PropertyDefinition) MakeReadOnly() *PropertyDefinition {
+	if property.isReadOnly {
+		return property
+	}
+
+	result := property.copy()
+	result.isReadOnly = true
+	return result
+}
+
+// IsReadOnly returns true if the value of the property is only ever set by the server;
+// returns false otherwise.
+func (property *PropertyDefinition) IsReadOnly() bool {
+	return property.isReadOnly
+}
+
+// MakeImmutable returns a new PropertyDefinition that is marked as immutable, as its value can only be given when the
+// resource is created
+func (property *PropertyDefinition) MakeImmutable() *PropertyDefinition {
+	if property.isImmutable {
+		return property
+	}
+
+	result := property.copy()
+	result.isImmutable = true
+	return result
+}
+
+// IsImmutable returns true if the value of the property can't be changed once the resource has been created;
+// returns false otherwise.
+func (property *PropertyDefinition) IsImmutable() bool {
+	return property.isImmutable
+}
+
+// MakeSecret returns a new PropertyDefinition that is marked as holding a sensitive value, such as a password or key
+func (property *PropertyDefinition) MakeSecret() *PropertyDefinition {
+	if property.isSecret {
+		return property
+	}
+
+	result := property.copy()
+	result.isSecret = true
+	return result
+}
+
+// IsSecret returns true if the value of the property is sensitive;
+// returns false otherwise.
+func (property *PropertyDefinition) IsSecret() bool {
+	return property.isSecret
+}
+
 // hasOptionalType returns true if the type of this property is an optional reference to a value
 // (and might therefore be nil).
 func (property *PropertyDefinition) hasOptionalType() bool {
@@ -311,6 +372,9 @@ func (property *PropertyDefinition) Equals(f *PropertyDefinition) bool {
 		property.propertyType.Equals(f.propertyType) &&
 		property.tagsEqual(f) &&
 		property.isRequired == f.isRequired &&
+		property.isReadOnly == f.isReadOnly &&
+		property.isImmutable == f.isImmutable &&
+		property.isSecret == f.isSecret &&
 		property.description == f.description)
 }
 
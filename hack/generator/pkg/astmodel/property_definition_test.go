@@ -452,6 +452,33 @@ func Test_PropertyDefinition_WithValidation_ReturnsNewPropertyDefinition(t *test
 	g.Expect(updated.IsRequired()).To(BeTrue())
 }
 
+/*
+ * MakeReadOnly(), MakeImmutable() and MakeSecret() Tests
+ */
+
+func Test_PropertyDefinitionFlags_WhenSet_ArePreservedByOtherChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	original := NewPropertyDefinition(propertyName, propertyJsonName, propertyType).
+		MakeReadOnly().
+		MakeImmutable().
+		MakeSecret()
+	updated := original.MakeOptional().WithType(IntType).WithDescription("changed")
+
+	g.Expect(updated.IsReadOnly()).To(BeTrue())
+	g.Expect(updated.IsImmutable()).To(BeTrue())
+	g.Expect(updated.IsSecret()).To(BeTrue())
+}
+
+func Test_PropertyDefinitionMakeImmutable_WhenImmutable_ReturnsExistingReference(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	original := NewPropertyDefinition(propertyName, propertyJsonName, propertyType).MakeImmutable()
+	updated := original.MakeImmutable()
+
+	g.Expect(updated).To(BeIdenticalTo(original))
+}
+
 /*
  * Equals Tests
  */
@@ -468,6 +495,8 @@ func TestPropertyDefinition_Equals_WhenGivenPropertyDefinition_ReturnsExpectedRe
 	differentTags := createStringProperty("FullName", "Full Legal Name").WithTag("a", "b")
 	differentDescription := createStringProperty("FullName", "The whole thing")
 	differentValidation := createStringProperty("FullName", "Full Legal Name").SetRequired(true)
+	differentMutability := createStringProperty("FullName", "Full Legal Name").MakeImmutable()
+	differentSensitivity := createStringProperty("FullName", "Full Legal Name").MakeSecret()
 
 	cases := []struct {
 		name          string
@@ -487,6 +516,8 @@ func TestPropertyDefinition_Equals_WhenGivenPropertyDefinition_ReturnsExpectedRe
 		{"Not-equal if descriptions are different", strProperty, differentDescription, false},
 		{"Not-equal if tags are different", strProperty, differentTags, false},
 		{"Not-equal if validations are different", strProperty, differentValidation, false},
+		{"Not-equal if mutability is different", strProperty, differentMutability, false},
+		{"Not-equal if sensitivity is different", strProperty, differentSensitivity, false},
 	}
 
 	for _, c := range cases {
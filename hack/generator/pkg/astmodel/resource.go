@@ -8,6 +8,7 @@ package astmodel
 import (
 	"fmt"
 	"go/token"
+	"sort"
 
 	"github.com/Azure/k8s-infra/hack/generator/pkg/astbuilder"
 	ast "github.com/dave/dst"
@@ -20,6 +21,7 @@ type ResourceType struct {
 	spec             Type
 	status           Type
 	isStorageVersion bool
+	isExtension      bool
 	owner            *TypeName
 	testcases        map[string]TestCase
 	InterfaceImplementer
@@ -29,6 +31,7 @@ type ResourceType struct {
 func NewResourceType(specType Type, statusType Type) *ResourceType {
 	result := &ResourceType{
 		isStorageVersion:     false,
+		isExtension:          false,
 		owner:                nil,
 		testcases:            make(map[string]TestCase),
 		InterfaceImplementer: MakeInterfaceImplementer(),
@@ -201,6 +204,7 @@ func (resource *ResourceType) Equals(other Type) bool {
 
 	// Do cheap tests earlier
 	if resource.isStorageVersion != otherResource.isStorageVersion ||
+		resource.isExtension != otherResource.isExtension ||
 		len(resource.testcases) != len(otherResource.testcases) ||
 		!TypeEquals(resource.spec, otherResource.spec) ||
 		!TypeEquals(resource.status, otherResource.status) ||
@@ -241,6 +245,11 @@ func (resource *ResourceType) Owner() *TypeName {
 	return resource.owner
 }
 
+// IsStorageVersion returns true if the resource is the Kubebuilder storage version
+func (resource *ResourceType) IsStorageVersion() bool {
+	return resource.isStorageVersion
+}
+
 // MarkAsStorageVersion marks the resource as the Kubebuilder storage version
 func (resource *ResourceType) MarkAsStorageVersion() *ResourceType {
 	result := resource.copy()
@@ -248,6 +257,19 @@ func (resource *ResourceType) MarkAsStorageVersion() *ResourceType {
 	return result
 }
 
+// IsExtension returns true if the resource is an ARM extension resource, which extends another resource of any kind
+// rather than being owned by a resource of a particular kind
+func (resource *ResourceType) IsExtension() bool {
+	return resource.isExtension
+}
+
+// MarkAsExtension marks the resource as an ARM extension resource
+func (resource *ResourceType) MarkAsExtension() *ResourceType {
+	result := resource.copy()
+	result.isExtension = true
+	return result
+}
+
 // WithOwner updates the owner of the resource and returns a copy of the resource
 func (resource *ResourceType) WithOwner(owner *TypeName) *ResourceType {
 	result := resource.copy()
@@ -403,6 +425,7 @@ func (resource *ResourceType) copy() *ResourceType {
 		spec:                 resource.spec,
 		status:               resource.status,
 		isStorageVersion:     resource.isStorageVersion,
+		isExtension:          resource.isExtension,
 		owner:                resource.owner,
 		testcases:            make(map[string]TestCase),
 		InterfaceImplementer: resource.InterfaceImplementer.copy(),
@@ -418,3 +441,17 @@ func (resource *ResourceType) copy() *ResourceType {
 func (resource *ResourceType) HasTestCases() bool {
 	return len(resource.testcases) > 0
 }
+
+// TestCases returns the test cases of this resource, ordered by name so that output is stable
+func (resource *ResourceType) TestCases() []TestCase {
+	var result []TestCase
+	for _, tc := range resource.testcases {
+		result = append(result, tc)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+
+	return result
+}
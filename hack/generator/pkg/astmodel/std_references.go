@@ -15,9 +15,18 @@ var (
 	// References to our Libraries
 	GenRuntimeReference PackageReference = MakeExternalPackageReference(genRuntimePathPrefix)
 
+	// Types from our libraries
+	SecretReferenceTypeName         = MakeTypeName(GenRuntimeReference, "SecretReference")
+	OperatorSpecTypeName            = MakeTypeName(GenRuntimeReference, "OperatorSpec")
+	ResourceReferenceTypeName       = MakeTypeName(GenRuntimeReference, "ResourceReference")
+	KnownResourceReferenceTypeName  = MakeTypeName(GenRuntimeReference, "KnownResourceReference")
+	ArbitraryOwnerReferenceTypeName = MakeTypeName(GenRuntimeReference, "ArbitraryOwnerReference")
+	PropertyBagTypeName             = MakeTypeName(GenRuntimeReference, "PropertyBag")
+
 	// References to other libraries
-	ApiExtensionsReference     = MakeExternalPackageReference("k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1")
-	ApiExtensionsJsonReference = MakeExternalPackageReference("k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1/JSON")
+	ApiExtensionsReference       = MakeExternalPackageReference("k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1")
+	ApiExtensionsJsonReference   = MakeExternalPackageReference("k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1/JSON")
+	ApiMachineryRuntimeReference = MakeExternalPackageReference("k8s.io/apimachinery/pkg/runtime")
 
 	// References to libraries used for testing
 	CmpReference        PackageReference = MakeExternalPackageReference("github.com/google/go-cmp/cmp")
@@ -0,0 +1,353 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package storageconversion
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astbuilder"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	ast "github.com/dave/dst"
+)
+
+type ConversionDirection string
+
+const (
+	ConversionDirectionToHub   = ConversionDirection("ToHub")
+	ConversionDirectionFromHub = ConversionDirection("FromHub")
+)
+
+var (
+	// ConversionReference is the controller-runtime package defining the hub and spoke conversion interfaces
+	ConversionReference = astmodel.MakeExternalPackageReference("sigs.k8s.io/controller-runtime/pkg/conversion")
+
+	// ConvertibleInterfaceName is the interface implemented by every version of a resource other than the hub
+	ConvertibleInterfaceName = astmodel.MakeTypeName(ConversionReference, "Convertible")
+
+	// HubInterfaceName is the interface implemented by the version of a resource that all others convert via
+	HubInterfaceName = astmodel.MakeTypeName(ConversionReference, "Hub")
+)
+
+// HubConversionFunction converts a spoke version of a resource to or from the hub version. Versions are chained: each
+// API version converts via its own storage version, and each storage version via the next newer storage version, until
+// the hub is reached. The spec and status are converted property by property by the methods generated on them (see
+// ObjectConversionFunction), with their property bags carrying any values the other versions have no place for.
+//
+// For ConvertToHub:
+//
+//	func (widget *Widget) ConvertTo(hub conversion.Hub) error {
+//		destination := &v20200101storage.Widget{}
+//		destination.ObjectMeta = *widget.ObjectMeta.DeepCopy()
+//		specBag := genruntime.NewPropertyBag(widget.Spec.PropertyBag)
+//		if err := widget.Spec.ConvertToV20200101storage(&destination.Spec, specBag, ""); err != nil {
+//			return err
+//		}
+//		destination.Spec.PropertyBag = specBag
+//		... and the same for the status ...
+//		return destination.ConvertTo(hub)
+//	}
+//
+// ConvertFromHub works in reverse, first populating the next version from the hub.
+type HubConversionFunction struct {
+	name      string
+	direction ConversionDirection
+	hub       astmodel.TypeName
+	// next is the version of the resource after this one, which may be the hub itself
+	next      astmodel.TypeName
+	parts     []ResourcePart
+	idFactory astmodel.IdentifierFactory
+}
+
+// ResourcePart captures how to convert the spec or status of a resource
+type ResourcePart struct {
+	// Property is the name of the property holding the part on the resource
+	Property astmodel.PropertyName
+	// Spoke and Next are the types of the part in this version and the next
+	Spoke astmodel.TypeName
+	Next  astmodel.TypeName
+	// SpokeHasBag and NextHasBag say whether the part has a property bag in each version
+	SpokeHasBag bool
+	NextHasBag  bool
+}
+
+var _ astmodel.Function = &HubConversionFunction{}
+
+// NewConvertibleImpl creates a new implementation of conversion.Convertible, converting to and from the given hub via
+// the next version of the resource
+func NewConvertibleImpl(
+	hub astmodel.TypeName,
+	next astmodel.TypeName,
+	parts []ResourcePart,
+	idFactory astmodel.IdentifierFactory) *astmodel.InterfaceImplementation {
+
+	convertTo := &HubConversionFunction{
+		name:      "ConvertTo",
+		direction: ConversionDirectionToHub,
+		hub:       hub,
+		next:      next,
+		parts:     parts,
+		idFactory: idFactory,
+	}
+
+	convertFrom := &HubConversionFunction{
+		name:      "ConvertFrom",
+		direction: ConversionDirectionFromHub,
+		hub:       hub,
+		next:      next,
+		parts:     parts,
+		idFactory: idFactory,
+	}
+
+	return astmodel.NewInterfaceImplementation(ConvertibleInterfaceName, convertTo, convertFrom)
+}
+
+// Name returns the name of this function
+func (c *HubConversionFunction) Name() string {
+	return c.name
+}
+
+// RequiredPackageReferences returns the packages required by this conversion function
+func (c *HubConversionFunction) RequiredPackageReferences() *astmodel.PackageReferenceSet {
+	if !c.next.Equals(c.hub) {
+		// We only need the hub package if we refer to the hub directly
+		return astmodel.NewPackageReferenceSet(
+			astmodel.GenRuntimeReference,
+			ConversionReference,
+			c.next.PackageReference)
+	}
+
+	return astmodel.NewPackageReferenceSet(
+		astmodel.GenRuntimeReference,
+		astmodel.FmtReference,
+		ConversionReference,
+		c.hub.PackageReference)
+}
+
+// References returns the hub and next versions, which this function converts via
+func (c *HubConversionFunction) References() astmodel.TypeNameSet {
+	return astmodel.NewTypeNameSet(c.hub, c.next)
+}
+
+// AsFunc returns the function as a Go AST
+func (c *HubConversionFunction) AsFunc(codeGenerationContext *astmodel.CodeGenerationContext, receiver astmodel.TypeName) *ast.FuncDecl {
+	genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(astmodel.GenRuntimeReference)
+	conversionPackage := codeGenerationContext.MustGetImportedPackageName(ConversionReference)
+	hubPackage := c.hub.PackageReference.PackageName()
+
+	receiverIdent := c.idFactory.CreateIdentifier(receiver.Name(), astmodel.NotExported)
+	hubIdent := "hub"
+
+	var localIdent string
+	var source string
+	var destination string
+	var comment string
+	switch c.direction {
+	case ConversionDirectionToHub:
+		localIdent = "destination"
+		source = receiverIdent
+		destination = localIdent
+		comment = fmt.Sprintf("converts this %s to the hub version, %s.%s", receiver.Name(), hubPackage, c.hub.Name())
+	case ConversionDirectionFromHub:
+		localIdent = "source"
+		source = localIdent
+		destination = receiverIdent
+		comment = fmt.Sprintf("populates this %s from the hub version, %s.%s", receiver.Name(), hubPackage, c.hub.Name())
+	default:
+		panic(fmt.Sprintf("Unknown conversion direction %s", c.direction))
+	}
+
+	var body []ast.Stmt
+	if c.next.Equals(c.hub) {
+		// destination, ok := hub.(*v20200601storage.Widget)
+		body = append(body,
+			astbuilder.TypeAssert(
+				ast.NewIdent(localIdent),
+				ast.NewIdent(hubIdent),
+				&ast.StarExpr{X: c.hub.AsType(codeGenerationContext)}),
+			astbuilder.ReturnIfNotOk(
+				astbuilder.FormatError(
+					codeGenerationContext.MustGetImportedPackageName(astmodel.FmtReference),
+					fmt.Sprintf("expected hub to be a *%s.%s but received %%T", hubPackage, c.hub.Name()),
+					ast.NewIdent(hubIdent))))
+	} else {
+		// destination := &v20200101storage.Widget{}
+		body = append(body,
+			astbuilder.SimpleAssignment(
+				ast.NewIdent(localIdent),
+				token.DEFINE,
+				astbuilder.AddrOf(&ast.CompositeLit{Type: c.next.AsType(codeGenerationContext)})))
+
+		if c.direction == ConversionDirectionFromHub {
+			// The next version has to be populated from the hub before we can populate ourselves from it
+			body = append(body, returnIfError(astbuilder.CallQualifiedFunc(localIdent, "ConvertFrom", ast.NewIdent(hubIdent))))
+		}
+	}
+
+	// destination.ObjectMeta = *source.ObjectMeta.DeepCopy()
+	body = append(body,
+		astbuilder.SimpleAssignment(
+			&ast.SelectorExpr{X: ast.NewIdent(destination), Sel: ast.NewIdent("ObjectMeta")},
+			token.ASSIGN,
+			&ast.StarExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   &ast.SelectorExpr{X: ast.NewIdent(source), Sel: ast.NewIdent("ObjectMeta")},
+						Sel: ast.NewIdent("DeepCopy"),
+					},
+				},
+			}))
+
+	for _, part := range c.parts {
+		body = append(body, c.convertPart(part, genRuntimePackage, receiverIdent, localIdent, source, destination)...)
+	}
+
+	if c.direction == ConversionDirectionToHub && !c.next.Equals(c.hub) {
+		body = append(body, astbuilder.Returns(astbuilder.CallQualifiedFunc(localIdent, "ConvertTo", ast.NewIdent(hubIdent))))
+	} else {
+		body = append(body, astbuilder.Returns(ast.NewIdent("nil")))
+	}
+
+	fn := &astbuilder.FuncDetails{
+		Name:          c.name,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: body,
+	}
+
+	fn.AddParameter(hubIdent, astbuilder.QualifiedTypeName(conversionPackage, "Hub"))
+	fn.AddReturns("error")
+	fn.AddComments(comment)
+
+	return fn.DefineFunc()
+}
+
+// convertPart returns the statements converting the spec or status of the resource
+func (c *HubConversionFunction) convertPart(
+	part ResourcePart,
+	genRuntimePackage string,
+	receiverIdent string,
+	localIdent string,
+	source string,
+	destination string) []ast.Stmt {
+
+	bagIdent := c.idFactory.CreateIdentifier(string(part.Property)+"Bag", astmodel.NotExported)
+	sourceHasBag, destinationHasBag := part.SpokeHasBag, part.NextHasBag
+	if c.direction == ConversionDirectionFromHub {
+		sourceHasBag, destinationHasBag = part.NextHasBag, part.SpokeHasBag
+	}
+
+	partOf := func(ident string) *ast.SelectorExpr {
+		return &ast.SelectorExpr{X: ast.NewIdent(ident), Sel: ast.NewIdent(string(part.Property))}
+	}
+
+	// specBag := genruntime.NewPropertyBag(source.Spec.PropertyBag)
+	var bags []ast.Expr
+	if sourceHasBag {
+		bags = append(bags, &ast.SelectorExpr{X: partOf(source), Sel: ast.NewIdent(string(astmodel.PropertyBagProperty))})
+	}
+
+	result := []ast.Stmt{
+		astbuilder.SimpleAssignment(
+			ast.NewIdent(bagIdent),
+			token.DEFINE,
+			astbuilder.CallQualifiedFunc(genRuntimePackage, "NewPropertyBag", bags...)),
+	}
+
+	// The conversion methods are always on the spoke side, which is us
+	call := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   partOf(receiverIdent),
+			Sel: ast.NewIdent(conversionMethodName(c.direction, c.next.PackageReference)),
+		},
+		Args: []ast.Expr{astbuilder.AddrOf(partOf(localIdent)), ast.NewIdent(bagIdent), astbuilder.StringLiteral("")},
+	}
+
+	result = append(result, returnIfError(call))
+
+	if destinationHasBag {
+		// destination.Spec.PropertyBag = specBag
+		result = append(result,
+			astbuilder.SimpleAssignment(
+				&ast.SelectorExpr{X: partOf(destination), Sel: ast.NewIdent(string(astmodel.PropertyBagProperty))},
+				token.ASSIGN,
+				ast.NewIdent(bagIdent)))
+	}
+
+	return result
+}
+
+// Equals determines if this function is equal to the passed in function
+func (c *HubConversionFunction) Equals(other astmodel.Function) bool {
+	if o, ok := other.(*HubConversionFunction); ok {
+		if len(c.parts) != len(o.parts) {
+			return false
+		}
+
+		for i := range c.parts {
+			if c.parts[i] != o.parts[i] {
+				return false
+			}
+		}
+
+		return c.name == o.name &&
+			c.hub.Equals(o.hub) &&
+			c.next.Equals(o.next) &&
+			c.direction == o.direction
+	}
+
+	return false
+}
+
+// HubFunction is the marker method identifying the hub version of a resource
+type HubFunction struct {
+	idFactory astmodel.IdentifierFactory
+}
+
+var _ astmodel.Function = HubFunction{}
+
+// NewHubImpl creates a new implementation of conversion.Hub
+func NewHubImpl(idFactory astmodel.IdentifierFactory) *astmodel.InterfaceImplementation {
+	return astmodel.NewInterfaceImplementation(HubInterfaceName, HubFunction{idFactory: idFactory})
+}
+
+// Name returns the name of this function
+func (f HubFunction) Name() string {
+	return "Hub"
+}
+
+// RequiredPackageReferences returns the packages required by this function, of which there are none
+func (f HubFunction) RequiredPackageReferences() *astmodel.PackageReferenceSet {
+	return astmodel.NewPackageReferenceSet()
+}
+
+// References returns the types referenced by this function, of which there are none
+func (f HubFunction) References() astmodel.TypeNameSet {
+	return nil
+}
+
+// AsFunc returns the function as a Go AST
+func (f HubFunction) AsFunc(codeGenerationContext *astmodel.CodeGenerationContext, receiver astmodel.TypeName) *ast.FuncDecl {
+	fn := &astbuilder.FuncDetails{
+		Name:          f.Name(),
+		ReceiverIdent: f.idFactory.CreateIdentifier(receiver.Name(), astmodel.NotExported),
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+	}
+
+	fn.AddComments("marks that this " + receiver.Name() + " is the hub type for conversion")
+
+	return fn.DefineFunc()
+}
+
+// Equals determines if this function is equal to the passed in function
+func (f HubFunction) Equals(other astmodel.Function) bool {
+	_, ok := other.(HubFunction)
+	return ok
+}
@@ -0,0 +1,317 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package storageconversion
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astbuilder"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	ast "github.com/dave/dst"
+)
+
+// ObjectConversionFunction converts an object type in a spoke version of a resource to or from the matching object
+// type in the next version towards the hub, property by property. Properties which the destination doesn't have (or
+// which have a different shape there) are kept in the property bag of the spec or status being converted, keyed by
+// their path, and are restored from there when converting back.
+//
+// For ConvertToHub:
+//
+//	func (spec *Widget_Spec) ConvertToV20200601storage(destination *v20200601storage.Widget_Spec, bag genruntime.PropertyBag, path string) error {
+//		destination.Name = spec.Name
+//		if err := bag.Add(genruntime.PropertyPath(path, "colour"), spec.Colour); err != nil {
+//			return err
+//		}
+//		return nil
+//	}
+//
+// For ConvertFromHub the receiver is populated from the source instead.
+type ObjectConversionFunction struct {
+	name      string
+	direction ConversionDirection
+	// other is the matching object type in the next version
+	other      astmodel.TypeName
+	properties []propertyConversion
+	idFactory  astmodel.IdentifierFactory
+}
+
+// propertyConversion captures how to convert a single property
+type propertyConversion struct {
+	jsonName string
+	// source and destination are the property in each version; either may be nil if the version lacks the property
+	source      *astmodel.PropertyDefinition
+	destination *astmodel.PropertyDefinition
+	// conversion is how to convert the value, or nil if it has to go via the property bag
+	conversion *valueConversion
+}
+
+var _ astmodel.Function = &ObjectConversionFunction{}
+
+// NewObjectConversionFunction creates a new function converting the spoke object type to or from the other object
+// type, which must be in the next version. Also returns the names of any nested spoke object types which need
+// conversion functions of their own.
+func NewObjectConversionFunction(
+	types astmodel.Types,
+	direction ConversionDirection,
+	spoke astmodel.TypeName,
+	other astmodel.TypeName,
+	idFactory astmodel.IdentifierFactory) (*ObjectConversionFunction, []astmodel.TypeName, error) {
+
+	spokeType, err := lookupObjectType(types, spoke)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	otherType, err := lookupObjectType(types, other)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sourceType, destinationType := spokeType, otherType
+	if direction == ConversionDirectionFromHub {
+		sourceType, destinationType = otherType, spokeType
+	}
+
+	planner := newConversionPlanner(types, direction, other.PackageReference)
+
+	propertyNames := make(map[astmodel.PropertyName]struct{})
+	for _, prop := range sourceType.Properties() {
+		propertyNames[prop.PropertyName()] = struct{}{}
+	}
+
+	for _, prop := range destinationType.Properties() {
+		propertyNames[prop.PropertyName()] = struct{}{}
+	}
+
+	// The property bag isn't converted; it's populated by the resource as a whole
+	delete(propertyNames, astmodel.PropertyBagProperty)
+
+	sortedNames := make([]astmodel.PropertyName, 0, len(propertyNames))
+	for name := range propertyNames {
+		sortedNames = append(sortedNames, name)
+	}
+
+	sort.Slice(sortedNames, func(i, j int) bool {
+		return sortedNames[i] < sortedNames[j]
+	})
+
+	result := &ObjectConversionFunction{
+		name:      conversionMethodName(direction, other.PackageReference),
+		direction: direction,
+		other:     other,
+		idFactory: idFactory,
+	}
+
+	for _, name := range sortedNames {
+		source, _ := sourceType.Property(name)
+		destination, _ := destinationType.Property(name)
+
+		property := propertyConversion{
+			source:      source,
+			destination: destination,
+		}
+
+		if source != nil {
+			property.jsonName = jsonNameOf(source)
+		} else {
+			property.jsonName = jsonNameOf(destination)
+		}
+
+		if source != nil && destination != nil {
+			property.conversion = planner.plan(source.PropertyType(), destination.PropertyType())
+		}
+
+		result.properties = append(result.properties, property)
+	}
+
+	var nested []astmodel.TypeName
+	for name := range planner.objects {
+		nested = append(nested, name)
+	}
+
+	sort.Slice(nested, func(i, j int) bool {
+		return nested[i].String() < nested[j].String()
+	})
+
+	return result, nested, nil
+}
+
+// Name returns the name of this function
+func (fn *ObjectConversionFunction) Name() string {
+	return fn.name
+}
+
+// RequiredPackageReferences returns the packages required by this conversion function
+func (fn *ObjectConversionFunction) RequiredPackageReferences() *astmodel.PackageReferenceSet {
+	result := astmodel.NewPackageReferenceSet(astmodel.GenRuntimeReference, fn.other.PackageReference)
+	for _, prop := range fn.properties {
+		if prop.conversion == nil {
+			continue
+		}
+
+		// Types of either version may be used when casting or declaring values
+		result.Merge(stripValidations(prop.source.PropertyType()).RequiredPackageReferences())
+		result.Merge(stripValidations(prop.destination.PropertyType()).RequiredPackageReferences())
+	}
+
+	return result
+}
+
+// References returns the types this function refers to
+func (fn *ObjectConversionFunction) References() astmodel.TypeNameSet {
+	result := astmodel.NewTypeNameSet(fn.other)
+	for _, prop := range fn.properties {
+		if prop.conversion == nil {
+			continue
+		}
+
+		result = astmodel.SetUnion(result, prop.source.PropertyType().References())
+		result = astmodel.SetUnion(result, prop.destination.PropertyType().References())
+	}
+
+	return result
+}
+
+// AsFunc returns the function as a Go AST
+func (fn *ObjectConversionFunction) AsFunc(codeGenerationContext *astmodel.CodeGenerationContext, receiver astmodel.TypeName) *ast.FuncDecl {
+	otherPackage := codeGenerationContext.MustGetImportedPackageName(fn.other.PackageReference)
+	genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(astmodel.GenRuntimeReference)
+
+	var parameterIdent string
+	var comment string
+	switch fn.direction {
+	case ConversionDirectionToHub:
+		parameterIdent = "destination"
+		comment = fmt.Sprintf("populates the provided destination %s.%s from our %s",
+			otherPackage, fn.other.Name(), receiver.Name())
+	case ConversionDirectionFromHub:
+		parameterIdent = "source"
+		comment = fmt.Sprintf("populates our %s from the provided source %s.%s",
+			receiver.Name(), otherPackage, fn.other.Name())
+	default:
+		panic(fmt.Sprintf("Unknown conversion direction %s", fn.direction))
+	}
+
+	receiverIdent := fn.idFactory.CreateIdentifier(receiver.Name(), astmodel.NotExported)
+	switch receiverIdent {
+	case parameterIdent, "bag", "path", "err":
+		receiverIdent = "receiver"
+	}
+
+	renderer := newConversionRenderer(codeGenerationContext, fn.direction, receiverIdent, parameterIdent)
+
+	source, destination := receiverIdent, parameterIdent
+	if fn.direction == ConversionDirectionFromHub {
+		source, destination = parameterIdent, receiverIdent
+	}
+
+	var body []ast.Stmt
+	for _, prop := range fn.properties {
+		body = append(body, fn.convertProperty(renderer, prop, ast.NewIdent(source), ast.NewIdent(destination))...)
+	}
+
+	body = append(body, astbuilder.Returns(ast.NewIdent("nil")))
+
+	details := &astbuilder.FuncDetails{
+		Name:          fn.name,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: body,
+	}
+
+	details.AddParameter(parameterIdent, &ast.StarExpr{X: fn.other.AsType(codeGenerationContext)})
+	details.AddParameter(renderer.bagIdent, astbuilder.QualifiedTypeName(genRuntimePackage, "PropertyBag"))
+	details.AddParameter(renderer.pathIdent, ast.NewIdent("string"))
+	details.AddReturns("error")
+	details.AddComments(comment)
+
+	return details.DefineFunc()
+}
+
+// convertProperty returns the statements converting a single property
+func (fn *ObjectConversionFunction) convertProperty(
+	renderer *conversionRenderer,
+	prop propertyConversion,
+	source ast.Expr,
+	destination ast.Expr) []ast.Stmt {
+
+	keys := []ast.Expr{astbuilder.StringLiteral(prop.jsonName)}
+
+	var sourceValue ast.Expr
+	if prop.source != nil {
+		sourceValue = &ast.SelectorExpr{X: source, Sel: ast.NewIdent(string(prop.source.PropertyName()))}
+	}
+
+	var destinationValue ast.Expr
+	if prop.destination != nil {
+		destinationValue = &ast.SelectorExpr{X: destination, Sel: ast.NewIdent(string(prop.destination.PropertyName()))}
+	}
+
+	switch {
+	case prop.conversion != nil:
+		nameHint := fn.idFactory.CreateIdentifier(string(prop.destination.PropertyName()), astmodel.NotExported)
+		return renderer.render(prop.conversion, sourceValue, destinationValue, keys, nameHint)
+
+	case prop.source != nil && prop.destination != nil:
+		// The property has a different shape in each version; restore any value we kept from a version where it
+		// fits, then keep this one in case we come back
+		return []ast.Stmt{
+			renderer.recall(destinationValue, keys),
+			renderer.stash(sourceValue, keys),
+		}
+
+	case prop.source != nil:
+		return []ast.Stmt{renderer.stash(sourceValue, keys)}
+
+	default:
+		return []ast.Stmt{renderer.recall(destinationValue, keys)}
+	}
+}
+
+// Equals determines if this function is equal to the passed in function
+func (fn *ObjectConversionFunction) Equals(other astmodel.Function) bool {
+	if o, ok := other.(*ObjectConversionFunction); ok {
+		return fn.name == o.name &&
+			fn.direction == o.direction &&
+			fn.other.Equals(o.other)
+	}
+
+	return false
+}
+
+// lookupObjectType returns the object type with the given name
+func lookupObjectType(types astmodel.Types, name astmodel.TypeName) (*astmodel.ObjectType, error) {
+	def, ok := types[name]
+	if !ok {
+		return nil, errors.Errorf("couldn't find type %s", name)
+	}
+
+	t := def.Type()
+	if flagged, ok := t.(*astmodel.FlaggedType); ok {
+		t = flagged.Element()
+	}
+
+	objectType, ok := t.(*astmodel.ObjectType)
+	if !ok {
+		return nil, errors.Errorf("type %s was %T, not an object", name, def.Type())
+	}
+
+	return objectType, nil
+}
+
+// jsonNameOf returns the name of the property in JSON, which is how we key it within a property bag
+func jsonNameOf(prop *astmodel.PropertyDefinition) string {
+	if name := prop.JsonName(); name != "" && name != "-" {
+		return name
+	}
+
+	return string(prop.PropertyName())
+}
@@ -0,0 +1,595 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package storageconversion
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astbuilder"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	ast "github.com/dave/dst"
+)
+
+type valueConversionKind string
+
+const (
+	// valueConversionAssign copies the value across, casting it if the two types differ only by name (as an enum
+	// and its base type do)
+	valueConversionAssign = valueConversionKind("assign")
+	// valueConversionObject converts the value by calling the conversion method of the object type
+	valueConversionObject = valueConversionKind("object")
+	// valueConversionArray converts each element of an array
+	valueConversionArray = valueConversionKind("array")
+	// valueConversionMap converts each value of a map
+	valueConversionMap = valueConversionKind("map")
+)
+
+// valueConversion describes how to convert a value of one type into a value of a matching type in another version
+type valueConversion struct {
+	kind                valueConversionKind
+	sourceOptional      bool
+	destinationOptional bool
+	// destinationType is the (non-optional) type of the destination, used to declare intermediate values
+	destinationType astmodel.Type
+	// castTo is the type the value is cast to when assigning it, if any
+	castTo astmodel.Type
+	// method is the conversion method to call for objects; it's always defined on the type in the spoke version
+	method string
+	// element is the conversion of each element of an array or map
+	element *valueConversion
+}
+
+// conversionPlanner works out how to convert the values of properties between an object type in a spoke version of
+// a resource and the matching object type in the next version along towards the hub
+type conversionPlanner struct {
+	types     astmodel.Types
+	direction ConversionDirection
+	// next is the package of the version being converted to or from
+	next astmodel.PackageReference
+	// objects records each object type of the spoke version which needs its own conversion methods, as we plan
+	// conversions which call them
+	objects map[astmodel.TypeName]struct{}
+}
+
+func newConversionPlanner(
+	types astmodel.Types,
+	direction ConversionDirection,
+	next astmodel.PackageReference) *conversionPlanner {
+	return &conversionPlanner{
+		types:     types,
+		direction: direction,
+		next:      next,
+		objects:   make(map[astmodel.TypeName]struct{}),
+	}
+}
+
+// plan returns how to convert a value of the source type into the destination type, or nil if it can't be done
+// (in which case the value is kept in the property bag instead)
+func (planner *conversionPlanner) plan(source astmodel.Type, destination astmodel.Type) *valueConversion {
+	source, sourceOptional := unwrapOptional(source)
+	destination, destinationOptional := unwrapOptional(destination)
+
+	result := &valueConversion{
+		sourceOptional:      sourceOptional,
+		destinationOptional: destinationOptional,
+		destinationType:     destination,
+	}
+
+	sourceShape := planner.shapeOf(source)
+	destinationShape := planner.shapeOf(destination)
+	if sourceShape.kind != destinationShape.kind {
+		return nil
+	}
+
+	switch sourceShape.kind {
+	case shapePrimitive:
+		if !sourceShape.primitive.Equals(destinationShape.primitive) {
+			return nil
+		}
+
+		result.kind = valueConversionAssign
+		if sourceShape.named || destinationShape.named {
+			result.castTo = destination
+		}
+
+		return result
+
+	case shapeOpaque:
+		if !source.Equals(destination) {
+			return nil
+		}
+
+		result.kind = valueConversionAssign
+		return result
+
+	case shapeObject:
+		return planner.planObject(result, sourceShape.name, destinationShape.name)
+
+	case shapeArray:
+		if sourceOptional || destinationOptional {
+			return nil
+		}
+
+		element := planner.plan(sourceShape.element, destinationShape.element)
+		if element == nil {
+			return nil
+		}
+
+		result.kind = valueConversionArray
+		result.element = element
+		return result
+
+	case shapeMap:
+		if sourceOptional || destinationOptional || !sourceShape.key.Equals(destinationShape.key) {
+			return nil
+		}
+
+		element := planner.plan(sourceShape.element, destinationShape.element)
+		if element == nil {
+			return nil
+		}
+
+		result.kind = valueConversionMap
+		result.element = element
+		return result
+	}
+
+	return nil
+}
+
+// planObject plans the conversion between two object types, which must be versions of the same type
+func (planner *conversionPlanner) planObject(
+	result *valueConversion,
+	source astmodel.TypeName,
+	destination astmodel.TypeName) *valueConversion {
+
+	spoke, other := source, destination
+	if planner.direction == ConversionDirectionFromHub {
+		spoke, other = destination, source
+	}
+
+	if spoke.Name() != other.Name() || !other.PackageReference.Equals(planner.next) {
+		return nil
+	}
+
+	planner.objects[spoke] = struct{}{}
+	result.kind = valueConversionObject
+	result.method = conversionMethodName(planner.direction, planner.next)
+	return result
+}
+
+type shapeKind string
+
+const (
+	shapePrimitive = shapeKind("primitive")
+	shapeObject    = shapeKind("object")
+	shapeArray     = shapeKind("array")
+	shapeMap       = shapeKind("map")
+	shapeOpaque    = shapeKind("opaque")
+)
+
+// shape captures what matters about a type when converting its values
+type shape struct {
+	kind shapeKind
+	// primitive is the underlying type of a primitive
+	primitive *astmodel.PrimitiveType
+	// named is true if the primitive is a named type (such as an enum), so needs casting when assigned
+	named bool
+	// name is the name of an object type
+	name astmodel.TypeName
+	// key and element are the key and element types of maps and arrays
+	key     astmodel.Type
+	element astmodel.Type
+}
+
+func (planner *conversionPlanner) shapeOf(t astmodel.Type) shape {
+	switch it := t.(type) {
+	case *astmodel.PrimitiveType:
+		return shape{kind: shapePrimitive, primitive: it}
+
+	case *astmodel.ArrayType:
+		return shape{kind: shapeArray, element: it.Element()}
+
+	case *astmodel.MapType:
+		return shape{kind: shapeMap, key: it.KeyType(), element: it.ValueType()}
+
+	case astmodel.ValidatedType:
+		return planner.shapeOf(it.ElementType())
+
+	case *astmodel.FlaggedType:
+		return planner.shapeOf(it.Element())
+
+	case astmodel.TypeName:
+		def, ok := planner.types[it]
+		if !ok {
+			// Defined elsewhere (such as in our runtime), so we can only copy it as it is
+			return shape{kind: shapeOpaque}
+		}
+
+		underlying := def.Type()
+		if flagged, ok := underlying.(*astmodel.FlaggedType); ok {
+			underlying = flagged.Element()
+		}
+
+		switch ut := underlying.(type) {
+		case *astmodel.ObjectType:
+			return shape{kind: shapeObject, name: it}
+		case *astmodel.EnumType:
+			return shape{kind: shapePrimitive, primitive: ut.BaseType(), named: true}
+		}
+
+		if result := planner.shapeOf(underlying); result.kind == shapePrimitive {
+			return shape{kind: shapePrimitive, primitive: result.primitive, named: true}
+		}
+
+		// Other named types are only convertible if they're the same type
+		return shape{kind: shapeOpaque}
+	}
+
+	return shape{kind: shapeOpaque}
+}
+
+// unwrapOptional returns the type held by an optional type (with any validations removed) and whether it was optional
+func unwrapOptional(t astmodel.Type) (astmodel.Type, bool) {
+	t = stripValidations(t)
+	if optional, ok := t.(*astmodel.OptionalType); ok {
+		return stripValidations(optional.Element()), true
+	}
+
+	return t, false
+}
+
+// stripValidations removes validations from a type, as they can't be rendered in expressions
+func stripValidations(t astmodel.Type) astmodel.Type {
+	switch it := t.(type) {
+	case astmodel.ValidatedType:
+		return stripValidations(it.ElementType())
+	case *astmodel.OptionalType:
+		return astmodel.NewOptionalType(stripValidations(it.Element()))
+	case *astmodel.ArrayType:
+		return astmodel.NewArrayType(stripValidations(it.Element()))
+	case *astmodel.MapType:
+		return astmodel.NewMapType(stripValidations(it.KeyType()), stripValidations(it.ValueType()))
+	}
+
+	return t
+}
+
+// conversionMethodName returns the name of the method converting an object type to or from its version in the
+// given package
+func conversionMethodName(direction ConversionDirection, next astmodel.PackageReference) string {
+	switch direction {
+	case ConversionDirectionToHub:
+		return "ConvertTo" + upperFirst(next.PackageName())
+	case ConversionDirectionFromHub:
+		return "ConvertFrom" + upperFirst(next.PackageName())
+	default:
+		panic(fmt.Sprintf("Unknown conversion direction %s", direction))
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return string(s[0]-'a'+'A') + s[1:]
+}
+
+// conversionRenderer renders the statements to convert values within a conversion method
+type conversionRenderer struct {
+	codeGenerationContext *astmodel.CodeGenerationContext
+	direction             ConversionDirection
+	genRuntimePackage     string
+	bagIdent              string
+	pathIdent             string
+	// locals holds the names of all the identifiers in use, so that those we introduce are unique
+	locals map[string]int
+}
+
+func newConversionRenderer(
+	codeGenerationContext *astmodel.CodeGenerationContext,
+	direction ConversionDirection,
+	reserved ...string) *conversionRenderer {
+
+	result := &conversionRenderer{
+		codeGenerationContext: codeGenerationContext,
+		direction:             direction,
+		genRuntimePackage:     codeGenerationContext.MustGetImportedPackageName(astmodel.GenRuntimeReference),
+		bagIdent:              "bag",
+		pathIdent:             "path",
+		locals:                make(map[string]int),
+	}
+
+	for _, name := range append(reserved, result.bagIdent, result.pathIdent, "err") {
+		result.locals[name] = 1
+	}
+
+	return result
+}
+
+// local returns a new, unique, identifier for a local variable based on the given name
+func (renderer *conversionRenderer) local(name string) string {
+	count := renderer.locals[name]
+	renderer.locals[name] = count + 1
+	if count == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s%d", name, count)
+}
+
+// path returns an expression for the path of a value, given the keys leading to it from the object being converted
+func (renderer *conversionRenderer) path(keys []ast.Expr) ast.Expr {
+	args := []ast.Expr{ast.NewIdent(renderer.pathIdent)}
+	for _, key := range keys {
+		args = append(args, clone(key))
+	}
+
+	return astbuilder.CallQualifiedFunc(renderer.genRuntimePackage, "PropertyPath", args...)
+}
+
+// stash returns a statement keeping the given value in the property bag
+func (renderer *conversionRenderer) stash(value ast.Expr, keys []ast.Expr) ast.Stmt {
+	return returnIfError(
+		astbuilder.CallQualifiedFunc(renderer.bagIdent, "Add", renderer.path(keys), clone(value)))
+}
+
+// recall returns a statement restoring the given value from the property bag
+func (renderer *conversionRenderer) recall(destination ast.Expr, keys []ast.Expr) ast.Stmt {
+	return returnIfError(
+		astbuilder.CallQualifiedFunc(renderer.bagIdent, "Pull", renderer.path(keys), astbuilder.AddrOf(clone(destination))))
+}
+
+// render returns the statements converting source into destination, which must be assignable
+func (renderer *conversionRenderer) render(
+	conversion *valueConversion,
+	source ast.Expr,
+	destination ast.Expr,
+	keys []ast.Expr,
+	nameHint string) []ast.Stmt {
+
+	switch conversion.kind {
+	case valueConversionAssign:
+		return renderer.renderAssign(conversion, source, destination, nameHint)
+	case valueConversionObject:
+		return renderer.renderObject(conversion, source, destination, keys, nameHint)
+	case valueConversionArray:
+		return renderer.renderArray(conversion, source, destination, keys, nameHint)
+	case valueConversionMap:
+		return renderer.renderMap(conversion, source, destination, keys, nameHint)
+	default:
+		panic(fmt.Sprintf("Unknown conversion kind %s", conversion.kind))
+	}
+}
+
+// renderAssign copies a value across, casting it if required. If the source is optional it's only copied if present.
+func (renderer *conversionRenderer) renderAssign(
+	conversion *valueConversion,
+	source ast.Expr,
+	destination ast.Expr,
+	nameHint string) []ast.Stmt {
+
+	value := clone(source)
+	if conversion.sourceOptional {
+		value = &ast.StarExpr{X: clone(source)}
+	}
+
+	if conversion.castTo != nil {
+		value = &ast.CallExpr{
+			Fun:  conversion.castTo.AsType(renderer.codeGenerationContext),
+			Args: []ast.Expr{value},
+		}
+	}
+
+	var stmts []ast.Stmt
+	if conversion.destinationOptional {
+		// Copy the value so the two versions don't share it
+		local := renderer.local(nameHint)
+		stmts = []ast.Stmt{
+			astbuilder.SimpleAssignment(ast.NewIdent(local), token.DEFINE, value),
+			astbuilder.SimpleAssignment(clone(destination), token.ASSIGN, astbuilder.AddrOf(ast.NewIdent(local))),
+		}
+	} else {
+		stmts = []ast.Stmt{
+			astbuilder.SimpleAssignment(clone(destination), token.ASSIGN, value),
+		}
+	}
+
+	return renderer.ifPresent(conversion, source, stmts)
+}
+
+// renderObject converts an object by calling the conversion method of the type in the spoke version
+func (renderer *conversionRenderer) renderObject(
+	conversion *valueConversion,
+	source ast.Expr,
+	destination ast.Expr,
+	keys []ast.Expr,
+	nameHint string) []ast.Stmt {
+
+	var stmts []ast.Stmt
+
+	// The value we populate directly
+	target := destination
+	if conversion.destinationOptional {
+		local := renderer.local(nameHint)
+		target = ast.NewIdent(local)
+		stmts = append(stmts, astbuilder.LocalVariableDeclaration(
+			local,
+			conversion.destinationType.AsType(renderer.codeGenerationContext),
+			""))
+	}
+
+	sourcePtr := clone(source)
+	if !conversion.sourceOptional {
+		sourcePtr = astbuilder.AddrOf(clone(source))
+	}
+
+	var call ast.Expr
+	if renderer.direction == ConversionDirectionToHub {
+		// The method is on the source
+		call = &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: clone(source), Sel: ast.NewIdent(conversion.method)},
+			Args: []ast.Expr{astbuilder.AddrOf(clone(target)), ast.NewIdent(renderer.bagIdent), renderer.path(keys)},
+		}
+	} else {
+		// The method is on the destination
+		call = &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: clone(target), Sel: ast.NewIdent(conversion.method)},
+			Args: []ast.Expr{sourcePtr, ast.NewIdent(renderer.bagIdent), renderer.path(keys)},
+		}
+	}
+
+	stmts = append(stmts, returnIfError(call))
+
+	if conversion.destinationOptional {
+		stmts = append(stmts, astbuilder.SimpleAssignment(clone(destination), token.ASSIGN, astbuilder.AddrOf(clone(target))))
+	}
+
+	return renderer.ifPresent(conversion, source, stmts)
+}
+
+// renderArray converts each element of an array
+func (renderer *conversionRenderer) renderArray(
+	conversion *valueConversion,
+	source ast.Expr,
+	destination ast.Expr,
+	keys []ast.Expr,
+	nameHint string) []ast.Stmt {
+
+	list := renderer.local(nameHint + "List")
+	index := renderer.local(nameHint + "Index")
+	item := renderer.local(nameHint + "Item")
+
+	elementType := conversion.destinationType.(*astmodel.ArrayType).Element()
+	elementKeys := append(append([]ast.Expr{}, keys...), ast.NewIdent(index))
+	elementStmts := renderer.render(
+		conversion.element,
+		ast.NewIdent(item),
+		&ast.IndexExpr{X: ast.NewIdent(list), Index: ast.NewIdent(index)},
+		elementKeys,
+		nameHint+"Element")
+
+	makeList := astbuilder.SimpleAssignment(
+		ast.NewIdent(list),
+		token.DEFINE,
+		astbuilder.CallFunc(
+			"make",
+			&ast.ArrayType{Elt: elementType.AsType(renderer.codeGenerationContext)},
+			astbuilder.CallFunc("len", clone(source))))
+
+	loop := &ast.RangeStmt{
+		Key:   ast.NewIdent(index),
+		Value: ast.NewIdent(item),
+		Tok:   token.DEFINE,
+		X:     clone(source),
+		Body:  &ast.BlockStmt{List: elementStmts},
+	}
+
+	assign := astbuilder.SimpleAssignment(clone(destination), token.ASSIGN, ast.NewIdent(list))
+
+	return []ast.Stmt{renderer.ifNotNil(source, makeList, loop, assign)}
+}
+
+// renderMap converts each value of a map
+func (renderer *conversionRenderer) renderMap(
+	conversion *valueConversion,
+	source ast.Expr,
+	destination ast.Expr,
+	keys []ast.Expr,
+	nameHint string) []ast.Stmt {
+
+	m := renderer.local(nameHint + "Map")
+	key := renderer.local(nameHint + "Key")
+	value := renderer.local(nameHint + "Value")
+	converted := renderer.local(nameHint + "Converted")
+
+	mapType := conversion.destinationType.(*astmodel.MapType)
+	elementKeys := append(append([]ast.Expr{}, keys...), ast.NewIdent(key))
+	elementStmts := []ast.Stmt{
+		astbuilder.LocalVariableDeclaration(converted, mapType.ValueType().AsType(renderer.codeGenerationContext), ""),
+	}
+
+	elementStmts = append(elementStmts, renderer.render(
+		conversion.element,
+		ast.NewIdent(value),
+		ast.NewIdent(converted),
+		elementKeys,
+		nameHint+"Element")...)
+
+	elementStmts = append(elementStmts,
+		astbuilder.SimpleAssignment(
+			&ast.IndexExpr{X: ast.NewIdent(m), Index: ast.NewIdent(key)},
+			token.ASSIGN,
+			ast.NewIdent(converted)))
+
+	makeMap := astbuilder.SimpleAssignment(
+		ast.NewIdent(m),
+		token.DEFINE,
+		astbuilder.CallFunc(
+			"make",
+			mapType.AsType(renderer.codeGenerationContext),
+			astbuilder.CallFunc("len", clone(source))))
+
+	loop := &ast.RangeStmt{
+		Key:   ast.NewIdent(key),
+		Value: ast.NewIdent(value),
+		Tok:   token.DEFINE,
+		X:     clone(source),
+		Body:  &ast.BlockStmt{List: elementStmts},
+	}
+
+	assign := astbuilder.SimpleAssignment(clone(destination), token.ASSIGN, ast.NewIdent(m))
+
+	return []ast.Stmt{renderer.ifNotNil(source, makeMap, loop, assign)}
+}
+
+// ifPresent wraps the statements converting an optional source so that they only run when it has a value
+func (renderer *conversionRenderer) ifPresent(conversion *valueConversion, source ast.Expr, stmts []ast.Stmt) []ast.Stmt {
+	if !conversion.sourceOptional {
+		return stmts
+	}
+
+	return []ast.Stmt{renderer.ifNotNil(source, stmts...)}
+}
+
+func (renderer *conversionRenderer) ifNotNil(toCheck ast.Expr, stmts ...ast.Stmt) ast.Stmt {
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  clone(toCheck),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{List: stmts},
+	}
+}
+
+// returnIfError returns a statement which calls a function returning an error, returning it if there is one:
+//
+//	if err := <call>; err != nil {
+//		return err
+//	}
+func returnIfError(call ast.Expr) ast.Stmt {
+	return &ast.IfStmt{
+		Init: astbuilder.SimpleAssignment(ast.NewIdent("err"), token.DEFINE, call),
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent("err")}},
+			},
+		},
+	}
+}
+
+// clone returns a copy of an expression, as each node may only appear once in the tree we generate
+func clone(expr ast.Expr) ast.Expr {
+	return ast.Clone(expr).(ast.Expr)
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package storageconversion
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astbuilder"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	ast "github.com/dave/dst"
+)
+
+// ResourceConversionTestCase represents a test that a version of a resource can be converted to the hub version and
+// back again without loss
+type ResourceConversionTestCase struct {
+	testName  string
+	subject   astmodel.TypeName
+	hub       astmodel.TypeName
+	spec      astmodel.TypeName
+	status    *astmodel.TypeName
+	idFactory astmodel.IdentifierFactory
+}
+
+var _ astmodel.TestCase = &ResourceConversionTestCase{}
+
+// NewResourceConversionTestCase creates a new test case for the round trip of the given resource via the hub. The
+// spec and status (if any) are populated using the generators created for their JSON serialization tests.
+func NewResourceConversionTestCase(
+	subject astmodel.TypeName,
+	hub astmodel.TypeName,
+	spec astmodel.TypeName,
+	status *astmodel.TypeName,
+	idFactory astmodel.IdentifierFactory) *ResourceConversionTestCase {
+	return &ResourceConversionTestCase{
+		testName:  fmt.Sprintf("%s_WhenConvertedToHub_RoundTripsWithoutLoss", subject.Name()),
+		subject:   subject,
+		hub:       hub,
+		spec:      spec,
+		status:    status,
+		idFactory: idFactory,
+	}
+}
+
+// Name returns the unique name of this test case
+func (tc *ResourceConversionTestCase) Name() string {
+	return tc.testName
+}
+
+// References returns the set of types to which this test case refers
+func (tc *ResourceConversionTestCase) References() astmodel.TypeNameSet {
+	result := astmodel.NewTypeNameSet(tc.hub, tc.spec)
+	if tc.status != nil {
+		result.Add(*tc.status)
+	}
+
+	return result
+}
+
+// RequiredImports returns a set of the package imports required by this test case
+func (tc *ResourceConversionTestCase) RequiredImports() *astmodel.PackageImportSet {
+	result := astmodel.NewPackageImportSet()
+	result.AddImportsOfReferences(
+		astmodel.TestingReference,
+		astmodel.CmpReference,
+		astmodel.CmpOptsReference,
+		astmodel.GopterReference,
+		astmodel.GopterPropReference,
+		astmodel.DiffReference,
+		astmodel.PrettyReference,
+		tc.hub.PackageReference)
+
+	return result
+}
+
+// AsFuncs renders the current test case and its supporting method as Go abstract syntax trees
+func (tc *ResourceConversionTestCase) AsFuncs(_ astmodel.TypeName, codeGenerationContext *astmodel.CodeGenerationContext) []ast.Decl {
+	return []ast.Decl{
+		tc.createTestRunner(codeGenerationContext),
+		tc.createTestMethod(codeGenerationContext),
+	}
+}
+
+// Equals determines if this TestCase is equal to another one
+func (tc *ResourceConversionTestCase) Equals(other astmodel.TestCase) bool {
+	o, ok := other.(*ResourceConversionTestCase)
+	if !ok {
+		return false
+	}
+
+	if (tc.status == nil) != (o.status == nil) {
+		return false
+	}
+
+	if tc.status != nil && !tc.status.Equals(*o.status) {
+		return false
+	}
+
+	return tc.testName == o.testName &&
+		tc.subject.Equals(o.subject) &&
+		tc.hub.Equals(o.hub) &&
+		tc.spec.Equals(o.spec)
+}
+
+// createTestRunner generates the AST for the test runner itself:
+//
+//	func Test_Widget_WhenConvertedToHub_RoundTripsWithoutLoss(t *testing.T) {
+//		parameters := gopter.DefaultTestParameters()
+//		parameters.MaxSize = 10
+//		properties := gopter.NewProperties(parameters)
+//		properties.Property("...", prop.ForAll(RunResourceConversionTestForWidget, Widget_SpecGenerator(), Widget_StatusGenerator()))
+//		properties.TestingRun(t)
+//	}
+func (tc *ResourceConversionTestCase) createTestRunner(codeGenerationContext *astmodel.CodeGenerationContext) ast.Decl {
+	const (
+		parametersLocal = "parameters"
+		propertiesLocal = "properties"
+	)
+
+	gopterPackage := codeGenerationContext.MustGetImportedPackageName(astmodel.GopterReference)
+	propPackage := codeGenerationContext.MustGetImportedPackageName(astmodel.GopterPropReference)
+	testingPackage := codeGenerationContext.MustGetImportedPackageName(astmodel.TestingReference)
+
+	defineParameters := astbuilder.SimpleAssignment(
+		ast.NewIdent(parametersLocal),
+		token.DEFINE,
+		astbuilder.CallQualifiedFunc(gopterPackage, "DefaultTestParameters"))
+
+	configureMaxSize := astbuilder.SimpleAssignment(
+		&ast.SelectorExpr{
+			X:   ast.NewIdent(parametersLocal),
+			Sel: ast.NewIdent("MaxSize"),
+		},
+		token.ASSIGN,
+		astbuilder.IntLiteral(10))
+
+	defineProperties := astbuilder.SimpleAssignment(
+		ast.NewIdent(propertiesLocal),
+		token.DEFINE,
+		astbuilder.CallQualifiedFunc(gopterPackage, "NewProperties", ast.NewIdent(parametersLocal)))
+
+	generators := []ast.Expr{
+		ast.NewIdent(tc.idOfTestMethod()),
+		astbuilder.CallFunc(tc.idOfGeneratorMethod(tc.spec)),
+	}
+
+	if tc.status != nil {
+		generators = append(generators, astbuilder.CallFunc(tc.idOfGeneratorMethod(*tc.status)))
+	}
+
+	defineTestCase := astbuilder.InvokeQualifiedFunc(
+		propertiesLocal,
+		"Property",
+		astbuilder.StringLiteralf("Round trip of %s via the hub returns original", tc.subject.Name()),
+		astbuilder.CallQualifiedFunc(propPackage, "ForAll", generators...))
+
+	runTests := astbuilder.InvokeQualifiedFunc(propertiesLocal, "TestingRun", ast.NewIdent("t"))
+
+	fn := astbuilder.NewTestFuncDetails(
+		testingPackage,
+		tc.testName,
+		defineParameters,
+		configureMaxSize,
+		defineProperties,
+		defineTestCase,
+		runTests)
+
+	return fn.DefineFunc()
+}
+
+// createTestMethod generates the AST for a method to run a single round trip of the resource via the hub
+func (tc *ResourceConversionTestCase) createTestMethod(codeGenerationContext *astmodel.CodeGenerationContext) ast.Decl {
+	const (
+		subjectId    = "subject"
+		hubId        = "hub"
+		actualId     = "actual"
+		matchId      = "match"
+		actualFmtId  = "actualFmt"
+		subjectFmtId = "subjectFmt"
+		resultId     = "result"
+		errId        = "err"
+	)
+
+	cmpPackage := codeGenerationContext.MustGetImportedPackageName(astmodel.CmpReference)
+	cmpoptsPackage := codeGenerationContext.MustGetImportedPackageName(astmodel.CmpOptsReference)
+	prettyPackage := codeGenerationContext.MustGetImportedPackageName(astmodel.PrettyReference)
+	diffPackage := codeGenerationContext.MustGetImportedPackageName(astmodel.DiffReference)
+
+	// subject := &Widget{Spec: spec, Status: status}
+	elements := []ast.Expr{
+		&ast.KeyValueExpr{Key: ast.NewIdent("Spec"), Value: ast.NewIdent("spec")},
+	}
+
+	if tc.status != nil {
+		elements = append(elements, &ast.KeyValueExpr{Key: ast.NewIdent("Status"), Value: ast.NewIdent("status")})
+	}
+
+	defineSubject := astbuilder.SimpleAssignment(
+		ast.NewIdent(subjectId),
+		token.DEFINE,
+		astbuilder.AddrOf(&ast.CompositeLit{
+			Type: tc.subject.AsType(codeGenerationContext),
+			Elts: elements,
+		}))
+
+	// var hub v20200601storage.Widget
+	declareHub := astbuilder.LocalVariableDeclaration(hubId, tc.hub.AsType(codeGenerationContext), "")
+
+	// err := subject.ConvertTo(&hub)
+	convertTo := astbuilder.SimpleAssignment(
+		ast.NewIdent(errId),
+		token.DEFINE,
+		astbuilder.CallQualifiedFunc(subjectId, "ConvertTo", astbuilder.AddrOf(ast.NewIdent(hubId))))
+
+	// var actual Widget
+	declareActual := astbuilder.LocalVariableDeclaration(actualId, tc.subject.AsType(codeGenerationContext), "")
+
+	// err = actual.ConvertFrom(&hub)
+	convertFrom := astbuilder.SimpleAssignment(
+		ast.NewIdent(errId),
+		token.ASSIGN,
+		astbuilder.CallQualifiedFunc(actualId, "ConvertFrom", astbuilder.AddrOf(ast.NewIdent(hubId))))
+
+	// match := cmp.Equal(subject, &actual, cmpopts.EquateEmpty())
+	compare := astbuilder.SimpleAssignment(
+		ast.NewIdent(matchId),
+		token.DEFINE,
+		astbuilder.CallQualifiedFunc(cmpPackage, "Equal",
+			ast.NewIdent(subjectId),
+			astbuilder.AddrOf(ast.NewIdent(actualId)),
+			astbuilder.CallQualifiedFunc(cmpoptsPackage, "EquateEmpty")))
+
+	// if !match { ... return diff.Diff(subjectFmt, actualFmt) }
+	prettyPrint := &ast.IfStmt{
+		Cond: &ast.UnaryExpr{
+			Op: token.NOT,
+			X:  ast.NewIdent(matchId),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				astbuilder.SimpleAssignment(
+					ast.NewIdent(actualFmtId),
+					token.DEFINE,
+					astbuilder.CallQualifiedFunc(prettyPackage, "Sprint", astbuilder.AddrOf(ast.NewIdent(actualId)))),
+				astbuilder.SimpleAssignment(
+					ast.NewIdent(subjectFmtId),
+					token.DEFINE,
+					astbuilder.CallQualifiedFunc(prettyPackage, "Sprint", ast.NewIdent(subjectId))),
+				astbuilder.SimpleAssignment(
+					ast.NewIdent(resultId),
+					token.DEFINE,
+					astbuilder.CallQualifiedFunc(diffPackage, "Diff", ast.NewIdent(subjectFmtId), ast.NewIdent(actualFmtId))),
+				astbuilder.Returns(ast.NewIdent(resultId)),
+			},
+		},
+	}
+
+	fn := &astbuilder.FuncDetails{
+		Name: tc.idOfTestMethod(),
+		Returns: []*ast.Field{
+			{
+				Type: ast.NewIdent("string"),
+			},
+		},
+		Body: []ast.Stmt{
+			defineSubject,
+			declareHub,
+			convertTo,
+			astbuilder.ReturnIfNotNil(ast.NewIdent(errId), astbuilder.CallQualifiedFunc(errId, "Error")),
+			declareActual,
+			convertFrom,
+			astbuilder.ReturnIfNotNil(ast.NewIdent(errId), astbuilder.CallQualifiedFunc(errId, "Error")),
+			compare,
+			prettyPrint,
+			astbuilder.Returns(astbuilder.StringLiteral("")),
+		},
+	}
+
+	fn.AddParameter("spec", tc.spec.AsType(codeGenerationContext))
+	if tc.status != nil {
+		fn.AddParameter("status", tc.status.AsType(codeGenerationContext))
+	}
+
+	fn.AddComments(fmt.Sprintf("tests if a random %s can be converted to the hub version and back without loss", tc.subject.Name()))
+
+	return fn.DefineFunc()
+}
+
+func (tc *ResourceConversionTestCase) idOfTestMethod() string {
+	return tc.idFactory.CreateIdentifier(
+		fmt.Sprintf("RunResourceConversionTestFor%s", tc.subject.Name()),
+		astmodel.Exported)
+}
+
+func (tc *ResourceConversionTestCase) idOfGeneratorMethod(name astmodel.TypeName) string {
+	return tc.idFactory.CreateIdentifier(
+		fmt.Sprintf("%sGenerator", name.Name()),
+		astmodel.Exported)
+}
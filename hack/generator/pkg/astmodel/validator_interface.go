@@ -0,0 +1,439 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package astmodel
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astbuilder"
+	ast "github.com/dave/dst"
+	"github.com/pkg/errors"
+)
+
+var ValidatorInterfaceName = MakeTypeName(admissionPackageReference, "Validator")
+
+// immutableResourceProperties are the properties of a resource spec which ARM doesn't allow to be changed once the
+// resource has been created
+var immutableResourceProperties = []PropertyName{AzureNameProperty, OwnerProperty, "Location"}
+
+// generateValidator creates an implementation of admission.Validator for the resource. It validates that the
+// immutable properties of the resource aren't changed and that at most one property of each discriminated union in
+// the spec is set, then runs any hand-written validations (see genruntime.Validator).
+func generateValidator(resourceName TypeName, spec *ObjectType, idFactory IdentifierFactory, types Types) (*InterfaceImplementation, error) {
+	annotation := webhookAnnotation(resourceName, "validate", false)
+
+	immutableProperties, err := findImmutableProperties(spec, types)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find immutable properties of %s", resourceName)
+	}
+
+	newValidatorFunction := func(name string, asFunc asFuncType) *objectFunction {
+		return &objectFunction{
+			name:             name,
+			o:                spec,
+			idFactory:        idFactory,
+			asFunc:           asFunc,
+			requiredPackages: []PackageReference{ApiMachineryRuntimeReference, FmtReference},
+		}
+	}
+
+	return NewInterfaceImplementation(
+		ValidatorInterfaceName,
+		newValidatorFunction("ValidateCreate", validateCreateFunction),
+		newValidatorFunction("ValidateUpdate", validateUpdateFunction),
+		newValidatorFunction("ValidateDelete", validateDeleteFunction),
+		newValidatorFunction("createValidations", createValidationsFunction),
+		newValidatorFunction("updateValidations", updateValidationsFunction),
+		newValidatorFunction("validateImmutableProperties", validateImmutablePropertiesFunction(immutableProperties)),
+		newValidatorFunction("validateOneOfProperties", validateOneOfPropertiesFunction),
+	).WithAnnotation(annotation), nil
+}
+
+// propertyPath is the path from a resource spec to one of its properties, through any nested objects
+type propertyPath []*PropertyDefinition
+
+// jsonPath returns the path as used in JSON, starting with "spec."
+func (path propertyPath) jsonPath() string {
+	result := "spec"
+	for _, property := range path {
+		result += "." + property.JsonName()
+	}
+
+	return result
+}
+
+// findImmutableProperties returns the paths to the properties of the spec which ARM doesn't allow to be changed once
+// the resource has been created: those every resource has (see immutableResourceProperties), and those marked as
+// immutable, however deeply they are nested in objects. Properties within arrays and maps aren't included.
+func findImmutableProperties(spec *ObjectType, types Types) ([]propertyPath, error) {
+	var result []propertyPath
+	isResourceProperty := make(map[PropertyName]bool)
+	for _, name := range immutableResourceProperties {
+		isResourceProperty[name] = true
+		if prop, ok := spec.Property(name); ok {
+			result = append(result, propertyPath{prop})
+		}
+	}
+
+	visiting := make(TypeNameSet)
+	var walk func(object *ObjectType, path propertyPath) error
+	walk = func(object *ObjectType, path propertyPath) error {
+		for _, prop := range object.Properties() {
+			if len(path) == 0 && isResourceProperty[prop.PropertyName()] {
+				// already included
+				continue
+			}
+
+			propPath := append(append(propertyPath(nil), path...), prop)
+			if prop.IsImmutable() {
+				result = append(result, propPath)
+				continue
+			}
+
+			propType := prop.PropertyType()
+			if optional, ok := propType.(*OptionalType); ok {
+				propType = optional.Element()
+			}
+
+			name, ok := propType.(TypeName)
+			if !ok || visiting.Contains(name) {
+				continue
+			}
+
+			if _, ok := types[name]; !ok {
+				// types from other packages, such as genruntime, are never immutable
+				continue
+			}
+
+			resolved, err := types.FullyResolve(name)
+			if err != nil {
+				return err
+			}
+
+			nested, ok := resolved.(*ObjectType)
+			if !ok {
+				continue
+			}
+
+			visiting.Add(name)
+			err = walk(nested, propPath)
+			if err != nil {
+				return err
+			}
+
+			delete(visiting, name)
+		}
+
+		return nil
+	}
+
+	err := walk(spec, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateCreateFunction returns a function that runs the validations for the creation of the resource
+func validateCreateFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+	genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(GenRuntimeReference)
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: []ast.Stmt{
+			astbuilder.Returns(
+				astbuilder.CallQualifiedFunc(
+					genRuntimePackage,
+					"ValidateCreate",
+					ast.NewIdent(receiverIdent),
+					astbuilder.CallQualifiedFunc(receiverIdent, "createValidations"))),
+		},
+	}
+
+	fn.AddComments("validates the creation of the resource")
+	fn.AddReturns("error")
+	return fn.DefineFunc()
+}
+
+// validateUpdateFunction returns a function that runs the validations for an update of the resource
+func validateUpdateFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+	genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(GenRuntimeReference)
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: []ast.Stmt{
+			astbuilder.Returns(
+				astbuilder.CallQualifiedFunc(
+					genRuntimePackage,
+					"ValidateUpdate",
+					ast.NewIdent(receiverIdent),
+					ast.NewIdent("old"),
+					astbuilder.CallQualifiedFunc(receiverIdent, "updateValidations"))),
+		},
+	}
+
+	fn.AddComments("validates an update of the resource")
+	fn.AddParameter("old", runtimeObjectType(codeGenerationContext))
+	fn.AddReturns("error")
+	return fn.DefineFunc()
+}
+
+// validateDeleteFunction returns a function that runs the validations for the deletion of the resource
+func validateDeleteFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+	genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(GenRuntimeReference)
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: []ast.Stmt{
+			astbuilder.Returns(
+				astbuilder.CallQualifiedFunc(genRuntimePackage, "ValidateDelete", ast.NewIdent(receiverIdent))),
+		},
+	}
+
+	fn.AddComments("validates the deletion of the resource")
+	fn.AddReturns("error")
+	return fn.DefineFunc()
+}
+
+// createValidationsFunction returns a function that lists the generated validations for the creation of the resource
+func createValidationsFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: []ast.Stmt{
+			astbuilder.Returns(
+				&ast.CompositeLit{
+					Type: &ast.ArrayType{Elt: validationFuncType()},
+					Elts: []ast.Expr{
+						astbuilder.QualifiedTypeName(receiverIdent, "validateOneOfProperties"),
+					},
+				}),
+		},
+		Returns: []*ast.Field{
+			{Type: &ast.ArrayType{Elt: validationFuncType()}},
+		},
+	}
+
+	fn.AddComments("returns the generated validations to run when the resource is created")
+	return fn.DefineFunc()
+}
+
+// updateValidationsFunction returns a function that lists the generated validations for an update of the resource
+func updateValidationsFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+
+	// The oneOf validations don't care about the old value of the resource
+	validateOneOfProperties := &ast.FuncLit{
+		Type: updateValidationFuncType(codeGenerationContext),
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				astbuilder.Returns(astbuilder.CallQualifiedFunc(receiverIdent, "validateOneOfProperties")),
+			},
+		},
+		Decs: ast.FuncLitDecorations{
+			NodeDecs: ast.NodeDecs{
+				Before: ast.NewLine,
+				After:  ast.NewLine,
+			},
+		},
+	}
+
+	validateImmutableProperties := astbuilder.QualifiedTypeName(receiverIdent, "validateImmutableProperties")
+	validateImmutableProperties.Decs.Before = ast.NewLine
+	validateImmutableProperties.Decs.After = ast.NewLine
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: []ast.Stmt{
+			astbuilder.Returns(
+				&ast.CompositeLit{
+					Type: &ast.ArrayType{Elt: updateValidationFuncType(codeGenerationContext)},
+					Elts: []ast.Expr{
+						validateOneOfProperties,
+						validateImmutableProperties,
+					},
+				}),
+		},
+		Returns: []*ast.Field{
+			{Type: &ast.ArrayType{Elt: updateValidationFuncType(codeGenerationContext)}},
+		},
+	}
+
+	fn.AddComments("returns the generated validations to run when the resource is updated")
+	return fn.DefineFunc()
+}
+
+// validateImmutablePropertiesFunction returns a function that checks none of the given properties of the resource
+// spec have changed
+func validateImmutablePropertiesFunction(properties []propertyPath) asFuncType {
+	return func(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+		receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+		genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(GenRuntimeReference)
+		fmtPackage := codeGenerationContext.MustGetImportedPackageName(FmtReference)
+
+		const oldIdent = "oldObj"
+
+		specProperty := func(obj string, property *PropertyDefinition) ast.Expr {
+			return &ast.SelectorExpr{
+				X:   astbuilder.QualifiedTypeName(obj, "Spec"),
+				Sel: ast.NewIdent(string(property.PropertyName())),
+			}
+		}
+
+		validateProperty := func(path propertyPath) ast.Expr {
+			if len(path) == 1 {
+				return astbuilder.CallQualifiedFunc(
+					genRuntimePackage,
+					"ValidateImmutableProperty",
+					astbuilder.StringLiteral(path.jsonPath()),
+					specProperty(oldIdent, path[0]),
+					specProperty(receiverIdent, path[0]))
+			}
+
+			// Nested properties are found by name, as any of the objects along the way may be missing
+			args := []ast.Expr{
+				astbuilder.StringLiteral(path.jsonPath()),
+				astbuilder.QualifiedTypeName(oldIdent, "Spec"),
+				astbuilder.QualifiedTypeName(receiverIdent, "Spec"),
+			}
+
+			for _, property := range path {
+				args = append(args, astbuilder.StringLiteral(string(property.PropertyName())))
+			}
+
+			return astbuilder.CallQualifiedFunc(genRuntimePackage, "ValidateImmutableNestedProperty", args...)
+		}
+
+		body := []ast.Stmt{
+			astbuilder.TypeAssert(
+				ast.NewIdent(oldIdent),
+				ast.NewIdent("old"),
+				&ast.StarExpr{X: receiver.AsType(codeGenerationContext)}),
+			astbuilder.ReturnIfNotOk(
+				astbuilder.FormatError(
+					fmtPackage,
+					fmt.Sprintf("unexpected type supplied for %s() function. Expected *%s, got %%T", methodName, receiver.Name()),
+					ast.NewIdent("old"))),
+			// Immutable properties can be changed freely until the resource has been created in Azure
+			astbuilder.ReturnIfExpr(
+				&ast.UnaryExpr{
+					Op: token.NOT,
+					X:  astbuilder.CallQualifiedFunc(genRuntimePackage, "IsProvisioned", ast.NewIdent(oldIdent)),
+				},
+				ast.NewIdent("nil")),
+		}
+
+		tok := token.DEFINE
+		for _, path := range properties {
+			body = append(body,
+				astbuilder.SimpleAssignment(ast.NewIdent("err"), tok, validateProperty(path)),
+				astbuilder.CheckErrorAndReturn())
+			tok = token.ASSIGN
+		}
+
+		body = append(body, astbuilder.Returns(ast.NewIdent("nil")))
+
+		fn := &astbuilder.FuncDetails{
+			Name:          methodName,
+			ReceiverIdent: receiverIdent,
+			ReceiverType: &ast.StarExpr{
+				X: receiver.AsType(codeGenerationContext),
+			},
+			Body: body,
+		}
+
+		fn.AddComments("validates that the properties of the resource which can't be changed in Azure haven't been changed")
+		fn.AddParameter("old", runtimeObjectType(codeGenerationContext))
+		fn.AddReturns("error")
+		return fn.DefineFunc()
+	}
+}
+
+// validateOneOfPropertiesFunction returns a function that checks at most one property of each discriminated union in
+// the resource spec is set
+func validateOneOfPropertiesFunction(k *objectFunction, codeGenerationContext *CodeGenerationContext, receiver TypeName, methodName string) *ast.FuncDecl {
+	receiverIdent := k.idFactory.CreateIdentifier(receiver.Name(), NotExported)
+	genRuntimePackage := codeGenerationContext.MustGetImportedPackageName(GenRuntimeReference)
+
+	fn := &astbuilder.FuncDetails{
+		Name:          methodName,
+		ReceiverIdent: receiverIdent,
+		ReceiverType: &ast.StarExpr{
+			X: receiver.AsType(codeGenerationContext),
+		},
+		Body: []ast.Stmt{
+			astbuilder.Returns(
+				astbuilder.CallQualifiedFunc(
+					genRuntimePackage,
+					"ValidateOneOfProperties",
+					astbuilder.StringLiteral("spec"),
+					astbuilder.QualifiedTypeName(receiverIdent, "Spec"))),
+		},
+	}
+
+	fn.AddComments("validates that at most one property of each discriminated union (JSON OneOf) in the spec is set")
+	fn.AddReturns("error")
+	return fn.DefineFunc()
+}
+
+// validationFuncType returns the type of a validation function, func() error
+func validationFuncType() *ast.FuncType {
+	return &ast.FuncType{
+		Params: &ast.FieldList{},
+		Results: &ast.FieldList{
+			List: []*ast.Field{{Type: ast.NewIdent("error")}},
+		},
+	}
+}
+
+// updateValidationFuncType returns the type of a validation function for updates, func(old runtime.Object) error
+func updateValidationFuncType(codeGenerationContext *CodeGenerationContext) *ast.FuncType {
+	return &ast.FuncType{
+		Params: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{ast.NewIdent("old")},
+					Type:  runtimeObjectType(codeGenerationContext),
+				},
+			},
+		},
+		Results: &ast.FieldList{
+			List: []*ast.Field{{Type: ast.NewIdent("error")}},
+		},
+	}
+}
+
+// runtimeObjectType returns the type runtime.Object
+func runtimeObjectType(codeGenerationContext *CodeGenerationContext) ast.Expr {
+	runtimePackage := codeGenerationContext.MustGetImportedPackageName(ApiMachineryRuntimeReference)
+	return astbuilder.QualifiedTypeName(runtimePackage, "Object")
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package astmodel
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_FindImmutableProperties_IncludesNestedImmutableProperties(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pkg := MakeLocalPackageReference("group", "2020-01-01")
+	propertiesName := MakeTypeName(pkg, "WidgetProperties")
+
+	size := NewPropertyDefinition("Size", "size", IntType).MakeImmutable()
+	colour := NewPropertyDefinition("Colour", "colour", StringType)
+	properties := NewPropertyDefinition("Properties", "properties", NewOptionalType(propertiesName))
+	location := NewPropertyDefinition("Location", "location", StringType).MakeImmutable()
+
+	types := make(Types)
+	types.Add(MakeTypeDefinition(propertiesName, NewObjectType().WithProperties(size, colour)))
+
+	spec := NewObjectType().WithProperties(location, properties)
+
+	paths, err := findImmutableProperties(spec, types)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(paths).To(HaveLen(2))
+	g.Expect(paths[0].jsonPath()).To(Equal("spec.location"))
+	g.Expect(paths[1].jsonPath()).To(Equal("spec.properties.size"))
+}
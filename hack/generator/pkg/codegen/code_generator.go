@@ -7,9 +7,14 @@ package codegen
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
 	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/reporting"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/schemasource"
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
 )
@@ -18,6 +23,7 @@ import (
 type CodeGenerator struct {
 	configuration *config.Configuration
 	pipeline      []PipelineStage
+	dumper        *typesDumper
 }
 
 // NewCodeGeneratorFromConfigFile produces a new Generator with the given configuration file
@@ -33,8 +39,24 @@ func NewCodeGeneratorFromConfigFile(configurationFile string) (*CodeGenerator, e
 // NewCodeGeneratorFromConfig produces a new Generator with the given configuration
 func NewCodeGeneratorFromConfig(configuration *config.Configuration, idFactory astmodel.IdentifierFactory) (*CodeGenerator, error) {
 	var pipeline []PipelineStage
-	pipeline = append(pipeline, loadSchemaIntoTypes(idFactory, configuration, defaultSchemaLoader))
-	pipeline = append(pipeline, corePipelineStages(idFactory, configuration)...)
+	if configuration.SpecSource == config.SpecSourceSwagger {
+		// Swagger provides both spec and status, so there's no need to augment the resources with status afterwards
+		pipeline = append(pipeline, loadSwaggerIntoTypes(idFactory, configuration))
+		for _, stage := range corePipelineStages(idFactory, configuration) {
+			if !stage.HasId("augmentStatus") {
+				pipeline = append(pipeline, stage)
+			}
+		}
+	} else {
+		source, err := schemasource.NewSourceFromConfig(configuration)
+		if err != nil {
+			return nil, err
+		}
+
+		pipeline = append(pipeline, loadSchemaIntoTypes(idFactory, configuration, sourceSchemaLoader(source)))
+		pipeline = append(pipeline, corePipelineStages(idFactory, configuration)...)
+	}
+
 	pipeline = append(pipeline, deleteGeneratedCode(configuration.OutputPath), exportPackages(configuration.OutputPath))
 
 	result := &CodeGenerator{
@@ -64,6 +86,9 @@ func corePipelineStages(idFactory astmodel.IdentifierFactory, configuration *con
 		// must come after nameTypesForCRD and convertAllOfAndOneOf so that objects are all expanded
 		applyPropertyRewrites(configuration),
 
+		// Apply what Swagger says about the mutability and sensitivity of properties to the specs:
+		applySwaggerPropertyFlags(),
+
 		// Figure out ARM resource owners:
 		determineResourceOwnership(),
 
@@ -83,12 +108,17 @@ func corePipelineStages(idFactory astmodel.IdentifierFactory, configuration *con
 		reportOnTypesAndVersions(configuration),
 
 		createArmTypesAndCleanKubernetesTypes(idFactory),
+		replaceSecretProperties(configuration),
+		replaceReferenceProperties(configuration),
+		addStatusConditions(),
 		applyKubernetesResourceInterface(idFactory),
 		createStorageTypes(),
 		simplifyDefinitions(),
 		injectJsonSerializationTests(idFactory),
 
 		markStorageVersion(),
+		addPropertyBags(),
+		createConversionFunctions(idFactory),
 
 		// Safety checks at the end:
 		ensureDefinitionsDoNotUseAnyTypes(),
@@ -100,30 +130,155 @@ func corePipelineStages(idFactory astmodel.IdentifierFactory, configuration *con
 func (generator *CodeGenerator) Generate(ctx context.Context) error {
 	klog.V(1).Infof("Generator version: %v", combinedVersion())
 
-	defs := make(astmodel.Types)
+	_, err := generator.runPipeline(ctx, generator.pipeline)
+	if err != nil {
+		return err
+	}
+
+	klog.Info("Finished")
+
+	return nil
+}
+
+// GenerateTypes runs the pipeline without writing anything to the output folder, returning the final set of types
+// that would have been exported
+func (generator *CodeGenerator) GenerateTypes(ctx context.Context) (astmodel.Types, error) {
+	var pipeline []PipelineStage
+	for _, stage := range generator.pipeline {
+		if !stage.writesOutput() {
+			pipeline = append(pipeline, stage)
+		}
+	}
+
+	return generator.runPipeline(ctx, pipeline)
+}
+
+// RemoveStages removes every stage with one of the given ids from the pipeline, returning an error if any of the ids
+// doesn't match a stage
+func (generator *CodeGenerator) RemoveStages(ids ...string) error {
+	for _, id := range ids {
+		if generator.indexOfStage(id) < 0 {
+			return generator.unknownStageError(id)
+		}
+	}
+
+	var pipeline []PipelineStage
+	for _, stage := range generator.pipeline {
+		if !stage.hasAnyId(ids) {
+			pipeline = append(pipeline, stage)
+		}
+	}
+
+	generator.pipeline = pipeline
+	return nil
+}
+
+// StopAfterStage removes every stage after the one with the given id from the pipeline, so that the pipeline stops
+// once that stage has run. If more than one stage has the id, the pipeline stops after the first of them.
+func (generator *CodeGenerator) StopAfterStage(id string) error {
+	index := generator.indexOfStage(id)
+	if index < 0 {
+		return generator.unknownStageError(id)
+	}
+
+	generator.pipeline = generator.pipeline[:index+1]
+	return nil
+}
+
+// DumpTypesAfterStages writes the types to a file in dir, in the given format, after each stage with one of the
+// given ids has run; the id "*" selects every stage
+func (generator *CodeGenerator) DumpTypesAfterStages(dir string, format DumpFormat, ids ...string) error {
+	if format != DumpAsText && format != DumpAsJSON {
+		return errors.Errorf("unknown dump format %q, expected %s or %s", format, DumpAsText, DumpAsJSON)
+	}
+
+	stages := make(map[string]bool)
+	for _, id := range ids {
+		if id != allStages && generator.indexOfStage(id) < 0 {
+			return generator.unknownStageError(id)
+		}
+
+		stages[id] = true
+	}
+
+	generator.dumper = &typesDumper{
+		dir:    dir,
+		format: format,
+		stages: stages,
+	}
+
+	return nil
+}
+
+// indexOfStage returns the index of the first stage in the pipeline with the given id, or -1 if there is none
+func (generator *CodeGenerator) indexOfStage(id string) int {
 	for i, stage := range generator.pipeline {
-		klog.V(0).Infof("Pipeline stage %d/%d: %s", i+1, len(generator.pipeline), stage.description)
+		if stage.HasId(id) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// unknownStageError returns an error for an id which doesn't match any stage, listing those which do
+func (generator *CodeGenerator) unknownStageError(id string) error {
+	var ids []string
+	for _, stage := range generator.pipeline {
+		ids = append(ids, stage.id)
+	}
+
+	return errors.Errorf("no pipeline stage has id %q; the stages are: %s", id, strings.Join(ids, ", "))
+}
+
+func (generator *CodeGenerator) runPipeline(ctx context.Context, pipeline []PipelineStage) (astmodel.Types, error) {
+	report := reporting.NewTable()
+	defs := make(astmodel.Types)
+	for i, stage := range pipeline {
+		klog.V(0).Infof("Pipeline stage %d/%d: %s", i+1, len(pipeline), stage.description)
+		start := time.Now()
 		// Defensive copy (in case the pipeline modifies its inputs) so that we can compare types in vs out
 		defsOut, err := stage.Action(ctx, defs.Copy())
 		if err != nil {
-			return errors.Wrapf(err, "Failed during pipeline stage %d/%d: %s", i+1, len(generator.pipeline), stage.description)
+			return nil, errors.Wrapf(err, "Failed during pipeline stage %d/%d: %s", i+1, len(pipeline), stage.description)
 		}
 
+		duration := time.Since(start)
 		defsAdded := defsOut.Except(defs)
 		defsRemoved := defs.Except(defsOut)
 
-		if len(defsAdded) > 0 && len(defsRemoved) > 0 {
-			klog.V(1).Infof("Added %d, removed %d type definitions", len(defsAdded), len(defsRemoved))
-		} else if len(defsAdded) > 0 {
-			klog.V(1).Infof("Added %d type definitions", len(defsAdded))
-		} else if len(defsRemoved) > 0 {
-			klog.V(1).Infof("Removed %d type definitions", len(defsRemoved))
+		klog.V(1).Infof(
+			"Stage %s took %v; added %d, removed %d type definitions (%d in total)",
+			stage.id,
+			duration.Round(time.Millisecond),
+			len(defsAdded),
+			len(defsRemoved),
+			len(defsOut))
+
+		row := fmt.Sprintf("%d. %s", i+1, stage.id)
+		report.AddRow(row)
+		report.SetCell(row, "Duration", duration.Round(time.Millisecond).String())
+		report.SetCell(row, "Added", fmt.Sprint(len(defsAdded)))
+		report.SetCell(row, "Removed", fmt.Sprint(len(defsRemoved)))
+		report.SetCell(row, "Total", fmt.Sprint(len(defsOut)))
+
+		if generator.dumper != nil && generator.dumper.dumpsAfter(stage) {
+			path, err := generator.dumper.dump(i, stage, defsOut)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to dump types after pipeline stage %d/%d: %s", i+1, len(pipeline), stage.description)
+			}
+
+			klog.V(0).Infof("Dumped %d type definitions to %s", len(defsOut), path)
 		}
 
 		defs = defsOut
 	}
 
-	klog.Info("Finished")
+	if klog.V(1).Enabled() {
+		var summary strings.Builder
+		report.WriteTo(&summary)
+		klog.V(1).Infof("Pipeline stages:\n%s", summary.String())
+	}
 
-	return nil
+	return defs, nil
 }
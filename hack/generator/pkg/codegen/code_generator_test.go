@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+
+	. "github.com/onsi/gomega"
+)
+
+// addTypeStage returns a stage which adds an empty object type with the given name
+func addTypeStage(id string, name string) PipelineStage {
+	return MakePipelineStage(id, "Add "+name, func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+		p := astmodel.MakeLocalPackageReference("horo.logy", "v20200730")
+		types.Add(astmodel.MakeTypeDefinition(astmodel.MakeTypeName(p, name), astmodel.NewObjectType()))
+		return types, nil
+	})
+}
+
+func makeTestGenerator() *CodeGenerator {
+	return &CodeGenerator{
+		pipeline: []PipelineStage{
+			addTypeStage("first", "Apple"),
+			addTypeStage("middle", "Banana"),
+			addTypeStage("first", "Cherry"),
+			addTypeStage("last", "Damson"),
+		},
+	}
+}
+
+func stageIds(generator *CodeGenerator) []string {
+	var result []string
+	for _, stage := range generator.pipeline {
+		result = append(result, stage.id)
+	}
+
+	return result
+}
+
+func TestRemoveStages_RemovesEveryStageWithId(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	generator := makeTestGenerator()
+	g.Expect(generator.RemoveStages("first", "last")).To(Succeed())
+	g.Expect(stageIds(generator)).To(Equal([]string{"middle"}))
+}
+
+func TestRemoveStages_GivenUnknownId_ReturnsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	generator := makeTestGenerator()
+	err := generator.RemoveStages("middle", "missing")
+	g.Expect(err).To(MatchError(ContainSubstring("\"missing\"")))
+	g.Expect(stageIds(generator)).To(HaveLen(4))
+}
+
+func TestStopAfterStage_StopsAfterFirstStageWithId(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	generator := makeTestGenerator()
+	g.Expect(generator.StopAfterStage("first")).To(Succeed())
+
+	types, err := generator.GenerateTypes(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(types).To(HaveLen(1))
+}
+
+func TestStopAfterStage_GivenUnknownId_ReturnsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	generator := makeTestGenerator()
+	g.Expect(generator.StopAfterStage("missing")).To(MatchError(ContainSubstring("first, middle, first, last")))
+}
+
+func TestDumpTypesAfterStages_WritesTypesAfterEachSelectedStage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "dump")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	generator := makeTestGenerator()
+	g.Expect(generator.DumpTypesAfterStages(dir, DumpAsText, "first")).To(Succeed())
+
+	_, err = generator.GenerateTypes(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(files).To(ConsistOf(filepath.Join(dir, "01-first.txt"), filepath.Join(dir, "03-first.txt")))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "03-first.txt"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal(
+		"package horo.logy/v20200730\n\n" +
+			"type Apple struct {\n}\n\n" +
+			"type Banana struct {\n}\n\n" +
+			"type Cherry struct {\n}\n\n"))
+}
+
+func TestDumpTypesAfterStages_AsJSON_WritesEveryStage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "dump")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	generator := makeTestGenerator()
+	g.Expect(generator.DumpTypesAfterStages(dir, DumpAsJSON, "*")).To(Succeed())
+
+	_, err = generator.GenerateTypes(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(files).To(HaveLen(4))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "04-last.json"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var definitions []dumpedDefinition
+	g.Expect(json.Unmarshal(content, &definitions)).To(Succeed())
+	g.Expect(definitions).To(HaveLen(4))
+	g.Expect(definitions[3].Name).To(Equal("Damson"))
+	g.Expect(definitions[3].Type.Kind).To(Equal("object"))
+}
+
+func TestDumpTypesAfterStages_GivenUnknownFormat_ReturnsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	generator := makeTestGenerator()
+	g.Expect(generator.DumpTypesAfterStages(".", DumpFormat("yaml"), "first")).To(HaveOccurred())
+}
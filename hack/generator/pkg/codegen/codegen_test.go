@@ -27,8 +27,10 @@ import (
 )
 
 type GoldenTestConfig struct {
-	HasArmResources      bool `yaml:"hasArmResources"`
-	InjectEmbeddedStruct bool `yaml:"injectEmbeddedStruct"`
+	HasArmResources      bool                      `yaml:"hasArmResources"`
+	InjectEmbeddedStruct bool                      `yaml:"injectEmbeddedStruct"`
+	SecretProperties     []*config.PropertyMatcher `yaml:"secretProperties"`
+	ReferenceProperties  []*config.PropertyMatcher `yaml:"referenceProperties"`
 }
 
 func makeDefaultTestConfig() GoldenTestConfig {
@@ -174,6 +176,22 @@ func runGoldenTest(t *testing.T, path string, testConfig GoldenTestConfig) {
 
 	idFactory := astmodel.NewIdentifierFactory()
 	cfg := config.NewConfiguration()
+	for _, secretProperty := range testConfig.SecretProperties {
+		err := secretProperty.Initialize()
+		if err != nil {
+			t.Fatalf("could not initialize secret property: %v", err)
+		}
+	}
+	cfg.SecretProperties = testConfig.SecretProperties
+
+	for _, referenceProperty := range testConfig.ReferenceProperties {
+		err := referenceProperty.Initialize()
+		if err != nil {
+			t.Fatalf("could not initialize reference property: %v", err)
+		}
+	}
+	cfg.ReferenceProperties = testConfig.ReferenceProperties
+
 	codegen, err := NewCodeGeneratorFromConfig(cfg, idFactory)
 
 	if err != nil {
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+)
+
+// propertyBagProperty holds the values of any properties which other versions of a resource have but this version
+// lacks, so that they aren't lost when converting between versions
+var propertyBagProperty = astmodel.NewPropertyDefinition(
+	astmodel.PropertyBagProperty,
+	"propertyBag",
+	astmodel.PropertyBagTypeName).
+	WithTag("json", "omitempty")
+
+// addPropertyBags returns a pipeline stage which adds a property bag to the spec and status of every version (both
+// API and storage) of each resource with more than one API version. Properties nested deeper are kept in the bag of
+// their spec or status by path, so nested types don't need bags of their own. Resources with a single API version
+// don't need bags as their storage version has the same properties.
+func addPropertyBags() PipelineStage {
+	return MakePipelineStage(
+		"addPropertyBags",
+		"Add property bags to the spec and status of resources with more than one API version",
+		func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+
+			resourceLookup, err := groupResourcesByVersion(types)
+			if err != nil {
+				return nil, err
+			}
+
+			result := types.Copy()
+			for _, versions := range resourceLookup {
+				if countApiVersions(versions) < 2 {
+					continue
+				}
+
+				for _, def := range versions {
+					resource := def.Type().(*astmodel.ResourceType)
+					for _, t := range []astmodel.Type{resource.SpecType(), resource.StatusType()} {
+						name, ok := t.(astmodel.TypeName)
+						if !ok {
+							// No status (or an unnamed one), nothing to do
+							continue
+						}
+
+						err := addPropertyBag(result, name)
+						if err != nil {
+							return nil, errors.Wrapf(err, "adding property bag to %s", def.Name())
+						}
+					}
+				}
+			}
+
+			return result, nil
+		})
+}
+
+// countApiVersions returns the number of the given versions of a resource which are API (rather than storage) versions
+func countApiVersions(versions []astmodel.TypeDefinition) int {
+	result := 0
+	for _, def := range versions {
+		if !astmodel.IsStoragePackageReference(def.Name().PackageReference) {
+			result++
+		}
+	}
+
+	return result
+}
+
+// addPropertyBag adds the property bag to the object type with the given name
+func addPropertyBag(types astmodel.Types, name astmodel.TypeName) error {
+	def, ok := types[name]
+	if !ok {
+		return errors.Errorf("couldn't find type %s", name)
+	}
+
+	objectType, ok := def.Type().(*astmodel.ObjectType)
+	if !ok {
+		return errors.Errorf("type %s was %T, not an object", name, def.Type())
+	}
+
+	if _, ok := objectType.Property(astmodel.PropertyBagProperty); ok {
+		// Already has one
+		return nil
+	}
+
+	types[name] = def.WithType(objectType.WithProperty(propertyBagProperty))
+	return nil
+}
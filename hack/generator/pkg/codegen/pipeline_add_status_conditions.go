@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/pkg/errors"
+)
+
+var (
+	conditionsPropertyName               = astmodel.PropertyName(astmodel.ConditionsProperty)
+	observedGenerationPropertyName       = astmodel.PropertyName(astmodel.ObservedGenerationProperty)
+	lastAppliedSpecSignaturePropertyName = astmodel.PropertyName(astmodel.LastAppliedSpecSignatureProperty)
+	pendingSpecSignaturePropertyName     = astmodel.PropertyName(astmodel.PendingSpecSignatureProperty)
+)
+
+// addStatusConditions adds the Conditions, ObservedGeneration and spec signature properties used by the generic
+// controller to report the state of a resource to the status type of every resource. These properties aren't part of the ARM
+// representation of the status, so this must happen after the ARM types have been created.
+func addStatusConditions() PipelineStage {
+
+	conditionsType := astmodel.NewArrayType(astmodel.MakeTypeName(astmodel.GenRuntimeReference, "Condition"))
+
+	return MakePipelineStage(
+		"addStatusConditions",
+		"Adds conditions, observedGeneration and spec signatures to the status of every resource",
+		func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+
+			result := types.Copy()
+			for _, def := range types {
+				resource, ok := def.Type().(*astmodel.ResourceType)
+				if !ok {
+					continue
+				}
+
+				statusName, ok := resource.StatusType().(astmodel.TypeName)
+				if !ok {
+					// No status (or an unnamed one), nothing to do
+					continue
+				}
+
+				statusDef, ok := result[statusName]
+				if !ok {
+					return nil, errors.Errorf("couldn't find status type %q of resource %q", statusName, def.Name())
+				}
+
+				statusType, ok := statusDef.Type().(*astmodel.ObjectType)
+				if !ok {
+					return nil, errors.Errorf("status type %q of resource %q was %T, not an object", statusName, def.Name(), statusDef.Type())
+				}
+
+				if _, ok := statusType.Property(conditionsPropertyName); !ok {
+					statusType = statusType.WithProperty(
+						astmodel.NewPropertyDefinition(conditionsPropertyName, "conditions", conditionsType).
+							WithDescription("Conditions describing the current state of the resource"))
+				}
+
+				if _, ok := statusType.Property(observedGenerationPropertyName); !ok {
+					statusType = statusType.WithProperty(
+						astmodel.NewPropertyDefinition(observedGenerationPropertyName, "observedGeneration", astmodel.Int64Type).
+							WithDescription("The metadata.generation of the resource most recently acted on by the controller"))
+				}
+
+				if _, ok := statusType.Property(lastAppliedSpecSignaturePropertyName); !ok {
+					statusType = statusType.WithProperty(
+						astmodel.NewPropertyDefinition(lastAppliedSpecSignaturePropertyName, "lastAppliedSpecSignature", astmodel.StringType).
+							WithDescription("The signature of the spec most recently deployed to Azure"))
+				}
+
+				if _, ok := statusType.Property(pendingSpecSignaturePropertyName); !ok {
+					statusType = statusType.WithProperty(
+						astmodel.NewPropertyDefinition(pendingSpecSignaturePropertyName, "pendingSpecSignature", astmodel.StringType).
+							WithDescription("The signature of the spec currently being deployed to Azure, if a deployment is in flight"))
+				}
+
+				result[statusName] = statusDef.WithType(statusType)
+			}
+
+			return result, nil
+		})
+}
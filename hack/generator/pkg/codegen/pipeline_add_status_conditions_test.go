@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestAddStatusConditions_AddsPropertiesToStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := astmodel.MakeLocalPackageReference("horo.logy", "v20200730")
+
+	specName := astmodel.MakeTypeName(p, "Widget_Spec")
+	statusName := astmodel.MakeTypeName(p, "Widget_Status")
+	resourceName := astmodel.MakeTypeName(p, "Widget")
+
+	defs := make(astmodel.Types)
+	defs.Add(astmodel.MakeTypeDefinition(specName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.IntType))))
+	defs.Add(astmodel.MakeTypeDefinition(statusName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Id", "id", astmodel.StringType))))
+	defs.Add(astmodel.MakeTypeDefinition(resourceName, astmodel.NewResourceType(specName, statusName)))
+
+	results, err := addStatusConditions().Action(context.Background(), defs)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	status, ok := results[statusName].Type().(*astmodel.ObjectType)
+	g.Expect(ok).To(BeTrue())
+
+	conditions, ok := status.Property("Conditions")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(conditions.PropertyType()).To(Equal(
+		astmodel.NewArrayType(astmodel.MakeTypeName(astmodel.GenRuntimeReference, "Condition"))))
+
+	observedGeneration, ok := status.Property("ObservedGeneration")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(observedGeneration.PropertyType()).To(Equal(astmodel.Int64Type))
+
+	lastAppliedSig, ok := status.Property("LastAppliedSpecSignature")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(lastAppliedSig.PropertyType()).To(Equal(astmodel.StringType))
+
+	pendingSig, ok := status.Property("PendingSpecSignature")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pendingSig.PropertyType()).To(Equal(astmodel.StringType))
+
+	_, ok = status.Property("Id")
+	g.Expect(ok).To(BeTrue())
+
+	// The spec is left alone
+	g.Expect(results[specName]).To(Equal(defs[specName]))
+}
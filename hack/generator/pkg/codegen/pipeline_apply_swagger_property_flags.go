@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+)
+
+// applySwaggerPropertyFlags returns a pipeline stage which copies what the Swagger specs say about the mutability and
+// sensitivity of each property (x-ms-mutability, readOnly and x-ms-secret) from the status of each resource to its
+// spec, matching properties by their JSON names. The deployment template schema doesn't carry this information, so
+// without it specs could include properties which are only ever set by Azure, and nothing would stop create-only
+// properties being changed. Read-only properties are removed from specs, while create-only and secret properties are
+// flagged so that later stages can add immutability validations and hold their values in secrets.
+// This must run after the specs and statuses have been reduced to named object types.
+func applySwaggerPropertyFlags() PipelineStage {
+	return MakePipelineStage(
+		"swaggerPropertyFlags",
+		"Apply mutability and sensitivity of properties from Swagger to resource specs",
+		func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+
+			flagger := propertyFlagger{
+				types:   types,
+				updated: make(astmodel.Types),
+				visited: make(map[typeNamePair]bool),
+			}
+
+			for _, def := range types {
+				resource, ok := def.Type().(*astmodel.ResourceType)
+				if !ok {
+					continue
+				}
+
+				specName, ok := resource.SpecType().(astmodel.TypeName)
+				if !ok {
+					continue
+				}
+
+				statusName, ok := resource.StatusType().(astmodel.TypeName)
+				if !ok {
+					continue
+				}
+
+				flagger.applyFlags(specName, statusName, true)
+			}
+
+			result := make(astmodel.Types)
+			for name, def := range types {
+				if updated, ok := flagger.updated[name]; ok {
+					result.Add(updated)
+				} else {
+					result.Add(def)
+				}
+			}
+
+			klog.V(1).Infof("Applied Swagger property flags to %v spec types", len(flagger.updated))
+
+			return result, nil
+		})
+}
+
+type typeNamePair struct {
+	spec   astmodel.TypeName
+	status astmodel.TypeName
+}
+
+// propertyFlagger walks spec and status types in parallel, accumulating the updated spec types
+type propertyFlagger struct {
+	types   astmodel.Types
+	updated astmodel.Types
+	visited map[typeNamePair]bool
+}
+
+// applyFlags copies the flags of the properties of the status type to the matching properties of the spec type,
+// and then to any nested types used by both
+func (flagger *propertyFlagger) applyFlags(specName astmodel.TypeName, statusName astmodel.TypeName, isResourceSpec bool) {
+	pair := typeNamePair{spec: specName, status: statusName}
+	if flagger.visited[pair] {
+		return
+	}
+
+	flagger.visited[pair] = true
+
+	specDef, ok := flagger.updated[specName]
+	if !ok {
+		specDef, ok = flagger.types[specName]
+		if !ok {
+			return
+		}
+	}
+
+	statusDef, ok := flagger.types[statusName]
+	if !ok {
+		return
+	}
+
+	specObject, ok := specDef.Type().(*astmodel.ObjectType)
+	if !ok {
+		return
+	}
+
+	statusObject, ok := statusDef.Type().(*astmodel.ObjectType)
+	if !ok {
+		return
+	}
+
+	statusProperties := make(map[string]*astmodel.PropertyDefinition)
+	for _, prop := range statusObject.Properties() {
+		statusProperties[prop.JsonName()] = prop
+	}
+
+	var nested []typeNamePair
+	for _, prop := range specObject.Properties() {
+		statusProp, ok := statusProperties[prop.JsonName()]
+		if !ok {
+			continue
+		}
+
+		if isResourceSpec && isArmTemplateProperty(prop) {
+			// these are required by ARM templates, even though the server sets them in the status
+			continue
+		}
+
+		if statusProp.IsReadOnly() {
+			klog.V(2).Infof("Removing %s.%s as it is read-only", specName, prop.PropertyName())
+			specObject = specObject.WithoutProperty(prop.PropertyName())
+			continue
+		}
+
+		newProp := prop
+		if statusProp.IsImmutable() {
+			newProp = newProp.MakeImmutable()
+		}
+
+		if statusProp.IsSecret() {
+			newProp = newProp.MakeSecret()
+		}
+
+		specObject = specObject.WithProperty(newProp)
+
+		if specNested, statusNested, ok := nestedTypeNames(prop.PropertyType(), statusProp.PropertyType()); ok {
+			nested = append(nested, typeNamePair{spec: specNested, status: statusNested})
+		}
+	}
+
+	if !specObject.Equals(specDef.Type()) {
+		flagger.updated[specName] = specDef.WithType(specObject)
+	}
+
+	for _, n := range nested {
+		flagger.applyFlags(n.spec, n.status, false)
+	}
+}
+
+// isArmTemplateProperty returns true if the property is one that every resource has in an ARM template
+func isArmTemplateProperty(prop *astmodel.PropertyDefinition) bool {
+	return prop.HasName(astmodel.NameProperty) ||
+		prop.HasName(astmodel.TypeProperty) ||
+		prop.HasName(astmodel.ApiVersionProperty)
+}
+
+// nestedTypeNames returns the names of the types held by a spec property and its matching status property, looking
+// through optional, array and map types, if both hold named types. Optionality is ignored, as a property may be
+// required in the spec but is always optional in the status.
+func nestedTypeNames(specType astmodel.Type, statusType astmodel.Type) (astmodel.TypeName, astmodel.TypeName, bool) {
+	if optional, ok := specType.(*astmodel.OptionalType); ok {
+		specType = optional.Element()
+	}
+
+	if optional, ok := statusType.(*astmodel.OptionalType); ok {
+		statusType = optional.Element()
+	}
+
+	switch s := specType.(type) {
+	case astmodel.TypeName:
+		if t, ok := statusType.(astmodel.TypeName); ok {
+			return s, t, true
+		}
+	case *astmodel.ArrayType:
+		if t, ok := statusType.(*astmodel.ArrayType); ok {
+			return nestedTypeNames(s.Element(), t.Element())
+		}
+	case *astmodel.MapType:
+		if t, ok := statusType.(*astmodel.MapType); ok {
+			return nestedTypeNames(s.ValueType(), t.ValueType())
+		}
+	}
+
+	return astmodel.TypeName{}, astmodel.TypeName{}, false
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestApplySwaggerPropertyFlags_CopiesFlagsFromStatusToSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := astmodel.MakeLocalPackageReference("horo.logy", "v20200730")
+
+	specName := astmodel.MakeTypeName(p, "Widget_Spec")
+	specPropertiesName := astmodel.MakeTypeName(p, "WidgetProperties")
+	statusName := astmodel.MakeTypeName(p, "Widget_Status")
+	statusPropertiesName := astmodel.MakeTypeName(p, "WidgetProperties_Status")
+	resourceName := astmodel.MakeTypeName(p, "Widget")
+
+	defs := make(astmodel.Types)
+	defs.Add(astmodel.MakeTypeDefinition(specName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition(astmodel.NameProperty, "name", astmodel.StringType),
+		astmodel.NewPropertyDefinition("Properties", "properties", astmodel.NewOptionalType(specPropertiesName)))))
+	defs.Add(astmodel.MakeTypeDefinition(specPropertiesName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.IntType),
+		astmodel.NewPropertyDefinition("Password", "password", astmodel.StringType),
+		astmodel.NewPropertyDefinition("ProvisioningState", "provisioningState", astmodel.StringType))))
+	defs.Add(astmodel.MakeTypeDefinition(statusName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition(astmodel.NameProperty, "name", astmodel.StringType).MakeReadOnly(),
+		astmodel.NewPropertyDefinition("Properties", "properties", astmodel.NewOptionalType(statusPropertiesName)))))
+	defs.Add(astmodel.MakeTypeDefinition(statusPropertiesName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.IntType).MakeImmutable(),
+		astmodel.NewPropertyDefinition("Password", "password", astmodel.StringType).MakeSecret(),
+		astmodel.NewPropertyDefinition("ProvisioningState", "provisioningState", astmodel.StringType).MakeReadOnly())))
+	defs.Add(astmodel.MakeTypeDefinition(resourceName, astmodel.NewResourceType(specName, statusName)))
+
+	results, err := applySwaggerPropertyFlags().Action(context.Background(), defs)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The name is required by ARM templates, so is kept even though it's read-only
+	g.Expect(results[specName]).To(Equal(defs[specName]))
+
+	properties, ok := results[specPropertiesName].Type().(*astmodel.ObjectType)
+	g.Expect(ok).To(BeTrue())
+
+	size, ok := properties.Property("Size")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(size.IsImmutable()).To(BeTrue())
+
+	password, ok := properties.Property("Password")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(password.IsSecret()).To(BeTrue())
+
+	_, ok = properties.Property("ProvisioningState")
+	g.Expect(ok).To(BeFalse())
+
+	// The status is left alone
+	g.Expect(results[statusPropertiesName]).To(Equal(defs[statusPropertiesName]))
+}
+
+func TestApplySwaggerPropertyFlags_GivenRequiredSpecAndOptionalStatus_AppliesFlagsToNestedTypes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := astmodel.MakeLocalPackageReference("horo.logy", "v20200730")
+
+	specName := astmodel.MakeTypeName(p, "Widget_Spec")
+	specPropertiesName := astmodel.MakeTypeName(p, "WidgetProperties")
+	statusName := astmodel.MakeTypeName(p, "Widget_Status")
+	statusPropertiesName := astmodel.MakeTypeName(p, "WidgetProperties_Status")
+	resourceName := astmodel.MakeTypeName(p, "Widget")
+
+	defs := make(astmodel.Types)
+	defs.Add(astmodel.MakeTypeDefinition(specName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Properties", "properties", specPropertiesName).MakeRequired())))
+	defs.Add(astmodel.MakeTypeDefinition(specPropertiesName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Password", "password", astmodel.StringType))))
+	defs.Add(astmodel.MakeTypeDefinition(statusName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Properties", "properties", astmodel.NewOptionalType(statusPropertiesName)))))
+	defs.Add(astmodel.MakeTypeDefinition(statusPropertiesName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Password", "password", astmodel.StringType).MakeSecret())))
+	defs.Add(astmodel.MakeTypeDefinition(resourceName, astmodel.NewResourceType(specName, statusName)))
+
+	results, err := applySwaggerPropertyFlags().Action(context.Background(), defs)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	properties, ok := results[specPropertiesName].Type().(*astmodel.ObjectType)
+	g.Expect(ok).To(BeTrue())
+
+	password, ok := properties.Property("Password")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(password.IsSecret()).To(BeTrue())
+}
@@ -52,7 +52,7 @@ func augmentResourcesWithStatus(idFactory astmodel.IdentifierFactory, config *co
 
 			klog.V(1).Infof("Loading Swagger data from %q", config.Status.SchemaRoot)
 
-			swaggerTypes, err := loadSwaggerData(ctx, idFactory, config)
+			swaggerTypes, err := loadSwaggerData(ctx, idFactory, config, false)
 			if err != nil {
 				return nil, errors.Wrapf(err, "unable to load Swagger data")
 			}
@@ -178,13 +178,25 @@ var swaggerVersionRegex = regexp.MustCompile(`\d{4}-\d{2}-\d{2}(-preview)?`)
 type swaggerTypes struct {
 	resources  astmodel.Types
 	otherTypes astmodel.Types
+
+	// specs and specTypes are only loaded when the specs of resources are generated from Swagger,
+	// and hold the same information for the specs as resources and otherTypes do for the statuses
+	specs     astmodel.Types
+	specTypes astmodel.Types
 }
 
-func loadSwaggerData(ctx context.Context, idFactory astmodel.IdentifierFactory, config *config.Configuration) (swaggerTypes, error) {
+// loadSwaggerData loads the status of each resource from the Swagger specs, along with its spec if withSpecs is true
+func loadSwaggerData(
+	ctx context.Context,
+	idFactory astmodel.IdentifierFactory,
+	config *config.Configuration,
+	withSpecs bool) (swaggerTypes, error) {
 
 	result := swaggerTypes{
 		resources:  make(astmodel.Types),
 		otherTypes: make(astmodel.Types),
+		specs:      make(astmodel.Types),
+		specTypes:  make(astmodel.Types),
 	}
 
 	schemas, err := loadAllSchemas(ctx, config.Status.SchemaRoot)
@@ -223,6 +235,13 @@ func loadSwaggerData(ctx context.Context, idFactory astmodel.IdentifierFactory,
 		if err != nil {
 			return swaggerTypes{}, errors.Wrapf(err, "error processing %q", schemaPath)
 		}
+
+		if withSpecs {
+			err = extractor.ExtractSpecTypes(ctx, schemaPath, schema, result.specs, result.specTypes)
+			if err != nil {
+				return swaggerTypes{}, errors.Wrapf(err, "error processing specs in %q", schemaPath)
+			}
+		}
 	}
 
 	return result, nil
@@ -263,6 +263,7 @@ func (s synthesizer) oneOfObject(oneOf astmodel.OneOfType, propNames []propertyN
 
 	objectType := astmodel.NewObjectType().WithProperties(properties...)
 	objectType = objectType.WithFunction(astmodel.NewOneOfJSONMarshalFunction(objectType, s.idFactory))
+	objectType = objectType.WithFunction(astmodel.NewOneOfValidationFunction(objectType, s.idFactory))
 
 	return objectType
 }
@@ -309,6 +309,9 @@ func removeValidations(t *astmodel.ObjectType) (*astmodel.ObjectType, error) {
 		t = t.WithProperty(p)
 	}
 
+	// ARM types are never validated by webhooks
+	t = t.WithoutFunction(astmodel.OneOfValidationFunctionName)
+
 	return t, nil
 }
 
@@ -346,6 +349,10 @@ func modifyKubeResourceSpecDefinition(
 	}
 
 	injectOwnerProperty := func(t *astmodel.ObjectType) (*astmodel.ObjectType, error) {
+		if resourceType.IsExtension() {
+			return t.WithProperty(createArbitraryOwnerProperty(idFactory)), nil
+		}
+
 		if resourceType.Owner() != nil {
 			ownerField, err := createOwnerProperty(idFactory, resourceType.Owner())
 			if err != nil {
@@ -357,6 +364,10 @@ func modifyKubeResourceSpecDefinition(
 		return t, nil
 	}
 
+	injectOperatorSpecProperty := func(t *astmodel.ObjectType) (*astmodel.ObjectType, error) {
+		return t.WithProperty(createOperatorSpecProperty(idFactory)), nil
+	}
+
 	remapProperties := func(t *astmodel.ObjectType) (*astmodel.ObjectType, error) {
 		// TODO: Right now the Kubernetes type has all of its standard requiredness (validations). If we want to allow
 		// TODO: users to submit "just a name and owner" types we will have to strip some validation until
@@ -376,7 +387,7 @@ func modifyKubeResourceSpecDefinition(
 		return t.WithoutProperty("Name").WithProperty(azureNameProp), nil
 	}
 
-	kubernetesDef, err := resourceSpecDef.ApplyObjectTransformations(remapProperties, injectOwnerProperty)
+	kubernetesDef, err := resourceSpecDef.ApplyObjectTransformations(remapProperties, injectOwnerProperty, injectOperatorSpecProperty)
 	if err != nil {
 		return astmodel.TypeDefinition{}, errors.Wrapf(err, "remapping properties of Kubernetes definition")
 	}
@@ -480,14 +491,10 @@ func convertPropertiesToArmTypes(t *astmodel.ObjectType, isSpecType bool, defini
 
 func createOwnerProperty(idFactory astmodel.IdentifierFactory, ownerTypeName *astmodel.TypeName) (*astmodel.PropertyDefinition, error) {
 
-	knownResourceReferenceType := astmodel.MakeTypeName(
-		astmodel.GenRuntimeReference,
-		"KnownResourceReference")
-
 	prop := astmodel.NewPropertyDefinition(
 		idFactory.CreatePropertyName(astmodel.OwnerProperty, astmodel.Exported),
 		idFactory.CreateIdentifier(astmodel.OwnerProperty, astmodel.NotExported),
-		knownResourceReferenceType)
+		astmodel.KnownResourceReferenceTypeName)
 
 	if localRef, ok := ownerTypeName.PackageReference.AsLocalPackage(); ok {
 		group := localRef.Group() + astmodel.GroupSuffix
@@ -499,3 +506,26 @@ func createOwnerProperty(idFactory astmodel.IdentifierFactory, ownerTypeName *as
 
 	return prop, nil
 }
+
+// createArbitraryOwnerProperty creates the owner property of an extension resource, which names the group and kind of
+// its owner as well as its name because an extension resource can be applied to a resource of any kind. The owner
+// can instead be given by ARM ID, for resources which aren't managed through Kubernetes.
+func createArbitraryOwnerProperty(idFactory astmodel.IdentifierFactory) *astmodel.PropertyDefinition {
+	return astmodel.NewPropertyDefinition(
+		idFactory.CreatePropertyName(astmodel.OwnerProperty, astmodel.Exported),
+		idFactory.CreateIdentifier(astmodel.OwnerProperty, astmodel.NotExported),
+		astmodel.ArbitraryOwnerReferenceTypeName).
+		SetRequired(true). // Owner is always required
+		WithDescription("The resource the extension resource is applied to, either a Kubernetes resource or an ARM ID.")
+}
+
+// createOperatorSpecProperty creates the property configuring how the operator handles a resource, such as which of
+// its values to export into Kubernetes secrets and config maps
+func createOperatorSpecProperty(idFactory astmodel.IdentifierFactory) *astmodel.PropertyDefinition {
+	return astmodel.NewPropertyDefinition(
+		idFactory.CreatePropertyName(astmodel.OperatorSpecProperty, astmodel.Exported),
+		idFactory.CreateIdentifier(astmodel.OperatorSpecProperty, astmodel.NotExported),
+		astmodel.OperatorSpecTypeName).
+		MakeOptional().
+		WithDescription("Configures how the operator handles the resource, such as which of its values to export to secrets and config maps.")
+}
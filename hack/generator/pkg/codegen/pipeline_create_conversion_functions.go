@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel/storageconversion"
+)
+
+// createConversionFunctions returns a pipeline stage which implements hub and spoke conversion for each resource.
+// The storage version of each resource is the hub, implementing conversion.Hub; every other version (whether an API
+// version or an older storage version) implements conversion.Convertible. Rather than converting directly to the hub,
+// each version converts via the next one: an API version via its own storage version, and a storage version via the
+// next newer storage version. The spec and status (and the objects nested within them) get methods converting them
+// property by property to and from their counterparts in the next version. Resources with only a single version don't
+// need converting, so are left alone.
+// This must run after markStorageVersion, as that determines which version is the hub, and after addPropertyBags.
+func createConversionFunctions(idFactory astmodel.IdentifierFactory) PipelineStage {
+	return MakePipelineStage(
+		"createConversions",
+		"Create conversion functions between versions of each resource",
+		func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+
+			resourceLookup, err := groupResourcesByVersion(types)
+			if err != nil {
+				return nil, err
+			}
+
+			result := types.Copy()
+			for name, versions := range resourceLookup {
+				if len(versions) < 2 {
+					// Nothing to convert between
+					continue
+				}
+
+				hub, err := findHub(versions)
+				if err != nil {
+					return nil, errors.Wrapf(err, "unable to find hub for %s/%s", name.group, name.name)
+				}
+
+				for index, def := range versions {
+					if def.Name().Equals(hub) {
+						resource := def.Type().(*astmodel.ResourceType)
+						result[hub] = def.WithType(resource.WithInterface(storageconversion.NewHubImpl(idFactory)))
+						continue
+					}
+
+					next, err := findNextVersion(versions, index)
+					if err != nil {
+						return nil, errors.Wrapf(err, "unable to create conversions for %s", def.Name())
+					}
+
+					err = addConversions(result, def.Name(), next, hub, idFactory)
+					if err != nil {
+						return nil, errors.Wrapf(err, "unable to create conversions for %s", def.Name())
+					}
+				}
+			}
+
+			return result, nil
+		})
+}
+
+// findNextVersion returns the name of the version a spoke converts via, given all the versions of the resource
+// (ordered as by groupResourcesByVersion) and the index of the spoke. As each storage version sorts immediately after
+// its API version, this is the first storage version following the spoke.
+func findNextVersion(versions []astmodel.TypeDefinition, index int) (astmodel.TypeName, error) {
+	for _, def := range versions[index+1:] {
+		if astmodel.IsStoragePackageReference(def.Name().PackageReference) {
+			return def.Name(), nil
+		}
+	}
+
+	return astmodel.TypeName{}, errors.Errorf("no storage version follows %s", versions[index].Name())
+}
+
+// addConversions implements conversion.Convertible on the spoke resource, converting via the next version, and adds
+// the methods converting its spec and status (and any objects nested within them)
+func addConversions(
+	types astmodel.Types,
+	spoke astmodel.TypeName,
+	next astmodel.TypeName,
+	hub astmodel.TypeName,
+	idFactory astmodel.IdentifierFactory) error {
+
+	spokeDef := types[spoke]
+	spokeResource := spokeDef.Type().(*astmodel.ResourceType)
+	nextResource, ok := types[next].Type().(*astmodel.ResourceType)
+	if !ok {
+		return errors.Errorf("next version %s is not a resource", next)
+	}
+
+	var parts []storageconversion.ResourcePart
+	var statusName *astmodel.TypeName
+	for _, part := range []struct {
+		property astmodel.PropertyName
+		spoke    astmodel.Type
+		next     astmodel.Type
+	}{
+		{"Spec", spokeResource.SpecType(), nextResource.SpecType()},
+		{"Status", spokeResource.StatusType(), nextResource.StatusType()},
+	} {
+		spokePart, ok := part.spoke.(astmodel.TypeName)
+		if !ok {
+			continue
+		}
+
+		nextPart, ok := part.next.(astmodel.TypeName)
+		if !ok {
+			continue
+		}
+
+		spokeHasBag, err := hasPropertyBag(types, spokePart)
+		if err != nil {
+			return err
+		}
+
+		nextHasBag, err := hasPropertyBag(types, nextPart)
+		if err != nil {
+			return err
+		}
+
+		parts = append(parts, storageconversion.ResourcePart{
+			Property:    part.property,
+			Spoke:       spokePart,
+			Next:        nextPart,
+			SpokeHasBag: spokeHasBag,
+			NextHasBag:  nextHasBag,
+		})
+
+		err = addObjectConversions(types, spokePart, nextPart, idFactory)
+		if err != nil {
+			return err
+		}
+
+		if part.property == "Status" {
+			statusName = &spokePart
+		}
+	}
+
+	if len(parts) == 0 || parts[0].Property != "Spec" {
+		return errors.Errorf("spec of %s can't be converted to the spec of %s", spoke, next)
+	}
+
+	resource := spokeResource.WithInterface(storageconversion.NewConvertibleImpl(hub, next, parts, idFactory))
+	if !astmodel.IsStoragePackageReference(spoke.PackageReference) {
+		// Check that the API version survives a trip to the hub and back; this covers the storage versions on the way
+		resource = resource.WithTestCase(
+			storageconversion.NewResourceConversionTestCase(spoke, hub, parts[0].Spoke, statusName, idFactory))
+	}
+
+	types[spoke] = spokeDef.WithType(resource)
+	return nil
+}
+
+// addObjectConversions adds the methods converting the spoke object type to and from the next version, along with
+// those of any objects nested within it
+func addObjectConversions(
+	types astmodel.Types,
+	spoke astmodel.TypeName,
+	next astmodel.TypeName,
+	idFactory astmodel.IdentifierFactory) error {
+
+	pending := []astmodel.TypeName{spoke}
+	others := map[astmodel.TypeName]astmodel.TypeName{spoke: next}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+
+		other, ok := others[name]
+		if !ok {
+			// Nested objects convert to the object with the same name in the next version
+			other = astmodel.MakeTypeName(next.PackageReference, name.Name())
+		}
+
+		def := types[name]
+		objectType, ok := def.Type().(*astmodel.ObjectType)
+		if !ok {
+			return errors.Errorf("type %s was %T, not an object", name, def.Type())
+		}
+
+		convertTo, nested, err := storageconversion.NewObjectConversionFunction(
+			types, storageconversion.ConversionDirectionToHub, name, other, idFactory)
+		if err != nil {
+			return errors.Wrapf(err, "creating conversion from %s to %s", name, other)
+		}
+
+		convertFrom, _, err := storageconversion.NewObjectConversionFunction(
+			types, storageconversion.ConversionDirectionFromHub, name, other, idFactory)
+		if err != nil {
+			return errors.Wrapf(err, "creating conversion to %s from %s", name, other)
+		}
+
+		if objectType.HasFunctionWithName(convertTo.Name()) {
+			// Already done, either for another resource sharing the type or earlier in this one
+			continue
+		}
+
+		types[name] = def.WithType(objectType.WithFunction(convertTo).WithFunction(convertFrom))
+		pending = append(pending, nested...)
+	}
+
+	return nil
+}
+
+// hasPropertyBag returns true if the object type with the given name has a property bag
+func hasPropertyBag(types astmodel.Types, name astmodel.TypeName) (bool, error) {
+	def, ok := types[name]
+	if !ok {
+		return false, errors.Errorf("couldn't find type %s", name)
+	}
+
+	objectType, ok := def.Type().(*astmodel.ObjectType)
+	if !ok {
+		return false, errors.Errorf("type %s was %T, not an object", name, def.Type())
+	}
+
+	_, ok = objectType.Property(astmodel.PropertyBagProperty)
+	return ok, nil
+}
+
+// findHub returns the name of the storage version of a resource, given all its versions
+func findHub(versions []astmodel.TypeDefinition) (astmodel.TypeName, error) {
+	var hub *astmodel.TypeName
+	for _, def := range versions {
+		def := def
+		if def.Type().(*astmodel.ResourceType).IsStorageVersion() {
+			if hub != nil {
+				return astmodel.TypeName{}, errors.Errorf("both %s and %s are marked as the storage version", *hub, def.Name())
+			}
+
+			name := def.Name()
+			hub = &name
+		}
+	}
+
+	if hub == nil {
+		return astmodel.TypeName{}, errors.New("no version is marked as the storage version")
+	}
+
+	return *hub, nil
+}
@@ -0,0 +1,308 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel/storageconversion"
+
+	. "github.com/onsi/gomega"
+)
+
+var (
+	conversionTestV1        = astmodel.MakeLocalPackageReference("horo.logy", "v20200101")
+	conversionTestV1Storage = astmodel.MakeStoragePackageReference(conversionTestV1)
+	conversionTestV2        = astmodel.MakeLocalPackageReference("horo.logy", "v20200601")
+	conversionTestV2Storage = astmodel.MakeStoragePackageReference(conversionTestV2)
+)
+
+// makeConversionTestResource adds a Widget resource to defs, with the given properties on its spec and a status
+// with a single property
+func makeConversionTestResource(
+	defs astmodel.Types,
+	pkg astmodel.PackageReference,
+	isStorageVersion bool,
+	specProperties ...*astmodel.PropertyDefinition) astmodel.TypeName {
+
+	specName := astmodel.MakeTypeName(pkg, "Widget_Spec")
+	statusName := astmodel.MakeTypeName(pkg, "Widget_Status")
+	resourceName := astmodel.MakeTypeName(pkg, "Widget")
+
+	resource := astmodel.NewResourceType(specName, statusName)
+	if isStorageVersion {
+		resource = resource.MarkAsStorageVersion()
+	}
+
+	defs.Add(astmodel.MakeTypeDefinition(specName, astmodel.NewObjectType().WithProperties(specProperties...)))
+	defs.Add(astmodel.MakeTypeDefinition(statusName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Location", "location", astmodel.NewOptionalType(astmodel.StringType)))))
+	defs.Add(astmodel.MakeTypeDefinition(resourceName, resource))
+
+	return resourceName
+}
+
+// makeConversionTestPart adds a Part object type to defs, which is nested within the spec of a Widget
+func makeConversionTestPart(defs astmodel.Types, pkg astmodel.PackageReference, nameType astmodel.Type) astmodel.TypeName {
+	name := astmodel.MakeTypeName(pkg, "Part")
+	defs.Add(astmodel.MakeTypeDefinition(name, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Name", "name", nameType))))
+	return name
+}
+
+// makeConversionTestTypes creates two API versions of a Widget, each with a storage version:
+// v20200101 has a colour, which v20200601 lacks; v20200601 has a weight, which v20200101 lacks.
+func makeConversionTestTypes() astmodel.Types {
+	defs := make(astmodel.Types)
+
+	colour := astmodel.MakeTypeName(conversionTestV1, "Colour")
+	defs.Add(astmodel.MakeTypeDefinition(colour, astmodel.NewEnumType(
+		astmodel.StringType,
+		[]astmodel.EnumValue{{Identifier: "Red", Value: "\"red\""}})))
+
+	v1Part := makeConversionTestPart(defs, conversionTestV1, astmodel.StringType)
+	makeConversionTestResource(defs, conversionTestV1, false,
+		astmodel.NewPropertyDefinition("Colour", "colour", colour),
+		astmodel.NewPropertyDefinition("Parts", "parts", astmodel.NewArrayType(v1Part)),
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.IntType))
+
+	v1StoragePart := makeConversionTestPart(defs, conversionTestV1Storage, astmodel.NewOptionalType(astmodel.StringType))
+	makeConversionTestResource(defs, conversionTestV1Storage, false,
+		astmodel.NewPropertyDefinition("Colour", "colour", astmodel.NewOptionalType(astmodel.StringType)),
+		astmodel.NewPropertyDefinition("Parts", "parts", astmodel.NewArrayType(v1StoragePart)),
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.NewOptionalType(astmodel.IntType)))
+
+	v2Part := makeConversionTestPart(defs, conversionTestV2, astmodel.StringType)
+	makeConversionTestResource(defs, conversionTestV2, false,
+		astmodel.NewPropertyDefinition("Parts", "parts", astmodel.NewArrayType(v2Part)),
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.IntType),
+		astmodel.NewPropertyDefinition("Weight", "weight", astmodel.IntType))
+
+	v2StoragePart := makeConversionTestPart(defs, conversionTestV2Storage, astmodel.NewOptionalType(astmodel.StringType))
+	makeConversionTestResource(defs, conversionTestV2Storage, true,
+		astmodel.NewPropertyDefinition("Parts", "parts", astmodel.NewArrayType(v2StoragePart)),
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.NewOptionalType(astmodel.IntType)),
+		astmodel.NewPropertyDefinition("Weight", "weight", astmodel.NewOptionalType(astmodel.IntType)))
+
+	return defs
+}
+
+// createConversionTestResults runs the stages adding property bags and conversions over the given types
+func createConversionTestResults(g *WithT, defs astmodel.Types) astmodel.Types {
+	idFactory := astmodel.NewIdentifierFactory()
+
+	withBags, err := addPropertyBags().Action(context.Background(), defs)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	results, err := createConversionFunctions(idFactory).Action(context.Background(), withBags)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	return results
+}
+
+// generateConversionTestCode returns the code generated for the given definitions, which must share a package
+func generateConversionTestCode(g *WithT, defs ...astmodel.TypeDefinition) string {
+	file := astmodel.NewFileDefinition(
+		defs[0].Name().PackageReference,
+		defs,
+		make(map[astmodel.PackageReference]*astmodel.PackageDefinition))
+
+	var buf bytes.Buffer
+	g.Expect(file.SaveToWriter(&buf)).To(Succeed())
+
+	return buf.String()
+}
+
+func TestCreateConversionFunctions_StorageVersionIsHub(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+
+	hub := results[astmodel.MakeTypeName(conversionTestV2Storage, "Widget")].Type().(*astmodel.ResourceType)
+	g.Expect(hub.HasInterface(storageconversion.HubInterfaceName)).To(BeTrue())
+	g.Expect(hub.HasInterface(storageconversion.ConvertibleInterfaceName)).To(BeFalse())
+
+	for _, pkg := range []astmodel.PackageReference{conversionTestV1, conversionTestV1Storage, conversionTestV2} {
+		spoke := results[astmodel.MakeTypeName(pkg, "Widget")].Type().(*astmodel.ResourceType)
+		g.Expect(spoke.HasInterface(storageconversion.ConvertibleInterfaceName)).To(BeTrue())
+		g.Expect(spoke.HasInterface(storageconversion.HubInterfaceName)).To(BeFalse())
+	}
+}
+
+func TestCreateConversionFunctions_ApiVersion_ConvertsViaOwnStorageVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+	code := generateConversionTestCode(g, results[astmodel.MakeTypeName(conversionTestV1, "Widget")])
+
+	g.Expect(code).To(ContainSubstring("func (widget *Widget) ConvertTo(hub conversion.Hub) error {"))
+	g.Expect(code).To(ContainSubstring("destination := &v20200101storage.Widget{}"))
+	g.Expect(code).To(ContainSubstring("destination.ObjectMeta = *widget.ObjectMeta.DeepCopy()"))
+	g.Expect(code).To(ContainSubstring("specBag := genruntime.NewPropertyBag(widget.Spec.PropertyBag)"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := widget.Spec.ConvertToV20200101storage(&destination.Spec, specBag, \"\"); err != nil {"))
+	g.Expect(code).To(ContainSubstring("destination.Spec.PropertyBag = specBag"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := widget.Status.ConvertToV20200101storage(&destination.Status, statusBag, \"\"); err != nil {"))
+	g.Expect(code).To(ContainSubstring("return destination.ConvertTo(hub)"))
+
+	g.Expect(code).To(ContainSubstring("func (widget *Widget) ConvertFrom(hub conversion.Hub) error {"))
+	g.Expect(code).To(ContainSubstring("source := &v20200101storage.Widget{}"))
+	g.Expect(code).To(ContainSubstring("if err := source.ConvertFrom(hub); err != nil {"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := widget.Spec.ConvertFromV20200101storage(&source.Spec, specBag, \"\"); err != nil {"))
+	g.Expect(code).To(ContainSubstring("widget.Spec.PropertyBag = specBag"))
+}
+
+func TestCreateConversionFunctions_OlderStorageVersion_ConvertsToHub(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+	code := generateConversionTestCode(g, results[astmodel.MakeTypeName(conversionTestV1Storage, "Widget")])
+
+	g.Expect(code).To(ContainSubstring("destination, ok := hub.(*v20200601storage.Widget)"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := widget.Spec.ConvertToV20200601storage(&destination.Spec, specBag, \"\"); err != nil {"))
+	g.Expect(code).To(ContainSubstring("source, ok := hub.(*v20200601storage.Widget)"))
+	g.Expect(code).ToNot(ContainSubstring("ConvertTo(hub)"))
+}
+
+func TestCreateConversionFunctions_NewerApiVersion_ConvertsViaHub(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+	code := generateConversionTestCode(g, results[astmodel.MakeTypeName(conversionTestV2, "Widget")])
+
+	// Its storage version is the hub, so it converts directly
+	g.Expect(code).To(ContainSubstring("destination, ok := hub.(*v20200601storage.Widget)"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := widget.Spec.ConvertToV20200601storage(&destination.Spec, specBag, \"\"); err != nil {"))
+}
+
+func TestCreateConversionFunctions_PropertyBags_OnlyOnSpecAndStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+
+	for _, pkg := range []astmodel.PackageReference{
+		conversionTestV1, conversionTestV1Storage, conversionTestV2, conversionTestV2Storage} {
+		for _, name := range []string{"Widget_Spec", "Widget_Status"} {
+			object := results[astmodel.MakeTypeName(pkg, name)].Type().(*astmodel.ObjectType)
+			_, ok := object.Property(astmodel.PropertyBagProperty)
+			g.Expect(ok).To(BeTrue(), "expected %s in %s to have a property bag", name, pkg)
+		}
+
+		part := results[astmodel.MakeTypeName(pkg, "Part")].Type().(*astmodel.ObjectType)
+		_, ok := part.Property(astmodel.PropertyBagProperty)
+		g.Expect(ok).To(BeFalse(), "expected Part in %s not to have a property bag", pkg)
+	}
+}
+
+func TestCreateConversionFunctions_ApiToStorage_ConvertsEachProperty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+	code := generateConversionTestCode(g, results[astmodel.MakeTypeName(conversionTestV1, "Widget_Spec")])
+
+	g.Expect(code).To(ContainSubstring(
+		"func (widgetSpec *Widget_Spec) ConvertToV20200101storage(destination *v20200101storage.Widget_Spec, bag genruntime.PropertyBag, path string) error {"))
+
+	// Enums are stored as their base type
+	g.Expect(code).To(ContainSubstring("colour := string(widgetSpec.Colour)"))
+	g.Expect(code).To(ContainSubstring("destination.Colour = &colour"))
+
+	// Nested objects are converted by their own methods, with the path of each element
+	g.Expect(code).To(ContainSubstring("partsList := make([]v20200101storage.Part, len(widgetSpec.Parts))"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := partsItem.ConvertToV20200101storage(&partsList[partsIndex], bag, genruntime.PropertyPath(path, \"parts\", partsIndex)); err != nil {"))
+
+	// And back again
+	g.Expect(code).To(ContainSubstring(
+		"func (widgetSpec *Widget_Spec) ConvertFromV20200101storage(source *v20200101storage.Widget_Spec, bag genruntime.PropertyBag, path string) error {"))
+	g.Expect(code).To(ContainSubstring("if source.Colour != nil {"))
+	g.Expect(code).To(ContainSubstring("widgetSpec.Colour = Colour(*source.Colour)"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := partsList[partsIndex].ConvertFromV20200101storage(&partsItem, bag, genruntime.PropertyPath(path, \"parts\", partsIndex)); err != nil {"))
+
+	// The nested object gets conversion methods too
+	part := results[astmodel.MakeTypeName(conversionTestV1, "Part")].Type().(*astmodel.ObjectType)
+	g.Expect(part.HasFunctionWithName("ConvertToV20200101storage")).To(BeTrue())
+	g.Expect(part.HasFunctionWithName("ConvertFromV20200101storage")).To(BeTrue())
+}
+
+func TestCreateConversionFunctions_MissingProperties_KeptInPropertyBag(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+	code := generateConversionTestCode(g, results[astmodel.MakeTypeName(conversionTestV1Storage, "Widget_Spec")])
+
+	// The hub has no colour, so we keep it in the bag and restore it from there
+	g.Expect(code).To(ContainSubstring(
+		"if err := bag.Add(genruntime.PropertyPath(path, \"colour\"), widgetSpec.Colour); err != nil {"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := bag.Pull(genruntime.PropertyPath(path, \"colour\"), &widgetSpec.Colour); err != nil {"))
+
+	// We have no weight, so keep the hub's in the bag and restore it from there
+	g.Expect(code).To(ContainSubstring(
+		"if err := bag.Add(genruntime.PropertyPath(path, \"weight\"), source.Weight); err != nil {"))
+	g.Expect(code).To(ContainSubstring(
+		"if err := bag.Pull(genruntime.PropertyPath(path, \"weight\"), &destination.Weight); err != nil {"))
+
+	// The bag itself isn't converted as a property
+	g.Expect(code).ToNot(ContainSubstring("destination.PropertyBag"))
+}
+
+func TestCreateConversionFunctions_ApiVersions_HaveRoundTripTests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	results := createConversionTestResults(g, makeConversionTestTypes())
+
+	testName := "Widget_WhenConvertedToHub_RoundTripsWithoutLoss"
+	for _, pkg := range []astmodel.PackageReference{conversionTestV1, conversionTestV2} {
+		resource := results[astmodel.MakeTypeName(pkg, "Widget")].Type().(*astmodel.ResourceType)
+		g.Expect(resource.TestCases()).To(HaveLen(1))
+		g.Expect(resource.TestCases()[0].Name()).To(Equal(testName))
+	}
+
+	storage := results[astmodel.MakeTypeName(conversionTestV1Storage, "Widget")].Type().(*astmodel.ResourceType)
+	g.Expect(storage.HasTestCases()).To(BeFalse())
+}
+
+func TestCreateConversionFunctions_SingleApiVersion_HasNoPropertyBags(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defs := make(astmodel.Types)
+	makeConversionTestResource(defs, conversionTestV1, false,
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.IntType))
+	makeConversionTestResource(defs, conversionTestV1Storage, true,
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.NewOptionalType(astmodel.IntType)))
+
+	results := createConversionTestResults(g, defs)
+
+	spec := results[astmodel.MakeTypeName(conversionTestV1Storage, "Widget_Spec")].Type().(*astmodel.ObjectType)
+	_, ok := spec.Property(astmodel.PropertyBagProperty)
+	g.Expect(ok).To(BeFalse())
+
+	code := generateConversionTestCode(g, results[astmodel.MakeTypeName(conversionTestV1, "Widget")])
+	g.Expect(code).To(ContainSubstring("specBag := genruntime.NewPropertyBag()"))
+	g.Expect(code).ToNot(ContainSubstring("PropertyBag = specBag"))
+}
+
+func TestCreateConversionFunctions_SingleVersion_IsLeftAlone(t *testing.T) {
+	g := NewGomegaWithT(t)
+	idFactory := astmodel.NewIdentifierFactory()
+
+	defs := make(astmodel.Types)
+	name := makeConversionTestResource(defs, conversionTestV1, true,
+		astmodel.NewPropertyDefinition("Size", "size", astmodel.IntType))
+
+	results, err := createConversionFunctions(idFactory).Action(context.Background(), defs)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(results[name]).To(Equal(defs[name]))
+}
@@ -130,8 +130,10 @@ func (factory *StorageTypeFactory) visitResourceType(
 	resource *astmodel.ResourceType,
 	ctx interface{}) (astmodel.Type, error) {
 
-	// storage resource types do not need defaulter interface, they have no webhooks
-	return resource.WithoutInterface(astmodel.DefaulterInterfaceName), nil
+	// storage resource types do not need defaulter or validator interfaces, they have no webhooks
+	return resource.
+		WithoutInterface(astmodel.DefaulterInterfaceName).
+		WithoutInterface(astmodel.ValidatorInterfaceName), nil
 }
 
 func (factory *StorageTypeFactory) visitObjectType(
@@ -241,6 +241,11 @@ func setResourceGroupOwnerForResourcesWithNoOwner(
 			continue
 		}
 
+		// Extension resources can be applied to a resource of any kind, so aren't owned by a resource group
+		if resourceType.IsExtension() {
+			continue
+		}
+
 		if resourceType.Owner() == nil {
 			ownerTypeName := astmodel.MakeTypeName(
 				// Note that the version doesn't really matter here -- it's removed later. We just need to refer to the logical
@@ -7,90 +7,24 @@ package codegen
 
 import (
 	"context"
-	"net/http"
-	"os"
 
 	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
 	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
 	"github.com/Azure/k8s-infra/hack/generator/pkg/jsonast"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/schemasource"
 	"github.com/pkg/errors"
-	"github.com/xeipuuv/gojsonreference"
 	"github.com/xeipuuv/gojsonschema"
 
 	"k8s.io/klog/v2"
 )
 
-type cancellableFileSystem struct {
-	ctx context.Context
-}
-
-var _ http.FileSystem = &cancellableFileSystem{} // interface assertion
-
-func (fs *cancellableFileSystem) Open(source string) (http.File, error) {
-	if fs.ctx.Err() != nil { // check for cancellation
-		return nil, fs.ctx.Err()
-	}
-
-	return os.Open(source)
-}
-
-type cancellableJSONLoaderFactory struct {
-	ctx   context.Context
-	inner gojsonschema.JSONLoaderFactory
-}
-
-var _ gojsonschema.JSONLoaderFactory = &cancellableJSONLoaderFactory{}
-
-func (factory *cancellableJSONLoaderFactory) New(source string) gojsonschema.JSONLoader {
-	return &cancellableJSONLoader{factory.ctx, factory.inner.New(source)}
-}
-
-type cancellableJSONLoader struct {
-	ctx   context.Context
-	inner gojsonschema.JSONLoader
-}
-
-var _ gojsonschema.JSONLoader = &cancellableJSONLoader{}
-
-func (loader *cancellableJSONLoader) LoadJSON() (interface{}, error) {
-	if loader.ctx.Err() != nil { // check for cancellation
-		return nil, loader.ctx.Err()
-	}
-
-	return loader.inner.LoadJSON()
-}
-
-func (loader *cancellableJSONLoader) JsonSource() interface{} {
-	return loader.inner.JsonSource()
-}
-
-func (loader *cancellableJSONLoader) JsonReference() (gojsonreference.JsonReference, error) {
-	if loader.ctx.Err() != nil { // check for cancellation
-		return gojsonreference.JsonReference{}, loader.ctx.Err()
-	}
-
-	return loader.inner.JsonReference()
-}
-
-func (loader *cancellableJSONLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
-	return &cancellableJSONLoaderFactory{loader.ctx, loader.inner.LoaderFactory()}
-}
-
 type schemaLoader func(ctx context.Context, source string) (*gojsonschema.Schema, error)
 
-func defaultSchemaLoader(ctx context.Context, source string) (*gojsonschema.Schema, error) {
-	sl := gojsonschema.NewSchemaLoader()
-	loader := &cancellableJSONLoader{
-		ctx,
-		gojsonschema.NewReferenceLoaderFileSystem(source, &cancellableFileSystem{ctx}),
+// sourceSchemaLoader returns a schemaLoader which loads the schema, and everything it references, from the given source
+func sourceSchemaLoader(schemaSource schemasource.Source) schemaLoader {
+	return func(ctx context.Context, source string) (*gojsonschema.Schema, error) {
+		return schemasource.Load(ctx, schemaSource, source)
 	}
-
-	schema, err := sl.Compile(loader)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error loading schema from %q", source)
-	}
-
-	return schema, nil
 }
 
 func loadSchemaIntoTypes(
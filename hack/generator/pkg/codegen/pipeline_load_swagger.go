@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+/* loadSwaggerIntoTypes creates a PipelineStage to load resources from the Azure Swagger specifications alone.
+
+The spec of each resource is generated from the body of its PUT request, leaving out any properties which are only
+ever set by the server, and its status from the response to its GET request. This replaces both loadSchemaIntoTypes
+and augmentResourcesWithStatus, so that resources can be generated for API versions which are not yet published in
+the ARM deployment template schema.
+
+*/
+func loadSwaggerIntoTypes(idFactory astmodel.IdentifierFactory, config *config.Configuration) PipelineStage {
+	return MakePipelineStage(
+		"loadSwagger",
+		"Load resources, with their 'spec' and 'status' fields, from Swagger specs",
+		func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+
+			klog.V(0).Infof("Loading Swagger data from %q", config.Status.SchemaRoot)
+
+			swaggerTypes, err := loadSwaggerData(ctx, idFactory, config, true)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to load Swagger data")
+			}
+
+			klog.V(1).Infof(
+				"Loaded Swagger data (%v resources, %v spec types, %v status types)",
+				len(swaggerTypes.specs),
+				len(swaggerTypes.specTypes),
+				len(swaggerTypes.otherTypes))
+
+			statusTypes, err := generateStatusTypes(swaggerTypes)
+			if err != nil {
+				return nil, err
+			}
+
+			newTypes := make(astmodel.Types)
+			newTypes.AddTypes(swaggerTypes.specTypes)
+			newTypes.AddAll(statusTypes.otherTypes)
+
+			for typeName, typeDef := range swaggerTypes.specs {
+				if _, ok := newTypes[typeName]; ok {
+					return nil, errors.Errorf("resource %v has the same name as one of the types it uses", typeName)
+				}
+
+				resource := typeDef.Type().(*astmodel.ResourceType)
+				if statusDef, ok := statusTypes.resourceTypes.tryFind(typeName); ok {
+					resource = resource.WithStatus(statusDef)
+				}
+
+				// missing status is caught later in pipeline (checkForMissingStatusInformation)
+				newTypes.Add(typeDef.WithType(resource))
+			}
+
+			return newTypes, nil
+		})
+}
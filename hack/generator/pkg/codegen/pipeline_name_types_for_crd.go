@@ -152,6 +152,10 @@ func nameInnerTypes(
 
 		// TODO: Should we have some better "clone" sort of thing in resource?
 		newResource := astmodel.NewResourceType(spec, status).WithOwner(it.Owner())
+		if it.IsExtension() {
+			newResource = newResource.MarkAsExtension()
+		}
+
 		resource := astmodel.MakeTypeDefinition(resourceName, newResource)
 		resource = resource.WithDescription(getDescription(resourceName))
 
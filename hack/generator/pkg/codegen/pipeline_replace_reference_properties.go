@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
+)
+
+// replaceReferenceProperties returns a pipeline stage which replaces the ARM ID properties selected by the
+// configuration with references to other resources, which may be given either by their Kubernetes group, kind and
+// name or by their ARM ID. The references are resolved to ARM IDs when the resource is converted to ARM, so the ARM
+// types keep the original properties; this must run after the ARM types have been created.
+// Only types used by resource specs are changed, as status types only ever hold what Azure returns.
+func replaceReferenceProperties(configuration *config.Configuration) PipelineStage {
+	return MakePipelineStage(
+		"referenceProperties",
+		"Replace ARM ID properties with references to other resources",
+		func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+
+			specTypes := findSpecTypes(types)
+
+			result := make(astmodel.Types)
+			var errs []error
+			for _, def := range types {
+				objectType, ok := def.Type().(*astmodel.ObjectType)
+				if !ok || !specTypes.Contains(def.Name()) {
+					// ARM types are flagged, so are skipped here too
+					result.Add(def)
+					continue
+				}
+
+				for _, prop := range objectType.Properties() {
+					isReference, because := configuration.IsReferenceProperty(def.Name(), prop.PropertyName())
+					if !isReference {
+						continue
+					}
+
+					referenceType, err := stringReferenceTypeFor(prop.PropertyType(), astmodel.ResourceReferenceTypeName)
+					if err != nil {
+						errs = append(errs, errors.Wrapf(err, "property %s of %s", prop.PropertyName(), def.Name()))
+						continue
+					}
+
+					klog.V(2).Infof("Replacing %s.%s with a resource reference because %s", def.Name(), prop.PropertyName(), because)
+					objectType = objectType.WithProperty(prop.WithType(referenceType))
+				}
+
+				result.Add(def.WithType(objectType))
+			}
+
+			if len(errs) > 0 {
+				return nil, kerrors.NewAggregate(errs)
+			}
+
+			return result, nil
+		})
+}
+
+// findSpecTypes returns the types reachable from the specs of resources
+func findSpecTypes(types astmodel.Types) astmodel.ReachableTypes {
+	roots := make(astmodel.TypeNameSet)
+	for _, def := range types {
+		if resource, ok := def.Type().(*astmodel.ResourceType); ok {
+			if specName, ok := resource.SpecType().(astmodel.TypeName); ok {
+				roots.Add(specName)
+			}
+		}
+	}
+
+	references := make(map[astmodel.TypeName]astmodel.TypeNameSet)
+	for _, def := range types {
+		references[def.Name()] = def.References()
+	}
+
+	return astmodel.NewReferenceGraph(roots, references).Connected()
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
+)
+
+// replaceSecretProperties returns a pipeline stage which replaces the sensitive properties selected by the
+// configuration, along with those of resource specs marked x-ms-secret in Swagger, with references to Kubernetes
+// secrets, so that their values aren't held in the resource itself.
+// The values are looked up when the resource is converted to ARM, so the ARM types keep the original properties;
+// this must run after the ARM types have been created.
+func replaceSecretProperties(configuration *config.Configuration) PipelineStage {
+	return MakePipelineStage(
+		"secretProperties",
+		"Replace sensitive properties with references to secrets",
+		func(ctx context.Context, types astmodel.Types) (astmodel.Types, error) {
+
+			specTypes := findSpecTypes(types)
+
+			result := make(astmodel.Types)
+			var errs []error
+			for _, def := range types {
+				objectType, ok := def.Type().(*astmodel.ObjectType)
+				if !ok {
+					// ARM types are flagged, so are skipped here too
+					result.Add(def)
+					continue
+				}
+
+				for _, prop := range objectType.Properties() {
+					isSecret, because := configuration.IsSecretProperty(def.Name(), prop.PropertyName())
+					if !isSecret && prop.IsSecret() && specTypes.Contains(def.Name()) {
+						// status types only ever hold what Azure returns, which never includes secrets
+						isSecret, because = true, "it is marked x-ms-secret"
+					}
+
+					if !isSecret {
+						continue
+					}
+
+					secretType, err := stringReferenceTypeFor(prop.PropertyType(), astmodel.SecretReferenceTypeName)
+					if err != nil {
+						errs = append(errs, errors.Wrapf(err, "property %s of %s", prop.PropertyName(), def.Name()))
+						continue
+					}
+
+					klog.V(2).Infof("Replacing %s.%s with a secret reference because %s", def.Name(), prop.PropertyName(), because)
+					objectType = objectType.WithProperty(prop.WithType(secretType))
+				}
+
+				result.Add(def.WithType(objectType))
+			}
+
+			if len(errs) > 0 {
+				return nil, kerrors.NewAggregate(errs)
+			}
+
+			return result, nil
+		})
+}
+
+// stringReferenceTypeFor returns the type of a reference (such as to a secret) standing in for a string property of
+// the given type, which is optional if the original type is
+func stringReferenceTypeFor(propertyType astmodel.Type, referenceType astmodel.TypeName) (astmodel.Type, error) {
+	t := propertyType
+	optional := false
+	if optionalType, ok := t.(*astmodel.OptionalType); ok {
+		t = optionalType.Element()
+		optional = true
+	}
+
+	if validatedType, ok := t.(astmodel.ValidatedType); ok {
+		t = validatedType.ElementType()
+	}
+
+	if !t.Equals(astmodel.StringType) {
+		return nil, errors.Errorf("only string properties can be replaced with a %s, but type was %s", referenceType.Name(), propertyType)
+	}
+
+	if optional {
+		return astmodel.NewOptionalType(referenceType), nil
+	}
+
+	return referenceType, nil
+}
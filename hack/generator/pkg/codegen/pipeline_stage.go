@@ -38,3 +38,19 @@ func MakePipelineStage(
 func (stage *PipelineStage) HasId(id string) bool {
 	return stage.id == id
 }
+
+// hasAnyId returns true if this stage has one of the specified ids, false otherwise
+func (stage *PipelineStage) hasAnyId(ids []string) bool {
+	for _, id := range ids {
+		if stage.HasId(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writesOutput returns true if this stage writes to the output folder, false otherwise
+func (stage *PipelineStage) writesOutput() bool {
+	return stage.HasId("deleteGenerated") || stage.HasId("exportPackages") || stage.HasId("reportTypesAndVersions")
+}
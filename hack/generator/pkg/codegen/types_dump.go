@@ -0,0 +1,420 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+)
+
+// DumpFormat is the format in which types are written when dumped after a pipeline stage
+type DumpFormat string
+
+const (
+	// DumpAsText writes types as Go-like declarations, for reading
+	DumpAsText = DumpFormat("text")
+	// DumpAsJSON writes types as JSON, for processing with other tools
+	DumpAsJSON = DumpFormat("json")
+)
+
+// allStages selects every stage of the pipeline when given as the id of a stage to dump types after
+const allStages = "*"
+
+// typesDumper writes the types held by the pipeline to a file after selected stages, so that the effect of each
+// stage can be inspected when debugging the generator
+type typesDumper struct {
+	dir    string
+	format DumpFormat
+	stages map[string]bool
+}
+
+// dumpsAfter returns true if the types should be dumped after the given stage, false otherwise
+func (dumper *typesDumper) dumpsAfter(stage PipelineStage) bool {
+	return dumper.stages[allStages] || dumper.stages[stage.id]
+}
+
+// dump writes the types to a file named after the position and id of the stage, returning the path of the file
+func (dumper *typesDumper) dump(index int, stage PipelineStage, types astmodel.Types) (string, error) {
+	err := os.MkdirAll(dumper.dir, 0700)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create directory %q", dumper.dir)
+	}
+
+	extension := "txt"
+	if dumper.format == DumpAsJSON {
+		extension = "json"
+	}
+
+	// Stages are numbered so that the files sort in pipeline order, and some stages run more than once
+	path := filepath.Join(dumper.dir, fmt.Sprintf("%02d-%s.%s", index+1, stage.id, extension))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create %q", path)
+	}
+
+	definitions := dumpDefinitions(types)
+	if dumper.format == DumpAsJSON {
+		err = writeDefinitionsAsJSON(file, definitions)
+	} else {
+		err = writeDefinitionsAsText(file, definitions)
+	}
+
+	if err != nil {
+		file.Close()
+		return "", errors.Wrapf(err, "unable to write %q", path)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to write %q", path)
+	}
+
+	return path, nil
+}
+
+// dumpedDefinition is the form in which a type definition is dumped
+type dumpedDefinition struct {
+	Package     string      `json:"package"`
+	Name        string      `json:"name"`
+	Description []string    `json:"description,omitempty"`
+	Type        *dumpedType `json:"type"`
+}
+
+// dumpedType is the form in which a type is dumped; which fields are used depends on the kind of type
+type dumpedType struct {
+	// Kind of type, such as object, resource, enum or name (for references to other types)
+	Kind string `json:"kind"`
+	// Name of the referenced type, primitive type or base type of an enum
+	Name string `json:"name,omitempty"`
+	// Flags applied to flagged types and resources
+	Flags []string `json:"flags,omitempty"`
+	// Validations of validated types
+	Validations []string `json:"validations,omitempty"`
+	// Values of enums, as Go literals
+	Values []string `json:"values,omitempty"`
+	// Element of optional, array, flagged and validated types
+	Element *dumpedType `json:"element,omitempty"`
+	// Key and Value of maps
+	Key   *dumpedType `json:"key,omitempty"`
+	Value *dumpedType `json:"value,omitempty"`
+	// Types of allOf and oneOf types
+	Types []*dumpedType `json:"types,omitempty"`
+	// Properties of objects, including any embedded properties (which have no name)
+	Properties []*dumpedProperty `json:"properties,omitempty"`
+	// Spec, Status and Owner of resources
+	Spec   *dumpedType `json:"spec,omitempty"`
+	Status *dumpedType `json:"status,omitempty"`
+	Owner  string      `json:"owner,omitempty"`
+}
+
+// dumpedProperty is the form in which a property of an object is dumped
+type dumpedProperty struct {
+	Name     string      `json:"name,omitempty"`
+	JsonName string      `json:"jsonName,omitempty"`
+	Flags    []string    `json:"flags,omitempty"`
+	Type     *dumpedType `json:"type"`
+}
+
+// dumpDefinitions returns the dumped form of the types, ordered by package and then name
+func dumpDefinitions(types astmodel.Types) []*dumpedDefinition {
+	var result []*dumpedDefinition
+	for _, def := range types {
+		result = append(result, &dumpedDefinition{
+			Package:     packageOf(def.Name()),
+			Name:        def.Name().Name(),
+			Description: def.Description(),
+			Type:        dumpType(def.Type()),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Package != result[j].Package {
+			return result[i].Package < result[j].Package
+		}
+
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// packageOf returns a short but unambiguous name for the package of the type: the group and version of local
+// packages, or just the package name of others (such as genruntime)
+func packageOf(name astmodel.TypeName) string {
+	if local, ok := name.PackageReference.AsLocalPackage(); ok {
+		return local.Group() + "/" + local.Version()
+	}
+
+	return name.PackageReference.PackageName()
+}
+
+// qualifiedName returns the name of the type, qualified by its package
+func qualifiedName(name astmodel.TypeName) string {
+	return packageOf(name) + "." + name.Name()
+}
+
+func dumpType(t astmodel.Type) *dumpedType {
+	switch t := t.(type) {
+	case astmodel.TypeName:
+		return &dumpedType{Kind: "name", Name: qualifiedName(t)}
+
+	case *astmodel.PrimitiveType:
+		return &dumpedType{Kind: "primitive", Name: t.Name()}
+
+	case *astmodel.OptionalType:
+		return &dumpedType{Kind: "optional", Element: dumpType(t.Element())}
+
+	case *astmodel.ArrayType:
+		return &dumpedType{Kind: "array", Element: dumpType(t.Element())}
+
+	case *astmodel.MapType:
+		return &dumpedType{Kind: "map", Key: dumpType(t.KeyType()), Value: dumpType(t.ValueType())}
+
+	case *astmodel.EnumType:
+		result := &dumpedType{Kind: "enum", Name: t.BaseType().Name()}
+		for _, option := range t.Options() {
+			result.Values = append(result.Values, option.Value)
+		}
+
+		return result
+
+	case astmodel.ValidatedType:
+		result := &dumpedType{Kind: "validated", Element: dumpType(t.ElementType())}
+		for _, validation := range t.Validations().ToKubeBuilderValidations() {
+			comment := astmodel.GenerateKubebuilderComment(validation)
+			result.Validations = append(result.Validations, strings.TrimPrefix(comment, "// +kubebuilder:validation:"))
+		}
+
+		return result
+
+	case *astmodel.FlaggedType:
+		result := &dumpedType{Kind: "flagged", Element: dumpType(t.Element())}
+		for _, flag := range t.Flags() {
+			result.Flags = append(result.Flags, flag.String())
+		}
+
+		return result
+
+	case astmodel.AllOfType:
+		return &dumpedType{Kind: "allOf", Types: dumpTypes(t.Types())}
+
+	case astmodel.OneOfType:
+		return &dumpedType{Kind: "oneOf", Types: dumpTypes(t.Types())}
+
+	case *astmodel.ObjectType:
+		result := &dumpedType{Kind: "object"}
+		for _, prop := range t.EmbeddedProperties() {
+			result.Properties = append(result.Properties, &dumpedProperty{Type: dumpType(prop.PropertyType())})
+		}
+
+		for _, prop := range t.Properties() {
+			result.Properties = append(result.Properties, dumpProperty(prop))
+		}
+
+		return result
+
+	case *astmodel.ResourceType:
+		result := &dumpedType{Kind: "resource"}
+		if t.SpecType() != nil {
+			result.Spec = dumpType(t.SpecType())
+		}
+
+		if t.StatusType() != nil {
+			result.Status = dumpType(t.StatusType())
+		}
+
+		if t.Owner() != nil {
+			result.Owner = qualifiedName(*t.Owner())
+		}
+
+		if t.IsExtension() {
+			result.Flags = append(result.Flags, "extension")
+		}
+
+		if t.IsStorageVersion() {
+			result.Flags = append(result.Flags, "storageVersion")
+		}
+
+		return result
+
+	default:
+		// Anything else is described as best it can be
+		return &dumpedType{Kind: "other", Name: t.String()}
+	}
+}
+
+func dumpTypes(types astmodel.ReadonlyTypeSet) []*dumpedType {
+	var result []*dumpedType
+	types.ForEach(func(t astmodel.Type, _ int) {
+		result = append(result, dumpType(t))
+	})
+
+	return result
+}
+
+func dumpProperty(prop *astmodel.PropertyDefinition) *dumpedProperty {
+	result := &dumpedProperty{
+		Name:     string(prop.PropertyName()),
+		JsonName: prop.JsonName(),
+		Type:     dumpType(prop.PropertyType()),
+	}
+
+	if prop.IsRequired() {
+		result.Flags = append(result.Flags, "required")
+	}
+
+	if prop.IsReadOnly() {
+		result.Flags = append(result.Flags, "readOnly")
+	}
+
+	if prop.IsImmutable() {
+		result.Flags = append(result.Flags, "immutable")
+	}
+
+	if prop.IsSecret() {
+		result.Flags = append(result.Flags, "secret")
+	}
+
+	return result
+}
+
+// writeDefinitionsAsJSON writes the definitions as an indented JSON array
+func writeDefinitionsAsJSON(w io.Writer, definitions []*dumpedDefinition) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(definitions)
+}
+
+// writeDefinitionsAsText writes the definitions as Go-like declarations, grouped by package. Types which have no
+// Go equivalent (such as resources, enums and allOf) are written as if they were Go types, like:
+//
+//	type Widget resource {
+//		spec   Widgets_Spec
+//		status Widget_Status
+//	}
+func writeDefinitionsAsText(w io.Writer, definitions []*dumpedDefinition) error {
+	var buffer strings.Builder
+	pkg := ""
+	for _, def := range definitions {
+		if def.Package != pkg {
+			pkg = def.Package
+			buffer.WriteString(fmt.Sprintf("package %s\n\n", pkg))
+		}
+
+		for _, line := range def.Description {
+			buffer.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+		}
+
+		buffer.WriteString(fmt.Sprintf("type %s ", def.Name))
+		def.Type.writeTo(&buffer, pkg, "")
+		buffer.WriteString("\n\n")
+	}
+
+	_, err := io.WriteString(w, buffer.String())
+	return err
+}
+
+// writeTo writes the type as a Go-like type expression, leaving references to types in pkg unqualified, and
+// indenting nested lines by indent
+func (t *dumpedType) writeTo(buffer *strings.Builder, pkg string, indent string) {
+	switch t.Kind {
+	case "name":
+		buffer.WriteString(strings.TrimPrefix(t.Name, pkg+"."))
+
+	case "optional":
+		buffer.WriteString("*")
+		t.Element.writeTo(buffer, pkg, indent)
+
+	case "array":
+		buffer.WriteString("[]")
+		t.Element.writeTo(buffer, pkg, indent)
+
+	case "map":
+		buffer.WriteString("map[")
+		t.Key.writeTo(buffer, pkg, indent)
+		buffer.WriteString("]")
+		t.Value.writeTo(buffer, pkg, indent)
+
+	case "enum":
+		buffer.WriteString(fmt.Sprintf("enum %s {", t.Name))
+		for _, value := range t.Values {
+			buffer.WriteString(fmt.Sprintf("\n%s\t%s", indent, value))
+		}
+
+		buffer.WriteString(fmt.Sprintf("\n%s}", indent))
+
+	case "validated":
+		t.Element.writeTo(buffer, pkg, indent)
+		buffer.WriteString(fmt.Sprintf(" /* %s */", strings.Join(t.Validations, ", ")))
+
+	case "flagged":
+		t.Element.writeTo(buffer, pkg, indent)
+		buffer.WriteString(fmt.Sprintf(" /* %s */", strings.Join(t.Flags, ", ")))
+
+	case "allOf", "oneOf":
+		buffer.WriteString(t.Kind + " {")
+		for _, member := range t.Types {
+			buffer.WriteString(fmt.Sprintf("\n%s\t", indent))
+			member.writeTo(buffer, pkg, indent+"\t")
+		}
+
+		buffer.WriteString(fmt.Sprintf("\n%s}", indent))
+
+	case "object":
+		buffer.WriteString("struct {")
+		for _, prop := range t.Properties {
+			buffer.WriteString(fmt.Sprintf("\n%s\t", indent))
+			if prop.Name != "" {
+				buffer.WriteString(prop.Name + " ")
+			}
+
+			prop.Type.writeTo(buffer, pkg, indent+"\t")
+			if prop.JsonName != "" {
+				buffer.WriteString(fmt.Sprintf(" `json:%q`", prop.JsonName))
+			}
+
+			if len(prop.Flags) > 0 {
+				buffer.WriteString(" // " + strings.Join(prop.Flags, ", "))
+			}
+		}
+
+		buffer.WriteString(fmt.Sprintf("\n%s}", indent))
+
+	case "resource":
+		buffer.WriteString("resource {")
+		if t.Spec != nil {
+			buffer.WriteString(fmt.Sprintf("\n%s\tspec   ", indent))
+			t.Spec.writeTo(buffer, pkg, indent+"\t")
+		}
+
+		if t.Status != nil {
+			buffer.WriteString(fmt.Sprintf("\n%s\tstatus ", indent))
+			t.Status.writeTo(buffer, pkg, indent+"\t")
+		}
+
+		if t.Owner != "" {
+			buffer.WriteString(fmt.Sprintf("\n%s\towner  %s", indent, strings.TrimPrefix(t.Owner, pkg+".")))
+		}
+
+		buffer.WriteString(fmt.Sprintf("\n%s}", indent))
+		if len(t.Flags) > 0 {
+			buffer.WriteString(fmt.Sprintf(" /* %s */", strings.Join(t.Flags, ", ")))
+		}
+
+	default:
+		// primitives, and anything else described by name
+		buffer.WriteString(t.Name)
+	}
+}
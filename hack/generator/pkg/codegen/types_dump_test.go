@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWriteDefinitionsAsText_WritesGoLikeDeclarations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := astmodel.MakeLocalPackageReference("horo.logy", "v20200730")
+
+	specName := astmodel.MakeTypeName(p, "Widget_Spec")
+	statusName := astmodel.MakeTypeName(p, "Widget_Status")
+	resourceName := astmodel.MakeTypeName(p, "Widget")
+	colourName := astmodel.MakeTypeName(p, "Colour")
+
+	defs := make(astmodel.Types)
+	defs.Add(astmodel.MakeTypeDefinition(specName, astmodel.NewObjectType().WithProperties(
+		astmodel.NewPropertyDefinition("Colour", "colour", colourName).MakeRequired(),
+		astmodel.NewPropertyDefinition("Sku", "sku", astmodel.StringType).MakeOptional().MakeImmutable(),
+		astmodel.NewPropertyDefinition("Tags", "tags", astmodel.NewMapType(astmodel.StringType, astmodel.StringType)),
+		astmodel.NewPropertyDefinition("Owner", "owner", astmodel.MakeTypeName(astmodel.GenRuntimeReference, "KnownResourceReference")))))
+	defs.Add(astmodel.MakeTypeDefinition(colourName, astmodel.NewEnumType(
+		astmodel.StringType,
+		[]astmodel.EnumValue{{Identifier: "Red", Value: "\"red\""}, {Identifier: "Blue", Value: "\"blue\""}})))
+	defs.Add(astmodel.MakeTypeDefinition(resourceName, astmodel.NewResourceType(specName, statusName)).
+		WithDescription([]string{"A widget "}))
+
+	var content strings.Builder
+	g.Expect(writeDefinitionsAsText(&content, dumpDefinitions(defs))).To(Succeed())
+	g.Expect(content.String()).To(Equal(`package horo.logy/v20200730
+
+type Colour enum string {
+	"blue"
+	"red"
+}
+
+// A widget
+type Widget resource {
+	spec   Widget_Spec
+	status Widget_Status
+}
+
+type Widget_Spec struct {
+	Colour Colour ` + "`json:\"colour\"`" + ` // required
+	Owner genruntime.KnownResourceReference ` + "`json:\"owner\"`" + `
+	Sku *string ` + "`json:\"sku\"`" + ` // immutable
+	Tags map[string]string ` + "`json:\"tags\"`" + `
+}
+
+`))
+}
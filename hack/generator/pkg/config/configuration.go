@@ -18,8 +18,15 @@ import (
 
 // Configuration is used to control which types get generated
 type Configuration struct {
+	// Where the spec of each resource is generated from; defaults to the JSON schema given by SchemaURL
+	SpecSource SpecSource `yaml:"specSource"`
 	// Base URL for the JSON schema to generate
 	SchemaURL string `yaml:"schemaUrl"`
+	// Where to load the JSON schema (and everything it references) from, if not from the network
+	SchemaSource SchemaSourceConfiguration `yaml:"schemaSource"`
+	// SchemaURLRewrites maps URL prefixes to their replacements, applied to every schema URL before it's loaded. This
+	// allows the schemas to be pinned to a specific revision, or loaded from a mirror.
+	SchemaURLRewrites map[string]string `yaml:"schemaUrlRewrites"`
 	// Information about where to locate status (Swagger) files
 	Status StatusConfiguration `yaml:"status"`
 	// The folder where the code should be generated
@@ -33,6 +40,10 @@ type Configuration struct {
 	TypeFilters []*TypeFilter `yaml:"typeFilters"`
 	// Transformers used to remap types
 	Transformers []*TypeTransformer `yaml:"typeTransformers"`
+	// SecretProperties select properties which are rendered as references to Kubernetes secrets
+	SecretProperties []*PropertyMatcher `yaml:"secretProperties"`
+	// ReferenceProperties select ARM ID properties which are rendered as references to other resources
+	ReferenceProperties []*PropertyMatcher `yaml:"referenceProperties"`
 
 	// after init TypeTransformers is split into property and non-property transformers
 	typeTransformers     []*TypeTransformer
@@ -66,6 +77,19 @@ func LoadConfiguration(configurationFile string) (*Configuration, error) {
 	return result, nil
 }
 
+// SpecSource selects where the spec of each resource is generated from
+type SpecSource string
+
+const (
+	// SpecSourceSchema generates specs from the ARM deployment template JSON schema given by SchemaURL, with the
+	// status of each resource coming from the Swagger specs
+	SpecSourceSchema = SpecSource("schema")
+	// SpecSourceSwagger generates specs from the body of the PUT request for each resource in the Swagger specs, with
+	// the status coming from the response to the GET request. No JSON schema is needed, so resources can be generated
+	// for API versions which are not yet in the deployment template.
+	SpecSourceSwagger = SpecSource("swagger")
+)
+
 // ShouldExportResult is returned by ShouldExport to indicate whether the supplied type should be exported
 type ShouldExportResult string
 
@@ -97,10 +121,23 @@ func (config *Configuration) WithExportFilters(filters ...*ExportFilter) *Config
 // initialize checks for common errors and initializes structures inside the configuration
 // which need additional setup after json deserialization
 func (config *Configuration) initialize(configPath string) error {
-	if config.SchemaURL == "" {
+	switch config.SpecSource {
+	case "":
+		config.SpecSource = SpecSourceSchema
+	case SpecSourceSchema, SpecSourceSwagger:
+		// valid
+	default:
+		return errors.Errorf("specSource %q is invalid, expected %q or %q", config.SpecSource, SpecSourceSchema, SpecSourceSwagger)
+	}
+
+	if config.SpecSource == SpecSourceSchema && config.SchemaURL == "" {
 		return errors.New("SchemaURL missing")
 	}
 
+	if config.SpecSource == SpecSourceSwagger && config.Status.SchemaRoot == "" {
+		return errors.Errorf("status.schemaRoot missing, it is required when specSource is %q", SpecSourceSwagger)
+	}
+
 	if config.OutputPath == "" {
 		// Default to an apis folder in the current directory if not specified
 		config.OutputPath = "apis"
@@ -138,16 +175,35 @@ func (config *Configuration) initialize(configPath string) error {
 	}
 
 	config.Transformers = nil
+
+	for _, secretProperty := range config.SecretProperties {
+		err := secretProperty.Initialize()
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "invalid secret property"))
+		}
+	}
+
+	for _, referenceProperty := range config.ReferenceProperties {
+		err := referenceProperty.Initialize()
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "invalid reference property"))
+		}
+	}
 	config.typeTransformers = typeTransformers
 	config.propertyTransformers = propertyTransformers
 
-	// make Status.SchemaRoot an absolute path
+	// make Status.SchemaRoot and the schema source absolute paths
 	absLocation, err := filepath.Abs(configPath)
 	if err != nil {
 		errs = append(errs, err)
 	} else {
 		parentDir := filepath.Dir(absLocation)
 		config.Status.SchemaRoot = filepath.Join(parentDir, config.Status.SchemaRoot)
+
+		err = config.SchemaSource.initialize(parentDir)
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	return kerrors.NewAggregate(errs)
@@ -223,6 +279,20 @@ func (config *Configuration) TransformTypeProperties(name astmodel.TypeName, obj
 	return results
 }
 
+// IsSecretProperty tests for whether the given property of the given type holds a sensitive value, which should be
+// held in a Kubernetes secret rather than the resource itself
+// Returns true if it does, along with a reason for logging
+func (config *Configuration) IsSecretProperty(name astmodel.TypeName, property astmodel.PropertyName) (bool, string) {
+	return findPropertyMatcher(config.SecretProperties, name, property)
+}
+
+// IsReferenceProperty tests for whether the given property of the given type holds the ARM ID of another resource,
+// which should be referred to by its Kubernetes group, kind and name instead
+// Returns true if it does, along with a reason for logging
+func (config *Configuration) IsReferenceProperty(name astmodel.TypeName, property astmodel.PropertyName) (bool, string) {
+	return findPropertyMatcher(config.ReferenceProperties, name, property)
+}
+
 // StatusConfiguration provides configuration options for the
 // status parts of resources, which are generated from the
 // Azure Swagger specs.
@@ -85,3 +85,28 @@ func Test_WithMultipleFilters_GivesPrecedenceToEarlierFilters(t *testing.T) {
 	g.Expect(config.ShouldExport(tutor2020)).To(Equal(Export))
 	g.Expect(config.ShouldExport(student2020)).To(Equal(Export))
 }
+
+func Test_Initialize_DefaultsSpecSourceToSchema(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := &Configuration{SchemaURL: "https://schema.example.com/root.json"}
+	g.Expect(config.initialize("azure-arm.yaml")).To(Succeed())
+	g.Expect(config.SpecSource).To(Equal(SpecSourceSchema))
+}
+
+func Test_Initialize_SwaggerSpecSource_RequiresOnlySchemaRoot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := &Configuration{SpecSource: SpecSourceSwagger}
+	g.Expect(config.initialize("azure-arm.yaml")).ToNot(Succeed())
+
+	config.Status.SchemaRoot = "specs"
+	g.Expect(config.initialize("azure-arm.yaml")).To(Succeed())
+}
+
+func Test_Initialize_InvalidSpecSource_ReturnsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := &Configuration{SpecSource: "openapi", SchemaURL: "https://schema.example.com/root.json"}
+	g.Expect(config.initialize("azure-arm.yaml")).To(MatchError(ContainSubstring("specSource")))
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package config
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+)
+
+// A PropertyMatcher selects properties of the types selected by its TypeMatcher, such as those whose values are
+// sensitive (see Configuration.SecretProperties) or which hold the ARM ID of another resource (see
+// Configuration.ReferenceProperties)
+type PropertyMatcher struct {
+	TypeMatcher `yaml:",inline"`
+
+	// Property is a wildcard matching the properties on the types selected by this matcher
+	Property      string `yaml:",omitempty"`
+	propertyRegex *regexp.Regexp
+}
+
+// Initialize initializes the property matcher
+func (pm *PropertyMatcher) Initialize() error {
+	if pm.Property == "" {
+		return errors.Errorf("property matcher for group: %s, version: %s, name: %s has no property", pm.Group, pm.Version, pm.Name)
+	}
+
+	err := pm.TypeMatcher.Initialize()
+	if err != nil {
+		return err
+	}
+
+	pm.propertyRegex = createGlobbingRegex(pm.Property)
+	return nil
+}
+
+// AppliesToProperty indicates whether the given property of the given type is selected by this matcher
+func (pm *PropertyMatcher) AppliesToProperty(typeName astmodel.TypeName, property astmodel.PropertyName) bool {
+	return pm.AppliesToType(typeName) &&
+		pm.matches(pm.Property, &pm.propertyRegex, string(property))
+}
+
+// findPropertyMatcher returns true if any of the matchers selects the given property of the given type, along with
+// the reason given by the first that does
+func findPropertyMatcher(matchers []*PropertyMatcher, typeName astmodel.TypeName, property astmodel.PropertyName) (bool, string) {
+	for _, matcher := range matchers {
+		if matcher.AppliesToProperty(typeName, property) {
+			return true, matcher.Because
+		}
+	}
+
+	return false, ""
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package config_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
+)
+
+func Test_PropertyMatcher_SelectsMatchingProperties(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	matcher := config.PropertyMatcher{
+		TypeMatcher: config.TypeMatcher{Group: "role"},
+		Property:    "*Password",
+	}
+	g.Expect(matcher.Initialize()).To(Succeed())
+
+	g.Expect(matcher.AppliesToProperty(student2019, "AdminPassword")).To(BeTrue())
+	g.Expect(matcher.AppliesToProperty(tutor2019, "password")).To(BeTrue())
+
+	// Other properties, and properties of other types, should not be selected
+	g.Expect(matcher.AppliesToProperty(student2019, "UserName")).To(BeFalse())
+	g.Expect(matcher.AppliesToProperty(person2020, "AdminPassword")).To(BeFalse())
+}
+
+func Test_PropertyMatcher_SelectsPropertiesOfNamedTypeOnly(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	matcher := config.PropertyMatcher{
+		TypeMatcher: config.TypeMatcher{Group: "role", Name: "student"},
+		Property:    "Id",
+	}
+	g.Expect(matcher.Initialize()).To(Succeed())
+
+	g.Expect(matcher.AppliesToProperty(student2019, "Id")).To(BeTrue())
+
+	g.Expect(matcher.AppliesToProperty(student2019, "Name")).To(BeFalse())
+	g.Expect(matcher.AppliesToProperty(tutor2019, "Id")).To(BeFalse())
+}
+
+func Test_PropertyMatcher_RequiresProperty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	matcher := config.PropertyMatcher{
+		TypeMatcher: config.TypeMatcher{Group: "role"},
+	}
+	g.Expect(matcher.Initialize()).ToNot(Succeed())
+}
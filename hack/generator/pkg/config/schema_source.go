@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaSourceConfiguration selects where the JSON schema documents are loaded from. At most one of the options may be
+// set; if none are, the documents are downloaded from their URLs.
+type SchemaSourceConfiguration struct {
+	// Directory is a local mirror of the schemas, laid out as <host>/<path> for each URL
+	Directory string `yaml:"directory,omitempty"`
+	// Tarball is a tar (optionally gzipped) archive of a local mirror, laid out the same way as Directory
+	Tarball string `yaml:"tarball,omitempty"`
+	// Cache is a content-addressed cache of the schemas, populated by the fetch-schemas command
+	Cache string `yaml:"cache,omitempty"`
+}
+
+// initialize checks that at most one source is selected and makes any paths relative to the given folder absolute
+func (ssc *SchemaSourceConfiguration) initialize(baseDir string) error {
+	count := 0
+	for _, path := range []*string{&ssc.Directory, &ssc.Tarball, &ssc.Cache} {
+		if *path == "" {
+			continue
+		}
+
+		count++
+		if !filepath.IsAbs(*path) {
+			*path = filepath.Join(baseDir, *path)
+		}
+	}
+
+	if count > 1 {
+		return errors.New("schemaSource may only specify one of directory, tarball or cache")
+	}
+
+	return nil
+}
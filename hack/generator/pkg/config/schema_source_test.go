@@ -0,0 +1,28 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSchemaSourceConfiguration_Initialize_MakesPathAbsolute(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ssc := SchemaSourceConfiguration{Cache: "schemas"}
+	g.Expect(ssc.initialize(filepath.FromSlash("/config"))).To(Succeed())
+	g.Expect(ssc.Cache).To(Equal(filepath.FromSlash("/config/schemas")))
+}
+
+func TestSchemaSourceConfiguration_Initialize_RejectsMultipleSources(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ssc := SchemaSourceConfiguration{Directory: "mirror", Tarball: "mirror.tar.gz"}
+	g.Expect(ssc.initialize(filepath.FromSlash("/config"))).ToNot(Succeed())
+}
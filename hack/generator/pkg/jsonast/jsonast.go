@@ -41,6 +41,7 @@ type (
 		TypeHandlers  map[SchemaType]TypeHandler
 		configuration *config.Configuration
 		idFactory     astmodel.IdentifierFactory
+		writableOnly  bool
 	}
 )
 
@@ -93,6 +94,13 @@ func (scanner *SchemaScanner) AddTypeHandler(schemaType SchemaType, handler Type
 	scanner.TypeHandlers[schemaType] = handler
 }
 
+// OmitReadOnlyProperties configures the scanner to leave out any properties which are only ever set by the server
+// (those marked readOnly, or with an x-ms-mutability that allows neither create nor update), as is required when
+// generating the types sent to ARM rather than those received from it
+func (scanner *SchemaScanner) OmitReadOnlyProperties() {
+	scanner.writableOnly = true
+}
+
 // RunHandler triggers the appropriate handler for the specified schemaType
 func (scanner *SchemaScanner) RunHandler(ctx context.Context, schemaType SchemaType, schema Schema) (astmodel.Type, error) {
 	if ctx.Err() != nil { // check for cancellation
@@ -482,6 +490,11 @@ func getProperties(
 	var properties []*astmodel.PropertyDefinition
 	for propName, propSchema := range schema.properties() {
 
+		if scanner.writableOnly && !isWritable(propSchema) {
+			klog.V(3).Infof("Property %s omitted as it is read-only", propName)
+			continue
+		}
+
 		property, err := generatePropertyDefinition(ctx, scanner, propName, propSchema)
 		if err != nil {
 			return nil, err
@@ -518,6 +531,9 @@ func getProperties(
 			property = property.MakeOptional()
 		}
 
+		// record the mutability and sensitivity given by the Swagger extensions
+		property = withPropertyFlags(property, propSchema)
+
 		properties = append(properties, property)
 	}
 
@@ -640,7 +656,12 @@ func generateDefinitionsFor(
 	}
 
 	if isResource(url) {
-		result = astmodel.NewAzureResourceType(result, nil, typeName)
+		resource := astmodel.NewAzureResourceType(result, nil, typeName)
+		if isExtensionResource(url) {
+			resource = resource.MarkAsExtension()
+		}
+
+		result = resource
 	}
 
 	description := []string{
@@ -860,6 +881,8 @@ func isResource(url *url.URL) bool {
 			// EventGrid does this, unsure why:
 			fragmentPart == "unknown_resourceDefinitions" ||
 
+			fragmentPart == extensionResourceDefinitions ||
+
 			// Treat all resourceBase things as resources so that "resourceness"
 			// is inherited:
 			strings.Contains(strings.ToLower(fragmentPart), "resourcebase") {
@@ -870,3 +893,19 @@ func isResource(url *url.URL) bool {
 
 	return false
 }
+
+// extensionResourceDefinitions is where the deployment template schemas keep extension resources, such as role
+// assignments and locks, which can be applied to a resource of any kind rather than being owned by one in particular
+const extensionResourceDefinitions = "extension_resourceDefinitions"
+
+func isExtensionResource(url *url.URL) bool {
+	fragmentParts := strings.FieldsFunc(url.Fragment, isURLPathSeparator)
+
+	for _, fragmentPart := range fragmentParts {
+		if fragmentPart == extensionResourceDefinitions {
+			return true
+		}
+	}
+
+	return false
+}
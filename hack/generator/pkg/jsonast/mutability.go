@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package jsonast
+
+import (
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+)
+
+// mutabilityExtension is the autorest extension listing when a property may be provided by the client;
+// see: https://github.com/Azure/autorest/tree/master/docs/extensions#x-ms-mutability
+const mutabilityExtension = "x-ms-mutability"
+
+// secretExtension is the autorest extension marking a property as sensitive, so that it's never returned by the server;
+// see: https://github.com/Azure/autorest/tree/master/docs/extensions#x-ms-secret
+const secretExtension = "x-ms-secret"
+
+// Values found in the list given by x-ms-mutability
+const (
+	mutabilityCreate = "create"
+	mutabilityRead   = "read"
+	mutabilityUpdate = "update"
+)
+
+// mutability returns the values given by the x-ms-mutability extension of the schema, and whether it was present
+func mutability(schema Schema) ([]string, bool) {
+	value, ok := schema.extensions()[mutabilityExtension]
+	if !ok {
+		return nil, false
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	var result []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+
+	return result, true
+}
+
+// isWritable returns true if a property with the given schema can be provided by the client when creating or
+// updating a resource, false if it is only ever set by the server
+func isWritable(schema Schema) bool {
+	if schema.readOnly() {
+		return false
+	}
+
+	values, ok := mutability(schema)
+	if !ok {
+		// all properties are writable unless marked otherwise
+		return true
+	}
+
+	for _, value := range values {
+		if value == mutabilityCreate || value == mutabilityUpdate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCreateOnly returns true if a property with the given schema can be provided by the client when creating a
+// resource, but not changed afterwards
+func isCreateOnly(schema Schema) bool {
+	values, ok := mutability(schema)
+	if !ok {
+		return false
+	}
+
+	canCreate := false
+	for _, value := range values {
+		if value == mutabilityUpdate {
+			return false
+		}
+
+		if value == mutabilityCreate {
+			canCreate = true
+		}
+	}
+
+	return canCreate
+}
+
+// isSecret returns true if a property with the given schema holds a sensitive value
+func isSecret(schema Schema) bool {
+	return schema.extensions()[secretExtension] == true
+}
+
+// withPropertyFlags marks the property as read-only, immutable or secret as given by its schema
+func withPropertyFlags(property *astmodel.PropertyDefinition, schema Schema) *astmodel.PropertyDefinition {
+	if !isWritable(schema) {
+		property = property.MakeReadOnly()
+	} else if isCreateOnly(schema) {
+		property = property.MakeImmutable()
+	}
+
+	if isSecret(schema) {
+		property = property.MakeSecret()
+	}
+
+	return property
+}
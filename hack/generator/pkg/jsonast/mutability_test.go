@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package jsonast
+
+import (
+	"testing"
+
+	"github.com/go-openapi/spec"
+	. "github.com/onsi/gomega"
+)
+
+func Test_IsWritable_GivenSchema_HasExpectedResult(t *testing.T) {
+	mutable := func(values ...interface{}) spec.Schema {
+		return spec.Schema{VendorExtensible: spec.VendorExtensible{Extensions: spec.Extensions{mutabilityExtension: values}}}
+	}
+
+	cases := []struct {
+		name     string
+		schema   spec.Schema
+		writable bool
+	}{
+		{"Unmarked", spec.Schema{}, true},
+		{"Read only", spec.Schema{SwaggerSchemaProps: spec.SwaggerSchemaProps{ReadOnly: true}}, false},
+		{"Create only", mutable(mutabilityCreate, mutabilityRead), true},
+		{"Update only", mutable(mutabilityUpdate), true},
+		{"Read mutability", mutable(mutabilityRead), false},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewGomegaWithT(t)
+
+			schema := MakeOpenAPISchema(c.schema, spec.Swagger{}, "", "", "", OpenAPISchemaCache{})
+			g.Expect(isWritable(schema)).To(Equal(c.writable))
+		})
+	}
+}
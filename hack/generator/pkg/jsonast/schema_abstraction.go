@@ -25,6 +25,9 @@ type Schema interface {
 	// for extensions like x-ms-...
 	extensions() map[string]interface{}
 
+	// true if the value is set by the server and cannot be provided by the client
+	readOnly() bool
+
 	hasType(schemaType SchemaType) bool
 
 	// number things
@@ -48,6 +48,10 @@ func (schema GoJSONSchema) extensions() map[string]interface{} {
 	return nil
 }
 
+func (schema GoJSONSchema) readOnly() bool {
+	return false
+}
+
 func (schema GoJSONSchema) hasType(schemaType SchemaType) bool {
 	return schema.inner.Types.Contains(string(schemaType))
 }
@@ -257,6 +257,10 @@ func (schema *OpenAPISchema) extensions() map[string]interface{} {
 	return schema.inner.Extensions
 }
 
+func (schema *OpenAPISchema) readOnly() bool {
+	return schema.inner.ReadOnly
+}
+
 func (schema *OpenAPISchema) isRef() bool {
 	return schema.inner.Ref.GetURL() != nil
 }
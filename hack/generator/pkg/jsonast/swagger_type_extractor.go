@@ -71,6 +71,14 @@ func (extractor *SwaggerTypeExtractor) ExtractTypes(
 			continue
 		}
 
+		if extractor.config.SpecSource == config.SpecSourceSwagger && op.Get != nil {
+			// the spec is generated from the body of the PUT, so take the status from the GET where possible,
+			// as that's what is used to read the status of the resource back from ARM
+			if getSchema := extractor.findARMResourceSchema(filePath, swagger, *op.Get); getSchema != nil {
+				resourceSchema = getSchema
+			}
+		}
+
 		for _, operationPath := range expandEnumsInPath(rawOperationPath, put.Parameters) {
 
 			resourceName, err := extractor.resourceNameFromOperationPath(packageName, operationPath)
@@ -117,6 +125,183 @@ func (extractor *SwaggerTypeExtractor) ExtractTypes(
 	return nil
 }
 
+// ExtractSpecTypes finds the same operations as ExtractTypes, but extracts the types of the body of each PUT request
+// rather than of its response, giving the spec of each resource rather than its status. Properties which are only ever
+// set by the server are omitted. The resources are placed into the 'resources' parameter, and any additional types
+// required by them into the 'otherTypes' parameter.
+func (extractor *SwaggerTypeExtractor) ExtractSpecTypes(
+	ctx context.Context,
+	filePath string,
+	swagger spec.Swagger,
+	resources astmodel.Types,
+	otherTypes astmodel.Types) error {
+
+	packageName := extractor.idFactory.CreatePackageNameFromVersion(extractor.outputVersion)
+
+	scanner := NewSchemaScanner(extractor.idFactory, extractor.config)
+	scanner.OmitReadOnlyProperties()
+
+	for rawOperationPath, op := range swagger.Paths.Paths {
+		put := op.Put
+		if put == nil {
+			continue
+		}
+
+		if extractor.findARMResourceSchema(filePath, swagger, *put) == nil {
+			continue
+		}
+
+		bodySchema := extractor.findBodySchema(filePath, swagger, *put)
+		if bodySchema == nil {
+			klog.Warningf("No request body found for PUT %s in %q", rawOperationPath, filePath)
+			continue
+		}
+
+		for _, operationPath := range expandEnumsInPath(rawOperationPath, put.Parameters) {
+
+			resourceName, err := extractor.resourceNameFromOperationPath(packageName, operationPath)
+			if err != nil {
+				klog.Errorf("Error extracting resource name (%s): %s", filePath, err.Error())
+				continue
+			}
+
+			shouldPrune, because := extractor.config.ShouldPrune(resourceName)
+			if shouldPrune == config.Prune {
+				klog.V(3).Infof("Skipping %s because %s", resourceName, because)
+				continue
+			}
+
+			bodyType, err := scanner.RunHandlerForSchema(ctx, *bodySchema)
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return err
+				}
+
+				return errors.Wrapf(err, "unable to produce spec type for resource %v", resourceName)
+			}
+
+			if bodyType == nil {
+				// this indicates a filtered-out type
+				continue
+			}
+
+			resourceType, err := extractor.makeResourceType(operationPath, bodyType, resourceName)
+			if err != nil {
+				return err
+			}
+
+			if existingResource, ok := resources[resourceName]; ok {
+				if !astmodel.TypeEquals(existingResource.Type(), resourceType) {
+					return errors.Errorf("resource already defined differently: %v", resourceName)
+				}
+			} else {
+				resources.Add(astmodel.MakeTypeDefinition(resourceName, resourceType))
+			}
+		}
+	}
+
+	for _, def := range scanner.Definitions() {
+		// now add in the additional type definitions required by the resources
+		if existingDef, ok := otherTypes[def.Name()]; ok {
+			if !astmodel.TypeEquals(existingDef.Type(), def.Type()) {
+				klog.Errorf("type already defined differently: %v", def.Name())
+			}
+		} else {
+			otherTypes.Add(def)
+		}
+	}
+
+	return nil
+}
+
+// makeResourceType creates the resource for the PUT at the given path, with a spec combining the body of the request
+// with the properties an ARM template requires of every resource
+func (extractor *SwaggerTypeExtractor) makeResourceType(
+	operationPath string,
+	bodyType astmodel.Type,
+	resourceName astmodel.TypeName) (*astmodel.ResourceType, error) {
+
+	armType, ownerName, err := armTypeFromURLPath(operationPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to infer ARM type of %v from path %q", resourceName, operationPath)
+	}
+
+	// name and type are read-only in the body, so they've been omitted from it
+	nameProperty := astmodel.NewPropertyDefinition(astmodel.NameProperty, "name", astmodel.StringType).
+		WithDescription("Name of the resource").
+		MakeRequired()
+	typeProperty := astmodel.NewPropertyDefinition(astmodel.TypeProperty, "type", extractor.singleValueEnum(armType)).
+		MakeRequired()
+	apiVersionProperty := astmodel.NewPropertyDefinition(astmodel.ApiVersionProperty, "apiVersion", extractor.singleValueEnum(extractor.outputVersion)).
+		MakeRequired()
+
+	envelope := astmodel.NewObjectType().WithProperties(nameProperty, typeProperty, apiVersionProperty)
+	resource := astmodel.NewAzureResourceType(astmodel.MakeAllOfType(bodyType, envelope), nil, resourceName)
+
+	if ownerName != "" {
+		owner := astmodel.MakeTypeName(resourceName.PackageReference, ownerName)
+		resource = resource.WithOwner(&owner)
+	}
+
+	if isExtensionURLPath(operationPath) {
+		resource = resource.MarkAsExtension()
+	}
+
+	return resource, nil
+}
+
+// singleValueEnum creates an enum allowing only the given string
+func (extractor *SwaggerTypeExtractor) singleValueEnum(value string) *astmodel.EnumType {
+	return astmodel.NewEnumType(
+		astmodel.StringType,
+		[]astmodel.EnumValue{
+			{
+				Identifier: extractor.idFactory.CreateIdentifier(value, astmodel.Exported),
+				Value:      fmt.Sprintf("%q", value),
+			},
+		})
+}
+
+// findBodySchema returns the schema of the body parameter of the operation, if it has one
+func (extractor *SwaggerTypeExtractor) findBodySchema(
+	filePath string,
+	swagger spec.Swagger,
+	op spec.Operation) *Schema {
+
+	for _, parameter := range op.Parameters {
+		if parameter.Ref.GetURL() != nil {
+			// parameters shared across operations live under "#/parameters/"
+			tokens := parameter.Ref.GetPointer().DecodedTokens()
+			if !parameter.Ref.HasFragmentOnly || len(tokens) != 2 || tokens[0] != "parameters" {
+				continue
+			}
+
+			shared, ok := swagger.Parameters[tokens[1]]
+			if !ok {
+				continue
+			}
+
+			parameter = shared
+		}
+
+		if parameter.In != "body" || parameter.Schema == nil {
+			continue
+		}
+
+		schema := MakeOpenAPISchema(
+			*parameter.Schema,
+			swagger,
+			filePath,
+			extractor.outputGroup,
+			extractor.outputVersion,
+			extractor.cache)
+
+		return &schema
+	}
+
+	return nil
+}
+
 // Look at the responses of the PUT to determine if this represents an ARM resource,
 // and if so, return the schema for it.
 // see: https://github.com/Azure/autorest/issues/1936#issuecomment-286928591
@@ -345,6 +530,59 @@ func inferNameFromURLPath(operationPath string) (string, string, error) {
 	return group, name, nil
 }
 
+// armTypeFromURLPath returns the ARM type of the resource at the given Swagger operation path, along with the name
+// of the resource which owns it (as inferred by inferNameFromURLPath), if any. For example
+// “…/Microsoft.GroupName/resourceType/{resourceId}/childType/{childId}” has the ARM type
+// “Microsoft.GroupName/resourceType/childType” and is owned by “ResourceType”.
+func armTypeFromURLPath(operationPath string) (string, string, error) {
+	group := ""
+	var typeParts []string
+
+	for _, urlPart := range strings.Split(operationPath, "/") {
+		if len(urlPart) == 0 {
+			// skip empty parts
+			continue
+		}
+
+		if group != "" {
+			// parameters and fixed names (such as “default”) aren't part of the type
+			if urlPart[0] != '{' && urlPart != "default" {
+				typeParts = append(typeParts, urlPart)
+			}
+		} else if SwaggerGroupRegex.MatchString(urlPart) {
+			group = urlPart
+		}
+	}
+
+	if group == "" {
+		return "", "", errors.Errorf("no group name (‘Microsoft…’) found")
+	}
+
+	if len(typeParts) == 0 {
+		return "", "", errors.Errorf("couldn’t infer type")
+	}
+
+	owner := ""
+	for _, typePart := range typeParts[:len(typeParts)-1] {
+		owner += strings.ToUpper(typePart[0:1]) + typePart[1:]
+	}
+
+	return group + "/" + strings.Join(typeParts, "/"), owner, nil
+}
+
+// isExtensionURLPath returns true if the Swagger operation path is for an extension resource, which can be applied
+// to a resource of any kind, such as “/{scope}/providers/Microsoft.GroupName/resourceType/{resourceId}”
+func isExtensionURLPath(operationPath string) bool {
+	var urlParts []string
+	for _, urlPart := range strings.Split(operationPath, "/") {
+		if len(urlPart) > 0 {
+			urlParts = append(urlParts, urlPart)
+		}
+	}
+
+	return len(urlParts) > 1 && urlParts[0][0] == '{' && strings.EqualFold(urlParts[1], "providers")
+}
+
 // SwaggerGroupRegex matches a “group” (Swagger ‘namespace’)
 // based on: https://github.com/Azure/autorest/blob/85de19623bdce3ccc5000bae5afbf22a49bc4665/core/lib/pipeline/metadata-generation.ts#L25
 var SwaggerGroupRegex = regexp.MustCompile(`[Mm]icrosoft\.[^/\\]+`)
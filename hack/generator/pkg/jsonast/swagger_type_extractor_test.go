@@ -6,11 +6,15 @@
 package jsonast
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/go-openapi/spec"
 	. "github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/astmodel"
+	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
 )
 
 func Example_inferNameFromURLPath() {
@@ -102,3 +106,113 @@ func Test_expandEnumsInPath_ExpandsMultipleEnums(t *testing.T) {
 		"/some/no/orange",
 	))
 }
+
+func Test_ArmTypeFromURLPath_ChildResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	armType, owner, err := armTypeFromURLPath("/Microsoft.GroupName/resourceName/{resourceId}/someChild/{childId}")
+	g.Expect(err).To(BeNil())
+	g.Expect(armType).To(Equal("Microsoft.GroupName/resourceName/someChild"))
+	g.Expect(owner).To(Equal("ResourceName"))
+}
+
+func Test_ArmTypeFromURLPath_SkipsDefault(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	armType, owner, err := armTypeFromURLPath("Microsoft.Storage/storageAccounts/{accountName}/blobServices/default/containers/{containerName}")
+	g.Expect(err).To(BeNil())
+	g.Expect(armType).To(Equal("Microsoft.Storage/storageAccounts/blobServices/containers"))
+	g.Expect(owner).To(Equal("StorageAccountsBlobServices"))
+}
+
+func Test_IsExtensionURLPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(isExtensionURLPath("/{scope}/providers/Microsoft.Authorization/locks/{lockName}")).To(BeTrue())
+	g.Expect(isExtensionURLPath("/subscriptions/{subscriptionId}/providers/Microsoft.Authorization/locks/{lockName}")).To(BeFalse())
+}
+
+const widgetSwagger = `{
+	"swagger": "2.0",
+	"info": { "title": "Widgets", "version": "2020-01-01" },
+	"paths": {
+		"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}": {
+			"put": {
+				"parameters": [
+					{ "name": "widgetName", "in": "path", "required": true, "type": "string" },
+					{ "$ref": "#/parameters/WidgetParameter" }
+				],
+				"responses": { "200": { "description": "OK", "schema": { "$ref": "#/definitions/Widget" } } }
+			}
+		},
+		"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Test/widgets/{widgetName}/parts/{partName}": {
+			"put": {
+				"parameters": [
+					{ "name": "parameters", "in": "body", "required": true, "schema": { "$ref": "#/definitions/Part" } }
+				],
+				"responses": { "200": { "description": "OK", "schema": { "$ref": "#/definitions/Part" } } }
+			}
+		},
+		"/{scope}/providers/Microsoft.Test/labels/{labelName}": {
+			"put": {
+				"parameters": [
+					{ "name": "parameters", "in": "body", "required": true, "schema": { "$ref": "#/definitions/Part" } }
+				],
+				"responses": { "201": { "description": "Created", "schema": { "$ref": "#/definitions/Part" } } }
+			}
+		}
+	},
+	"parameters": {
+		"WidgetParameter": { "name": "parameters", "in": "body", "required": true, "schema": { "$ref": "#/definitions/Widget" } }
+	},
+	"definitions": {
+		"Widget": {
+			"x-ms-azure-resource": true,
+			"properties": {
+				"id": { "type": "string", "readOnly": true },
+				"name": { "type": "string", "readOnly": true },
+				"type": { "type": "string", "readOnly": true },
+				"location": { "type": "string", "x-ms-mutability": ["create", "read"] },
+				"provisioningState": { "type": "string", "x-ms-mutability": ["read"] }
+			}
+		},
+		"Part": {
+			"x-ms-azure-resource": true,
+			"properties": {
+				"id": { "type": "string", "readOnly": true },
+				"colour": { "type": "string" }
+			}
+		}
+	}
+}`
+
+func Test_ExtractSpecTypes_GeneratesResourcesFromPutBodies(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var swagger spec.Swagger
+	g.Expect(swagger.UnmarshalJSON([]byte(widgetSwagger))).To(Succeed())
+
+	const filePath = "/specs/Microsoft.Test/stable/2020-01-01/widgets.json"
+	cache := NewOpenAPISchemaCache(map[string]spec.Swagger{filePath: swagger})
+	extractor := NewSwaggerTypeExtractor(config.NewConfiguration(), astmodel.NewIdentifierFactory(), "Microsoft.Test", "2020-01-01", cache)
+
+	resources := make(astmodel.Types)
+	otherTypes := make(astmodel.Types)
+	err := extractor.ExtractSpecTypes(context.Background(), filePath, swagger, resources, otherTypes)
+	g.Expect(err).To(BeNil())
+
+	packageRef := astmodel.MakeLocalPackageReference("microsoft.test", "v20200101")
+	widgets := resources[astmodel.MakeTypeName(packageRef, "Widgets")].Type().(*astmodel.ResourceType)
+	parts := resources[astmodel.MakeTypeName(packageRef, "WidgetsParts")].Type().(*astmodel.ResourceType)
+	labels := resources[astmodel.MakeTypeName(packageRef, "Labels")].Type().(*astmodel.ResourceType)
+
+	g.Expect(widgets.Owner()).To(BeNil())
+	g.Expect(*parts.Owner()).To(Equal(astmodel.MakeTypeName(packageRef, "Widgets")))
+	g.Expect(labels.IsExtension()).To(BeTrue())
+
+	// Only the properties which can be provided by the client are included in the spec
+	widget := otherTypes[astmodel.MakeTypeName(packageRef, "Widget")].Type().(*astmodel.ObjectType)
+	g.Expect(widget.Properties()).To(HaveLen(1))
+	_, ok := widget.Property("Location")
+	g.Expect(ok).To(BeTrue())
+}
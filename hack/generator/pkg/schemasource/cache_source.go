@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package schemasource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	cacheIndexFile = "index.json"
+	cacheBlobDir   = "sha256"
+)
+
+// CacheSource reads documents from a content-addressed cache. The cache holds each document in a file named after the
+// SHA-256 hash of its content, along with an index mapping the URL of each document to its hash. The cache is
+// populated by the fetch-schemas command, using the source returned by Populate.
+type CacheSource struct {
+	dir   string
+	lock  sync.Mutex
+	index map[string]string // URL to hash, loaded on first use
+}
+
+var _ Source = &CacheSource{}
+
+// NewCacheSource creates a new source that reads documents from the cache in the given directory
+func NewCacheSource(dir string) *CacheSource {
+	return &CacheSource{dir: dir}
+}
+
+// Open returns the document with the given URL from the cache, checking that its content hasn't changed
+func (cache *CacheSource) Open(ctx context.Context, documentURL string) (io.ReadCloser, error) {
+	if ctx.Err() != nil { // check for cancellation
+		return nil, ctx.Err()
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	err := cache.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	hash, ok := cache.index[documentURL]
+	if !ok {
+		return nil, errors.Errorf("schema %q not found in %s, run fetch-schemas to add it", documentURL, cache)
+	}
+
+	content, err := ioutil.ReadFile(cache.blobPath(hash))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read schema %q from %s", documentURL, cache)
+	}
+
+	if contentHash(content) != hash {
+		return nil, errors.Errorf("schema %q in %s has been modified, run fetch-schemas to repair it", documentURL, cache)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+// Store adds the document with the given URL to the cache, replacing any earlier version
+func (cache *CacheSource) Store(documentURL string, content []byte) error {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	err := cache.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	hash := contentHash(content)
+	blobPath := cache.blobPath(hash)
+	err = os.MkdirAll(filepath.Dir(blobPath), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create %s", cache)
+	}
+
+	err = ioutil.WriteFile(blobPath, content, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "unable to store schema %q in %s", documentURL, cache)
+	}
+
+	cache.index[documentURL] = hash
+
+	index, err := json.MarshalIndent(cache.index, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "unable to serialize index of %s", cache)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(cache.dir, cacheIndexFile), index, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "unable to update index of %s", cache)
+	}
+
+	return nil
+}
+
+// Populate returns a source which opens documents using the given source, storing each of them in the cache
+func (cache *CacheSource) Populate(from Source) Source {
+	return &populatingSource{cache: cache, from: from}
+}
+
+func (cache *CacheSource) loadIndex() error {
+	if cache.index != nil {
+		return nil
+	}
+
+	cache.index = make(map[string]string)
+
+	content, err := ioutil.ReadFile(filepath.Join(cache.dir, cacheIndexFile))
+	if os.IsNotExist(err) {
+		// Nothing has been cached yet
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to read index of %s", cache)
+	}
+
+	err = json.Unmarshal(content, &cache.index)
+	if err != nil {
+		return errors.Wrapf(err, "index of %s is invalid", cache)
+	}
+
+	return nil
+}
+
+func (cache *CacheSource) blobPath(hash string) string {
+	return filepath.Join(cache.dir, cacheBlobDir, hash+".json")
+}
+
+func (cache *CacheSource) String() string {
+	return "cache " + cache.dir
+}
+
+func contentHash(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// populatingSource stores every document it opens in a cache
+type populatingSource struct {
+	cache *CacheSource
+	from  Source
+}
+
+var _ Source = &populatingSource{}
+
+// Open opens the document using the wrapped source and adds it to the cache
+func (source *populatingSource) Open(ctx context.Context, documentURL string) (io.ReadCloser, error) {
+	reader, err := source.from.Open(ctx, documentURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read schema %q", documentURL)
+	}
+
+	err = source.cache.Store(documentURL, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (source *populatingSource) String() string {
+	return source.from.String()
+}
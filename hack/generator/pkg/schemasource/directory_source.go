@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package schemasource
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// DirectorySource reads documents from a local mirror, where the document at https://host/some/path.json is found at
+// <root>/host/some/path.json
+type DirectorySource struct {
+	root string
+}
+
+var _ Source = &DirectorySource{}
+
+// NewDirectorySource creates a new source that reads documents from the mirror in the given directory
+func NewDirectorySource(root string) *DirectorySource {
+	return &DirectorySource{root: root}
+}
+
+// Open reads the document with the given URL from the mirror
+func (source *DirectorySource) Open(ctx context.Context, documentURL string) (io.ReadCloser, error) {
+	if ctx.Err() != nil { // check for cancellation
+		return nil, ctx.Err()
+	}
+
+	relative, err := mirrorPath(documentURL)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(source.root, filepath.FromSlash(relative)))
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("schema %q not found in %s", documentURL, source)
+	}
+
+	return file, err
+}
+
+func (source *DirectorySource) String() string {
+	return "directory " + source.root
+}
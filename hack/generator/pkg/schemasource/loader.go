@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package schemasource
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonreference"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Load compiles the JSON schema with the given URL, loading it and every document it references from the source.
+// The documents keep their original URLs, so references between them are resolved exactly as if they had been
+// downloaded.
+func Load(ctx context.Context, source Source, schemaURL string) (*gojsonschema.Schema, error) {
+	sl := gojsonschema.NewSchemaLoader()
+	schema, err := sl.Compile(newJSONLoader(ctx, source, schemaURL))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading schema from %q", schemaURL)
+	}
+
+	return schema, nil
+}
+
+// jsonLoader is a gojsonschema.JSONLoader which loads documents from a Source
+type jsonLoader struct {
+	ctx       context.Context
+	source    Source
+	reference string
+}
+
+var _ gojsonschema.JSONLoader = &jsonLoader{}
+
+func newJSONLoader(ctx context.Context, source Source, reference string) *jsonLoader {
+	return &jsonLoader{
+		ctx:       ctx,
+		source:    source,
+		reference: reference,
+	}
+}
+
+func (loader *jsonLoader) LoadJSON() (interface{}, error) {
+	if loader.ctx.Err() != nil { // check for cancellation
+		return nil, loader.ctx.Err()
+	}
+
+	reference, err := gojsonreference.NewJsonReference(loader.reference)
+	if err != nil {
+		return nil, err
+	}
+
+	// The fragment selects part of the document, which gojsonschema takes care of
+	reference.GetUrl().Fragment = ""
+	documentURL := reference.String()
+
+	reader, err := loader.source.Open(loader.ctx, documentURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	var document interface{}
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	err = decoder.Decode(&document)
+	if err != nil {
+		return nil, errors.Wrapf(err, "schema %q is not valid JSON", documentURL)
+	}
+
+	return document, nil
+}
+
+func (loader *jsonLoader) JsonSource() interface{} {
+	return loader.reference
+}
+
+func (loader *jsonLoader) JsonReference() (gojsonreference.JsonReference, error) {
+	if loader.ctx.Err() != nil { // check for cancellation
+		return gojsonreference.JsonReference{}, loader.ctx.Err()
+	}
+
+	return gojsonreference.NewJsonReference(loader.reference)
+}
+
+func (loader *jsonLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return &jsonLoaderFactory{ctx: loader.ctx, source: loader.source}
+}
+
+// jsonLoaderFactory creates jsonLoaders for the documents referenced by a schema
+type jsonLoaderFactory struct {
+	ctx    context.Context
+	source Source
+}
+
+var _ gojsonschema.JSONLoaderFactory = &jsonLoaderFactory{}
+
+func (factory *jsonLoaderFactory) New(source string) gojsonschema.JSONLoader {
+	return newJSONLoader(factory.ctx, factory.source, source)
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package schemasource
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Azure/k8s-infra/hack/generator/pkg/config"
+)
+
+// Source provides the content of JSON schema documents, identified by their URL
+type Source interface {
+	// Open returns the content of the document at the given URL, which has no fragment
+	Open(ctx context.Context, documentURL string) (io.ReadCloser, error)
+	// String describes the source for use in error messages
+	String() string
+}
+
+// NewSourceFromConfig creates the Source selected by the given configuration, applying any URL rewrites it specifies
+func NewSourceFromConfig(configuration *config.Configuration) (Source, error) {
+	var source Source
+	var err error
+
+	ssc := configuration.SchemaSource
+	switch {
+	case ssc.Directory != "":
+		source = NewDirectorySource(ssc.Directory)
+	case ssc.Tarball != "":
+		source, err = NewTarballSource(ssc.Tarball)
+	case ssc.Cache != "":
+		source = NewCacheSource(ssc.Cache)
+	default:
+		source = NewNetworkSource()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return WithRewrites(source, configuration.SchemaURLRewrites), nil
+}
+
+// NetworkSource downloads documents from their URLs; file URLs are read from disk
+type NetworkSource struct {
+	client *http.Client
+}
+
+var _ Source = &NetworkSource{}
+
+// NewNetworkSource creates a new source that downloads documents
+func NewNetworkSource() *NetworkSource {
+	return &NetworkSource{client: http.DefaultClient}
+}
+
+// Open downloads the document at the given URL
+func (source *NetworkSource) Open(ctx context.Context, documentURL string) (io.ReadCloser, error) {
+	if strings.HasPrefix(documentURL, "file://") {
+		filename, err := url.PathUnescape(strings.TrimPrefix(documentURL, "file://"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid file URL %q", documentURL)
+		}
+
+		return os.Open(filename)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, documentURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := source.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download %q", documentURL)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("unable to download %q: %s", documentURL, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+func (source *NetworkSource) String() string {
+	return "network"
+}
+
+// rewritingSource rewrites URLs before passing them to the source it wraps
+type rewritingSource struct {
+	inner Source
+	// URL prefixes to rewrite, longest first so that the most specific rewrite is used
+	prefixes []string
+	rewrites map[string]string
+}
+
+var _ Source = &rewritingSource{}
+
+// WithRewrites returns a source which replaces the longest matching prefix of each URL with its rewrite before
+// passing it to the given source
+func WithRewrites(source Source, rewrites map[string]string) Source {
+	if len(rewrites) == 0 {
+		return source
+	}
+
+	var prefixes []string
+	for prefix := range rewrites {
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+
+		return prefixes[i] < prefixes[j]
+	})
+
+	return &rewritingSource{
+		inner:    source,
+		prefixes: prefixes,
+		rewrites: rewrites,
+	}
+}
+
+// Open opens the rewritten URL using the wrapped source
+func (source *rewritingSource) Open(ctx context.Context, documentURL string) (io.ReadCloser, error) {
+	return source.inner.Open(ctx, source.rewrite(documentURL))
+}
+
+func (source *rewritingSource) rewrite(documentURL string) string {
+	for _, prefix := range source.prefixes {
+		if strings.HasPrefix(documentURL, prefix) {
+			return source.rewrites[prefix] + strings.TrimPrefix(documentURL, prefix)
+		}
+	}
+
+	return documentURL
+}
+
+func (source *rewritingSource) String() string {
+	return source.inner.String()
+}
+
+// mirrorPath returns the relative path of the document with the given URL within a local mirror
+func mirrorPath(documentURL string) (string, error) {
+	u, err := url.Parse(documentURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid schema URL %q", documentURL)
+	}
+
+	if u.Host == "" {
+		return "", errors.Errorf("schema URL %q has no host, so can't be found in a mirror", documentURL)
+	}
+
+	// Clean away any attempt to escape the mirror
+	return path.Join(u.Host, path.Clean("/"+u.Path)), nil
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package schemasource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const rootURL = "https://schema.example.com/schemas/2020-01-01/root.json"
+
+// A root schema referring to another document both absolutely and relatively
+var mirror = map[string]string{
+	"schema.example.com/schemas/2020-01-01/root.json": `{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"properties": {
+			"absolute": { "$ref": "https://schema.example.com/schemas/2020-01-01/common.json#/definitions/name" },
+			"relative": { "$ref": "common.json#/definitions/name" }
+		}
+	}`,
+	"schema.example.com/schemas/2020-01-01/common.json": `{
+		"definitions": {
+			"name": { "type": "string" }
+		}
+	}`,
+}
+
+func writeMirror(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "schemas")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range mirror {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func writeTarball(t *testing.T) string {
+	file, err := ioutil.TempFile("", "schemas*.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Remove(file.Name()) })
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+	for name, content := range mirror {
+		header := &tar.Header{Name: "./" + name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Name()
+}
+
+func Test_Load_FromDirectory_ResolvesReferences(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	source := NewDirectorySource(writeMirror(t))
+	schema, err := Load(context.Background(), source, rootURL)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(schema.Root().PropertiesChildren).To(HaveLen(2))
+}
+
+func Test_Load_FromTarball_ResolvesReferences(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	source, err := NewTarballSource(writeTarball(t))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = Load(context.Background(), source, rootURL)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func Test_Load_MissingDocument_ReturnsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	source := NewDirectorySource(writeMirror(t))
+	_, err := Load(context.Background(), source, "https://schema.example.com/schemas/2020-01-01/missing.json")
+	g.Expect(err).To(MatchError(ContainSubstring("not found in directory")))
+}
+
+func Test_WithRewrites_UsesLongestMatchingPrefix(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	source := WithRewrites(NewNetworkSource(), map[string]string{
+		"https://schema.example.com/":                    "https://mirror.example.com/",
+		"https://schema.example.com/schemas/2020-01-01/": "https://pinned.example.com/abc123/",
+	}).(*rewritingSource)
+
+	g.Expect(source.rewrite(rootURL)).To(Equal("https://pinned.example.com/abc123/root.json"))
+	g.Expect(source.rewrite("https://schema.example.com/other.json")).To(Equal("https://mirror.example.com/other.json"))
+	g.Expect(source.rewrite("https://elsewhere.example.com/other.json")).To(Equal("https://elsewhere.example.com/other.json"))
+}
+
+func Test_CacheSource_PopulatedCache_LoadsOffline(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx := context.Background()
+
+	rewrites := map[string]string{"https://schema.example.com/schemas/": "https://pinned.example.com/schemas/"}
+	mirrorDir := writeMirror(t)
+	g.Expect(os.Rename(
+		filepath.Join(mirrorDir, "schema.example.com"),
+		filepath.Join(mirrorDir, "pinned.example.com"))).To(Succeed())
+
+	cacheDir, err := ioutil.TempDir("", "cache")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(cacheDir)
+
+	// Populate the cache from the mirror, standing in for the network
+	cache := NewCacheSource(cacheDir)
+	_, err = Load(ctx, WithRewrites(cache.Populate(NewDirectorySource(mirrorDir)), rewrites), rootURL)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// A fresh cache must find everything without the mirror
+	g.Expect(os.RemoveAll(mirrorDir)).To(Succeed())
+	_, err = Load(ctx, WithRewrites(NewCacheSource(cacheDir), rewrites), rootURL)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Without the rewrites, the documents aren't in the cache
+	_, err = Load(ctx, NewCacheSource(cacheDir), rootURL)
+	g.Expect(err).To(MatchError(ContainSubstring("run fetch-schemas")))
+}
+
+func Test_CacheSource_ModifiedContent_ReturnsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cacheDir, err := ioutil.TempDir("", "cache")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(cacheDir)
+
+	cache := NewCacheSource(cacheDir)
+	g.Expect(cache.Store(rootURL, []byte(`{}`))).To(Succeed())
+
+	g.Expect(ioutil.WriteFile(cache.blobPath(contentHash([]byte(`{}`))), []byte(`{"type": "string"}`), 0600)).To(Succeed())
+
+	_, err = NewCacheSource(cacheDir).Open(context.Background(), rootURL)
+	g.Expect(err).To(MatchError(ContainSubstring("has been modified")))
+}
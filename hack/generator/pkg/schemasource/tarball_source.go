@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ */
+
+package schemasource
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TarballSource reads documents from a tar archive (optionally gzipped) of a local mirror, laid out in the same way as
+// for a DirectorySource. The whole archive is read into memory when the source is created.
+type TarballSource struct {
+	filename  string
+	documents map[string][]byte
+}
+
+var _ Source = &TarballSource{}
+
+// NewTarballSource creates a new source that reads documents from the given archive
+func NewTarballSource(filename string) (*TarballSource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open schema tarball")
+	}
+
+	defer file.Close()
+
+	documents, err := readTarball(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read schema tarball %q", filename)
+	}
+
+	return &TarballSource{
+		filename:  filename,
+		documents: documents,
+	}, nil
+}
+
+// readTarball returns the content of every file in the archive, keyed by its path
+func readTarball(r io.Reader) (map[string][]byte, error) {
+	reader := bufio.NewReader(r)
+
+	// gzip streams start with the magic number 0x1f 0x8b
+	magic, err := reader.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		defer gz.Close()
+		r = gz
+	} else {
+		r = reader
+	}
+
+	result := make(map[string][]byte)
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %q", header.Name)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		result[name] = content
+	}
+}
+
+// Open returns the document with the given URL from the archive
+func (source *TarballSource) Open(ctx context.Context, documentURL string) (io.ReadCloser, error) {
+	if ctx.Err() != nil { // check for cancellation
+		return nil, ctx.Err()
+	}
+
+	relative, err := mirrorPath(documentURL)
+	if err != nil {
+		return nil, err
+	}
+
+	content, ok := source.documents[relative]
+	if !ok {
+		return nil, errors.Errorf("schema %q not found in %s", documentURL, source)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (source *TarballSource) String() string {
+	return "tarball " + source.filename
+}
@@ -54,9 +54,15 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var driftDetection controllers.DriftDetectionPolicy
+	var driftRemediation string
 	flagSet.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flagSet.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flagSet.DurationVar(&driftDetection.Interval, "drift-detection-interval", 0,
+		"How often to check that resources in Azure haven't been changed outside of Kubernetes. Zero disables drift detection.")
+	flagSet.StringVar(&driftRemediation, "drift-remediation", string(controllers.DriftRemediationReport),
+		"What to do when a resource has drifted: Report records it on the resource, Reapply also applies the spec again.")
 	flagSet.Parse(os.Args[1:]) //nolint: error will never be returned due to ExitOnError
 
 	ctrl.SetLogger(klogr.New())
@@ -79,7 +85,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	if errs := controllers.RegisterAll(mgr, applier, controllers.KnownTypes, ctrl.Log.WithName("controllers"), concurrency(1)); errs != nil {
+	driftDetection.Remediation = controllers.DriftRemediation(driftRemediation)
+	if err := driftDetection.Validate(); err != nil {
+		setupLog.Error(err, "invalid drift detection settings")
+		os.Exit(1)
+	}
+
+	driftOptions := controllers.DriftDetectionOptions{Default: driftDetection}
+	if errs := controllers.RegisterAll(mgr, applier, controllers.KnownTypes, ctrl.Log.WithName("controllers"), concurrency(1), driftOptions); errs != nil {
 		for _, err := range errs {
 			setupLog.Error(err, "failed to register gvk: %v")
 		}
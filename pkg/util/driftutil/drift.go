@@ -0,0 +1,89 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package driftutil
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Compare compares the desired state of a resource with the live resource returned by Azure, both as JSON unmarshalled
+// into an interface{}, and returns the paths of the fields which differ. Fields which Azure doesn't return (such as
+// secrets) can't be compared so are ignored, as are fields which are only present on the live resource (such as
+// read-only properties and server side defaults). Strings are compared case insensitively as ARM frequently
+// normalizes the case of the values it is given.
+func Compare(desired interface{}, live interface{}) []string {
+	var drift []string
+	compare("", desired, live, &drift)
+	return drift
+}
+
+func compare(path string, desired interface{}, live interface{}, drift *[]string) {
+	switch d := desired.(type) {
+	case nil:
+		// not specified, so anything goes
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			*drift = append(*drift, path)
+			return
+		}
+
+		keys := make([]string, 0, len(d))
+		for key := range d {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			liveValue, ok := l[key]
+			if !ok {
+				continue
+			}
+			compare(joinPath(path, key), d[key], liveValue, drift)
+		}
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			*drift = append(*drift, path)
+			return
+		}
+
+		for i := range d {
+			compare(fmt.Sprintf("%s[%d]", path, i), d[i], l[i], drift)
+		}
+	case string:
+		if d == "" {
+			// not specified
+			return
+		}
+
+		l, ok := live.(string)
+		if path == "location" {
+			// Azure returns the canonical form of locations, so "West US" comes back as "westus"
+			d = strings.ReplaceAll(d, " ", "")
+			l = strings.ReplaceAll(l, " ", "")
+		}
+
+		if !ok || !strings.EqualFold(d, l) {
+			*drift = append(*drift, path)
+		}
+	default:
+		if !reflect.DeepEqual(d, live) {
+			*drift = append(*drift, path)
+		}
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
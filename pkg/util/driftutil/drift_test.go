@@ -0,0 +1,51 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package driftutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func unmarshal(g *gomega.WithT, body string) interface{} {
+	var result interface{}
+	g.Expect(json.Unmarshal([]byte(body), &result)).To(gomega.Succeed())
+	return result
+}
+
+func TestCompare_IgnoresUnspecifiedNormalizedAndReadOnlyFields(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	desired := unmarshal(g, `{"location": "West US", "sku": "", "properties": {"accessTier": "Hot", "secret": "s3cret"}}`)
+	live := unmarshal(g, `{"location": "westus", "sku": "Standard", "properties": {"accessTier": "hot", "provisioningState": "Succeeded"}}`)
+
+	g.Expect(Compare(desired, live)).To(gomega.BeEmpty())
+}
+
+func TestCompare_ReportsChangedFields(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	desired := unmarshal(g, `{"tags": {"team": "a"}, "properties": {"httpsOnly": true, "ipRules": ["10.0.0.1"], "network": {"subnet": "a"}}}`)
+	live := unmarshal(g, `{"tags": {"team": "b"}, "properties": {"httpsOnly": false, "ipRules": ["10.0.0.1", "10.0.0.2"], "network": "a"}}`)
+
+	g.Expect(Compare(desired, live)).To(gomega.Equal([]string{
+		"properties.httpsOnly",
+		"properties.ipRules",
+		"properties.network",
+		"tags.team",
+	}))
+}
+
+func TestCompare_ComparesArrayElements(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	desired := unmarshal(g, `{"rules": [{"port": 80}, {"port": 443}]}`)
+	live := unmarshal(g, `{"rules": [{"port": 80}, {"port": 8443}]}`)
+
+	g.Expect(Compare(desired, live)).To(gomega.Equal([]string{"rules[1].port"}))
+}
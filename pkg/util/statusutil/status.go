@@ -8,8 +8,11 @@ package statusutil
 import (
 	"fmt"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+
+	azcorev1 "github.com/Azure/k8s-infra/apis/core/v1"
 )
 
 func GetProvisioningState(obj runtime.Object) (string, error) {
@@ -25,3 +28,95 @@ func GetProvisioningState(obj runtime.Object) (string, error) {
 
 	return status, nil
 }
+
+// conditions holds the conditions from the status of an object
+type conditions struct {
+	Conditions []azcorev1.Condition `json:"conditions,omitempty"`
+}
+
+// GetCondition returns the condition of the given type from the status of obj, or nil if there isn't one
+func GetCondition(obj runtime.Object, conditionType azcorev1.ConditionType) (*azcorev1.Condition, error) {
+	unObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert to unstructured with: %w", err)
+	}
+
+	current, err := getConditions(unObj)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range current.Conditions {
+		if current.Conditions[i].Type == conditionType {
+			return &current.Conditions[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SetCondition sets a condition in the status of obj, replacing any existing condition of the same type. The
+// LastTransitionTime is only updated if the status of the condition has changed.
+func SetCondition(obj runtime.Object, condition azcorev1.Condition) error {
+	unObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("unable to convert to unstructured with: %w", err)
+	}
+
+	current, err := getConditions(unObj)
+	if err != nil {
+		return err
+	}
+
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+
+	found := false
+	for i := range current.Conditions {
+		existing := &current.Conditions[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+
+		*existing = condition
+		found = true
+	}
+
+	if !found {
+		current.Conditions = append(current.Conditions, condition)
+	}
+
+	unConditions, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&current)
+	if err != nil {
+		return fmt.Errorf("unable to convert conditions to unstructured with: %w", err)
+	}
+
+	if err := unstructured.SetNestedField(unObj, unConditions["conditions"], "status", "conditions"); err != nil {
+		return fmt.Errorf("unable to set conditions with: %w", err)
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unObj, obj); err != nil {
+		return fmt.Errorf("unable to convert from unstructured with: %w", err)
+	}
+
+	return nil
+}
+
+func getConditions(unObj map[string]interface{}) (conditions, error) {
+	var result conditions
+	status, _, err := unstructured.NestedMap(unObj, "status")
+	if err != nil {
+		return result, fmt.Errorf("unable to fetch status with: %w", err)
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(status, &result); err != nil {
+		return result, fmt.Errorf("unable to read conditions with: %w", err)
+	}
+
+	return result, nil
+}
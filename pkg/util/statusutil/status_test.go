@@ -0,0 +1,61 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package statusutil
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	azcorev1 "github.com/Azure/k8s-infra/apis/core/v1"
+	microsoftresourcesv1 "github.com/Azure/k8s-infra/apis/microsoft.resources/v1"
+)
+
+func TestSetCondition_ReplacesConditionOfSameType(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	rg := &microsoftresourcesv1.ResourceGroup{
+		Status: microsoftresourcesv1.ResourceGroupStatus{ID: "id", ProvisioningState: "Succeeded"},
+	}
+
+	condition, err := GetCondition(rg, azcorev1.ConditionTypeDriftDetected)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(condition).To(gomega.BeNil())
+
+	g.Expect(SetCondition(rg, azcorev1.Condition{
+		Type:    azcorev1.ConditionTypeDriftDetected,
+		Status:  metav1.ConditionTrue,
+		Reason:  azcorev1.ReasonDrifted,
+		Message: "changed fields: tags.team",
+	})).To(gomega.Succeed())
+
+	g.Expect(rg.Status.ID).To(gomega.Equal("id"))
+	g.Expect(rg.Status.Conditions).To(gomega.HaveLen(1))
+	transitioned := rg.Status.Conditions[0].LastTransitionTime
+	g.Expect(transitioned.IsZero()).To(gomega.BeFalse())
+
+	// The same status again doesn't count as a transition
+	g.Expect(SetCondition(rg, azcorev1.Condition{
+		Type:    azcorev1.ConditionTypeDriftDetected,
+		Status:  metav1.ConditionTrue,
+		Reason:  azcorev1.ReasonDrifted,
+		Message: "changed fields: location",
+	})).To(gomega.Succeed())
+
+	condition, err = GetCondition(rg, azcorev1.ConditionTypeDriftDetected)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(condition.Message).To(gomega.Equal("changed fields: location"))
+	g.Expect(condition.LastTransitionTime).To(gomega.Equal(transitioned))
+
+	g.Expect(SetCondition(rg, azcorev1.Condition{
+		Type:   azcorev1.ConditionTypeDriftDetected,
+		Status: metav1.ConditionFalse,
+		Reason: azcorev1.ReasonInSync,
+	})).To(gomega.Succeed())
+	g.Expect(rg.Status.Conditions).To(gomega.HaveLen(1))
+	g.Expect(rg.Status.Conditions[0].Status).To(gomega.Equal(metav1.ConditionFalse))
+}
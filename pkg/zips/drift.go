@@ -0,0 +1,102 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package zips
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Azure/k8s-infra/pkg/util/driftutil"
+)
+
+// completeFields are the fields which Azure always returns in full, so any of their keys missing from the live
+// resource have been removed outside of Kubernetes rather than being secrets that Azure doesn't return
+var completeFields = []string{"sku", "tags"}
+
+// Drift compares the desired state of a resource with the live resource returned by Azure and returns the paths of
+// the fields which differ, as described by driftutil.Compare. Unlike driftutil.Compare, tags and sku fields which are
+// missing from the live resource are also reported.
+func Drift(desired *Resource, live *Resource) ([]string, error) {
+	desiredFields, err := driftFields(desired)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read desired state of resource with: %w", err)
+	}
+
+	liveFields, err := driftFields(live)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read live state of resource with: %w", err)
+	}
+
+	drift := driftutil.Compare(desiredFields, liveFields)
+	for _, field := range completeFields {
+		drift = append(drift, missingKeys(field, desiredFields[field], liveFields[field])...)
+	}
+
+	sort.Strings(drift)
+	return drift, nil
+}
+
+// missingKeys returns the paths of the keys set in desired which aren't present in live
+func missingKeys(path string, desired interface{}, live interface{}) []string {
+	desiredMap, _ := desired.(map[string]interface{})
+	liveMap, _ := live.(map[string]interface{})
+
+	var result []string
+	for key, value := range desiredMap {
+		if value == nil || value == "" {
+			// not specified
+			continue
+		}
+
+		if _, ok := liveMap[key]; !ok {
+			result = append(result, path+"."+key)
+		}
+	}
+
+	return result
+}
+
+// driftFields returns the fields of the resource which are compared to find drift
+func driftFields(res *Resource) (map[string]interface{}, error) {
+	fields := map[string]interface{}{
+		"location":  res.Location,
+		"managedBy": res.ManagedBy,
+	}
+
+	if res.Tags != nil {
+		tags := make(map[string]interface{}, len(res.Tags))
+		for k, v := range res.Tags {
+			tags[k] = v
+		}
+		fields["tags"] = tags
+	}
+
+	if res.Sku != nil {
+		// Round trip the sku through JSON so that, like the live resource, its numbers are float64 and only the
+		// fields set on it are compared
+		bytes, err := json.Marshal(res.Sku)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal sku with: %w", err)
+		}
+
+		var sku interface{}
+		if err := json.Unmarshal(bytes, &sku); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal sku with: %w", err)
+		}
+		fields["sku"] = sku
+	}
+
+	if len(res.Properties) > 0 {
+		var properties interface{}
+		if err := json.Unmarshal(res.Properties, &properties); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal properties with: %w", err)
+		}
+		fields["properties"] = properties
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package zips_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/Azure/k8s-infra/pkg/zips"
+)
+
+func TestDrift(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	desired := &zips.Resource{
+		Name:       "vnet",
+		Location:   "West US 2",
+		Tags:       map[string]string{"team": "a"},
+		Properties: json.RawMessage(`{"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]}, "enableDdosProtection": false}`),
+	}
+
+	live := &zips.Resource{
+		ID:         "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+		Name:       "vnet",
+		Location:   "westus2",
+		Tags:       map[string]string{"team": "a"},
+		Properties: json.RawMessage(`{"addressSpace": {"addressPrefixes": ["10.0.0.0/16"]}, "enableDdosProtection": false, "provisioningState": "Succeeded"}`),
+	}
+
+	drift, err := zips.Drift(desired, live)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(drift).To(gomega.BeEmpty())
+
+	live.Tags["team"] = "b"
+	live.Properties = json.RawMessage(`{"addressSpace": {"addressPrefixes": ["10.0.0.0/16", "10.1.0.0/16"]}, "enableDdosProtection": true}`)
+
+	drift, err = zips.Drift(desired, live)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(drift).To(gomega.Equal([]string{"properties.addressSpace.addressPrefixes", "properties.enableDdosProtection", "tags.team"}))
+}
+
+func TestDrift_ComparesOnlySpecifiedSkuFields(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	desired := &zips.Resource{
+		Name:     "account",
+		Location: "westus2",
+		Sku:      map[string]interface{}{"name": "Standard_LRS", "capacity": 1},
+	}
+
+	// Azure returns the tier as well as the fields which were set, and numbers as float64
+	live := &zips.Resource{
+		Name:     "account",
+		Location: "westus2",
+		Sku:      map[string]interface{}{"name": "standard_lrs", "tier": "Standard", "capacity": float64(1)},
+	}
+
+	drift, err := zips.Drift(desired, live)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(drift).To(gomega.BeEmpty())
+
+	live.Sku["capacity"] = float64(2)
+	drift, err = zips.Drift(desired, live)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(drift).To(gomega.Equal([]string{"sku.capacity"}))
+}
+
+func TestDrift_ReportsTagsAndSkuFieldsMissingFromLiveResource(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	desired := &zips.Resource{
+		Name:     "vnet",
+		Location: "westus2",
+		Tags:     map[string]string{"team": "a", "env": "prod"},
+		Sku:      map[string]interface{}{"name": "Standard", "family": "A"},
+	}
+
+	live := &zips.Resource{
+		Name:     "vnet",
+		Location: "westus2",
+		Tags:     map[string]string{"team": "a"},
+		Sku:      map[string]interface{}{"name": "Standard"},
+	}
+
+	drift, err := zips.Drift(desired, live)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(drift).To(gomega.Equal([]string{"sku.family", "tags.env"}))
+
+	// Every tag was deleted outside of Kubernetes
+	live.Tags = nil
+	drift, err = zips.Drift(desired, live)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(drift).To(gomega.Equal([]string{"sku.family", "tags.env", "tags.team"}))
+}
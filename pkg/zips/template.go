@@ -149,7 +149,7 @@ func (atc *AzureTemplateClient) GetResource(ctx context.Context, res *Resource)
 		return nil, fmt.Errorf("resource ID cannot be empty")
 	}
 
-	path := fmt.Sprintf("%s?api-version%s", res.ID, res.APIVersion)
+	path := fmt.Sprintf("%s?api-version=%s", res.ID, res.APIVersion)
 	err := atc.RawClient.GetResource(ctx, path, &res)
 	return res, err
 }